@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
@@ -24,18 +25,22 @@ func (i *StringSlice) Set(value string) error {
 }
 
 var (
-	flagApp         string
-	flagAuth        string
-	flagBuildArgs   StringSlice
-	flagCache       string
-	flagDevelopment string
-	flagEnvWrapper  string
-	flagID          string
-	flagManifest    string
-	flagMethod      string
-	flagPush        string
-	flagRack        string
-	flagUrl         string
+	flagApp           string
+	flagAuth          string
+	flagBuildArgs     StringSlice
+	flagCache         string
+	flagDevelopment   string
+	flagEnvWrapper    string
+	flagID            string
+	flagManifest      string
+	flagMethod        string
+	flagPush          string
+	flagRack          string
+	flagSbom          string
+	flagScan          string
+	flagScanAction    string
+	flagScanThreshold string
+	flagUrl           string
 
 	currentBuild    *structs.Build
 	currentLogs     string
@@ -74,6 +79,10 @@ func execute() error {
 	fs.StringVar(&flagMethod, "method", "", "source method")
 	fs.StringVar(&flagPush, "push", "", "push to registry")
 	fs.StringVar(&flagRack, "rack", "convox", "rack name")
+	fs.StringVar(&flagSbom, "sbom", "false", "generate and attest an sbom for built images")
+	fs.StringVar(&flagScan, "scan", "false", "scan built images for vulnerabilities")
+	fs.StringVar(&flagScanAction, "scan-action", "fail", "fail or block when the scan threshold is exceeded")
+	fs.StringVar(&flagScanThreshold, "scan-threshold", "", "lowest vulnerability severity that exceeds the threshold")
 	fs.StringVar(&flagUrl, "url", "", "source url")
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
@@ -112,6 +121,22 @@ func execute() error {
 		flagRack = v
 	}
 
+	if v := os.Getenv("BUILD_SBOM"); v != "" {
+		flagSbom = v
+	}
+
+	if v := os.Getenv("BUILD_SCAN"); v != "" {
+		flagScan = v
+	}
+
+	if v := os.Getenv("BUILD_SCAN_ACTION"); v != "" {
+		flagScanAction = v
+	}
+
+	if v := os.Getenv("BUILD_SCAN_THRESHOLD"); v != "" {
+		flagScanThreshold = v
+	}
+
 	if v := os.Getenv("BUILD_URL"); v != "" {
 		flagUrl = v
 	}
@@ -119,17 +144,21 @@ func execute() error {
 	// BUILD_GIT_SHA is exposed as env as well
 
 	opts := build.Options{
-		App:         flagApp,
-		Auth:        flagAuth,
-		BuildArgs:   flagBuildArgs,
-		Cache:       flagCache == "true",
-		Development: flagDevelopment == "true",
-		EnvWrapper:  flagEnvWrapper == "true",
-		Id:          flagID,
-		Manifest:    flagManifest,
-		Push:        flagPush,
-		Rack:        flagRack,
-		Source:      flagUrl,
+		App:           flagApp,
+		Auth:          flagAuth,
+		BuildArgs:     flagBuildArgs,
+		Cache:         flagCache == "true",
+		Development:   flagDevelopment == "true",
+		EnvWrapper:    flagEnvWrapper == "true",
+		Id:            flagID,
+		Manifest:      flagManifest,
+		Push:          flagPush,
+		Rack:          flagRack,
+		Sbom:          flagSbom == "true",
+		Scan:          flagScan == "true",
+		ScanAction:    flagScanAction,
+		ScanThreshold: flagScanThreshold,
+		Source:        flagUrl,
 	}
 
 	rack, err := sdk.NewFromEnv()
@@ -137,6 +166,17 @@ func execute() error {
 		return err
 	}
 
+	// the rack only lets the build process store the objects of its build
+	if v := os.Getenv("BUILD_TOKEN"); v != "" {
+		headers := rack.Client.Headers
+
+		rack.Client.Headers = func() http.Header {
+			h := headers()
+			h.Set("Build-Token", v)
+			return h
+		}
+	}
+
 	var engine build.Engine = &build.BuildKit{}
 	b, err := build.New(rack, opts, engine)
 	if err != nil {
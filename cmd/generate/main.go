@@ -31,6 +31,12 @@ func run() error {
 			return err
 		}
 		fmt.Println(string(data))
+	case "openapi":
+		data, err := generate.OpenAPI()
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
 	case "sdk":
 		data, err := generate.SDK()
 		if err != nil {
@@ -45,6 +51,6 @@ func run() error {
 }
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "usage: generate <controllers|routes>\n")
+	fmt.Fprintf(os.Stderr, "usage: generate <controllers|openapi|routes|sdk>\n")
 	os.Exit(1)
 }
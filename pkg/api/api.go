@@ -10,6 +10,9 @@ import (
 	"github.com/convox/stdapi"
 )
 
+// authJwtParam is set on requests authenticated with a jwt token
+const authJwtParam = "CONVOX_AUTH_JWT"
+
 type Server struct {
 	*stdapi.Server
 	Password string
@@ -66,9 +69,13 @@ func NewWithProvider(p structs.Provider) *Server {
 	// })
 
 	s.Subrouter("/", func(auth *stdapi.Router) {
+		auth.Use(s.instrument)
 		auth.Use(s.authenticate)
+		auth.Use(s.audit)
 
 		auth.Route("GET", "/auth", func(c *stdapi.Context) error { return c.RenderOK() })
+		auth.Route("GET", "/metrics", s.Metrics)
+		auth.Route("GET", "/openapi.json", s.OpenAPI)
 
 		// auth.Route("GET", "/v2/{path:.*}", s.RegistryProxy)
 
@@ -87,12 +94,19 @@ func (s *Server) authenticate(next stdapi.HandlerFunc) stdapi.HandlerFunc {
 				return stdapi.Errorf(http.StatusUnauthorized, "invalid authentication: %s", err)
 			}
 			c.Set(structs.ConvoxRoleParam, data.Role)
+			c.Set(structs.ConvoxUserParam, data.User)
+			c.Set(authJwtParam, true)
+			if data.Policy != "" {
+				c.Set(structs.ConvoxPolicyParam, data.Policy)
+				c.Set(structs.ConvoxAppsParam, data.Apps)
+			}
 		} else {
 			if s.Password != "" && s.Password != pass {
 				c.Response().Header().Set("WWW-Authenticate", `Basic realm="convox"`)
 				return stdapi.Errorf(http.StatusUnauthorized, "invalid authentication")
 			}
 			SetReadWriteRole(c)
+			c.Set(structs.ConvoxUserParam, username)
 		}
 
 		return next(c)
@@ -123,6 +137,10 @@ func (s *Server) hook(name string, args ...interface{}) error {
 	return nil
 }
 
-func (s *Server) provider(_ *stdapi.Context) structs.Provider {
+func (s *Server) provider(c *stdapi.Context) structs.Provider {
+	if scopes, ok := c.Get(policyScopesParam).([][]string); ok {
+		return &scopedProvider{Provider: s.Provider, scopes: scopes}
+	}
+
 	return s.Provider
 }
@@ -20,6 +20,7 @@ func testServer(t *testing.T, fn func(*stdsdk.Client, *structs.MockProvider)) {
 	p.On("Initialize", mock.Anything).Return(nil)
 	p.On("Start").Return(nil)
 	p.On("WithContext", mock.Anything).Return(p).Maybe()
+	p.On("AuditLogAppend", mock.Anything).Return(nil).Maybe()
 	p.On("SystemJwtSignKey").Return("test", nil)
 
 	s := api.NewWithProvider(p)
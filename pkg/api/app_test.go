@@ -200,6 +200,36 @@ func TestAppLogsError(t *testing.T) {
 	})
 }
 
+func TestAppPrune(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		p1 := structs.PruneObjects{
+			{Created: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Id: "BUILD1", Images: []string{"web.BUILD1"}, Type: "build"},
+			{Created: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Id: "RELEASE1", Type: "release"},
+		}
+		p2 := structs.PruneObjects{}
+		opts := structs.AppPruneOptions{DryRun: options.Bool(true)}
+		ro := stdsdk.RequestOptions{
+			Params: stdsdk.Params{
+				"dry-run": "true",
+			},
+		}
+		p.On("AppPrune", "app1", opts).Return(p1, nil)
+		err := c.Post("/apps/app1/prune", ro, &p2)
+		require.NoError(t, err)
+		require.Equal(t, p1, p2)
+	})
+}
+
+func TestAppPruneError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var p1 structs.PruneObjects
+		p.On("AppPrune", "app1", structs.AppPruneOptions{}).Return(nil, fmt.Errorf("err1"))
+		err := c.Post("/apps/app1/prune", stdsdk.RequestOptions{}, &p1)
+		require.EqualError(t, err, "err1")
+		require.Nil(t, p1)
+	})
+}
+
 func TestAppUpdate(t *testing.T) {
 	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
 		opts := structs.AppUpdateOptions{
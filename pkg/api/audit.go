@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/convox/convox/pkg/metrics"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/stdapi"
+)
+
+const auditMask = "****"
+
+var auditMaskedParams = []string{"env", "key", "password", "secret", "token", "value"}
+
+var auditAppendFailures = metrics.NewCounter("convox_audit_append_failures_total", "Audit log entries the rack failed to record", "action")
+
+// audit records every mutating call in the rack audit log, including the ones
+// rejected by authorization. Websockets are upgraded from a GET but carry
+// exec, console and proxy sessions so they are recorded as well.
+func (s *Server) audit(next stdapi.HandlerFunc) stdapi.HandlerFunc {
+	return func(c *stdapi.Context) error {
+		socket := auditSocket(c)
+
+		if c.Request().Method == http.MethodGet && !socket {
+			return next(c)
+		}
+
+		err := next(c)
+
+		l := structs.NewAuditLog()
+
+		l.Action = c.Name()
+		l.App = contextApp(c)
+		l.Method = c.Request().Method
+		l.Params = auditParams(c.Request())
+
+		if socket {
+			l.Method = "SOCKET"
+
+			if cmd := c.Request().Header.Get("Command"); cmd != "" {
+				if l.Params == nil {
+					l.Params = map[string]string{}
+				}
+				l.Params["command"] = cmd
+			}
+		}
+
+		l.Result = "success"
+		l.Route = contextRoute(c)
+		l.User = auditUser(c)
+
+		if err != nil {
+			l.Error = err.Error()
+			l.Result = "error"
+		}
+
+		if aerr := s.provider(c).WithContext(c.Context()).AuditLogAppend(*l); aerr != nil {
+			c.Logf("ns=audit at=append error=%q", aerr)
+
+			auditAppendFailures.Inc(l.Action)
+
+			s.provider(c).EventSend("audit:fail", structs.EventSendOptions{
+				Data:  map[string]string{"action": l.Action, "app": l.App, "id": l.Id, "user": l.User},
+				Error: options.String(aerr.Error()),
+			})
+		}
+
+		return err
+	}
+}
+
+// auditSocket is true for the routes that are served over a websocket
+func auditSocket(c *stdapi.Context) bool {
+	return strings.HasPrefix(structs.Routes()[c.Name()], "SOCKET ")
+}
+
+// auditParams only looks at an already parsed form so that streaming request
+// bodies are never consumed here
+func auditParams(r *http.Request) map[string]string {
+	if len(r.Form) == 0 {
+		return nil
+	}
+
+	params := map[string]string{}
+
+	for k, vs := range r.Form {
+		if auditMasked(k) {
+			params[k] = auditMask
+			continue
+		}
+
+		params[k] = strings.Join(vs, ",")
+	}
+
+	return params
+}
+
+func auditMasked(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, m := range auditMaskedParams {
+		if strings.Contains(name, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func auditUser(c *stdapi.Context) string {
+	if u, ok := c.Get(structs.ConvoxUserParam).(string); ok {
+		return u
+	}
+
+	return ""
+}
@@ -0,0 +1,150 @@
+package api_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/stdsdk"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var fxAuditLog = structs.AuditLog{
+	Id:        "audit1",
+	Action:    "ReleasePromote",
+	App:       "app1",
+	Method:    "POST",
+	Params:    map[string]string{"force": "true"},
+	Result:    "success",
+	Route:     "/apps/{app}/releases/{id}/promote",
+	Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+	User:      "user1",
+}
+
+func TestAuditLogList(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		a1 := structs.AuditLogs{fxAuditLog, fxAuditLog}
+		a2 := structs.AuditLogs{}
+		opts := structs.AuditLogListOptions{
+			App:   options.String("app1"),
+			Since: options.Duration(2 * time.Hour),
+			User:  options.String("user1"),
+		}
+		ro := stdsdk.RequestOptions{
+			Query: stdsdk.Query{
+				"app":   "app1",
+				"since": "2h",
+				"user":  "user1",
+			},
+		}
+		p.On("AuditLogList", opts).Return(a1, nil)
+		err := c.Get("/system/audit", ro, &a2)
+		require.NoError(t, err)
+		require.Equal(t, a1, a2)
+	})
+}
+
+func TestAuditLogListError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var a1 structs.AuditLogs
+		p.On("AuditLogList", mock.Anything).Return(nil, fmt.Errorf("err1"))
+		err := c.Get("/system/audit", stdsdk.RequestOptions{}, &a1)
+		require.EqualError(t, err, "err1")
+		require.Nil(t, a1)
+	})
+}
+
+func TestAuditRecordsMutation(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		opts := structs.ReleaseCreateOptions{
+			Build: options.String("build1"),
+			Env:   options.String("SECRET=value"),
+		}
+		ro := stdsdk.RequestOptions{
+			Params: stdsdk.Params{
+				"build": "build1",
+				"env":   "SECRET=value",
+			},
+		}
+		p.On("ReleaseCreate", "app1", opts).Return(nil, fmt.Errorf("err1"))
+		err := c.Post("/apps/app1/releases", ro, nil)
+		require.EqualError(t, err, "err1")
+
+		l := auditLogged(t, p)
+		require.Equal(t, "ReleaseCreate", l.Action)
+		require.Equal(t, "app1", l.App)
+		require.Equal(t, "err1", l.Error)
+		require.Equal(t, "POST", l.Method)
+		require.Equal(t, map[string]string{"build": "build1", "env": "****"}, l.Params)
+		require.Equal(t, "error", l.Result)
+		require.Equal(t, "/apps/{app}/releases", l.Route)
+	})
+}
+
+func TestAuditSkipsReads(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		p.On("AuditLogList", mock.Anything).Return(structs.AuditLogs{}, nil)
+		err := c.Get("/system/audit", stdsdk.RequestOptions{}, nil)
+		require.NoError(t, err)
+		p.AssertNotCalled(t, "AuditLogAppend", mock.Anything)
+	})
+}
+
+func auditLogged(t *testing.T, p *structs.MockProvider) structs.AuditLog {
+	for _, call := range p.Calls {
+		if call.Method == "AuditLogAppend" {
+			return call.Arguments.Get(0).(structs.AuditLog)
+		}
+	}
+
+	require.FailNow(t, "no audit log appended")
+
+	return structs.AuditLog{}
+}
+
+func TestAuditRecordsSocket(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		a1 := fxApp
+		p.On("AppGet", "app1").Return(&a1, nil)
+		p.On("ProcessExec", "app1", "pid1", "bin/console", mock.Anything, mock.Anything).Return(0, nil)
+		ro := stdsdk.RequestOptions{
+			Headers: stdsdk.Headers{
+				"Command": "bin/console",
+			},
+		}
+		r, err := c.Websocket("/apps/app1/processes/pid1/exec", ro)
+		require.NoError(t, err)
+		_, err = io.ReadAll(r)
+		require.NoError(t, err)
+
+		l := auditLogged(t, p)
+		require.Equal(t, "ProcessExec", l.Action)
+		require.Equal(t, "app1", l.App)
+		require.Equal(t, "SOCKET", l.Method)
+		require.Equal(t, map[string]string{"command": "bin/console"}, l.Params)
+		require.Equal(t, "/apps/{app}/processes/{pid}/exec", l.Route)
+	})
+}
+
+func TestAuditAppendError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		for _, call := range p.ExpectedCalls {
+			if call.Method == "AuditLogAppend" {
+				call.Unset()
+			}
+		}
+
+		p.On("AuditLogAppend", mock.Anything).Return(fmt.Errorf("bucket full"))
+		p.On("AppDelete", "app1").Return(nil)
+		p.On("EventSend", "audit:fail", mock.MatchedBy(func(opts structs.EventSendOptions) bool {
+			return opts.Data["action"] == "AppDelete" && opts.Data["app"] == "app1" && *opts.Error == "bucket full"
+		})).Return(nil)
+
+		err := c.Delete("/apps/app1", stdsdk.RequestOptions{}, nil)
+		require.NoError(t, err)
+	})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
@@ -8,8 +9,65 @@ import (
 	"github.com/convox/stdapi"
 )
 
+// policyActionVerbs maps actions to the policy verb they require, any action
+// not listed here needs read for GET and admin for everything else
+var policyActionVerbs = map[string]string{
+	"AppCancel":            structs.PolicyVerbDeploy,
+	"AppConfigSet":         structs.PolicyVerbEnv,
+	"AppPrune":             structs.PolicyVerbDeploy,
+	"BuildAttest":          structs.PolicyVerbDeploy,
+	"BuildCreate":          structs.PolicyVerbDeploy,
+	"BuildImport":          structs.PolicyVerbDeploy,
+	"BuildUpdate":          structs.PolicyVerbDeploy,
+	"FilesDelete":          structs.PolicyVerbExec,
+	"FilesDownload":        structs.PolicyVerbExec,
+	"FilesUpload":          structs.PolicyVerbExec,
+	"InstanceShell":        structs.PolicyVerbAdmin,
+	"ObjectDelete":         structs.PolicyVerbDeploy,
+	"ObjectStore":          structs.PolicyVerbDeploy,
+	"ProcessExec":          structs.PolicyVerbExec,
+	"ProcessRun":           structs.PolicyVerbRun,
+	"ProcessStop":          structs.PolicyVerbScale,
+	"Proxy":                structs.PolicyVerbExec,
+	"ReleaseApprove":       structs.PolicyVerbDeploy,
+	"ReleaseCreate":        structs.PolicyVerbDeploy,
+	"ReleasePlan":          structs.PolicyVerbDeploy,
+	"ReleasePromote":       structs.PolicyVerbDeploy,
+	"ResourceConsole":      structs.PolicyVerbResources,
+	"ResourceExport":       structs.PolicyVerbResources,
+	"ResourceImport":       structs.PolicyVerbResources,
+	"ResourceRestore":      structs.PolicyVerbResources,
+	"ServiceRestart":       structs.PolicyVerbScale,
+	"ServiceUpdate":        structs.PolicyVerbScale,
+	"SystemResourceCreate": structs.PolicyVerbResources,
+	"SystemResourceDelete": structs.PolicyVerbResources,
+	"SystemResourceLink":   structs.PolicyVerbResources,
+	"SystemResourceTypes":  structs.PolicyVerbRead,
+	"SystemResourceUnlink": structs.PolicyVerbResources,
+	"SystemResourceUpdate": structs.PolicyVerbResources,
+	"TimerRun":             structs.PolicyVerbRun,
+}
+
+// policyScopedReads are the rack-level actions a policy scoped to apps may
+// still call, the provider filters their responses down to the scoped apps
+var policyScopedReads = map[string]bool{
+	"AppList":             true,
+	"SystemGet":           true,
+	"SystemResourceTypes": true,
+}
+
+// policyScopesParam holds the app patterns of every scope on the request
+const policyScopesParam = "CONVOX_POLICY_SCOPES"
+
 func (s *Server) Authorize(next stdapi.HandlerFunc) stdapi.HandlerFunc {
 	return func(c *stdapi.Context) error {
+		if name, ok := c.Get(structs.ConvoxPolicyParam).(string); ok && name != "" {
+			if err := s.authorizePolicy(c, name); err != nil {
+				return err
+			}
+			return next(c)
+		}
+
 		switch c.Request().Method {
 		case http.MethodGet:
 			if !CanRead(c) {
@@ -24,6 +82,122 @@ func (s *Server) Authorize(next stdapi.HandlerFunc) stdapi.HandlerFunc {
 	}
 }
 
+func (s *Server) authorizePolicy(c *stdapi.Context, name string) error {
+	p, ok := structs.BuiltinPolicy(name)
+	if !ok {
+		v, err := s.provider(c).WithContext(c.Context()).PolicyGet(name)
+		if err != nil {
+			return stdapi.Errorf(http.StatusUnauthorized, "invalid policy: %s", name)
+		}
+		p = v
+	}
+
+	verb := policyVerb(c)
+	app := contextApp(c)
+
+	scopes := [][]string{}
+
+	if len(p.Apps) > 0 {
+		scopes = append(scopes, p.Apps)
+	}
+
+	if apps, ok := c.Get(structs.ConvoxAppsParam).([]string); ok && len(apps) > 0 {
+		scopes = append(scopes, apps)
+	}
+
+	if app == "" && len(scopes) > 0 && policyScopedReads[c.Name()] {
+		if !p.AllowsVerb(verb) {
+			return stdapi.Errorf(http.StatusUnauthorized, "you are unauthorized to access this")
+		}
+
+		c.Set(policyScopesParam, scopes)
+
+		return nil
+	}
+
+	if !p.Allows(verb, app) {
+		return stdapi.Errorf(http.StatusUnauthorized, "you are unauthorized to access this")
+	}
+
+	for _, apps := range scopes {
+		scoped := structs.Policy{Apps: apps, Verbs: []string{structs.PolicyVerbAll}}
+		if !scoped.Allows(verb, app) {
+			return stdapi.Errorf(http.StatusUnauthorized, "you are unauthorized to access this")
+		}
+	}
+
+	return nil
+}
+
+// scopedProvider limits the rack-level reads in policyScopedReads to the apps
+// a scoped policy can see
+type scopedProvider struct {
+	structs.Provider
+	scopes [][]string
+}
+
+func (p *scopedProvider) WithContext(ctx context.Context) structs.Provider {
+	return &scopedProvider{Provider: p.Provider.WithContext(ctx), scopes: p.scopes}
+}
+
+func (p *scopedProvider) AppList() (structs.Apps, error) {
+	as, err := p.Provider.AppList()
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := structs.Apps{}
+
+	for _, a := range as {
+		if p.allows(a.Name) {
+			scoped = append(scoped, a)
+		}
+	}
+
+	return scoped, nil
+}
+
+func (p *scopedProvider) SystemGet() (*structs.System, error) {
+	s, err := p.Provider.SystemGet()
+	if err != nil {
+		return nil, err
+	}
+
+	// rack parameters and outputs are not scoped to an app
+	ss := *s
+	ss.Outputs = nil
+	ss.Parameters = nil
+
+	return &ss, nil
+}
+
+func (p *scopedProvider) allows(app string) bool {
+	for _, apps := range p.scopes {
+		if !structs.MatchApps(apps, app) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func policyVerb(c *stdapi.Context) string {
+	verb, ok := policyActionVerbs[c.Name()]
+	if !ok {
+		if c.Request().Method == http.MethodGet {
+			return structs.PolicyVerbRead
+		}
+		return structs.PolicyVerbAdmin
+	}
+
+	// a release carrying env changes the app environment
+	if c.Name() == "ReleaseCreate" && c.Form("env") != "" {
+		return structs.PolicyVerbEnv
+	}
+
+	return verb
+}
+
 func CanRead(c *stdapi.Context) bool {
 	if d := c.Get(structs.ConvoxRoleParam); d != nil {
 		v, _ := d.(string)
@@ -1,13 +1,22 @@
 package api_test
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/convox/convox/pkg/api"
+	"github.com/convox/convox/pkg/jwt"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/sdk"
 	"github.com/convox/stdapi"
+	"github.com/convox/stdsdk"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAuthorize(t *testing.T) {
@@ -61,3 +70,174 @@ func TestAuthorize(t *testing.T) {
 		}
 	}
 }
+
+func testPolicyClient(t *testing.T, c *stdsdk.Client, policy string, apps []string) *stdsdk.Client {
+	tk, err := jwt.NewJwtManager("test").PolicyToken("ci", policy, apps, time.Hour)
+	require.NoError(t, err)
+
+	u := *c.Endpoint
+	u.User = url.UserPassword("jwt", tk)
+
+	pc, err := sdk.New(u.String())
+	require.NoError(t, err)
+
+	return pc.Client
+}
+
+func TestAuthorizePolicyBuiltin(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		pc := testPolicyClient(t, c, "deployer", []string{"staging-*"})
+
+		p.On("ReleasePromote", "staging-web", "release1", structs.ReleasePromoteOptions{User: options.String("ci")}).Return(nil)
+		p.On("AppGet", "staging-web").Return(&structs.App{Status: "running"}, nil)
+		p.On("ReleaseGet", "staging-web", "release1").Return(&structs.Release{Id: "release1", Manifest: "services: {}"}, nil)
+
+		err := pc.Post("/apps/staging-web/releases/release1/promote", stdsdk.RequestOptions{}, nil)
+		require.NoError(t, err)
+
+		err = pc.Post("/apps/production-web/releases/release1/promote", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "you are unauthorized to access this")
+
+		err = pc.Post("/apps/staging-web/releases", stdsdk.RequestOptions{Params: stdsdk.Params{"env": "FOO=bar"}}, nil)
+		require.EqualError(t, err, "you are unauthorized to access this")
+
+		err = pc.Post("/apps", stdsdk.RequestOptions{Params: stdsdk.Params{"name": "staging-new"}}, nil)
+		require.EqualError(t, err, "you are unauthorized to access this")
+	})
+}
+
+func TestAuthorizePolicyCustom(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		pc := testPolicyClient(t, c, "operator", nil)
+
+		p.On("PolicyGet", "operator").Return(&structs.Policy{Name: "operator", Verbs: []string{"read", "scale"}}, nil)
+		p.On("ServiceRestart", "app1", "web").Return(nil)
+		p.On("SystemGet").Return(&structs.System{Name: "rack1"}, nil)
+
+		err := pc.Post("/apps/app1/services/web/restart", stdsdk.RequestOptions{}, nil)
+		require.NoError(t, err)
+
+		var s structs.System
+		err = pc.Get("/system", stdsdk.RequestOptions{}, &s)
+		require.NoError(t, err)
+
+		err = pc.Put("/apps/app1/configs/foo", stdsdk.RequestOptions{Params: stdsdk.Params{"value": "YmFy"}}, nil)
+		require.EqualError(t, err, "you are unauthorized to access this")
+	})
+}
+
+func TestAuthorizePolicyUnknown(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		pc := testPolicyClient(t, c, "missing", nil)
+
+		p.On("PolicyGet", "missing").Return(nil, fmt.Errorf("policy not found: missing"))
+
+		err := pc.Get("/system", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "invalid policy: missing")
+	})
+}
+
+func TestSystemJwtTokenPolicy(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var tk structs.SystemJwt
+		ro := stdsdk.RequestOptions{
+			Params: stdsdk.Params{
+				"apps": "staging-*",
+				"role": "deployer",
+				"ttl":  "2h",
+			},
+		}
+		err := c.Post("/system/jwt/token", ro, &tk)
+		require.NoError(t, err)
+
+		data, err := jwt.NewJwtManager("test").Verify(tk.Token)
+		require.NoError(t, err)
+		require.Equal(t, "deployer", data.Policy)
+		require.Equal(t, []string{"staging-*"}, data.Apps)
+		require.Equal(t, "system-access", data.User)
+		require.WithinDuration(t, time.Now().Add(2*time.Hour), data.ExpiresAt, time.Minute)
+	})
+}
+
+func TestSystemJwtTokenPolicyUser(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var tk structs.SystemJwt
+		ro := stdsdk.RequestOptions{
+			Params: stdsdk.Params{
+				"role": "write",
+				"ttl":  "2h",
+				"user": "ci",
+			},
+		}
+		err := c.Post("/system/jwt/token", ro, &tk)
+		require.NoError(t, err)
+
+		data, err := jwt.NewJwtManager("test").Verify(tk.Token)
+		require.NoError(t, err)
+		require.Equal(t, "write", data.Policy)
+		require.Equal(t, "ci", data.User)
+
+		ro.Params["user"] = "system-write"
+		err = c.Post("/system/jwt/token", ro, nil)
+		require.EqualError(t, err, "invalid user: system-write")
+	})
+}
+
+func TestSystemJwtTokenPolicyUnknown(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		p.On("PolicyGet", "missing").Return(nil, fmt.Errorf("policy not found: missing"))
+		ro := stdsdk.RequestOptions{
+			Params: stdsdk.Params{
+				"role": "missing",
+				"ttl":  "2h",
+			},
+		}
+		err := c.Post("/system/jwt/token", ro, nil)
+		require.EqualError(t, err, "policy not found: missing")
+	})
+}
+
+func TestAuthorizePolicyScopedRack(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		pc := testPolicyClient(t, c, "deployer", []string{"staging-*"})
+
+		p.On("AppList").Return(structs.Apps{{Name: "production-web"}, {Name: "staging-web"}}, nil)
+		p.On("SystemGet").Return(&structs.System{Name: "rack1", Parameters: map[string]string{"foo": "bar"}, Version: "1"}, nil)
+
+		var as structs.Apps
+		err := pc.Get("/apps", stdsdk.RequestOptions{}, &as)
+		require.NoError(t, err)
+		require.Equal(t, structs.Apps{{Name: "staging-web"}}, as)
+
+		var s structs.System
+		err = pc.Get("/system", stdsdk.RequestOptions{}, &s)
+		require.NoError(t, err)
+		require.Equal(t, structs.System{Name: "rack1", Version: "1"}, s)
+
+		err = pc.Get("/resources", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "you are unauthorized to access this")
+
+		err = pc.Get("/system/audit", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "you are unauthorized to access this")
+
+		err = pc.Get("/instances", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "you are unauthorized to access this")
+	})
+}
+
+func TestAuthorizePolicyCustomScoped(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		pc := testPolicyClient(t, c, "viewer", nil)
+
+		p.On("PolicyGet", "viewer").Return(&structs.Policy{Name: "viewer", Apps: []string{"app1"}, Verbs: []string{"read"}}, nil)
+		p.On("AppList").Return(structs.Apps{{Name: "app1"}, {Name: "app2"}}, nil)
+
+		var as structs.Apps
+		err := pc.Get("/apps", stdsdk.RequestOptions{}, &as)
+		require.NoError(t, err)
+		require.Equal(t, structs.Apps{{Name: "app1"}}, as)
+
+		err = pc.Get("/system/webhooks", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "you are unauthorized to access this")
+	})
+}
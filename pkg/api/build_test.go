@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/convox/convox/pkg/jwt"
 	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/convox/stdsdk"
@@ -16,6 +17,12 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+var fxBuildAttestation = structs.BuildAttestation{
+	Payload:     "payload",
+	PayloadType: "application/vnd.in-toto+json",
+	Signatures:  []structs.BuildAttestationSignature{{KeyId: "SHA256:key", Sig: "sig"}},
+}
+
 var fxBuild = structs.Build{
 	Id:          "build1",
 	App:         "app1",
@@ -109,6 +116,65 @@ func TestBuildExportError(t *testing.T) {
 	})
 }
 
+func TestBuildAttest(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		a1 := fxBuildAttestation
+		a2 := structs.BuildAttestation{}
+		p.On("BuildAttest", "app1", "build1").Return(&a1, nil)
+		ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{"Build-Token": jwt.NewJwtManager("test").BuildToken("app1", "build1")}}
+		err := c.Post("/apps/app1/builds/build1/attestation", ro, &a2)
+		require.NoError(t, err)
+		require.Equal(t, a1, a2)
+	})
+}
+
+func TestBuildAttestToken(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var a1 *structs.BuildAttestation
+
+		err := c.Post("/apps/app1/builds/build1/attestation", stdsdk.RequestOptions{}, a1)
+		require.EqualError(t, err, "only the build process of build build1 can do this")
+
+		ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{"Build-Token": jwt.NewJwtManager("test").BuildToken("app1", "build2")}}
+		err = c.Post("/apps/app1/builds/build1/attestation", ro, a1)
+		require.EqualError(t, err, "only the build process of build build1 can do this")
+
+		p.AssertNotCalled(t, "BuildAttest", "app1", "build1")
+	})
+}
+
+func TestBuildAttestError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var a1 *structs.BuildAttestation
+		p.On("BuildAttest", "app1", "build1").Return(nil, fmt.Errorf("err1"))
+		ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{"Build-Token": jwt.NewJwtManager("test").BuildToken("app1", "build1")}}
+		err := c.Post("/apps/app1/builds/build1/attestation", ro, a1)
+		require.Nil(t, a1)
+		require.EqualError(t, err, "err1")
+	})
+}
+
+func TestBuildAttestation(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		a1 := fxBuildAttestation
+		a2 := structs.BuildAttestation{}
+		p.On("BuildAttestation", "app1", "build1").Return(&a1, nil)
+		err := c.Get("/apps/app1/builds/build1/attestation", stdsdk.RequestOptions{}, &a2)
+		require.NoError(t, err)
+		require.Equal(t, a1, a2)
+	})
+}
+
+func TestBuildAttestationError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var a1 *structs.BuildAttestation
+		p.On("BuildAttestation", "app1", "build1").Return(nil, fmt.Errorf("err1"))
+		err := c.Get("/apps/app1/builds/build1/attestation", stdsdk.RequestOptions{}, a1)
+		require.Nil(t, a1)
+		require.EqualError(t, err, "err1")
+	})
+}
+
 func TestBuildGet(t *testing.T) {
 	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
 		b1 := fxBuild
@@ -214,6 +280,31 @@ func TestBuildListError(t *testing.T) {
 	})
 }
 
+func TestBuildSbom(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		d1 := []byte("sbom")
+		r1 := ioutil.NopCloser(bytes.NewReader(d1))
+		opts := structs.BuildSbomOptions{Service: options.String("web")}
+		ro := stdsdk.RequestOptions{Query: stdsdk.Query{"service": "web"}}
+		p.On("BuildSbom", "app1", "build1", opts).Return(r1, nil)
+		res, err := c.GetStream("/apps/app1/builds/build1/sbom", ro)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		d2, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, d1, d2)
+	})
+}
+
+func TestBuildSbomError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		p.On("BuildSbom", "app1", "build1", structs.BuildSbomOptions{}).Return(nil, fmt.Errorf("err1"))
+		res, err := c.GetStream("/apps/app1/builds/build1/sbom", stdsdk.RequestOptions{})
+		require.EqualError(t, err, "err1")
+		require.Nil(t, res)
+	})
+}
+
 func TestBuildUpdate(t *testing.T) {
 	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
 		b1 := fxBuild
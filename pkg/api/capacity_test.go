@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/convox/stdsdk"
 	"github.com/stretchr/testify/require"
@@ -15,6 +16,22 @@ var fxCapacity = structs.Capacity{
 	ProcessCount:  5,
 	ProcessCPU:    6,
 	ProcessMemory: 7,
+	Fragmentation: 8,
+	NodeGroups: structs.CapacityNodeGroups{
+		{Name: "group1", AllocatableCPU: 1, AllocatableMemory: 2, Nodes: 1, RequestedCPU: 3, RequestedMemory: 4},
+	},
+	Nodes: structs.CapacityNodes{
+		{Name: "node1", AllocatableCPU: 1, AllocatableMemory: 2, AllocatablePods: 3, Group: "group1", Pods: 4, RequestedCPU: 5, RequestedMemory: 6, Schedulable: true},
+	},
+}
+
+var fxCapacityPlan = structs.CapacityPlan{
+	App:     "app1",
+	Fits:    false,
+	Release: "release1",
+	Services: structs.CapacityPlanServices{
+		{Name: "web", Count: 1, CPU: 2, Memory: 3, Pending: 1, Reason: "insufficient cpu"},
+	},
 }
 
 func TestCapacityGet(t *testing.T) {
@@ -37,3 +54,34 @@ func TestCapacityGetError(t *testing.T) {
 		require.EqualError(t, err, "err1")
 	})
 }
+
+func TestCapacityPlan(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		c1 := fxCapacityPlan
+		c2 := structs.CapacityPlan{}
+		opts := structs.CapacityPlanOptions{
+			Release: options.String("release1"),
+			Scale:   map[string]string{"web": "10", "worker": "2"},
+		}
+		ro := stdsdk.RequestOptions{
+			Query: stdsdk.Query{
+				"release": "release1",
+				"scale":   "web=10&worker=2",
+			},
+		}
+		p.On("CapacityPlan", "app1", opts).Return(&c1, nil)
+		err := c.Get("/apps/app1/capacity", ro, &c2)
+		require.NoError(t, err)
+		require.Equal(t, c1, c2)
+	})
+}
+
+func TestCapacityPlanError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var c1 *structs.CapacityPlan
+		p.On("CapacityPlan", "app1", structs.CapacityPlanOptions{}).Return(nil, fmt.Errorf("err1"))
+		err := c.Get("/apps/app1/capacity", stdsdk.RequestOptions{}, c1)
+		require.Nil(t, c1)
+		require.EqualError(t, err, "err1")
+	})
+}
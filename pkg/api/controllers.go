@@ -23,7 +23,9 @@ func (s *Server) AppCancel(c *stdapi.Context) error {
 
 	name := c.Var("name")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).AppCancel(name)
+	providerCall("AppCancel", start, err)
 	if err != nil {
 		return err
 	}
@@ -43,7 +45,9 @@ func (s *Server) AppCreate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).AppCreate(name, opts)
+	providerCall("AppCreate", start, err)
 	if err != nil {
 		return err
 	}
@@ -59,7 +63,9 @@ func (s *Server) AppConfigGet(c *stdapi.Context) error {
 	name := c.Var("name")
 	app := c.Var("app")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).AppConfigGet(app, name)
+	providerCall("AppConfigGet", start, err)
 	if err != nil {
 		return err
 	}
@@ -70,7 +76,9 @@ func (s *Server) AppConfigGet(c *stdapi.Context) error {
 func (s *Server) AppConfigList(c *stdapi.Context) error {
 	app := c.Var("app")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).AppConfigList(app)
+	providerCall("AppConfigList", start, err)
 	if err != nil {
 		return err
 	}
@@ -88,7 +96,9 @@ func (s *Server) AppConfigSet(c *stdapi.Context) error {
 
 	valaue64 := c.Value("value")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).AppConfigSet(app, name, valaue64)
+	providerCall("AppConfigSet", start, err)
 	if err != nil {
 		return err
 	}
@@ -103,7 +113,9 @@ func (s *Server) AppDelete(c *stdapi.Context) error {
 
 	name := c.Var("name")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).AppDelete(name)
+	providerCall("AppDelete", start, err)
 	if err != nil {
 		return err
 	}
@@ -118,7 +130,9 @@ func (s *Server) AppGet(c *stdapi.Context) error {
 
 	name := c.Var("name")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).AppGet(name)
+	providerCall("AppGet", start, err)
 	if err != nil {
 		return err
 	}
@@ -135,7 +149,9 @@ func (s *Server) AppList(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).AppList()
+	providerCall("AppList", start, err)
 	if err != nil {
 		return err
 	}
@@ -159,7 +175,9 @@ func (s *Server) AppLogs(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).AppLogs(name, opts)
+	providerCall("AppLogs", start, err)
 	if err != nil {
 		return err
 	}
@@ -191,7 +209,35 @@ func (s *Server) AppMetrics(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).AppMetrics(name, opts)
+	providerCall("AppMetrics", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) AppPrune(c *stdapi.Context) error {
+	if err := s.hook("AppPruneValidate", c); err != nil {
+		return err
+	}
+
+	name := c.Var("name")
+
+	var opts structs.AppPruneOptions
+	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).AppPrune(name, opts)
+	providerCall("AppPrune", start, err)
 	if err != nil {
 		return err
 	}
@@ -215,7 +261,9 @@ func (s *Server) AppUpdate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).AppUpdate(name, opts)
+	providerCall("AppUpdate", start, err)
 	if err != nil {
 		return err
 	}
@@ -223,6 +271,34 @@ func (s *Server) AppUpdate(c *stdapi.Context) error {
 	return c.RenderOK()
 }
 
+func (*Server) AuditLogAppend(_ *stdapi.Context) error {
+	return stdapi.Errorf(404, "not available via api")
+}
+
+func (s *Server) AuditLogList(c *stdapi.Context) error {
+	if err := s.hook("AuditLogListValidate", c); err != nil {
+		return err
+	}
+
+	var opts structs.AuditLogListOptions
+	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).AuditLogList(opts)
+	providerCall("AuditLogList", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
 func (s *Server) BalancerList(c *stdapi.Context) error {
 	if err := s.hook("BalancerListValidate", c); err != nil {
 		return err
@@ -230,7 +306,9 @@ func (s *Server) BalancerList(c *stdapi.Context) error {
 
 	app := c.Var("app")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).BalancerList(app)
+	providerCall("BalancerList", start, err)
 	if err != nil {
 		return err
 	}
@@ -255,7 +333,9 @@ func (s *Server) BuildCreate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).BuildCreate(app, url, opts)
+	providerCall("BuildCreate", start, err)
 	if err != nil {
 		return err
 	}
@@ -276,7 +356,9 @@ func (s *Server) BuildExport(c *stdapi.Context) error {
 	id := c.Var("id")
 	w := c
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).BuildExport(app, id, w)
+	providerCall("BuildExport", start, err)
 	if err != nil {
 		return err
 	}
@@ -284,6 +366,50 @@ func (s *Server) BuildExport(c *stdapi.Context) error {
 	return nil
 }
 
+func (s *Server) BuildAttest(c *stdapi.Context) error {
+	if err := s.hook("BuildAttestValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+	id := c.Var("id")
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).BuildAttest(app, id)
+	providerCall("BuildAttest", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) BuildAttestation(c *stdapi.Context) error {
+	if err := s.hook("BuildAttestationValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+	id := c.Var("id")
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).BuildAttestation(app, id)
+	providerCall("BuildAttestation", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
 func (s *Server) BuildGet(c *stdapi.Context) error {
 	if err := s.hook("BuildGetValidate", c); err != nil {
 		return err
@@ -292,7 +418,9 @@ func (s *Server) BuildGet(c *stdapi.Context) error {
 	app := c.Var("app")
 	id := c.Var("id")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).BuildGet(app, id)
+	providerCall("BuildGet", start, err)
 	if err != nil {
 		return err
 	}
@@ -312,7 +440,9 @@ func (s *Server) BuildImport(c *stdapi.Context) error {
 	app := c.Var("app")
 	r := c
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).BuildImport(app, r)
+	providerCall("BuildImport", start, err)
 	if err != nil {
 		return err
 	}
@@ -336,7 +466,9 @@ func (s *Server) BuildList(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).BuildList(app, opts)
+	providerCall("BuildList", start, err)
 	if err != nil {
 		return err
 	}
@@ -361,7 +493,44 @@ func (s *Server) BuildLogs(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).BuildLogs(app, id, opts)
+	providerCall("BuildLogs", start, err)
+	if err != nil {
+		return err
+	}
+
+	if c, ok := interface{}(v).(io.Closer); ok {
+		defer c.Close()
+	}
+
+	if _, err := io.Copy(c, v); err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return nil
+}
+
+func (s *Server) BuildSbom(c *stdapi.Context) error {
+	if err := s.hook("BuildSbomValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+	id := c.Var("id")
+
+	var opts structs.BuildSbomOptions
+	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).BuildSbom(app, id, opts)
+	providerCall("BuildSbom", start, err)
 	if err != nil {
 		return err
 	}
@@ -394,7 +563,9 @@ func (s *Server) BuildUpdate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).BuildUpdate(app, id, opts)
+	providerCall("BuildUpdate", start, err)
 	if err != nil {
 		return err
 	}
@@ -411,7 +582,35 @@ func (s *Server) CapacityGet(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).CapacityGet()
+	providerCall("CapacityGet", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) CapacityPlan(c *stdapi.Context) error {
+	if err := s.hook("CapacityPlanValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+
+	var opts structs.CapacityPlanOptions
+	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).CapacityPlan(app, opts)
+	providerCall("CapacityPlan", start, err)
 	if err != nil {
 		return err
 	}
@@ -437,7 +636,9 @@ func (s *Server) CertificateApply(c *stdapi.Context) error {
 		return cerr
 	}
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).CertificateApply(app, service, port, id)
+	providerCall("CertificateApply", start, err)
 	if err != nil {
 		return err
 	}
@@ -458,7 +659,9 @@ func (s *Server) CertificateCreate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).CertificateCreate(pub, key, opts)
+	providerCall("CertificateCreate", start, err)
 	if err != nil {
 		return err
 	}
@@ -477,7 +680,9 @@ func (s *Server) CertificateDelete(c *stdapi.Context) error {
 
 	id := c.Var("id")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).CertificateDelete(id)
+	providerCall("CertificateDelete", start, err)
 	if err != nil {
 		return err
 	}
@@ -497,7 +702,9 @@ func (s *Server) CertificateGenerate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).CertificateGenerate(domains, opts)
+	providerCall("CertificateGenerate", start, err)
 	if err != nil {
 		return err
 	}
@@ -519,7 +726,9 @@ func (s *Server) CertificateList(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).CertificateList(opts)
+	providerCall("CertificateList", start, err)
 	if err != nil {
 		return err
 	}
@@ -532,7 +741,9 @@ func (s *Server) CertificateList(c *stdapi.Context) error {
 }
 
 func (s *Server) LetsEncryptConfigGet(c *stdapi.Context) error {
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).LetsEncryptConfigGet()
+	providerCall("LetsEncryptConfigGet", start, err)
 	if err != nil {
 		return err
 	}
@@ -555,7 +766,9 @@ func (s *Server) LetsEncryptConfigApply(c *stdapi.Context) error {
 
 	fmt.Printf("%#v\n", config)
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).LetsEncryptConfigApply(config)
+	providerCall("LetsEncryptConfigApply", start, err)
 	if err != nil {
 		return err
 	}
@@ -575,7 +788,9 @@ func (s *Server) EventSend(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).EventSend(action, opts)
+	providerCall("EventSend", start, err)
 	if err != nil {
 		return err
 	}
@@ -583,6 +798,38 @@ func (s *Server) EventSend(c *stdapi.Context) error {
 	return c.RenderOK()
 }
 
+func (s *Server) EventStream(c *stdapi.Context) error {
+	if err := s.hook("EventStreamValidate", c); err != nil {
+		return err
+	}
+
+	var opts structs.EventStreamOptions
+	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).EventStream(opts)
+	providerCall("EventStream", start, err)
+	if err != nil {
+		return err
+	}
+
+	if c, ok := interface{}(v).(io.Closer); ok {
+		defer c.Close()
+	}
+
+	if _, err := io.Copy(c, v); err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return nil
+}
+
 func (s *Server) FilesDelete(c *stdapi.Context) error {
 	if err := s.hook("FilesDeleteValidate", c); err != nil {
 		return err
@@ -592,7 +839,9 @@ func (s *Server) FilesDelete(c *stdapi.Context) error {
 	pid := c.Var("pid")
 	files := strings.Split(c.Value("files"), ",")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).FilesDelete(app, pid, files)
+	providerCall("FilesDelete", start, err)
 	if err != nil {
 		return err
 	}
@@ -609,7 +858,9 @@ func (s *Server) FilesDownload(c *stdapi.Context) error {
 	pid := c.Var("pid")
 	file := c.Value("file")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).FilesDownload(app, pid, file)
+	providerCall("FilesDownload", start, err)
 	if err != nil {
 		return err
 	}
@@ -643,7 +894,9 @@ func (s *Server) FilesUpload(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).FilesUpload(app, pid, r, opts)
+	providerCall("FilesUpload", start, err)
 	if err != nil {
 		return err
 	}
@@ -660,7 +913,9 @@ func (s *Server) InstanceKeyroll(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).InstanceKeyroll()
+	providerCall("InstanceKeyroll", start, err)
 	if err != nil {
 		return err
 	}
@@ -673,7 +928,9 @@ func (s *Server) InstanceList(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).InstanceList()
+	providerCall("InstanceList", start, err)
 	if err != nil {
 		return err
 	}
@@ -697,7 +954,9 @@ func (s *Server) InstanceShell(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).InstanceShell(id, stdsdk.NewAdapterWs(c.Websocket()), opts)
+	providerCall("InstanceShell", start, err)
 	if err != nil {
 		return err
 	}
@@ -716,7 +975,9 @@ func (s *Server) InstanceTerminate(c *stdapi.Context) error {
 
 	id := c.Var("id")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).InstanceTerminate(id)
+	providerCall("InstanceTerminate", start, err)
 	if err != nil {
 		return err
 	}
@@ -732,7 +993,9 @@ func (s *Server) ObjectDelete(c *stdapi.Context) error {
 	app := c.Var("app")
 	key := c.Var("key")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).ObjectDelete(app, key)
+	providerCall("ObjectDelete", start, err)
 	if err != nil {
 		return err
 	}
@@ -748,7 +1011,9 @@ func (s *Server) ObjectExists(c *stdapi.Context) error {
 	app := c.Var("app")
 	key := c.Var("key")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ObjectExists(app, key)
+	providerCall("ObjectExists", start, err)
 	if err != nil {
 		return err
 	}
@@ -768,7 +1033,9 @@ func (s *Server) ObjectFetch(c *stdapi.Context) error {
 	app := c.Var("app")
 	key := c.Var("key")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ObjectFetch(app, key)
+	providerCall("ObjectFetch", start, err)
 	if err != nil {
 		return err
 	}
@@ -796,7 +1063,9 @@ func (s *Server) ObjectList(c *stdapi.Context) error {
 	app := c.Var("app")
 	prefix := c.Value("prefix")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ObjectList(app, prefix)
+	providerCall("ObjectList", start, err)
 	if err != nil {
 		return err
 	}
@@ -822,7 +1091,84 @@ func (s *Server) ObjectStore(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ObjectStore(app, key, r, opts)
+	providerCall("ObjectStore", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) PolicyCreate(c *stdapi.Context) error {
+	if err := s.hook("PolicyCreateValidate", c); err != nil {
+		return err
+	}
+
+	name := c.Value("name")
+
+	var opts structs.PolicyCreateOptions
+	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).PolicyCreate(name, opts)
+	providerCall("PolicyCreate", start, err)
+	if err != nil {
+		return err
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) PolicyDelete(c *stdapi.Context) error {
+	if err := s.hook("PolicyDeleteValidate", c); err != nil {
+		return err
+	}
+
+	name := c.Var("name")
+
+	start := time.Now()
+	err := s.provider(c).WithContext(c.Context()).PolicyDelete(name)
+	providerCall("PolicyDelete", start, err)
+	if err != nil {
+		return err
+	}
+
+	return c.RenderOK()
+}
+
+func (s *Server) PolicyGet(c *stdapi.Context) error {
+	if err := s.hook("PolicyGetValidate", c); err != nil {
+		return err
+	}
+
+	name := c.Var("name")
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).PolicyGet(name)
+	providerCall("PolicyGet", start, err)
+	if err != nil {
+		return err
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) PolicyList(c *stdapi.Context) error {
+	if err := s.hook("PolicyListValidate", c); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).PolicyList()
+	providerCall("PolicyList", start, err)
 	if err != nil {
 		return err
 	}
@@ -848,7 +1194,9 @@ func (s *Server) ProcessExec(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ProcessExec(app, pid, command, stdsdk.NewAdapterWs(c.Websocket()), opts)
+	providerCall("ProcessExec", start, err)
 	if err != nil {
 		renderStatusCode(c, v)
 		return err
@@ -869,7 +1217,9 @@ func (s *Server) ProcessGet(c *stdapi.Context) error {
 	app := c.Var("app")
 	pid := c.Var("pid")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ProcessGet(app, pid)
+	providerCall("ProcessGet", start, err)
 	if err != nil {
 		return err
 	}
@@ -893,7 +1243,9 @@ func (s *Server) ProcessList(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ProcessList(app, opts)
+	providerCall("ProcessList", start, err)
 	if err != nil {
 		return err
 	}
@@ -918,7 +1270,9 @@ func (s *Server) ProcessLogs(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ProcessLogs(app, pid, opts)
+	providerCall("ProcessLogs", start, err)
 	if err != nil {
 		return err
 	}
@@ -951,7 +1305,9 @@ func (s *Server) ProcessRun(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ProcessRun(app, service, opts)
+	providerCall("ProcessRun", start, err)
 	if err != nil {
 		return err
 	}
@@ -971,7 +1327,9 @@ func (s *Server) ProcessStop(c *stdapi.Context) error {
 	app := c.Var("app")
 	pid := c.Var("pid")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).ProcessStop(app, pid)
+	providerCall("ProcessStop", start, err)
 	if err != nil {
 		return err
 	}
@@ -996,7 +1354,9 @@ func (s *Server) Proxy(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).Proxy(host, port, stdsdk.NewAdapterWs(c.Websocket()), opts)
+	providerCall("Proxy", start, err)
 	if err != nil {
 		return err
 	}
@@ -1040,7 +1400,9 @@ func (s *Server) RegistryAdd(c *stdapi.Context) error {
 	username := c.Value("username")
 	password := c.Value("password")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).RegistryAdd(server, username, password)
+	providerCall("RegistryAdd", start, err)
 	if err != nil {
 		return err
 	}
@@ -1057,7 +1419,9 @@ func (s *Server) RegistryList(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).RegistryList()
+	providerCall("RegistryList", start, err)
 	if err != nil {
 		return err
 	}
@@ -1076,7 +1440,9 @@ func (s *Server) RegistryProxy(c *stdapi.Context) error {
 
 	ctx := c
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).RegistryProxy(ctx)
+	providerCall("RegistryProxy", start, err)
 	if err != nil {
 		return err
 	}
@@ -1091,7 +1457,9 @@ func (s *Server) RegistryRemove(c *stdapi.Context) error {
 
 	server := c.Var("server")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).RegistryRemove(server)
+	providerCall("RegistryRemove", start, err)
 	if err != nil {
 		return err
 	}
@@ -1099,6 +1467,33 @@ func (s *Server) RegistryRemove(c *stdapi.Context) error {
 	return c.RenderOK()
 }
 
+func (s *Server) ReleaseApprove(c *stdapi.Context) error {
+	if err := s.hook("ReleaseApproveValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+	id := c.Var("id")
+
+	var opts structs.ReleaseApproveOptions
+	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).ReleaseApprove(app, id, opts)
+	providerCall("ReleaseApprove", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
 func (s *Server) ReleaseCreate(c *stdapi.Context) error {
 	if err := s.hook("ReleaseCreateValidate", c); err != nil {
 		return err
@@ -1111,7 +1506,36 @@ func (s *Server) ReleaseCreate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ReleaseCreate(app, opts)
+	providerCall("ReleaseCreate", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) ReleaseDiff(c *stdapi.Context) error {
+	if err := s.hook("ReleaseDiffValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+	id := c.Var("id")
+
+	var opts structs.ReleaseDiffOptions
+	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).ReleaseDiff(app, id, opts)
+	providerCall("ReleaseDiff", start, err)
 	if err != nil {
 		return err
 	}
@@ -1131,7 +1555,9 @@ func (s *Server) ReleaseGet(c *stdapi.Context) error {
 	app := c.Var("app")
 	id := c.Var("id")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ReleaseGet(app, id)
+	providerCall("ReleaseGet", start, err)
 	if err != nil {
 		return err
 	}
@@ -1155,7 +1581,36 @@ func (s *Server) ReleaseList(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ReleaseList(app, opts)
+	providerCall("ReleaseList", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) ReleasePlan(c *stdapi.Context) error {
+	if err := s.hook("ReleasePlanValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+	id := c.Var("id")
+
+	var opts structs.ReleasePromoteOptions
+	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).ReleasePlan(app, id, opts)
+	providerCall("ReleasePlan", start, err)
 	if err != nil {
 		return err
 	}
@@ -1180,7 +1635,9 @@ func (s *Server) ReleasePromote(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).ReleasePromote(app, id, opts)
+	providerCall("ReleasePromote", start, err)
 	if err != nil {
 		return err
 	}
@@ -1188,6 +1645,28 @@ func (s *Server) ReleasePromote(c *stdapi.Context) error {
 	return c.RenderOK()
 }
 
+func (s *Server) ResourceBackupList(c *stdapi.Context) error {
+	if err := s.hook("ResourceBackupListValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+	name := c.Var("name")
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).ResourceBackupList(app, name)
+	providerCall("ResourceBackupList", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
 func (s *Server) ResourceConsole(c *stdapi.Context) error {
 	if err := s.hook("ResourceConsoleValidate", c); err != nil {
 		return err
@@ -1202,7 +1681,9 @@ func (s *Server) ResourceConsole(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).ResourceConsole(app, name, rw, opts)
+	providerCall("ResourceConsole", start, err)
 	if err != nil {
 		return err
 	}
@@ -1218,7 +1699,9 @@ func (s *Server) ResourceExport(c *stdapi.Context) error {
 	app := c.Var("app")
 	name := c.Var("name")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ResourceExport(app, name)
+	providerCall("ResourceExport", start, err)
 	if err != nil {
 		return err
 	}
@@ -1246,7 +1729,9 @@ func (s *Server) ResourceGet(c *stdapi.Context) error {
 	app := c.Var("app")
 	name := c.Var("name")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ResourceGet(app, name)
+	providerCall("ResourceGet", start, err)
 	if err != nil {
 		return err
 	}
@@ -1267,7 +1752,9 @@ func (s *Server) ResourceImport(c *stdapi.Context) error {
 	name := c.Var("name")
 	r := c
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).ResourceImport(app, name, r)
+	providerCall("ResourceImport", start, err)
 	if err != nil {
 		return err
 	}
@@ -1282,7 +1769,9 @@ func (s *Server) ResourceList(c *stdapi.Context) error {
 
 	app := c.Var("app")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ResourceList(app)
+	providerCall("ResourceList", start, err)
 	if err != nil {
 		return err
 	}
@@ -1294,6 +1783,25 @@ func (s *Server) ResourceList(c *stdapi.Context) error {
 	return c.RenderJSON(v)
 }
 
+func (s *Server) ResourceRestore(c *stdapi.Context) error {
+	if err := s.hook("ResourceRestoreValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+	name := c.Var("name")
+	backup := c.Var("backup")
+
+	start := time.Now()
+	err := s.provider(c).WithContext(c.Context()).ResourceRestore(app, name, backup)
+	providerCall("ResourceRestore", start, err)
+	if err != nil {
+		return err
+	}
+
+	return c.RenderOK()
+}
+
 func (s *Server) ServiceList(c *stdapi.Context) error {
 	if err := s.hook("ServiceListValidate", c); err != nil {
 		return err
@@ -1301,7 +1809,9 @@ func (s *Server) ServiceList(c *stdapi.Context) error {
 
 	app := c.Var("app")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ServiceList(app)
+	providerCall("ServiceList", start, err)
 	if err != nil {
 		return err
 	}
@@ -1315,14 +1825,16 @@ func (s *Server) ServiceList(c *stdapi.Context) error {
 
 func (s *Server) ServiceLogs(c *stdapi.Context) error {
 	app := c.Var("app")
-	name := c.Var("service")
+	name := c.Var("name")
 
 	var opts structs.LogsOptions
 	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).ServiceLogs(app, name, opts)
+	providerCall("ServiceLogs", start, err)
 	if err != nil {
 		return err
 	}
@@ -1350,7 +1862,9 @@ func (s *Server) ServiceRestart(c *stdapi.Context) error {
 	app := c.Var("app")
 	name := c.Var("name")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).ServiceRestart(app, name)
+	providerCall("ServiceRestart", start, err)
 	if err != nil {
 		return err
 	}
@@ -1371,7 +1885,9 @@ func (s *Server) ServiceUpdate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).ServiceUpdate(app, name, opts)
+	providerCall("ServiceUpdate", start, err)
 	if err != nil {
 		return err
 	}
@@ -1388,7 +1904,9 @@ func (s *Server) SystemGet(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemGet()
+	providerCall("SystemGet", start, err)
 	if err != nil {
 		return err
 	}
@@ -1414,7 +1932,9 @@ func (s *Server) SystemLogs(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemLogs(opts)
+	providerCall("SystemLogs", start, err)
 	if err != nil {
 		return err
 	}
@@ -1444,7 +1964,9 @@ func (s *Server) SystemMetrics(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemMetrics(opts)
+	providerCall("SystemMetrics", start, err)
 	if err != nil {
 		return err
 	}
@@ -1457,7 +1979,9 @@ func (s *Server) SystemMetrics(c *stdapi.Context) error {
 }
 
 func (s *Server) SystemJwtSignKeyRotate(c *stdapi.Context) error {
+	start := time.Now()
 	_, err := s.provider(c).WithContext(c.Context()).SystemJwtSignKeyRotate()
+	providerCall("SystemJwtSignKeyRotate", start, err)
 	if err != nil {
 		return err
 	}
@@ -1466,21 +1990,60 @@ func (s *Server) SystemJwtSignKeyRotate(c *stdapi.Context) error {
 
 func (s *Server) SystemJwtToken(c *stdapi.Context) error {
 	role := c.Value("role")
-	durationInHour, err := strconv.Atoi(c.Value("durationInHour"))
-	if err != nil {
-		return fmt.Errorf("invalid duration")
+
+	var duration time.Duration
+
+	if ttl := c.Value("ttl"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return fmt.Errorf("invalid ttl")
+		}
+		duration = d
+	} else {
+		durationInHour, err := strconv.Atoi(c.Value("durationInHour"))
+		if err != nil {
+			return fmt.Errorf("invalid duration")
+		}
+		duration = time.Hour * time.Duration(durationInHour)
+	}
+
+	var apps []string
+	if v := c.Value("apps"); v != "" {
+		apps = strings.Split(v, ",")
+	}
+
+	// the system- prefix is kept for the users of tokens minted without one
+	user := c.Value("user")
+	if strings.HasPrefix(user, "system-") {
+		return fmt.Errorf("invalid user: %s", user)
 	}
 
 	var tk string
+	var err error
 
-	switch role {
-	case "read":
-		tk, err = s.JwtMngr.ReadToken(time.Hour * time.Duration(durationInHour))
+	switch {
+	case role == "read" && len(apps) == 0 && user == "":
+		tk, err = s.JwtMngr.ReadToken(duration)
 		if err != nil {
 			return err
 		}
-	case "write":
-		tk, err = s.JwtMngr.WriteToken(time.Hour * time.Duration(durationInHour))
+	case role == "write" && len(apps) == 0 && user == "":
+		tk, err = s.JwtMngr.WriteToken(duration)
+		if err != nil {
+			return err
+		}
+	default:
+		if _, ok := structs.BuiltinPolicy(role); !ok {
+			if _, err := s.provider(c).WithContext(c.Context()).PolicyGet(role); err != nil {
+				return err
+			}
+		}
+
+		if user == "" {
+			user = "system-access"
+		}
+
+		tk, err = s.JwtMngr.PolicyToken(user, role, apps, duration)
 		if err != nil {
 			return err
 		}
@@ -1501,7 +2064,9 @@ func (s *Server) SystemProcesses(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemProcesses(opts)
+	providerCall("SystemProcesses", start, err)
 	if err != nil {
 		return err
 	}
@@ -1523,7 +2088,9 @@ func (s *Server) SystemReadAccess(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemProcesses(opts)
+	providerCall("SystemProcesses", start, err)
 	if err != nil {
 		return err
 	}
@@ -1540,7 +2107,9 @@ func (s *Server) SystemReleases(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemReleases()
+	providerCall("SystemReleases", start, err)
 	if err != nil {
 		return err
 	}
@@ -1552,6 +2121,21 @@ func (s *Server) SystemReleases(c *stdapi.Context) error {
 	return c.RenderJSON(v)
 }
 
+func (s *Server) SystemSigningKey(c *stdapi.Context) error {
+	if err := s.hook("SystemSigningKeyValidate", c); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).SystemSigningKey()
+	providerCall("SystemSigningKey", start, err)
+	if err != nil {
+		return err
+	}
+
+	return c.RenderJSON(v)
+}
+
 func (s *Server) SystemResourceCreate(c *stdapi.Context) error {
 	if err := s.hook("SystemResourceCreateValidate", c); err != nil {
 		return err
@@ -1564,7 +2148,9 @@ func (s *Server) SystemResourceCreate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemResourceCreate(kind, opts)
+	providerCall("SystemResourceCreate", start, err)
 	if err != nil {
 		return err
 	}
@@ -1583,7 +2169,9 @@ func (s *Server) SystemResourceDelete(c *stdapi.Context) error {
 
 	name := c.Var("name")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).SystemResourceDelete(name)
+	providerCall("SystemResourceDelete", start, err)
 	if err != nil {
 		return err
 	}
@@ -1598,7 +2186,9 @@ func (s *Server) SystemResourceGet(c *stdapi.Context) error {
 
 	name := c.Var("name")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemResourceGet(name)
+	providerCall("SystemResourceGet", start, err)
 	if err != nil {
 		return err
 	}
@@ -1618,7 +2208,9 @@ func (s *Server) SystemResourceLink(c *stdapi.Context) error {
 	name := c.Var("name")
 	app := c.Value("app")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemResourceLink(name, app)
+	providerCall("SystemResourceLink", start, err)
 	if err != nil {
 		return err
 	}
@@ -1635,7 +2227,9 @@ func (s *Server) SystemResourceList(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemResourceList()
+	providerCall("SystemResourceList", start, err)
 	if err != nil {
 		return err
 	}
@@ -1652,7 +2246,9 @@ func (s *Server) SystemResourceTypes(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemResourceTypes()
+	providerCall("SystemResourceTypes", start, err)
 	if err != nil {
 		return err
 	}
@@ -1672,7 +2268,9 @@ func (s *Server) SystemResourceUnlink(c *stdapi.Context) error {
 	name := c.Var("name")
 	app := c.Var("app")
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemResourceUnlink(name, app)
+	providerCall("SystemResourceUnlink", start, err)
 	if err != nil {
 		return err
 	}
@@ -1696,7 +2294,9 @@ func (s *Server) SystemResourceUpdate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	v, err := s.provider(c).WithContext(c.Context()).SystemResourceUpdate(name, opts)
+	providerCall("SystemResourceUpdate", start, err)
 	if err != nil {
 		return err
 	}
@@ -1722,7 +2322,137 @@ func (s *Server) SystemUpdate(c *stdapi.Context) error {
 		return err
 	}
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).SystemUpdate(opts)
+	providerCall("SystemUpdate", start, err)
+	if err != nil {
+		return err
+	}
+
+	return c.RenderOK()
+}
+
+func (s *Server) TimerList(c *stdapi.Context) error {
+	if err := s.hook("TimerListValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).TimerList(app)
+	providerCall("TimerList", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) TimerRun(c *stdapi.Context) error {
+	if err := s.hook("TimerRunValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+	name := c.Var("name")
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).TimerRun(app, name)
+	providerCall("TimerRun", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) TimerRunList(c *stdapi.Context) error {
+	if err := s.hook("TimerRunListValidate", c); err != nil {
+		return err
+	}
+
+	app := c.Var("app")
+	name := c.Var("name")
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).TimerRunList(app, name)
+	providerCall("TimerRunList", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) WebhookDeliveryList(c *stdapi.Context) error {
+	if err := s.hook("WebhookDeliveryListValidate", c); err != nil {
+		return err
+	}
+
+	name := c.Var("name")
+
+	var opts structs.WebhookDeliveryListOptions
+	if err := stdapi.UnmarshalOptions(c.Request(), &opts); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).WebhookDeliveryList(name, opts)
+	providerCall("WebhookDeliveryList", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) WebhookList(c *stdapi.Context) error {
+	if err := s.hook("WebhookListValidate", c); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	v, err := s.provider(c).WithContext(c.Context()).WebhookList()
+	providerCall("WebhookList", start, err)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := interface{}(v).(Sortable); ok {
+		sort.Slice(v, vs.Less)
+	}
+
+	return c.RenderJSON(v)
+}
+
+func (s *Server) WebhookRedeliver(c *stdapi.Context) error {
+	if err := s.hook("WebhookRedeliverValidate", c); err != nil {
+		return err
+	}
+
+	name := c.Var("name")
+	id := c.Var("id")
+
+	start := time.Now()
+	err := s.provider(c).WithContext(c.Context()).WebhookRedeliver(name, id)
+	providerCall("WebhookRedeliver", start, err)
 	if err != nil {
 		return err
 	}
@@ -1741,7 +2471,9 @@ func (s *Server) CertificateRenew(c *stdapi.Context) error {
 
 	id := c.Var("id")
 
+	start := time.Now()
 	err := s.provider(c).WithContext(c.Context()).CertificateRenew(id)
+	providerCall("CertificateRenew", start, err)
 	if err != nil {
 		return err
 	}
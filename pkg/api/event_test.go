@@ -1,8 +1,11 @@
 package api_test
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
@@ -57,3 +60,41 @@ func TestEventSendError(t *testing.T) {
 		require.EqualError(t, err, "err1")
 	})
 }
+
+func TestEventStream(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		d1 := []byte(`{"action":"release:promote","data":{"app":"app1"},"status":"success","timestamp":"2020-01-02T03:04:05Z"}` + "\n")
+		r1 := io.NopCloser(bytes.NewReader(d1))
+		opts := structs.EventStreamOptions{
+			Action: options.String("release:*"),
+			App:    options.String("app1"),
+			Follow: options.Bool(true),
+			Since:  options.Duration(time.Hour),
+		}
+		ro := stdsdk.RequestOptions{
+			Headers: stdsdk.Headers{
+				"Action": "release:*",
+				"App":    "app1",
+				"Follow": "true",
+			},
+		}
+		p.On("EventStream", opts).Return(r1, nil)
+		r2, err := c.Websocket("/events", ro)
+		require.NoError(t, err)
+		d2, err := io.ReadAll(r2)
+		require.NoError(t, err)
+		require.Equal(t, d1, d2)
+	})
+}
+
+func TestEventStreamError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		opts := structs.EventStreamOptions{Since: options.Duration(time.Hour)}
+		p.On("EventStream", opts).Return(nil, fmt.Errorf("err1"))
+		r1, err := c.Websocket("/events", stdsdk.RequestOptions{})
+		require.NoError(t, err)
+		d1, err := io.ReadAll(r1)
+		require.NoError(t, err)
+		require.Equal(t, []byte("ERROR: err1\n"), d1)
+	})
+}
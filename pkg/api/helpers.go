@@ -3,9 +3,36 @@ package api
 import (
 	"fmt"
 	"io"
+	"strings"
+
+	"github.com/convox/stdapi"
+	"github.com/gorilla/mux"
 )
 
 func renderStatusCode(w io.Writer, code int) error {
 	_, err := fmt.Fprintf(w, "F1E49A85-0AD7-4AEF-A618-C249C6E6568D:%d\n", code)
 	return err
 }
+
+// contextApp returns the app targeted by the current route, if any
+func contextApp(c *stdapi.Context) string {
+	if app := c.Var("app"); app != "" {
+		return app
+	}
+
+	if strings.HasPrefix(contextRoute(c), "/apps/{name}") {
+		return c.Var("name")
+	}
+
+	return ""
+}
+
+func contextRoute(c *stdapi.Context) string {
+	if r := mux.CurrentRoute(c.Request()); r != nil {
+		if t, err := r.GetPathTemplate(); err == nil {
+			return t
+		}
+	}
+
+	return c.Request().URL.Path
+}
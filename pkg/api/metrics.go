@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/convox/convox/pkg/metrics"
+	"github.com/convox/stdapi"
+)
+
+var (
+	apiRequests        = metrics.NewCounter("convox_api_requests_total", "Requests served by the rack api", "method", "route", "code")
+	apiRequestDuration = metrics.NewHistogram("convox_api_request_duration_seconds", "Time taken to serve a rack api request", metrics.DefaultBuckets, "method", "route")
+	providerCalls      = metrics.NewHistogram("convox_provider_call_duration_seconds", "Time taken by the provider to handle a call from the rack api", metrics.DefaultBuckets, "call", "result")
+)
+
+// instrument counts and times every request by route template so that the
+// labels stay bounded no matter which apps or objects are requested
+func (s *Server) instrument(next stdapi.HandlerFunc) stdapi.HandlerFunc {
+	return func(c *stdapi.Context) error {
+		start := time.Now()
+
+		err := next(c)
+
+		method := c.Request().Method
+		route := contextRoute(c)
+
+		apiRequests.Inc(method, route, strconv.Itoa(responseCode(c, err)))
+		apiRequestDuration.Observe(time.Since(start).Seconds(), method, route)
+
+		return err
+	}
+}
+
+// providerCall records how long a provider call that started at start took
+func providerCall(name string, start time.Time, err error) {
+	result := "success"
+
+	if err != nil {
+		result = "error"
+	}
+
+	providerCalls.Observe(time.Since(start).Seconds(), name, result)
+}
+
+// responseCode is the status code stdapi will answer with once the handler
+// has returned err
+func responseCode(c *stdapi.Context, err error) int {
+	switch t := err.(type) {
+	case nil:
+	case stdapi.Error:
+		return t.Code()
+	default:
+		return http.StatusInternalServerError
+	}
+
+	if code := c.Response().Code(); code != 0 {
+		return code
+	}
+
+	return http.StatusOK
+}
+
+func (s *Server) Metrics(c *stdapi.Context) error {
+	metrics.Handler().ServeHTTP(c.Response(), c.Request())
+	return nil
+}
@@ -0,0 +1,37 @@
+package api_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/stdsdk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var a1 structs.Apps
+		p.On("AppList").Return(structs.Apps{}, nil)
+		require.NoError(t, c.Get("/apps", stdsdk.RequestOptions{}, &a1))
+
+		p.On("AppGet", "app1").Return(nil, fmt.Errorf("err1"))
+		require.EqualError(t, c.Get("/apps/app1", stdsdk.RequestOptions{}, &structs.App{}), "err1")
+
+		res, err := c.GetStream("/metrics", stdsdk.RequestOptions{})
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", res.Header.Get("Content-Type"))
+
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		require.Contains(t, string(data), `convox_api_requests_total{method="GET",route="/apps",code="200"}`)
+		require.Contains(t, string(data), `convox_api_requests_total{method="GET",route="/apps/{name}",code="500"}`)
+		require.Contains(t, string(data), `convox_api_request_duration_seconds_count{method="GET",route="/apps"}`)
+		require.Contains(t, string(data), `convox_provider_call_duration_seconds_count{call="AppList",result="success"}`)
+		require.Contains(t, string(data), `convox_provider_call_duration_seconds_count{call="AppGet",result="error"}`)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/convox/convox/pkg/jwt"
 	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/convox/stdsdk"
@@ -134,6 +135,28 @@ func TestObjectStore(t *testing.T) {
 	})
 }
 
+func TestObjectStoreBuild(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		o1 := fxObject
+		o2 := structs.Object{}
+
+		for _, key := range []string{"build/build1/sbom/web.json", "other/../build/build1/attestation.json", "/build/build1/logs"} {
+			ro := stdsdk.RequestOptions{Body: strings.NewReader("data")}
+			err := c.Post(fmt.Sprintf("/apps/app1/objects/%s", key), ro, &o2)
+			require.EqualError(t, err, "only the build process of build build1 can do this", key)
+		}
+
+		p.On("ObjectStore", "app1", "build/build1/sbom/web.json", mock.Anything, structs.ObjectStoreOptions{}).Return(&o1, nil)
+		ro := stdsdk.RequestOptions{
+			Body:    strings.NewReader("data"),
+			Headers: stdsdk.Headers{"Build-Token": jwt.NewJwtManager("test").BuildToken("app1", "build1")},
+		}
+		err := c.Post("/apps/app1/objects/build/build1/sbom/web.json", ro, &o2)
+		require.NoError(t, err)
+		require.Equal(t, o1, o2)
+	})
+}
+
 func TestObjectStoreError(t *testing.T) {
 	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
 		var o1 *structs.Object
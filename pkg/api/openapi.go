@@ -0,0 +1,31 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"os"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/stdapi"
+)
+
+// openapi is generated from the provider interface by make generate-provider
+//
+//go:embed openapi.json
+var openapi []byte
+
+// OpenAPI serves the OpenAPI document describing this api, versioned as the
+// running rack
+func (s *Server) OpenAPI(c *stdapi.Context) error {
+	var doc map[string]interface{}
+
+	if err := json.Unmarshal(openapi, &doc); err != nil {
+		return err
+	}
+
+	if info, ok := doc["info"].(map[string]interface{}); ok {
+		info["version"] = common.CoalesceString(os.Getenv("VERSION"), "dev")
+	}
+
+	return c.RenderJSON(doc)
+}
@@ -0,0 +1,35 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/stdsdk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var doc struct {
+			OpenAPI string
+			Info    map[string]string
+			Paths   map[string]map[string]struct {
+				OperationId string
+			}
+		}
+
+		require.NoError(t, c.Get("/openapi.json", stdsdk.RequestOptions{}, &doc))
+
+		require.Equal(t, "3.0.3", doc.OpenAPI)
+		require.Equal(t, "dev", doc.Info["version"])
+		require.Equal(t, "AppList", doc.Paths["/apps"]["get"].OperationId)
+		require.Equal(t, "AppGet", doc.Paths["/apps/{name}"]["get"].OperationId)
+		require.Equal(t, "EventStream", doc.Paths["/events"]["get"].OperationId)
+		require.Equal(t, "EventSend", doc.Paths["/events"]["post"].OperationId)
+		require.Equal(t, "AppConfigSet", doc.Paths["/apps/{app}/configs/{name}"]["put"].OperationId)
+		require.Equal(t, "CertificateRenew", doc.Paths["/certificates/{id}/renew"]["post"].OperationId)
+		require.Equal(t, "LetsEncryptConfigApply", doc.Paths["/letsencrypt/config"]["put"].OperationId)
+		require.Equal(t, "ServiceLogs", doc.Paths["/apps/{app}/services/{name}/logs"]["get"].OperationId)
+		require.Equal(t, "SystemJwtToken", doc.Paths["/system/jwt/token"]["post"].OperationId)
+	})
+}
@@ -2,12 +2,15 @@ package api_test
 
 import (
 	"fmt"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/sdk"
 	"github.com/convox/stdsdk"
+	jwtv4 "github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/require"
 )
 
@@ -20,6 +23,87 @@ var fxRelease = structs.Release{
 	Created:  time.Now().UTC(),
 }
 
+func TestReleaseApprove(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		uc := testUserClient(t, c, "user1")
+		r1 := fxRelease
+		r1.Approvals = structs.ReleaseApprovals{{User: "user1", Time: time.Now().UTC()}}
+		r2 := structs.Release{}
+		p.On("ReleaseApprove", "app1", "release1", structs.ReleaseApproveOptions{User: options.String("user1")}).Return(&r1, nil)
+		err := uc.Post("/apps/app1/releases/release1/approve", stdsdk.RequestOptions{}, &r2)
+		require.NoError(t, err)
+		require.Equal(t, r1, r2)
+	})
+}
+
+func TestReleaseApproveUser(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		uc := testUserClient(t, c, "user1")
+		r1 := fxRelease
+		r2 := structs.Release{}
+		opts := structs.ReleaseApproveOptions{User: options.String("user1")}
+		ro := stdsdk.RequestOptions{
+			Headers: stdsdk.Headers{
+				"User": "user2",
+			},
+		}
+		p.On("ReleaseApprove", "app1", "release1", opts).Return(&r1, nil)
+		err := uc.Post("/apps/app1/releases/release1/approve", ro, &r2)
+		require.NoError(t, err)
+	})
+}
+
+func TestReleaseApprovePassword(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		u := *c.Endpoint
+		u.User = url.UserPassword("user1", "")
+
+		pc, err := sdk.New(u.String())
+		require.NoError(t, err)
+
+		err = pc.Client.Post("/apps/app1/releases/release1/approve", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "releases can only be approved by users authenticated with a token")
+	})
+}
+
+func TestReleaseApproveRackToken(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		pc := testPolicyClient(t, c, "deployer", []string{"app1"})
+		err := pc.Post("/apps/app1/releases/release1/approve", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "releases can not be approved with a rack token")
+	})
+}
+
+func TestReleaseApproveError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		uc := testUserClient(t, c, "user1")
+		var r1 *structs.Release
+		p.On("ReleaseApprove", "app1", "release1", structs.ReleaseApproveOptions{User: options.String("user1")}).Return(nil, fmt.Errorf("err1"))
+		err := uc.Post("/apps/app1/releases/release1/approve", stdsdk.RequestOptions{}, &r1)
+		require.EqualError(t, err, "err1")
+		require.Nil(t, r1)
+	})
+}
+
+// testUserClient authenticates as user with a token like the ones issued to
+// people by the console
+func testUserClient(t *testing.T, c *stdsdk.Client, user string) *stdsdk.Client {
+	tk, err := jwtv4.NewWithClaims(jwtv4.SigningMethodHS256, jwtv4.MapClaims{
+		"user":      user,
+		"role":      structs.ConvoxRoleReadWrite,
+		"expiresAt": time.Now().UTC().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test"))
+	require.NoError(t, err)
+
+	u := *c.Endpoint
+	u.User = url.UserPassword("jwt", tk)
+
+	uc, err := sdk.New(u.String())
+	require.NoError(t, err)
+
+	return uc.Client
+}
+
 func TestReleaseCreate(t *testing.T) {
 	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
 		r1 := fxRelease
@@ -61,6 +145,43 @@ func TestReleaseCreateError(t *testing.T) {
 	})
 }
 
+func TestReleaseDiff(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		d1 := structs.ReleaseDiff{
+			App:  "app1",
+			From: "release1",
+			To:   "release2",
+			Changes: structs.ReleaseDiffChanges{
+				{Action: "changed", Kind: "build", From: "build1", To: "build2"},
+				{Action: "added", Kind: "env", Name: "FOO"},
+			},
+		}
+		d2 := structs.ReleaseDiff{}
+		opts := structs.ReleaseDiffOptions{
+			From: options.String("release1"),
+		}
+		ro := stdsdk.RequestOptions{
+			Query: stdsdk.Query{
+				"from": "release1",
+			},
+		}
+		p.On("ReleaseDiff", "app1", "release2", opts).Return(&d1, nil)
+		err := c.Get("/apps/app1/releases/release2/diff", ro, &d2)
+		require.NoError(t, err)
+		require.Equal(t, d1, d2)
+	})
+}
+
+func TestReleaseDiffError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var d1 *structs.ReleaseDiff
+		p.On("ReleaseDiff", "app1", "release2", structs.ReleaseDiffOptions{}).Return(nil, fmt.Errorf("err1"))
+		err := c.Get("/apps/app1/releases/release2/diff", stdsdk.RequestOptions{}, &d1)
+		require.EqualError(t, err, "err1")
+		require.Nil(t, d1)
+	})
+}
+
 func TestReleaseGet(t *testing.T) {
 	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
 		r1 := fxRelease
@@ -111,6 +232,44 @@ func TestReleaseListError(t *testing.T) {
 	})
 }
 
+func TestReleasePlan(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		p1 := structs.ReleasePlan{
+			App:     "app1",
+			Release: "release1",
+			Operations: structs.ReleasePlanOperations{
+				{Action: "update", Kind: "Deployment", Name: "web", Namespace: "rack1-app1", Changes: structs.ReleasePlanChanges{
+					{Field: "spec.replicas", From: "1", To: "2"},
+				}},
+			},
+			Warnings: []string{"warning1"},
+		}
+		p2 := structs.ReleasePlan{}
+		opts := structs.ReleasePromoteOptions{
+			Development: options.Bool(true),
+		}
+		ro := stdsdk.RequestOptions{
+			Params: stdsdk.Params{
+				"development": "true",
+			},
+		}
+		p.On("ReleasePlan", "app1", "release1", opts).Return(&p1, nil)
+		err := c.Post("/apps/app1/releases/release1/plan", ro, &p2)
+		require.NoError(t, err)
+		require.Equal(t, p1, p2)
+	})
+}
+
+func TestReleasePlanError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var p1 *structs.ReleasePlan
+		p.On("ReleasePlan", "app1", "release1", structs.ReleasePromoteOptions{}).Return(nil, fmt.Errorf("err1"))
+		err := c.Post("/apps/app1/releases/release1/plan", stdsdk.RequestOptions{}, &p1)
+		require.EqualError(t, err, "err1")
+		require.Nil(t, p1)
+	})
+}
+
 func TestReleasePromote(t *testing.T) {
 	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
 		r1 := fxRelease
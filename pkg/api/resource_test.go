@@ -3,6 +3,7 @@ package api_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
@@ -27,6 +28,29 @@ var fxResourceType = structs.ResourceType{
 	},
 }
 
+func TestResourceBackupList(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		b1 := structs.ResourceBackups{
+			{Id: "20240306T030000Z", Created: time.Date(2024, 3, 6, 3, 0, 0, 0, time.UTC), Resource: "resource1"},
+		}
+		b2 := structs.ResourceBackups{}
+		p.On("ResourceBackupList", "app1", "resource1").Return(b1, nil)
+		err := c.Get("/apps/app1/resources/resource1/backups", stdsdk.RequestOptions{}, &b2)
+		require.NoError(t, err)
+		require.Equal(t, b1, b2)
+	})
+}
+
+func TestResourceBackupListError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var b1 structs.ResourceBackups
+		p.On("ResourceBackupList", "app1", "resource1").Return(nil, fmt.Errorf("err1"))
+		err := c.Get("/apps/app1/resources/resource1/backups", stdsdk.RequestOptions{}, &b1)
+		require.EqualError(t, err, "err1")
+		require.Nil(t, b1)
+	})
+}
+
 func TestResourceGet(t *testing.T) {
 	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
 		r1 := fxResource
@@ -69,6 +93,22 @@ func TestResourceListError(t *testing.T) {
 	})
 }
 
+func TestResourceRestore(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		p.On("ResourceRestore", "app1", "resource1", "20240306T030000Z").Return(nil)
+		err := c.Post("/apps/app1/resources/resource1/backups/20240306T030000Z/restore", stdsdk.RequestOptions{}, nil)
+		require.NoError(t, err)
+	})
+}
+
+func TestResourceRestoreError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		p.On("ResourceRestore", "app1", "resource1", "20240306T030000Z").Return(fmt.Errorf("err1"))
+		err := c.Post("/apps/app1/resources/resource1/backups/20240306T030000Z/restore", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "err1")
+	})
+}
+
 func TestSystemResourceCreate(t *testing.T) {
 	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
 		r1 := fxResource
@@ -12,19 +12,26 @@ func (s *Server) setupRoutes(r stdapi.Router) {
 	r.Route("GET", "/apps", s.AppList)
 	r.Route("SOCKET", "/apps/{name}/logs", s.AppLogs)
 	r.Route("GET", "/apps/{name}/metrics", s.AppMetrics)
+	r.Route("POST", "/apps/{name}/prune", s.AppPrune)
 	r.Route("PUT", "/apps/{name}", s.AppUpdate)
+	r.Route("", "", s.AuditLogAppend)
+	r.Route("GET", "/system/audit", s.AuditLogList)
 	r.Route("GET", "/apps/{app}/balancers", s.BalancerList)
+	r.Route("POST", "/apps/{app}/builds/{id}/attestation", s.BuildAttest)
+	r.Route("GET", "/apps/{app}/builds/{id}/attestation", s.BuildAttestation)
 	r.Route("POST", "/apps/{app}/builds", s.BuildCreate)
 	r.Route("GET", "/apps/{app}/builds/{id}.tgz", s.BuildExport)
 	r.Route("GET", "/apps/{app}/builds/{id}", s.BuildGet)
 	r.Route("POST", "/apps/{app}/builds/import", s.BuildImport)
 	r.Route("GET", "/apps/{app}/builds", s.BuildList)
 	r.Route("SOCKET", "/apps/{app}/builds/{id}/logs", s.BuildLogs)
+	r.Route("GET", "/apps/{app}/builds/{id}/sbom", s.BuildSbom)
 	r.Route("PUT", "/apps/{app}/builds/{id}", s.BuildUpdate)
 	r.Route("GET", "/apps/{app}/configs", s.AppConfigList)
 	r.Route("GET", "/apps/{app}/configs/{name}", s.AppConfigGet)
 	r.Route("PUT", "/apps/{app}/configs/{name}", s.AppConfigSet)
 	r.Route("GET", "/system/capacity", s.CapacityGet)
+	r.Route("GET", "/apps/{app}/capacity", s.CapacityPlan)
 	r.Route("PUT", "/apps/{app}/ssl/{service}/{port}", s.CertificateApply)
 	r.Route("POST", "/certificates", s.CertificateCreate)
 	r.Route("DELETE", "/certificates/{id}", s.CertificateDelete)
@@ -34,6 +41,7 @@ func (s *Server) setupRoutes(r stdapi.Router) {
 	r.Route("GET", "/letsencrypt/config", s.LetsEncryptConfigGet)
 	r.Route("PUT", "/letsencrypt/config", s.LetsEncryptConfigApply)
 	r.Route("POST", "/events", s.EventSend)
+	r.Route("SOCKET", "/events", s.EventStream)
 	r.Route("DELETE", "/apps/{app}/processes/{pid}/files", s.FilesDelete)
 	r.Route("GET", "/apps/{app}/processes/{pid}/files", s.FilesDownload)
 	r.Route("POST", "/apps/{app}/processes/{pid}/files", s.FilesUpload)
@@ -47,27 +55,36 @@ func (s *Server) setupRoutes(r stdapi.Router) {
 	r.Route("GET", "/apps/{app}/objects/{key:.*}", s.ObjectFetch)
 	r.Route("GET", "/apps/{app}/objects", s.ObjectList)
 	r.Route("POST", "/apps/{app}/objects/{key:.*}", s.ObjectStore)
+	r.Route("POST", "/system/policies", s.PolicyCreate)
+	r.Route("DELETE", "/system/policies/{name}", s.PolicyDelete)
+	r.Route("GET", "/system/policies/{name}", s.PolicyGet)
+	r.Route("GET", "/system/policies", s.PolicyList)
 	r.Route("SOCKET", "/apps/{app}/processes/{pid}/exec", s.ProcessExec)
 	r.Route("GET", "/apps/{app}/processes/{pid}", s.ProcessGet)
 	r.Route("GET", "/apps/{app}/processes", s.ProcessList)
 	r.Route("SOCKET", "/apps/{app}/processes/{pid}/logs", s.ProcessLogs)
 	r.Route("POST", "/apps/{app}/services/{service}/processes", s.ProcessRun)
-	r.Route("SOCKET", "/apps/{app}/services/{service}/logs", s.ServiceLogs)
+	r.Route("SOCKET", "/apps/{app}/services/{name}/logs", s.ServiceLogs)
 	r.Route("DELETE", "/apps/{app}/processes/{pid}", s.ProcessStop)
 	r.Route("SOCKET", "/proxy/{host}/{port}", s.Proxy)
 	r.Route("POST", "/registries", s.RegistryAdd)
 	r.Route("GET", "/registries", s.RegistryList)
 	r.Route("ANY", "/v2/{path:.*}", s.RegistryProxy)
 	r.Route("DELETE", "/registries/{server:.*}", s.RegistryRemove)
+	r.Route("POST", "/apps/{app}/releases/{id}/approve", s.ReleaseApprove)
 	r.Route("POST", "/apps/{app}/releases", s.ReleaseCreate)
+	r.Route("GET", "/apps/{app}/releases/{id}/diff", s.ReleaseDiff)
 	r.Route("GET", "/apps/{app}/releases/{id}", s.ReleaseGet)
 	r.Route("GET", "/apps/{app}/releases", s.ReleaseList)
+	r.Route("POST", "/apps/{app}/releases/{id}/plan", s.ReleasePlan)
 	r.Route("POST", "/apps/{app}/releases/{id}/promote", s.ReleasePromote)
+	r.Route("GET", "/apps/{app}/resources/{name}/backups", s.ResourceBackupList)
 	r.Route("SOCKET", "/apps/{app}/resources/{name}/console", s.ResourceConsole)
 	r.Route("GET", "/apps/{app}/resources/{name}/data", s.ResourceExport)
 	r.Route("GET", "/apps/{app}/resources/{name}", s.ResourceGet)
 	r.Route("PUT", "/apps/{app}/resources/{name}/data", s.ResourceImport)
 	r.Route("GET", "/apps/{app}/resources", s.ResourceList)
+	r.Route("POST", "/apps/{app}/resources/{name}/backups/{backup}/restore", s.ResourceRestore)
 	r.Route("GET", "/apps/{app}/services", s.ServiceList)
 	r.Route("POST", "/apps/{app}/services/{name}/restart", s.ServiceRestart)
 	r.Route("PUT", "/apps/{app}/services/{name}", s.ServiceUpdate)
@@ -80,6 +97,7 @@ func (s *Server) setupRoutes(r stdapi.Router) {
 	r.Route("POST", "/system/jwt/token", s.SystemJwtToken)
 	r.Route("GET", "/system/processes", s.SystemProcesses)
 	r.Route("GET", "/system/releases", s.SystemReleases)
+	r.Route("GET", "/system/signing-key", s.SystemSigningKey)
 	r.Route("POST", "/resources", s.SystemResourceCreate)
 	r.Route("DELETE", "/resources/{name}", s.SystemResourceDelete)
 	r.Route("GET", "/resources/{name}", s.SystemResourceGet)
@@ -90,6 +108,12 @@ func (s *Server) setupRoutes(r stdapi.Router) {
 	r.Route("PUT", "/resources/{name}", s.SystemResourceUpdate)
 	r.Route("", "", s.SystemUninstall)
 	r.Route("PUT", "/system", s.SystemUpdate)
+	r.Route("GET", "/apps/{app}/timers", s.TimerList)
+	r.Route("POST", "/apps/{app}/timers/{name}/runs", s.TimerRun)
+	r.Route("GET", "/apps/{app}/timers/{name}/runs", s.TimerRunList)
+	r.Route("GET", "/system/webhooks/{name}/deliveries", s.WebhookDeliveryList)
+	r.Route("GET", "/system/webhooks", s.WebhookList)
+	r.Route("POST", "/system/webhooks/{name}/deliveries/{id}/redeliver", s.WebhookRedeliver)
 	r.Route("", "", s.Workers)
 
 	r.Route("ANY", "/custom/http/proxy/{path:.*}", s.ProxyHttpService)
@@ -177,6 +177,25 @@ func TestSystemReleasesError(t *testing.T) {
 	})
 }
 
+func TestSystemSigningKey(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var k string
+		p.On("SystemSigningKey").Return("key1", nil)
+		err := c.Get("/system/signing-key", stdsdk.RequestOptions{}, &k)
+		require.NoError(t, err)
+		require.Equal(t, "key1", k)
+	})
+}
+
+func TestSystemSigningKeyError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var k string
+		p.On("SystemSigningKey").Return("", fmt.Errorf("err1"))
+		err := c.Get("/system/signing-key", stdsdk.RequestOptions{}, &k)
+		require.EqualError(t, err, "err1")
+	})
+}
+
 func TestSystemUpdate(t *testing.T) {
 	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
 		opts := structs.SystemUpdateOptions{
@@ -0,0 +1,91 @@
+package api_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/stdsdk"
+	"github.com/stretchr/testify/require"
+)
+
+var fxTimer = structs.Timer{
+	Name:        "timer1",
+	Command:     "bin/cleanup",
+	Concurrency: "forbid",
+	LastRun:     time.Date(2024, 3, 6, 10, 0, 0, 0, time.UTC),
+	Release:     "release1",
+	Schedule:    "0 * * * *",
+	Service:     "web",
+}
+
+var fxTimerRun = structs.TimerRun{
+	Id:      "timer-timer1-28500000",
+	Ended:   time.Date(2024, 3, 6, 10, 1, 0, 0, time.UTC),
+	Release: "release1",
+	Started: time.Date(2024, 3, 6, 10, 0, 0, 0, time.UTC),
+	Status:  structs.TimerRunSucceeded,
+	Timer:   "timer1",
+}
+
+func TestTimerList(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		t1 := structs.Timers{fxTimer}
+		t2 := structs.Timers{}
+		p.On("TimerList", "app1").Return(t1, nil)
+		err := c.Get("/apps/app1/timers", stdsdk.RequestOptions{}, &t2)
+		require.NoError(t, err)
+		require.Equal(t, t1, t2)
+	})
+}
+
+func TestTimerListError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var t1 structs.Timers
+		p.On("TimerList", "app1").Return(nil, fmt.Errorf("err1"))
+		err := c.Get("/apps/app1/timers", stdsdk.RequestOptions{}, &t1)
+		require.EqualError(t, err, "err1")
+		require.Nil(t, t1)
+	})
+}
+
+func TestTimerRun(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		r1 := fxTimerRun
+		r2 := structs.TimerRun{}
+		p.On("TimerRun", "app1", "timer1").Return(&r1, nil)
+		err := c.Post("/apps/app1/timers/timer1/runs", stdsdk.RequestOptions{}, &r2)
+		require.NoError(t, err)
+		require.Equal(t, r1, r2)
+	})
+}
+
+func TestTimerRunError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		p.On("TimerRun", "app1", "timer1").Return(nil, fmt.Errorf("err1"))
+		err := c.Post("/apps/app1/timers/timer1/runs", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "err1")
+	})
+}
+
+func TestTimerRunList(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		r1 := structs.TimerRuns{fxTimerRun}
+		r2 := structs.TimerRuns{}
+		p.On("TimerRunList", "app1", "timer1").Return(r1, nil)
+		err := c.Get("/apps/app1/timers/timer1/runs", stdsdk.RequestOptions{}, &r2)
+		require.NoError(t, err)
+		require.Equal(t, r1, r2)
+	})
+}
+
+func TestTimerRunListError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var r1 structs.TimerRuns
+		p.On("TimerRunList", "app1", "timer1").Return(nil, fmt.Errorf("err1"))
+		err := c.Get("/apps/app1/timers/timer1/runs", stdsdk.RequestOptions{}, &r1)
+		require.EqualError(t, err, "err1")
+		require.Nil(t, r1)
+	})
+}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
+	"github.com/convox/convox/pkg/structs"
 	"github.com/convox/stdapi"
 )
 
@@ -20,6 +22,31 @@ func (s *Server) AppCancelValidate(c *stdapi.Context) error {
 	return nil
 }
 
+// BuildAttestValidate only lets the build process of a build sign it
+func (s *Server) BuildAttestValidate(c *stdapi.Context) error {
+	return s.buildTokenValidate(c, c.Var("app"), c.Var("id"))
+}
+
+// ObjectStoreValidate keeps the objects of a build, such as the sboms its
+// attestation covers, writable only by its build process
+func (s *Server) ObjectStoreValidate(c *stdapi.Context) error {
+	key := strings.TrimPrefix(path.Clean("/"+c.Var("key")), "/")
+
+	if parts := strings.SplitN(key, "/", 3); len(parts) > 1 && parts[0] == "build" {
+		return s.buildTokenValidate(c, c.Var("app"), parts[1])
+	}
+
+	return nil
+}
+
+func (s *Server) buildTokenValidate(c *stdapi.Context, app, id string) error {
+	if s.JwtMngr == nil || !s.JwtMngr.VerifyBuildToken(app, id, c.Request().Header.Get("Build-Token")) {
+		return stdapi.Errorf(403, "only the build process of build %s can do this", id)
+	}
+
+	return nil
+}
+
 func (s *Server) ProcessExecValidate(c *stdapi.Context) error {
 	if _, err := s.Provider.AppGet(c.Var("app")); err != nil {
 		return err
@@ -36,9 +63,38 @@ func (s *Server) ProcessRunValidate(c *stdapi.Context) error {
 	return nil
 }
 
+// ReleaseApproveValidate records an approval under the authenticated user.
+// Only users with a jwt token can approve, the basic auth username of the
+// rack password is not checked and tokens minted by the rack are not people,
+// whatever user they were minted for.
+func (s *Server) ReleaseApproveValidate(c *stdapi.Context) error {
+	if jwt, _ := c.Get(authJwtParam).(bool); !jwt {
+		return stdapi.Errorf(403, "releases can only be approved by users authenticated with a token")
+	}
+
+	if _, ok := c.Get(structs.ConvoxPolicyParam).(string); ok || strings.HasPrefix(auditUser(c), "system-") {
+		return stdapi.Errorf(403, "releases can not be approved with a rack token")
+	}
+
+	requestUser(c)
+
+	return nil
+}
+
+// ReleasePlanValidate previews the promotion policy for the authenticated
+// user
+func (s *Server) ReleasePlanValidate(c *stdapi.Context) error {
+	requestUser(c)
+
+	return nil
+}
+
 func (s *Server) ReleasePromoteValidate(c *stdapi.Context) error {
 	app := c.Var("app")
 
+	// an overridden promotion policy is audited under the authenticated user
+	requestUser(c)
+
 	a, err := s.Provider.AppGet(app)
 	if err != nil {
 		return err
@@ -70,3 +126,13 @@ func (s *Server) ReleasePromoteValidate(c *stdapi.Context) error {
 
 	return nil
 }
+
+// requestUser replaces any user header sent by the client with the
+// authenticated user
+func requestUser(c *stdapi.Context) {
+	c.Request().Header.Del("User")
+
+	if u := auditUser(c); u != "" {
+		c.Request().Header.Set("User", u)
+	}
+}
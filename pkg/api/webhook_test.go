@@ -0,0 +1,78 @@
+package api_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/stdsdk"
+	"github.com/stretchr/testify/require"
+)
+
+var fxWebhook = structs.Webhook{
+	Name:   "webhook1",
+	Events: []string{"app:*"},
+	Kind:   "webhook",
+	Signed: true,
+	Url:    "https://example.org",
+}
+
+var fxWebhookDelivery = structs.WebhookDelivery{
+	Id:       "delivery1",
+	Attempts: 1,
+	Event:    "app:create",
+	Payload:  `{"action":"app:create"}`,
+	Status:   structs.WebhookDeliveryPending,
+	Webhook:  "webhook1",
+}
+
+func TestWebhookDeliveryList(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		d1 := structs.WebhookDeliveries{fxWebhookDelivery}
+		d2 := structs.WebhookDeliveries{}
+		opts := structs.WebhookDeliveryListOptions{Status: options.String("pending")}
+		ro := stdsdk.RequestOptions{Query: stdsdk.Query{"status": "pending"}}
+		p.On("WebhookDeliveryList", "webhook1", opts).Return(d1, nil)
+		err := c.Get("/system/webhooks/webhook1/deliveries", ro, &d2)
+		require.NoError(t, err)
+		require.Equal(t, d1, d2)
+	})
+}
+
+func TestWebhookDeliveryListError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		var d1 structs.WebhookDeliveries
+		p.On("WebhookDeliveryList", "webhook1", structs.WebhookDeliveryListOptions{}).Return(nil, fmt.Errorf("err1"))
+		err := c.Get("/system/webhooks/webhook1/deliveries", stdsdk.RequestOptions{}, &d1)
+		require.EqualError(t, err, "err1")
+		require.Nil(t, d1)
+	})
+}
+
+func TestWebhookList(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		w1 := structs.Webhooks{fxWebhook}
+		w2 := structs.Webhooks{}
+		p.On("WebhookList").Return(w1, nil)
+		err := c.Get("/system/webhooks", stdsdk.RequestOptions{}, &w2)
+		require.NoError(t, err)
+		require.Equal(t, w1, w2)
+	})
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		p.On("WebhookRedeliver", "webhook1", "delivery1").Return(nil)
+		err := c.Post("/system/webhooks/webhook1/deliveries/delivery1/redeliver", stdsdk.RequestOptions{}, nil)
+		require.NoError(t, err)
+	})
+}
+
+func TestWebhookRedeliverError(t *testing.T) {
+	testServer(t, func(c *stdsdk.Client, p *structs.MockProvider) {
+		p.On("WebhookRedeliver", "webhook1", "delivery1").Return(fmt.Errorf("err1"))
+		err := c.Post("/system/webhooks/webhook1/deliveries/delivery1/redeliver", stdsdk.RequestOptions{}, nil)
+		require.EqualError(t, err, "err1")
+	})
+}
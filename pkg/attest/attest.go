@@ -0,0 +1,195 @@
+package attest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/convox/convox/pkg/structs"
+)
+
+const (
+	PayloadType   = "application/vnd.in-toto+json"
+	PredicateType = "https://convox.com/attestation/build/v1"
+	StatementType = "https://in-toto.io/Statement/v1"
+)
+
+// Statement is an in-toto statement whose subjects are the sboms of a build
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type Predicate struct {
+	App      string    `json:"app"`
+	Build    string    `json:"build"`
+	Created  time.Time `json:"created"`
+	GitSha   string    `json:"gitSha,omitempty"`
+	Manifest string    `json:"manifest"`
+	Rack     string    `json:"rack"`
+}
+
+func NewStatement(p Predicate) *Statement {
+	return &Statement{
+		Type:          StatementType,
+		Subject:       []Subject{},
+		PredicateType: PredicateType,
+		Predicate:     p,
+	}
+}
+
+// Digest returns the hex encoded sha256 of everything read from r
+func Digest(r io.Reader) (string, error) {
+	h := sha256.New()
+
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// GenerateKey returns a new encoded signing key
+func GenerateKey() (string, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key.Seed()), nil
+}
+
+// KeyId returns the fingerprint of an encoded public key
+func KeyId(public string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(public)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key")
+	}
+
+	sum := sha256.Sum256(data)
+
+	return fmt.Sprintf("SHA256:%s", base64.RawStdEncoding.EncodeToString(sum[:])), nil
+}
+
+// PublicKey returns the encoded public key of an encoded signing key
+func PublicKey(key string) (string, error) {
+	pk, err := privateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(pk.Public().(ed25519.PublicKey)), nil
+}
+
+// Sign wraps a statement in an envelope signed by an encoded signing key
+func Sign(key string, s *Statement) (*structs.BuildAttestation, error) {
+	pk, err := privateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := KeyId(base64.StdEncoding.EncodeToString(pk.Public().(ed25519.PublicKey)))
+	if err != nil {
+		return nil, err
+	}
+
+	a := &structs.BuildAttestation{
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		PayloadType: PayloadType,
+		Signatures: []structs.BuildAttestationSignature{
+			{KeyId: id, Sig: base64.StdEncoding.EncodeToString(ed25519.Sign(pk, pae(PayloadType, payload)))},
+		},
+	}
+
+	return a, nil
+}
+
+// Verify checks that an envelope is signed by an encoded public key and
+// returns the statement it carries
+func Verify(public string, a *structs.BuildAttestation) (*Statement, error) {
+	data, err := base64.StdEncoding.DecodeString(public)
+	if err != nil || len(data) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key")
+	}
+
+	id, err := KeyId(public)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.PayloadType != PayloadType {
+		return nil, fmt.Errorf("unexpected payload type: %s", a.PayloadType)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(a.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload")
+	}
+
+	verified := false
+
+	for _, s := range a.Signatures {
+		if s.KeyId != id {
+			continue
+		}
+
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature")
+		}
+
+		if ed25519.Verify(ed25519.PublicKey(data), pae(a.PayloadType, payload), sig) {
+			verified = true
+			break
+		}
+	}
+
+	if !verified {
+		return nil, fmt.Errorf("attestation is not signed by key %s", id)
+	}
+
+	var s Statement
+
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return nil, err
+	}
+
+	if s.Type != StatementType || s.PredicateType != PredicateType {
+		return nil, fmt.Errorf("unexpected statement type: %s", s.PredicateType)
+	}
+
+	return &s, nil
+}
+
+func privateKey(key string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid signing key")
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// pae is the pre-authentication encoding of DSSE, the bytes that are
+// actually signed
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
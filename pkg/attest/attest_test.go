@@ -0,0 +1,80 @@
+package attest_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/attest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	key, err := attest.GenerateKey()
+	require.NoError(t, err)
+
+	public, err := attest.PublicKey(key)
+	require.NoError(t, err)
+
+	digest, err := attest.Digest(strings.NewReader("sbom"))
+	require.NoError(t, err)
+	require.Equal(t, "98f3ae1ef67113d8140d4f6cb8d2830070e21ea48f091be519659846c771a374", digest)
+
+	s := attest.NewStatement(attest.Predicate{App: "app1", Build: "B1", Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Rack: "rack1"})
+	s.Subject = append(s.Subject, attest.Subject{Name: "sbom/web.json", Digest: map[string]string{"sha256": digest}})
+
+	a, err := attest.Sign(key, s)
+	require.NoError(t, err)
+	require.Equal(t, attest.PayloadType, a.PayloadType)
+	require.Len(t, a.Signatures, 1)
+
+	id, err := attest.KeyId(public)
+	require.NoError(t, err)
+	require.Equal(t, id, a.Signatures[0].KeyId)
+
+	vs, err := attest.Verify(public, a)
+	require.NoError(t, err)
+	require.Equal(t, s, vs)
+}
+
+func TestVerifyWrongKey(t *testing.T) {
+	key, err := attest.GenerateKey()
+	require.NoError(t, err)
+
+	other, err := attest.GenerateKey()
+	require.NoError(t, err)
+
+	public, err := attest.PublicKey(other)
+	require.NoError(t, err)
+
+	a, err := attest.Sign(key, attest.NewStatement(attest.Predicate{Build: "B1"}))
+	require.NoError(t, err)
+
+	id, err := attest.KeyId(public)
+	require.NoError(t, err)
+
+	_, err = attest.Verify(public, a)
+	require.EqualError(t, err, "attestation is not signed by key "+id)
+}
+
+func TestVerifyTampered(t *testing.T) {
+	key, err := attest.GenerateKey()
+	require.NoError(t, err)
+
+	public, err := attest.PublicKey(key)
+	require.NoError(t, err)
+
+	a, err := attest.Sign(key, attest.NewStatement(attest.Predicate{Build: "B1"}))
+	require.NoError(t, err)
+
+	b, err := attest.Sign(key, attest.NewStatement(attest.Predicate{Build: "B2"}))
+	require.NoError(t, err)
+
+	a.Payload = b.Payload
+
+	_, err = attest.Verify(public, a)
+	require.Error(t, err)
+
+	_, err = attest.Verify("invalid", a)
+	require.EqualError(t, err, "invalid public key")
+}
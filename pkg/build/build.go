@@ -24,19 +24,23 @@ type Engine interface {
 }
 
 type Options struct {
-	App         string
-	Auth        string
-	BuildArgs   []string
-	Cache       bool
-	Development bool
-	EnvWrapper  bool
-	Id          string
-	Manifest    string
-	Output      io.Writer
-	Push        string
-	Rack        string
-	Source      string
-	Terminal    bool
+	App           string
+	Auth          string
+	BuildArgs     []string
+	Cache         bool
+	Development   bool
+	EnvWrapper    bool
+	Id            string
+	Manifest      string
+	Output        io.Writer
+	Push          string
+	Rack          string
+	Sbom          bool
+	Scan          bool
+	ScanAction    string
+	ScanThreshold string
+	Source        string
+	Terminal      bool
 }
 
 type Build struct {
@@ -44,6 +48,7 @@ type Build struct {
 	Exec     exec.Interface
 	Provider structs.Provider
 	Engine   Engine
+	images   map[string]string
 	logs     bytes.Buffer
 	writer   io.Writer
 }
@@ -55,6 +60,8 @@ func New(rack structs.Provider, opts Options, engine Engine) (*Build, error) {
 
 	b.Engine = engine
 
+	b.images = map[string]string{}
+
 	b.Manifest = common.CoalesceString(b.Manifest, "convox.yml")
 
 	b.Provider = rack
@@ -128,6 +135,19 @@ func (bb *Build) execute() error {
 		return err
 	}
 
+	// a missing sbom or signature leaves the build unverifiable but usable
+	if bb.Sbom && len(bb.images) > 0 {
+		if err := bb.sbom(); err != nil {
+			bb.Printf("WARNING: could not attest build: %s\n", err)
+		}
+	}
+
+	if bb.Scan {
+		if err := bb.scan(); err != nil {
+			return err
+		}
+	}
+
 	if err := bb.success(); err != nil {
 		return err
 	}
@@ -71,6 +71,7 @@ func (bk *BuildKit) Build(bb *Build, dir string) error {
 
 		if bb.Push != "" {
 			b.Tag = fmt.Sprintf("%s:%s.%s", bb.Push, m.Services[i].Name, bb.Id)
+			bb.images[m.Services[i].Name] = b.Tag
 		}
 
 		builds = append(builds, b)
@@ -74,6 +74,8 @@ func (d *Docker) Build(bb *Build, dir string) error {
 			tags[hash] = append(tags[hash], to)
 		}
 
+		bb.images[m.Services[i].Name] = to
+
 		if bb.Push != "" {
 			pushes[to] = fmt.Sprintf("%s:%s.%s", bb.Push, m.Services[i].Name, bb.Id)
 		}
@@ -0,0 +1,61 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/convox/convox/pkg/structs"
+)
+
+// sbom stores a CycloneDX bill of materials for every built image next to
+// the build and has the rack sign a provenance statement covering them
+func (bb *Build) sbom() error {
+	services := []string{}
+
+	for service := range bb.images {
+		services = append(services, service)
+	}
+
+	sort.Strings(services)
+
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	for _, service := range services {
+		bb.Printf("Generating SBOM: %s\n", bb.images[service])
+
+		file := filepath.Join(dir, fmt.Sprintf("%s.json", service))
+
+		if data, err := bb.Exec.Execute("trivy", "image", "--quiet", "--skip-db-update", "--offline-scan", "--format", "cyclonedx", "--output", file, bb.images[service]); err != nil {
+			return fmt.Errorf("could not generate sbom for %s: %s", bb.images[service], strings.TrimSpace(string(data)))
+		}
+
+		fd, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+
+		_, err = bb.Provider.ObjectStore(bb.App, fmt.Sprintf("build/%s/sbom/%s.json", bb.Id, service), fd, structs.ObjectStoreOptions{})
+		fd.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	a, err := bb.Provider.BuildAttest(bb.App, bb.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range a.Signatures {
+		bb.Printf("Signed: %s\n", s.KeyId)
+	}
+
+	return nil
+}
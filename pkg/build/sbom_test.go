@@ -0,0 +1,91 @@
+package build_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/convox/convox/pkg/build"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/exec"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSbom(t *testing.T) {
+	opts := build.Options{
+		App:    "app1",
+		Auth:   "{}",
+		Cache:  true,
+		Id:     "build1",
+		Rack:   "rack1",
+		Sbom:   true,
+		Source: "object://app1/object.tgz",
+	}
+
+	testBuild(t, opts, dockerEngine, func(b *build.Build, p *structs.MockProvider, e *exec.MockInterface, out *bytes.Buffer) {
+		testBuildImagesSetup(t, p, e)
+
+		sboms := map[string]string{}
+
+		for _, service := range []string{"web", "web2"} {
+			service := service
+
+			p.On("ObjectStore", "app1", "build/build1/sbom/"+service+".json", mock.Anything, structs.ObjectStoreOptions{}).Return(fxObject(), nil).Run(func(args mock.Arguments) {
+				data, err := io.ReadAll(args.Get(2).(io.Reader))
+				require.NoError(t, err)
+				sboms[service] = string(data)
+			})
+		}
+
+		for image, service := range map[string]string{"rack1/app1:web.build1": "web", "rack1/app1:web2.build1": "web2"} {
+			service := service
+
+			e.On("Execute", "trivy", "image", "--quiet", "--skip-db-update", "--offline-scan", "--format", "cyclonedx", "--output", mock.Anything, image).Return([]byte{}, nil).Run(func(args mock.Arguments) {
+				require.NoError(t, os.WriteFile(args.String(8), []byte(`{"bomFormat":"CycloneDX","metadata":{"component":{"name":"`+service+`"}}}`), 0600))
+			})
+		}
+
+		p.On("BuildAttest", "app1", "build1").Return(&structs.BuildAttestation{Signatures: []structs.BuildAttestationSignature{{KeyId: "SHA256:key1"}}}, nil)
+		p.On("BuildUpdate", "app1", "build1", mock.Anything).Return(fxBuildStarted(), nil)
+		p.On("ReleaseCreate", "app1", structs.ReleaseCreateOptions{Build: options.String("build1")}).Return(fxRelease2(), nil)
+		p.On("EventSend", "build:create", structs.EventSendOptions{Data: map[string]string{"app": "app1", "id": "build1", "release_id": "release2"}}).Return(nil)
+
+		require.NoError(t, b.Execute())
+
+		require.Equal(t, map[string]string{
+			"web":  `{"bomFormat":"CycloneDX","metadata":{"component":{"name":"web"}}}`,
+			"web2": `{"bomFormat":"CycloneDX","metadata":{"component":{"name":"web2"}}}`,
+		}, sboms)
+		require.Contains(t, out.String(), "Generating SBOM: rack1/app1:web.build1\nGenerating SBOM: rack1/app1:web2.build1\nSigned: SHA256:key1\n")
+	})
+}
+
+func TestBuildSbomError(t *testing.T) {
+	opts := build.Options{
+		App:    "app1",
+		Auth:   "{}",
+		Cache:  true,
+		Id:     "build1",
+		Rack:   "rack1",
+		Sbom:   true,
+		Source: "object://app1/object.tgz",
+	}
+
+	testBuild(t, opts, dockerEngine, func(b *build.Build, p *structs.MockProvider, e *exec.MockInterface, out *bytes.Buffer) {
+		testBuildImagesSetup(t, p, e)
+
+		e.On("Execute", "trivy", "image", "--quiet", "--skip-db-update", "--offline-scan", "--format", "cyclonedx", "--output", mock.Anything, "rack1/app1:web.build1").Return([]byte("no db"), fmt.Errorf("exit 1"))
+
+		p.On("BuildUpdate", "app1", "build1", mock.Anything).Return(fxBuildStarted(), nil)
+		p.On("ReleaseCreate", "app1", structs.ReleaseCreateOptions{Build: options.String("build1")}).Return(fxRelease2(), nil)
+		p.On("EventSend", "build:create", structs.EventSendOptions{Data: map[string]string{"app": "app1", "id": "build1", "release_id": "release2"}}).Return(nil)
+
+		require.NoError(t, b.Execute())
+
+		require.Contains(t, out.String(), "WARNING: could not attest build: could not generate sbom for rack1/app1:web.build1: no db\n")
+	})
+}
@@ -0,0 +1,142 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+)
+
+// trivyReport is the part of the json report of trivy that is kept
+type trivyReport struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			FixedVersion     string
+			InstalledVersion string
+			PkgName          string
+			Severity         string
+			Title            string
+			VulnerabilityID  string
+		}
+	}
+}
+
+// scan checks every built image against the vulnerability database that
+// ships with the build image, the database is never updated during a build
+// so scans do not depend on network access
+func (bb *Build) scan() error {
+	s := &structs.BuildScan{
+		Images:          bb.images,
+		Vulnerabilities: structs.BuildVulnerabilities{},
+	}
+
+	services := []string{}
+
+	for service := range bb.images {
+		services = append(services, service)
+	}
+
+	sort.Strings(services)
+
+	for _, service := range services {
+		bb.Printf("Scanning: %s\n", bb.images[service])
+
+		vs, err := bb.scanImage(service, bb.images[service])
+		if err != nil {
+			return err
+		}
+
+		s.Vulnerabilities = append(s.Vulnerabilities, vs...)
+	}
+
+	sort.Slice(s.Vulnerabilities, s.Vulnerabilities.Less)
+
+	s.Scanned = time.Now().UTC()
+
+	bb.Printf("Scan: %s\n", s.Summary())
+
+	// the report can be large so the build only keeps its counts
+	report, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	if _, err := bb.Provider.ObjectStore(bb.App, fmt.Sprintf("build/%s/scan.json", bb.Id), bytes.NewReader(report), structs.ObjectStoreOptions{}); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s.Summarize())
+	if err != nil {
+		return err
+	}
+
+	if _, err := bb.Provider.BuildUpdate(bb.App, bb.Id, structs.BuildUpdateOptions{Scan: options.String(string(data))}); err != nil {
+		return err
+	}
+
+	if bb.ScanThreshold == "" || common.CoalesceString(bb.ScanAction, structs.BuildScanActionFail) != structs.BuildScanActionFail {
+		return nil
+	}
+
+	vs, err := s.Exceeding(bb.ScanThreshold)
+	if err != nil {
+		return err
+	}
+
+	if len(vs) > 0 {
+		return fmt.Errorf("image scan found %d vulnerabilities at or above %s severity", len(vs), strings.ToLower(bb.ScanThreshold))
+	}
+
+	return nil
+}
+
+func (bb *Build) scanImage(service, image string) (structs.BuildVulnerabilities, error) {
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	report := filepath.Join(dir, "report.json")
+
+	if data, err := bb.Exec.Execute("trivy", "image", "--quiet", "--skip-db-update", "--offline-scan", "--format", "json", "--output", report, image); err != nil {
+		return nil, fmt.Errorf("could not scan %s: %s", image, strings.TrimSpace(string(data)))
+	}
+
+	data, err := os.ReadFile(report)
+	if err != nil {
+		return nil, err
+	}
+
+	var tr trivyReport
+
+	if err := json.Unmarshal(data, &tr); err != nil {
+		return nil, err
+	}
+
+	vs := structs.BuildVulnerabilities{}
+
+	for _, r := range tr.Results {
+		for _, v := range r.Vulnerabilities {
+			vs = append(vs, structs.BuildVulnerability{
+				Id:        v.VulnerabilityID,
+				Fixed:     v.FixedVersion,
+				Installed: v.InstalledVersion,
+				Package:   v.PkgName,
+				Service:   service,
+				Severity:  strings.ToLower(v.Severity),
+				Title:     v.Title,
+			})
+		}
+	}
+
+	return vs, nil
+}
@@ -0,0 +1,125 @@
+package build_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/convox/convox/pkg/build"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/exec"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildScan(t *testing.T) {
+	opts := build.Options{
+		App:           "app1",
+		Auth:          "{}",
+		Cache:         true,
+		Id:            "build1",
+		Rack:          "rack1",
+		Scan:          true,
+		ScanAction:    "block",
+		ScanThreshold: "high",
+		Source:        "object://app1/object.tgz",
+	}
+
+	testBuild(t, opts, dockerEngine, func(b *build.Build, p *structs.MockProvider, e *exec.MockInterface, out *bytes.Buffer) {
+		testBuildScanSetup(t, p, e)
+
+		var scan *structs.BuildScan
+		var summary *structs.BuildScanSummary
+
+		p.On("ObjectStore", "app1", "build/build1/scan.json", mock.Anything, structs.ObjectStoreOptions{}).Return(fxObject(), nil).Run(func(args mock.Arguments) {
+			require.NoError(t, json.NewDecoder(args.Get(2).(io.Reader)).Decode(&scan))
+		})
+		p.On("BuildUpdate", "app1", "build1", mock.Anything).Return(fxBuildStarted(), nil).Run(func(args mock.Arguments) {
+			if opts := args.Get(2).(structs.BuildUpdateOptions); opts.Scan != nil {
+				require.NoError(t, json.Unmarshal([]byte(*opts.Scan), &summary))
+			}
+		})
+		p.On("ReleaseCreate", "app1", structs.ReleaseCreateOptions{Build: options.String("build1")}).Return(fxRelease2(), nil)
+		p.On("EventSend", "build:create", structs.EventSendOptions{Data: map[string]string{"app": "app1", "id": "build1", "release_id": "release2"}}).Return(nil)
+
+		require.NoError(t, b.Execute())
+
+		require.NotNil(t, scan)
+		require.False(t, scan.Scanned.IsZero())
+		require.Equal(t, map[string]string{"web": "rack1/app1:web.build1", "web2": "rack1/app1:web2.build1"}, scan.Images)
+		require.Equal(t, structs.BuildVulnerabilities{
+			{Id: "CVE-2024-0001", Fixed: "3.0.14", Installed: "3.0.13", Package: "openssl", Service: "web", Severity: "critical", Title: "openssl overflow"},
+			{Id: "CVE-2024-0002", Installed: "1.2.3", Package: "zlib", Service: "web2", Severity: "low"},
+		}, scan.Vulnerabilities)
+		require.NotNil(t, summary)
+		require.Equal(t, map[string]int{"critical": 1, "low": 1}, summary.Counts)
+		require.Equal(t, scan.Images, summary.Images)
+		require.Contains(t, out.String(), "Scanning: rack1/app1:web.build1\nScanning: rack1/app1:web2.build1\nScan: 1 critical, 1 low\n")
+	})
+}
+
+func TestBuildScanFail(t *testing.T) {
+	opts := build.Options{
+		App:           "app1",
+		Auth:          "{}",
+		Cache:         true,
+		Id:            "build1",
+		Rack:          "rack1",
+		Scan:          true,
+		ScanAction:    "fail",
+		ScanThreshold: "high",
+		Source:        "object://app1/object.tgz",
+	}
+
+	testBuild(t, opts, dockerEngine, func(b *build.Build, p *structs.MockProvider, e *exec.MockInterface, out *bytes.Buffer) {
+		testBuildScanSetup(t, p, e)
+
+		p.On("ObjectStore", "app1", "build/build1/scan.json", mock.Anything, structs.ObjectStoreOptions{}).Return(fxObject(), nil)
+		p.On("BuildUpdate", "app1", "build1", mock.Anything).Return(fxBuildStarted(), nil).Run(func(args mock.Arguments) {
+			if opts := args.Get(2).(structs.BuildUpdateOptions); opts.Status != nil {
+				require.Equal(t, "failed", *opts.Status)
+			}
+		})
+		p.On("EventSend", "build:create", structs.EventSendOptions{Data: map[string]string{"app": "app1", "id": "build1"}, Error: options.String("image scan found 1 vulnerabilities at or above high severity")}).Return(nil)
+
+		require.EqualError(t, b.Execute(), "image scan found 1 vulnerabilities at or above high severity")
+
+		p.AssertNotCalled(t, "ReleaseCreate", mock.Anything, mock.Anything)
+	})
+}
+
+func testBuildScanSetup(t *testing.T, p *structs.MockProvider, e *exec.MockInterface) {
+	testBuildImagesSetup(t, p, e)
+
+	reports := map[string]string{
+		"rack1/app1:web.build1":  `{"Results":[{"Target":"debian","Vulnerabilities":[{"VulnerabilityID":"CVE-2024-0001","PkgName":"openssl","InstalledVersion":"3.0.13","FixedVersion":"3.0.14","Severity":"CRITICAL","Title":"openssl overflow"}]}]}`,
+		"rack1/app1:web2.build1": `{"Results":[{"Target":"debian","Vulnerabilities":[{"VulnerabilityID":"CVE-2024-0002","PkgName":"zlib","InstalledVersion":"1.2.3","Severity":"LOW"}]}]}`,
+	}
+
+	for image, report := range reports {
+		report := report
+
+		e.On("Execute", "trivy", "image", "--quiet", "--skip-db-update", "--offline-scan", "--format", "json", "--output", mock.Anything, image).Return([]byte{}, nil).Run(func(args mock.Arguments) {
+			require.NoError(t, os.WriteFile(args.String(8), []byte(report), 0600))
+		})
+	}
+}
+
+func testBuildImagesSetup(t *testing.T, p *structs.MockProvider, e *exec.MockInterface) {
+	p.On("BuildGet", "app1", "build1").Return(fxBuildStarted(), nil).Once()
+	bdata, err := os.ReadFile("testdata/httpd.tgz")
+	require.NoError(t, err)
+	p.On("ObjectFetch", "app1", "/object.tgz").Return(io.NopCloser(bytes.NewReader(bdata)), nil)
+	p.On("ReleaseList", "app1", structs.ReleaseListOptions{Limit: options.Int(1)}).Return(structs.Releases{*fxRelease()}, nil)
+	p.On("ReleaseGet", "app1", "release1").Return(fxRelease(), nil)
+	p.On("ObjectStore", "app1", "build/build1/logs", mock.Anything, structs.ObjectStoreOptions{}).Return(fxObject(), nil)
+
+	e.On("Run", mock.Anything, "docker", "build", "-t", "e00bc968ebe3f5b4c934a1f3c00fcfba74384f944f6f9fa2ba819445", "-f", mock.MatchedBy(matchTempdirFile("Dockerfile")), "--network", "host", mock.MatchedBy(matchTempdir)).Return(nil)
+	e.On("Execute", "docker", "inspect", "e00bc968ebe3f5b4c934a1f3c00fcfba74384f944f6f9fa2ba819445", "--format", "{{json .Config.Entrypoint}}").Return([]byte("[]"), nil)
+	e.On("Execute", "docker", "pull", "httpd").Return([]byte("pulling\n"), nil)
+	e.On("Execute", "docker", "tag", "httpd", "rack1/app1:web.build1").Return([]byte("tagging\n"), nil)
+	e.On("Execute", "docker", "tag", "e00bc968ebe3f5b4c934a1f3c00fcfba74384f944f6f9fa2ba819445", "rack1/app1:web2.build1").Return([]byte("tagging\n"), nil)
+}
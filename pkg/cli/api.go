@@ -14,12 +14,25 @@ func init() {
 		Usage:    "<path>",
 		Validate: stdcli.Args(1),
 	})
+
+	register("api spec", "print the openapi specification of the rack api", ApiSpec, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagRack},
+		Validate: stdcli.Args(0),
+	})
 }
 
 func Api(rack sdk.Interface, c *stdcli.Context) error {
+	return apiGet(rack, c, c.Arg(0))
+}
+
+func ApiSpec(rack sdk.Interface, c *stdcli.Context) error {
+	return apiGet(rack, c, "/openapi.json")
+}
+
+func apiGet(rack sdk.Interface, c *stdcli.Context, path string) error {
 	var v interface{}
 
-	if err := rack.Get(c.Arg(0), stdsdk.RequestOptions{}, &v); err != nil {
+	if err := rack.Get(path, stdsdk.RequestOptions{}, &v); err != nil {
 		return err
 	}
 
@@ -38,3 +38,18 @@ func TestApiError(t *testing.T) {
 		require.Equal(t, "ERROR: err1\n", res.Stderr)
 	})
 }
+
+func TestApiSpec(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("Get", "/openapi.json", stdsdk.RequestOptions{}, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			err := json.Unmarshal([]byte(`{"openapi":"3.0.3","info":{"title":"Convox Rack API"}}`), args.Get(2))
+			require.NoError(t, err)
+		})
+
+		res, err := testExecute(e, "api spec", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		require.Equal(t, "{\n  \"info\": {\n    \"title\": \"Convox Rack API\"\n  },\n  \"openapi\": \"3.0.3\"\n}\n", res.Stdout)
+		require.Equal(t, "", res.Stderr)
+	})
+}
@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"archive/tar"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,7 +12,9 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/convox/convox/pkg/common"
 	"github.com/convox/convox/pkg/options"
@@ -47,6 +52,8 @@ func init() {
 			flagApp,
 			flagRack,
 			stdcli.StringFlag("file", "f", "export to file"),
+			stdcli.StringFlag("only", "", "only export these parts (app,build,env,params,resources)"),
+			stdcli.BoolFlag("skip-resources", "", "do not export resources"),
 		},
 		Usage:    "[app]",
 		Validate: stdcli.ArgsMax(1),
@@ -57,6 +64,8 @@ func init() {
 			flagApp,
 			flagRack,
 			stdcli.StringFlag("file", "f", "import from file"),
+			stdcli.StringFlag("only", "", "only import these parts (app,build,env,params,resources)"),
+			stdcli.BoolFlag("skip-resources", "", "do not import resources"),
 		},
 		Usage:    "[app]",
 		Validate: stdcli.ArgsMax(1),
@@ -74,6 +83,19 @@ func init() {
 		Validate: stdcli.ArgsMax(1),
 	})
 
+	register("apps metrics", "display app metrics", AppsMetrics, stdcli.CommandOptions{
+		Flags: []stdcli.Flag{
+			flagApp,
+			flagRack,
+			stdcli.StringFlag("metrics", "m", "only show these metrics (cpu,mem,replicas,requests,restarts or a full name)"),
+			stdcli.StringFlag("period", "", "aggregation period (default 1m)"),
+			stdcli.StringFlag("since", "", "show metrics since this duration ago (default 1h)"),
+			stdcli.BoolFlag("sparklines", "", "render each metric as a sparkline"),
+		},
+		Usage:    "[app]",
+		Validate: stdcli.ArgsMax(1),
+	})
+
 	register("apps params", "display app parameters", AppsParams, stdcli.CommandOptions{
 		Flags:    []stdcli.Flag{flagApp, flagRack},
 		Usage:    "[app]",
@@ -86,6 +108,12 @@ func init() {
 		Validate: stdcli.ArgsMin(1),
 	})
 
+	register("apps prune", "remove builds and releases outside the retention policy", AppsPrune, stdcli.CommandOptions{
+		Flags:    append(stdcli.OptionFlags(structs.AppPruneOptions{}), flagApp, flagRack),
+		Usage:    "[app]",
+		Validate: stdcli.ArgsMax(1),
+	})
+
 	register("apps unlock", "disable termination protection", AppsUnlock, stdcli.CommandOptions{
 		Flags:    []stdcli.Flag{flagApp, flagRack},
 		Usage:    "[app]",
@@ -174,8 +202,17 @@ func AppsDelete(rack sdk.Interface, c *stdcli.Context) error {
 func AppsExport(rack sdk.Interface, c *stdcli.Context) error {
 	app := coalesce(c.Arg(0), app(c))
 
+	sel, err := appParts(c)
+	if err != nil {
+		return err
+	}
+
 	var w io.Writer
 
+	// exports to a file stage their parts next to it so an interrupted export
+	// can pick up where it left off
+	dir := ""
+
 	if file := c.String("file"); file != "" {
 		f, err := os.Create(file)
 		if err != nil {
@@ -183,6 +220,7 @@ func AppsExport(rack sdk.Interface, c *stdcli.Context) error {
 		}
 		defer f.Close()
 		w = f
+		dir = fmt.Sprintf("%s.parts", file)
 	} else {
 		if c.Writer().IsTerminal() {
 			return fmt.Errorf("pipe this command into a file or specify --file")
@@ -191,24 +229,43 @@ func AppsExport(rack sdk.Interface, c *stdcli.Context) error {
 		c.Writer().Stdout = c.Writer().Stderr
 	}
 
-	if err := appExport(rack, c, app, w); err != nil {
+	if dir == "" {
+		tmp, err := ioutil.TempDir("", "")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	if err := appExport(rack, c, app, w, dir, sel); err != nil {
 		return err
 	}
 
-	return nil
+	return os.RemoveAll(dir)
 }
 
 func AppsImport(rack sdk.Interface, c *stdcli.Context) error {
 	app := coalesce(c.Arg(0), app(c))
 
+	sel, err := appParts(c)
+	if err != nil {
+		return err
+	}
+
 	var r io.ReadCloser
 
+	// imports from a file record their progress next to it so an interrupted
+	// import can be resumed
+	progress := ""
+
 	if file := c.String("file"); file != "" {
 		f, err := os.Open(file)
 		if err != nil {
 			return err
 		}
 		r = f
+		progress = fmt.Sprintf("%s.progress", file)
 	} else {
 		if c.Reader().IsTerminal() {
 			return fmt.Errorf("pipe a file into this command or specify --file")
@@ -218,7 +275,7 @@ func AppsImport(rack sdk.Interface, c *stdcli.Context) error {
 
 	defer r.Close()
 
-	if err := appImport(rack, c, app, r); err != nil {
+	if err := appImport(rack, c, app, r, progress, sel); err != nil {
 		return err
 	}
 
@@ -237,6 +294,17 @@ func AppsInfo(rack sdk.Interface, c *stdcli.Context) error {
 	i.Add("Status", a.Status)
 
 	i.Add("Generation", a.Generation)
+
+	if len(a.Idle) > 0 {
+		idle := []string{}
+
+		for _, ai := range a.Idle {
+			idle = append(idle, fmt.Sprintf("%s (%s)", ai.Service, common.Ago(ai.Since)))
+		}
+
+		i.Add("Idle", strings.Join(idle, ", "))
+	}
+
 	i.Add("Locked", fmt.Sprintf("%t", a.Locked))
 	i.Add("Release", a.Release)
 
@@ -259,6 +327,78 @@ func AppsLock(rack sdk.Interface, c *stdcli.Context) error {
 	return c.OK()
 }
 
+func AppsMetrics(rack sdk.Interface, c *stdcli.Context) error {
+	since, err := time.ParseDuration(coalesce(c.String("since"), "1h"))
+	if err != nil || since <= 0 {
+		return fmt.Errorf("invalid since: %s", c.String("since"))
+	}
+
+	period, err := time.ParseDuration(coalesce(c.String("period"), "1m"))
+	if err != nil || period < time.Second {
+		return fmt.Errorf("invalid period: %s", c.String("period"))
+	}
+
+	end := time.Now().UTC()
+
+	opts := structs.MetricsOptions{
+		End:    options.Time(end),
+		Period: options.Int64(int64(period.Seconds())),
+		Start:  options.Time(end.Add(-1 * since)),
+	}
+
+	if m := c.String("metrics"); m != "" {
+		opts.Metrics = strings.Split(m, ",")
+	}
+
+	ms, err := rack.AppMetrics(coalesce(c.Arg(0), app(c)), opts)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("sparklines") {
+		t := c.Table("METRIC", "LATEST", "HISTORY")
+
+		for _, m := range ms {
+			latest := "-"
+
+			if len(m.Values) > 0 {
+				latest = metricNumber(m.Values[len(m.Values)-1].Average)
+			}
+
+			t.AddRow(m.Name, latest, sparkline(m.Values))
+		}
+
+		return t.Print()
+	}
+
+	t := c.Table("METRIC", "MIN", "AVG", "MAX", "LATEST")
+
+	for _, m := range ms {
+		if len(m.Values) == 0 {
+			t.AddRow(m.Name, "-", "-", "-", "-")
+			continue
+		}
+
+		min, max, sum := m.Values[0].Minimum, m.Values[0].Maximum, 0.0
+
+		for _, v := range m.Values {
+			if v.Minimum < min {
+				min = v.Minimum
+			}
+
+			if v.Maximum > max {
+				max = v.Maximum
+			}
+
+			sum += v.Average
+		}
+
+		t.AddRow(m.Name, metricNumber(min), metricNumber(sum/float64(len(m.Values))), metricNumber(max), metricNumber(m.Values[len(m.Values)-1].Average))
+	}
+
+	return t.Print()
+}
+
 func AppsParams(rack sdk.Interface, c *stdcli.Context) error {
 	s, err := rack.SystemGet()
 	if err != nil {
@@ -351,6 +491,44 @@ func AppsParamsSet(rack sdk.Interface, c *stdcli.Context) error {
 	return c.OK()
 }
 
+func AppsPrune(rack sdk.Interface, c *stdcli.Context) error {
+	app := coalesce(c.Arg(0), app(c))
+
+	var opts structs.AppPruneOptions
+
+	if err := c.Options(&opts); err != nil {
+		return err
+	}
+
+	if !c.Bool("dry-run") {
+		c.Startf("Pruning <app>%s</app>", app)
+	}
+
+	pos, err := rack.AppPrune(app, opts)
+	if err != nil {
+		return err
+	}
+
+	if !c.Bool("dry-run") {
+		if err := c.OK(); err != nil {
+			return err
+		}
+	}
+
+	if len(pos) == 0 {
+		c.Writef("Nothing to remove\n")
+		return nil
+	}
+
+	t := c.Table("TYPE", "ID", "CREATED", "IMAGES")
+
+	for _, po := range pos {
+		t.AddRow(po.Type, po.Id, common.Ago(po.Created), strings.Join(po.Images, ","))
+	}
+
+	return t.Print()
+}
+
 func AppsUnlock(rack sdk.Interface, c *stdcli.Context) error {
 	app := coalesce(c.Arg(0), app(c))
 
@@ -363,12 +541,69 @@ func AppsUnlock(rack sdk.Interface, c *stdcli.Context) error {
 	return c.OK()
 }
 
-func appExport(rack sdk.Interface, c *stdcli.Context, app string, w io.Writer) error {
-	tmp, err := ioutil.TempDir("", "")
+const appExportVersion = 2
+
+var appExportParts = []string{"app", "build", "env", "params", "resources"}
+
+// appExportIndex is the first entry of an app export and lists every part
+// that follows it with its checksum. Every export gets a run id that is kept
+// when an interrupted export is resumed so that only parts staged by the same
+// export are reused
+type appExportIndex struct {
+	App      string          `json:"app"`
+	Version  int             `json:"version"`
+	Run      string          `json:"run,omitempty"`
+	Started  time.Time       `json:"started,omitempty"`
+	Packaged time.Time       `json:"packaged,omitempty"`
+	Parts    []appExportPart `json:"parts"`
+}
+
+// appExportPart records when its data was dumped, parts reused from an
+// interrupted run are marked resumed and are as old as their exported time
+type appExportPart struct {
+	Kind     string    `json:"kind"`
+	Name     string    `json:"name"`
+	Source   string    `json:"source,omitempty"`
+	Sha256   string    `json:"sha256,omitempty"`
+	Size     int64     `json:"size"`
+	Exported time.Time `json:"exported,omitempty"`
+	Resumed  bool      `json:"resumed,omitempty"`
+}
+
+// appImportProgress records the parts of an export that have already been
+// imported along with their checksums
+type appImportProgress struct {
+	App     string            `json:"app"`
+	Done    map[string]string `json:"done"`
+	Release string            `json:"release,omitempty"`
+
+	file string
+}
+
+func appExport(rack sdk.Interface, c *stdcli.Context, app string, w io.Writer, dir string, sel map[string]bool) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// parts staged by an earlier run that was interrupted
+	staged, err := appExportIndexRead(dir)
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(tmp)
+
+	idx := &appExportIndex{App: app, Version: appExportVersion, Started: time.Now().UTC(), Parts: []appExportPart{}}
+
+	if staged.Run != "" && staged.App == app {
+		idx.Run = staged.Run
+		idx.Started = staged.Started
+	} else {
+		run, err := common.RandomString(10)
+		if err != nil {
+			return err
+		}
+
+		idx.Run = run
+	}
 
 	c.Startf("Exporting app <app>%s</app>", app)
 
@@ -383,41 +618,84 @@ func appExport(rack sdk.Interface, c *stdcli.Context, app string, w io.Writer) e
 		}
 	}
 
+	if !sel["params"] {
+		a.Parameters = map[string]string{}
+	}
+
 	data, err := json.Marshal(a)
 	if err != nil {
 		return err
 	}
 
-	if err := ioutil.WriteFile(filepath.Join(tmp, "app.json"), data, 0600); err != nil {
+	if err := appExportStage(dir, idx, appExportPart{Kind: "app", Name: "app.json"}, appExportBytes(data)); err != nil {
 		return err
 	}
 
 	c.OK()
 
-	if a.Release != "" {
-		c.Startf("Exporting env")
-
+	if a.Release != "" && (sel["env"] || sel["build"]) {
 		_, r, err := common.AppManifest(rack, app)
 		if err != nil {
 			return err
 		}
 
-		if err := ioutil.WriteFile(filepath.Join(tmp, "env"), []byte(r.Env), 0600); err != nil {
+		if sel["env"] {
+			c.Startf("Exporting env")
+
+			if err := appExportStage(dir, idx, appExportPart{Kind: "env", Name: "env"}, appExportBytes([]byte(r.Env))); err != nil {
+				return err
+			}
+
+			c.OK()
+		}
+
+		if sel["build"] && r.Build != "" {
+			c.Startf("Exporting build <build>%s</build>", r.Build)
+
+			part := appExportPart{Kind: "build", Name: "build.tgz", Source: r.Build}
+
+			if p := appExportStaged(dir, idx, staged, part); p != nil {
+				c.OK(fmt.Sprintf("resumed from %s", common.Ago(p.Exported)))
+			} else {
+				err := appExportStage(dir, idx, part, func(w io.Writer) error {
+					return rack.BuildExport(app, r.Build, w)
+				})
+				if err != nil {
+					return err
+				}
+
+				c.OK()
+			}
+		}
+	}
+
+	if sel["resources"] {
+		rs, err := rack.ResourceList(app)
+		if err != nil {
 			return err
 		}
 
-		c.OK()
+		for _, r := range rs {
+			c.Startf("Exporting resource <resource>%s</resource>", r.Name)
 
-		if r.Build != "" {
-			c.Startf("Exporting build <build>%s</build>", r.Build)
+			part := appExportPart{Kind: "resource", Name: fmt.Sprintf("resources/%s.tgz", r.Name), Source: r.Name}
 
-			fd, err := os.OpenFile(filepath.Join(tmp, "build.tgz"), os.O_CREATE|os.O_WRONLY, 0600)
-			if err != nil {
-				return err
+			if p := appExportStaged(dir, idx, staged, part); p != nil {
+				c.OK(fmt.Sprintf("resumed from %s", common.Ago(p.Exported)))
+				continue
 			}
-			defer fd.Close()
 
-			if err := rack.BuildExport(app, r.Build, fd); err != nil {
+			err := appExportStage(dir, idx, part, func(w io.Writer) error {
+				rr, err := rack.ResourceExport(app, r.Name)
+				if err != nil {
+					return err
+				}
+				defer rr.Close()
+
+				_, err = io.Copy(w, rr)
+				return err
+			})
+			if err != nil {
 				return err
 			}
 
@@ -425,53 +703,175 @@ func appExport(rack sdk.Interface, c *stdcli.Context, app string, w io.Writer) e
 		}
 	}
 
-	rs, err := rack.ResourceList(app)
+	c.Startf("Packaging export")
+
+	if err := appExportPackage(dir, idx, w); err != nil {
+		return err
+	}
+
+	return c.OK()
+}
+
+// appExportBytes writes a part that is already in memory
+func appExportBytes(data []byte) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}
+}
+
+func appExportIndexRead(dir string) (*appExportIndex, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "index.json"))
+	if os.IsNotExist(err) {
+		return &appExportIndex{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var idx appExportIndex
+
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, err
+	}
+
+	return &idx, nil
+}
+
+// appExportPackage streams the index followed by every staged part as a
+// gzipped tarball
+func appExportPackage(dir string, idx *appExportIndex, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	idx.Packaged = time.Now().UTC()
+
+	data, err := json.Marshal(idx)
 	if err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(filepath.Join(tmp, "resources"), 0700); err != nil {
+	if err := tw.WriteHeader(&tar.Header{Name: "index.json", Mode: 0600, Size: int64(len(data)), ModTime: time.Now()}); err != nil {
 		return err
 	}
 
-	for _, r := range rs {
-		c.Startf("Exporting resource <resource>%s</resource>", r.Name)
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
 
-		fd, err := os.OpenFile(filepath.Join(tmp, "resources", fmt.Sprintf("%s.tgz", r.Name)), os.O_CREATE|os.O_WRONLY, 0600)
+	for _, p := range idx.Parts {
+		fd, err := os.Open(filepath.Join(dir, p.Name))
 		if err != nil {
 			return err
 		}
-		defer fd.Close()
 
-		rr, err := rack.ResourceExport(app, r.Name)
-		if err != nil {
+		if err := tw.WriteHeader(&tar.Header{Name: p.Name, Mode: 0600, Size: p.Size, ModTime: time.Now()}); err != nil {
+			fd.Close()
 			return err
 		}
 
-		if _, err := io.Copy(fd, rr); err != nil {
+		_, err = io.Copy(tw, fd)
+		fd.Close()
+		if err != nil {
 			return err
 		}
+	}
 
-		c.OK()
+	if err := tw.Close(); err != nil {
+		return err
 	}
 
-	c.Startf("Packaging export")
+	return gz.Close()
+}
 
-	tgz, err := common.Tarball(tmp)
+// appExportStage writes a part to the staging directory and records it in
+// the index on disk so a later run can reuse it
+func appExportStage(dir string, idx *appExportIndex, part appExportPart, fn func(io.Writer) error) error {
+	file := filepath.Join(dir, part.Name)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+
+	fd, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
 	if err != nil {
 		return err
 	}
+	defer fd.Close()
+
+	h := sha256.New()
 
-	if _, err := w.Write(tgz); err != nil {
+	if err := fn(io.MultiWriter(fd, h)); err != nil {
 		return err
 	}
 
-	c.OK()
+	fi, err := fd.Stat()
+	if err != nil {
+		return err
+	}
+
+	part.Exported = time.Now().UTC()
+	part.Sha256 = hex.EncodeToString(h.Sum(nil))
+	part.Size = fi.Size()
+
+	idx.Parts = append(idx.Parts, part)
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, "index.json"), data, 0600)
+}
+
+// appExportStaged reuses a part staged by an interrupted invocation of the
+// same run if it was exported from the same source and is intact
+func appExportStaged(dir string, idx, staged *appExportIndex, part appExportPart) *appExportPart {
+	if staged.App != idx.App || staged.Run == "" || staged.Run != idx.Run {
+		return nil
+	}
+
+	for _, p := range staged.Parts {
+		if p.Name != part.Name || p.Source != part.Source {
+			continue
+		}
+
+		if err := appExportVerify(dir, p); err != nil {
+			return nil
+		}
+
+		p.Resumed = true
+
+		idx.Parts = append(idx.Parts, p)
+
+		return &p
+	}
+
+	return nil
+}
+
+func appExportVerify(dir string, part appExportPart) error {
+	fd, err := os.Open(filepath.Join(dir, part.Name))
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	h := sha256.New()
+
+	n, err := io.Copy(h, fd)
+	if err != nil {
+		return err
+	}
+
+	if n != part.Size || hex.EncodeToString(h.Sum(nil)) != part.Sha256 {
+		return fmt.Errorf("checksum mismatch for %s", part.Name)
+	}
 
 	return nil
 }
 
-func appImport(rack sdk.Interface, c *stdcli.Context, app string, r io.Reader) error {
+func appImport(rack sdk.Interface, c *stdcli.Context, app string, r io.Reader, progress string, sel map[string]bool) error {
 	tmp, err := ioutil.TempDir("", "")
 	if err != nil {
 		return err
@@ -487,38 +887,55 @@ func appImport(rack sdk.Interface, c *stdcli.Context, app string, r io.Reader) e
 		return err
 	}
 
-	var a structs.App
-
-	data, err := ioutil.ReadFile(filepath.Join(tmp, "app.json"))
+	idx, err := appImportIndex(tmp)
 	if err != nil {
 		return err
 	}
 
-	if err := json.Unmarshal(data, &a); err != nil {
+	p, err := appImportProgressRead(progress, app)
+	if err != nil {
 		return err
 	}
 
-	c.Startf("Creating app <app>%s</app>", app)
+	if len(p.Done) > 0 {
+		c.Writef("Resuming import of <app>%s</app>\n", app)
+	}
+
+	var a structs.App
 
-	if _, err := rack.AppCreate(app, structs.AppCreateOptions{Generation: options.String(a.Generation)}); err != nil {
+	data, err := ioutil.ReadFile(filepath.Join(tmp, "app.json"))
+	if err != nil {
 		return err
 	}
 
-	if err := common.WaitForAppRunning(rack, app); err != nil {
+	if err := json.Unmarshal(data, &a); err != nil {
 		return err
 	}
 
-	c.OK()
+	if sel["app"] && !p.done("app", "") {
+		c.Startf("Creating app <app>%s</app>", app)
 
-	build := filepath.Join(tmp, "build.tgz")
-	env := filepath.Join(tmp, "env")
-	release := ""
+		if _, err := rack.AppCreate(app, structs.AppCreateOptions{Generation: options.String(a.Generation)}); err != nil {
+			return err
+		}
+
+		if err := common.WaitForAppRunning(rack, app); err != nil {
+			return err
+		}
+
+		c.OK()
+
+		if err := p.complete("app", ""); err != nil {
+			return err
+		}
+	}
 
-	if _, err := os.Stat(build); !os.IsNotExist(err) {
-		fd, err := os.Open(build)
+	if part := idx.part("build"); part != nil && sel["build"] && !p.done(part.Name, part.Sha256) {
+		fd, err := os.Open(filepath.Join(tmp, part.Name))
 		if err != nil {
 			return err
 		}
+		defer fd.Close()
 
 		c.Startf("Importing build")
 
@@ -529,11 +946,15 @@ func appImport(rack sdk.Interface, c *stdcli.Context, app string, r io.Reader) e
 
 		c.OK(b.Release)
 
-		release = b.Release
+		p.Release = b.Release
+
+		if err := p.complete(part.Name, part.Sha256); err != nil {
+			return err
+		}
 	}
 
-	if _, err := os.Stat(env); !os.IsNotExist(err) {
-		data, err := ioutil.ReadFile(env)
+	if part := idx.part("env"); part != nil && sel["env"] && !p.done(part.Name, part.Sha256) {
+		data, err := ioutil.ReadFile(filepath.Join(tmp, part.Name))
 		if err != nil {
 			return err
 		}
@@ -547,13 +968,17 @@ func appImport(rack sdk.Interface, c *stdcli.Context, app string, r io.Reader) e
 
 		c.OK(r.Id)
 
-		release = r.Id
+		p.Release = r.Id
+
+		if err := p.complete(part.Name, part.Sha256); err != nil {
+			return err
+		}
 	}
 
-	if release != "" {
-		c.Startf("Promoting <release>%s</release>", release)
+	if p.Release != "" && !p.done("promote", p.Release) {
+		c.Startf("Promoting <release>%s</release>", p.Release)
 
-		if err := rack.ReleasePromote(app, release, structs.ReleasePromoteOptions{}); err != nil {
+		if err := rack.ReleasePromote(app, p.Release, structs.ReleasePromoteOptions{}); err != nil {
 			return err
 		}
 
@@ -562,42 +987,40 @@ func appImport(rack sdk.Interface, c *stdcli.Context, app string, r io.Reader) e
 		}
 
 		c.OK()
-	}
 
-	if _, err := os.Stat(filepath.Join(tmp, "resources")); !os.IsNotExist(err) {
-		err = filepath.Walk(filepath.Join(tmp, "resources"), func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
+		if err := p.complete("promote", p.Release); err != nil {
+			return err
+		}
+	}
 
-			if info.IsDir() {
-				return nil
+	if sel["resources"] {
+		for _, part := range idx.Parts {
+			if part.Kind != "resource" || p.done(part.Name, part.Sha256) {
+				continue
 			}
 
-			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			c.Startf("Importing resource <resource>%s</resource>", part.Source)
 
-			c.Startf("Importing resource <resource>%s</resource>", name)
-
-			fd, err := os.Open(path)
+			fd, err := os.Open(filepath.Join(tmp, part.Name))
 			if err != nil {
 				return err
 			}
 
-			if err := rack.ResourceImport(app, name, fd); err != nil {
-				return err
-			}
+			err = rack.ResourceImport(app, part.Source, fd)
+			fd.Close()
 			if err != nil {
 				return err
 			}
 
-			return c.OK()
-		})
-		if err != nil {
-			return err
+			c.OK()
+
+			if err := p.complete(part.Name, part.Sha256); err != nil {
+				return err
+			}
 		}
 	}
 
-	if len(a.Parameters) > 0 {
+	if sel["params"] && len(a.Parameters) > 0 {
 		ae, err := rack.AppGet(app)
 		if err != nil {
 			return err
@@ -627,5 +1050,206 @@ func appImport(rack sdk.Interface, c *stdcli.Context, app string, r io.Reader) e
 		}
 	}
 
+	return p.remove()
+}
+
+// appImportIndex reads and verifies the index of an unpacked export, exports
+// made before the index existed get one built from their files
+func appImportIndex(dir string) (*appExportIndex, error) {
+	idx, err := appExportIndexRead(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if idx.Version > appExportVersion {
+		return nil, fmt.Errorf("export version %d is not supported, update your cli", idx.Version)
+	}
+
+	if idx.Version == 0 {
+		return appImportIndexLegacy(dir)
+	}
+
+	for _, p := range idx.Parts {
+		if p.Name != filepath.Clean(p.Name) || filepath.IsAbs(p.Name) || strings.HasPrefix(p.Name, "..") {
+			return nil, fmt.Errorf("invalid export part: %s", p.Name)
+		}
+
+		if err := appExportVerify(dir, p); err != nil {
+			return nil, fmt.Errorf("export is corrupt: %s", err)
+		}
+	}
+
+	return idx, nil
+}
+
+func appImportIndexLegacy(dir string) (*appExportIndex, error) {
+	idx := &appExportIndex{Parts: []appExportPart{{Kind: "app", Name: "app.json"}}}
+
+	for _, p := range []appExportPart{{Kind: "build", Name: "build.tgz"}, {Kind: "env", Name: "env"}} {
+		if _, err := os.Stat(filepath.Join(dir, p.Name)); err == nil {
+			idx.Parts = append(idx.Parts, p)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "resources", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		idx.Parts = append(idx.Parts, appExportPart{Kind: "resource", Name: filepath.Join("resources", filepath.Base(f)), Source: name})
+	}
+
+	return idx, nil
+}
+
+func (idx *appExportIndex) part(kind string) *appExportPart {
+	for _, p := range idx.Parts {
+		if p.Kind == kind {
+			return &p
+		}
+	}
+
+	return nil
+}
+
+func appImportProgressRead(file, app string) (*appImportProgress, error) {
+	p := &appImportProgress{App: app, Done: map[string]string{}, file: file}
+
+	if file == "" {
+		return p, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pp appImportProgress
+
+	if err := json.Unmarshal(data, &pp); err != nil {
+		return nil, err
+	}
+
+	// progress of an import into another app does not apply
+	if pp.App != app || pp.Done == nil {
+		return p, nil
+	}
+
+	pp.file = file
+
+	return &pp, nil
+}
+
+func (p *appImportProgress) done(step, sum string) bool {
+	s, ok := p.Done[step]
+	return ok && s == sum
+}
+
+func (p *appImportProgress) complete(step, sum string) error {
+	p.Done[step] = sum
+
+	if p.file == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p.file, data, 0600)
+}
+
+func (p *appImportProgress) remove() error {
+	if p.file == "" {
+		return nil
+	}
+
+	if err := os.Remove(p.file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
 	return nil
 }
+
+// appParts returns the parts of an app selected by --only and --skip-resources
+func appParts(c *stdcli.Context) (map[string]bool, error) {
+	sel := map[string]bool{}
+
+	if only := c.String("only"); only != "" {
+		for _, part := range strings.Split(only, ",") {
+			part = strings.TrimSpace(part)
+
+			valid := false
+
+			for _, p := range appExportParts {
+				if p == part {
+					valid = true
+				}
+			}
+
+			if !valid {
+				return nil, fmt.Errorf("invalid part: %s, must be one of: %s", part, strings.Join(appExportParts, ", "))
+			}
+
+			sel[part] = true
+		}
+	} else {
+		for _, p := range appExportParts {
+			sel[p] = true
+		}
+	}
+
+	if c.Bool("skip-resources") {
+		delete(sel, "resources")
+	}
+
+	return sel, nil
+}
+
+func metricNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+// sparkline draws the average of each metric value scaled between the
+// lowest and highest average
+func sparkline(vs structs.MetricValues) string {
+	ticks := []rune("▁▂▃▄▅▆▇█")
+
+	if len(vs) == 0 {
+		return "-"
+	}
+
+	min, max := vs[0].Average, vs[0].Average
+
+	for _, v := range vs {
+		if v.Average < min {
+			min = v.Average
+		}
+
+		if v.Average > max {
+			max = v.Average
+		}
+	}
+
+	line := make([]rune, len(vs))
+
+	for i, v := range vs {
+		t := 0
+
+		if max > min {
+			t = int((v.Average - min) / (max - min) * float64(len(ticks)-1))
+		}
+
+		line[i] = ticks[t]
+	}
+
+	return string(line)
+}
@@ -1,13 +1,18 @@
 package cli_test
 
 import (
+	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -221,6 +226,11 @@ func TestAppsExport(t *testing.T) {
 		data, err = ioutil.ReadFile(filepath.Join(tmp, "build.tgz"))
 		require.NoError(t, err)
 		require.Equal(t, bdata, data)
+
+		data, err = ioutil.ReadFile(filepath.Join(tmp, "index.json"))
+		require.NoError(t, err)
+		require.Contains(t, string(data), fmt.Sprintf(`{"kind":"resource","name":"resources/resource1.tgz","source":"resource1","sha256":"%s","size":%d,"exported":"`, testSha256(string(rdata)), len(rdata)))
+		require.Contains(t, string(data), `"run":"`)
 	})
 }
 
@@ -376,6 +386,329 @@ func TestAppsImportNoResources(t *testing.T) {
 	})
 }
 
+func TestAppsExportOnly(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppGet", "app1").Return(fxApp(), nil)
+		i.On("ReleaseGet", "app1", "release1").Return(fxRelease(), nil)
+		i.On("BuildExport", "app1", "build1", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(2).(io.Writer).Write([]byte("build"))
+		})
+
+		tmp, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(tmp)
+
+		res, err := testExecute(e, fmt.Sprintf("apps export -a app1 -f %s/app.tgz --only env,build", tmp), nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Exporting app app1... OK",
+			"Exporting env... OK",
+			"Exporting build build1... OK",
+			"Packaging export... OK",
+		})
+
+		files := testAppExportRead(t, filepath.Join(tmp, "app.tgz"))
+		require.Equal(t, "{\"generation\":\"2\",\"locked\":false,\"name\":\"app1\",\"release\":\"release1\",\"router\":\"\",\"status\":\"running\",\"parameters\":{}}", files["app.json"])
+		require.Equal(t, "build", files["build.tgz"])
+		require.Contains(t, files["index.json"], `{"kind":"build","name":"build.tgz","source":"build1","sha256":"`)
+		require.NotContains(t, files["index.json"], "resource")
+
+		_, err = os.Stat(filepath.Join(tmp, "app.tgz.parts"))
+		require.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestAppsExportOnlyInvalid(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		res, err := testExecute(e, "apps export -a app1 -f /dev/null --only env,logs", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: invalid part: logs, must be one of: app, build, env, params, resources"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestAppsExportResume(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppGet", "app1").Return(fxApp(), nil)
+		i.On("ReleaseGet", "app1", "release1").Return(fxRelease(), nil)
+		i.On("ResourceList", "app1").Return(structs.Resources{*fxResource()}, nil)
+		i.On("ResourceExport", "app1", "resource1").Return(ioutil.NopCloser(bytes.NewReader([]byte("resource"))), nil)
+
+		tmp, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(tmp)
+
+		// a build staged by an export that was interrupted
+		parts := filepath.Join(tmp, "app.tgz.parts")
+		require.NoError(t, os.MkdirAll(parts, 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(parts, "build.tgz"), []byte("build"), 0600))
+		exported := time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(parts, "index.json"), []byte(`{"app":"app1","version":2,"run":"run1","started":"`+exported+`","parts":[{"kind":"build","name":"build.tgz","source":"build1","sha256":"`+testSha256("build")+`","size":5,"exported":"`+exported+`"}]}`), 0600))
+
+		res, err := testExecute(e, fmt.Sprintf("apps export -a app1 -f %s/app.tgz", tmp), nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Exporting app app1... OK",
+			"Exporting env... OK",
+			"Exporting build build1... OK, resumed from 2 hours ago",
+			"Exporting resource resource1... OK",
+			"Packaging export... OK",
+		})
+
+		files := testAppExportRead(t, filepath.Join(tmp, "app.tgz"))
+		require.Equal(t, "build", files["build.tgz"])
+		require.Equal(t, "FOO=bar\nBAZ=quux", files["env"])
+		require.Equal(t, "resource", files["resources/resource1.tgz"])
+		require.Contains(t, files["index.json"], `"run":"run1","started":"`+exported+`"`)
+		require.Contains(t, files["index.json"], `"size":5,"exported":"`+exported+`","resumed":true}`)
+
+		i.AssertNotCalled(t, "BuildExport", "app1", "build1", mock.Anything)
+	})
+}
+
+func TestAppsExportResumeOtherRun(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppGet", "app1").Return(fxApp(), nil)
+		i.On("ReleaseGet", "app1", "release1").Return(fxRelease(), nil)
+		i.On("ResourceList", "app1").Return(structs.Resources{*fxResource()}, nil)
+		i.On("ResourceExport", "app1", "resource1").Return(ioutil.NopCloser(bytes.NewReader([]byte("resource"))), nil)
+		i.On("BuildExport", "app1", "build1", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(2).(io.Writer).Write([]byte("build"))
+		})
+
+		tmp, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(tmp)
+
+		// a resource dump staged before exports had a run id
+		parts := filepath.Join(tmp, "app.tgz.parts")
+		require.NoError(t, os.MkdirAll(filepath.Join(parts, "resources"), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(parts, "resources", "resource1.tgz"), []byte("stale"), 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(parts, "index.json"), []byte(`{"app":"app1","version":2,"parts":[{"kind":"resource","name":"resources/resource1.tgz","source":"resource1","sha256":"`+testSha256("stale")+`","size":5}]}`), 0600))
+
+		res, err := testExecute(e, fmt.Sprintf("apps export -a app1 -f %s/app.tgz", tmp), nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Exporting app app1... OK",
+			"Exporting env... OK",
+			"Exporting build build1... OK",
+			"Exporting resource resource1... OK",
+			"Packaging export... OK",
+		})
+
+		files := testAppExportRead(t, filepath.Join(tmp, "app.tgz"))
+		require.Equal(t, "resource", files["resources/resource1.tgz"])
+		require.NotContains(t, files["index.json"], `"resumed"`)
+	})
+}
+
+func TestAppsImportOnly(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseCreate", "app1", structs.ReleaseCreateOptions{Env: options.String("ALPHA=one\nBRAVO=two\n")}).Return(fxRelease(), nil)
+		i.On("ReleasePromote", "app1", "release1", structs.ReleasePromoteOptions{}).Return(nil)
+		i.On("AppGet", "app1").Return(fxApp(), nil).Twice()
+
+		res, err := testExecute(e, "apps import -a app1 -f testdata/app.tgz --only env", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Importing env... OK, release1",
+			"Promoting release1... OK",
+		})
+	})
+}
+
+func TestAppsImportResume(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleasePromote", "app1", "release1", structs.ReleasePromoteOptions{}).Return(nil)
+		i.On("AppGet", "app1").Return(fxApp(), nil).Twice()
+		i.On("ResourceImport", "app1", "resource1", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			rdata, err := ioutil.ReadAll(args.Get(2).(io.Reader))
+			require.NoError(t, err)
+			require.Equal(t, "resource", string(rdata))
+		})
+
+		tmp, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(tmp)
+
+		file := filepath.Join(tmp, "app.tgz")
+
+		testAppExportWrite(t, file, map[string]string{
+			"app.json":                `{"generation":"2","name":"app1","parameters":{}}`,
+			"build.tgz":               "build",
+			"env":                     "FOO=bar",
+			"resources/resource1.tgz": "resource",
+		}, "")
+
+		// an import that was interrupted after importing the build and env
+		require.NoError(t, ioutil.WriteFile(file+".progress", []byte(`{"app":"app1","done":{"app":"","build.tgz":"`+testSha256("build")+`","env":"`+testSha256("FOO=bar")+`"},"release":"release1"}`), 0600))
+
+		res, err := testExecute(e, fmt.Sprintf("apps import -a app1 -f %s", file), nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Resuming import of app1",
+			"Promoting release1... OK",
+			"Importing resource resource1... OK",
+		})
+
+		_, err = os.Stat(file + ".progress")
+		require.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestAppsImportResumeError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppCreate", "app1", structs.AppCreateOptions{Generation: options.String("2")}).Return(fxApp(), nil)
+		i.On("AppGet", "app1").Return(fxApp(), nil).Twice()
+		i.On("BuildImport", "app1", mock.Anything).Return(nil, fmt.Errorf("err1"))
+
+		tmp, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(tmp)
+
+		file := filepath.Join(tmp, "app.tgz")
+
+		testAppExportWrite(t, file, map[string]string{
+			"app.json":  `{"generation":"2","name":"app1","parameters":{}}`,
+			"build.tgz": "build",
+		}, "")
+
+		res, err := testExecute(e, fmt.Sprintf("apps import -a app1 -f %s", file), nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{
+			"Creating app app1... OK",
+			"Importing build... ",
+		})
+
+		data, err := ioutil.ReadFile(file + ".progress")
+		require.NoError(t, err)
+		require.Equal(t, `{"app":"app1","done":{"app":""}}`, string(data))
+	})
+}
+
+func TestAppsImportCorrupt(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		tmp, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(tmp)
+
+		file := filepath.Join(tmp, "app.tgz")
+
+		testAppExportWrite(t, file, map[string]string{
+			"app.json":  `{"generation":"2","name":"app1","parameters":{}}`,
+			"build.tgz": "build",
+		}, "build.tgz")
+
+		res, err := testExecute(e, fmt.Sprintf("apps import -a app1 -f %s", file), nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: export is corrupt: checksum mismatch for build.tgz"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+// testAppExportRead returns the contents of every file in an export
+func testAppExportRead(t *testing.T, file string) map[string]string {
+	fd, err := os.Open(file)
+	require.NoError(t, err)
+	defer fd.Close()
+
+	gz, err := gzip.NewReader(fd)
+	require.NoError(t, err)
+
+	tr := tar.NewReader(gz)
+
+	files := map[string]string{}
+
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+
+		files[h.Name] = string(data)
+	}
+
+	return files
+}
+
+// testAppExportWrite writes an export of the given files with an index,
+// the checksum of the corrupt file does not match its contents
+func testAppExportWrite(t *testing.T, file string, files map[string]string, corrupt string) {
+	names := []string{}
+
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	parts := []string{}
+
+	for _, name := range names {
+		kind := strings.TrimSuffix(name, filepath.Ext(name))
+
+		switch {
+		case name == "build.tgz":
+			kind = "build"
+		case strings.HasPrefix(name, "resources/"):
+			kind = "resource"
+		}
+
+		sum := testSha256(files[name])
+
+		if name == corrupt {
+			sum = testSha256("corrupt")
+		}
+
+		parts = append(parts, fmt.Sprintf(`{"kind":%q,"name":%q,"source":%q,"sha256":%q,"size":%d}`, kind, name, strings.TrimSuffix(filepath.Base(name), ".tgz"), sum, len(files[name])))
+	}
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	index := fmt.Sprintf(`{"app":"app1","version":2,"parts":[%s]}`, strings.Join(parts, ","))
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "index.json", Mode: 0600, Size: int64(len(index))}))
+	_, err := tw.Write([]byte(index))
+	require.NoError(t, err)
+
+	for _, name := range names {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(files[name]))}))
+		_, err := tw.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	require.NoError(t, ioutil.WriteFile(file, buf.Bytes(), 0600))
+}
+
+func testSha256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
 func TestAppsInfo(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("AppGet", "app1").Return(fxAppRouter(), nil)
@@ -408,6 +741,31 @@ func TestAppsInfo(t *testing.T) {
 	})
 }
 
+func TestAppsInfoIdle(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		a := fxApp()
+		a.Idle = structs.AppIdles{
+			{Service: "web", Since: time.Now().UTC().Add(-2 * time.Hour)},
+			{Service: "worker", Since: time.Now().UTC().Add(-3 * time.Hour)},
+		}
+
+		i.On("AppGet", "app1").Return(a, nil)
+
+		res, err := testExecute(e, "apps info app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Name        app1",
+			"Status      running",
+			"Generation  2",
+			"Idle        web (2 hours ago), worker (3 hours ago)",
+			"Locked      false",
+			"Release     release1",
+		})
+	})
+}
+
 func TestAppsInfoRouter(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("AppGet", "app1").Return(fxApp(), nil)
@@ -451,6 +809,78 @@ func TestAppsInfoError(t *testing.T) {
 
 }
 
+func fxMetrics() structs.Metrics {
+	t := time.Date(2020, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	return structs.Metrics{
+		{
+			Name: "app:cpu",
+			Values: structs.MetricValues{
+				{Time: t, Average: 0.1, Minimum: 0.05, Maximum: 0.2},
+				{Time: t.Add(time.Minute), Average: 0.5, Minimum: 0.2, Maximum: 0.8},
+				{Time: t.Add(2 * time.Minute), Average: 0.3, Minimum: 0.3, Maximum: 0.3},
+			},
+		},
+		{
+			Name: "service:web:requests",
+		},
+	}
+}
+
+func TestAppsMetrics(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppMetrics", "app1", mock.MatchedBy(func(opts structs.MetricsOptions) bool {
+			return opts.End.Sub(*opts.Start) == time.Hour && *opts.Period == 60 && len(opts.Metrics) == 0
+		})).Return(fxMetrics(), nil)
+
+		res, err := testExecute(e, "apps metrics app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"METRIC                MIN   AVG   MAX   LATEST",
+			"app:cpu               0.05  0.30  0.80  0.30",
+			"service:web:requests  -     -     -     -",
+		})
+	})
+}
+
+func TestAppsMetricsSparklines(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppMetrics", "app1", mock.MatchedBy(func(opts structs.MetricsOptions) bool {
+			return opts.End.Sub(*opts.Start) == 6*time.Hour && *opts.Period == 300 && strings.Join(opts.Metrics, ",") == "cpu,requests"
+		})).Return(fxMetrics(), nil)
+
+		res, err := testExecute(e, "apps metrics -a app1 --since 6h --period 5m --metrics cpu,requests --sparklines", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"METRIC                LATEST  HISTORY",
+			"app:cpu               0.30    ▁█▄",
+			"service:web:requests  -       -",
+		})
+	})
+}
+
+func TestAppsMetricsError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppMetrics", "app1", mock.Anything).Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "apps metrics app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+
+		res, err = testExecute(e, "apps metrics app1 --period 1x", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: invalid period: 1x"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
 func TestAppsParams(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("SystemGet").Return(fxSystem(), nil)
@@ -574,3 +1004,56 @@ func TestAppsParamsSetClassic(t *testing.T) {
 		})
 	})
 }
+
+func TestAppsPrune(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppPrune", "app1", structs.AppPruneOptions{}).Return(fxPruneObjects(), nil)
+
+		res, err := testExecute(e, "apps prune app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Pruning app1... OK",
+			"TYPE     ID        CREATED     IMAGES",
+			"build    build1    2 days ago  web.build1,worker.build1",
+			"release  release1  2 days ago  ",
+		})
+	})
+}
+
+func TestAppsPruneDryRun(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppPrune", "app1", structs.AppPruneOptions{DryRun: options.Bool(true)}).Return(fxPruneObjects(), nil)
+
+		res, err := testExecute(e, "apps prune app1 --dry-run", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"TYPE     ID        CREATED     IMAGES",
+			"build    build1    2 days ago  web.build1,worker.build1",
+			"release  release1  2 days ago  ",
+		})
+
+		i.On("AppPrune", "app2", structs.AppPruneOptions{DryRun: options.Bool(true)}).Return(structs.PruneObjects{}, nil)
+
+		res, err = testExecute(e, "apps prune -a app2 --dry-run", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"Nothing to remove"})
+	})
+}
+
+func TestAppsPruneError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppPrune", "app1", structs.AppPruneOptions{}).Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "apps prune app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{"Pruning app1... "})
+	})
+}
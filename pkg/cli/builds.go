@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/convox/convox/pkg/attest"
 	builder "github.com/convox/convox/pkg/build"
 	"github.com/convox/convox/pkg/common"
 	"github.com/convox/convox/pkg/options"
@@ -62,6 +64,22 @@ func init() {
 		Usage:    "<build>",
 		Validate: stdcli.Args(1),
 	})
+
+	register("builds sbom", "get the software bill of materials for a build", BuildsSbom, stdcli.CommandOptions{
+		Flags: append(stdcli.OptionFlags(structs.BuildSbomOptions{}),
+			flagRack,
+			flagApp,
+			stdcli.StringFlag("file", "f", "write to file"),
+		),
+		Usage:    "<build>",
+		Validate: stdcli.Args(1),
+	})
+
+	register("builds verify", "verify the signed provenance of a build", BuildsVerify, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagRack, flagApp},
+		Usage:    "<build>",
+		Validate: stdcli.Args(1),
+	})
 }
 
 func Build(rack sdk.Interface, c *stdcli.Context) error {
@@ -423,7 +441,43 @@ func BuildsInfo(rack sdk.Interface, c *stdcli.Context) error {
 	i.Add("Started", common.Ago(b.Started))
 	i.Add("Elapsed", common.Duration(b.Started, b.Ended))
 
-	return i.Print()
+	if b.Scan != nil {
+		i.Add("Scan", b.Scan.Summary())
+	}
+
+	if b.Tested != nil {
+		i.Add("Tested", common.Ago(*b.Tested))
+	}
+
+	if err := i.Print(); err != nil {
+		return err
+	}
+
+	if b.Scan == nil || b.Scan.Total() == 0 {
+		return nil
+	}
+
+	r, err := rack.ObjectFetch(app(c), fmt.Sprintf("build/%s/scan.json", b.Id))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var s structs.BuildScan
+
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return err
+	}
+
+	c.Writef("\n")
+
+	t := c.Table("SERVICE", "SEVERITY", "ID", "PACKAGE", "INSTALLED", "FIXED")
+
+	for _, v := range s.Vulnerabilities {
+		t.AddRow(v.Service, v.Severity, v.Id, v.Package, v.Installed, v.Fixed)
+	}
+
+	return t.Print()
 }
 
 func BuildsLogs(rack sdk.Interface, c *stdcli.Context) error {
@@ -442,3 +496,122 @@ func BuildsLogs(rack sdk.Interface, c *stdcli.Context) error {
 
 	return nil
 }
+
+func BuildsSbom(rack sdk.Interface, c *stdcli.Context) error {
+	var opts structs.BuildSbomOptions
+
+	if err := c.Options(&opts); err != nil {
+		return err
+	}
+
+	r, err := rack.BuildSbom(app(c), c.Arg(0), opts)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if file := c.String("file"); file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(f, r)
+		return err
+	}
+
+	_, err = io.Copy(c, r)
+	return err
+}
+
+func BuildsVerify(rack sdk.Interface, c *stdcli.Context) error {
+	b, err := rack.BuildGet(app(c), c.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	key, err := rack.SystemSigningKey()
+	if err != nil {
+		return err
+	}
+
+	a, err := rack.BuildAttestation(app(c), b.Id)
+	if err != nil {
+		return err
+	}
+
+	s, err := attest.Verify(key, a)
+	if err != nil {
+		return err
+	}
+
+	if s.Predicate.App != app(c) || s.Predicate.Build != b.Id {
+		return fmt.Errorf("attestation is for build %s of app %s", s.Predicate.Build, s.Predicate.App)
+	}
+
+	manifest, err := attest.Digest(strings.NewReader(b.Manifest))
+	if err != nil {
+		return err
+	}
+
+	if manifest != s.Predicate.Manifest {
+		return fmt.Errorf("manifest of build %s does not match its attestation", b.Id)
+	}
+
+	images := map[string]string{}
+
+	for _, sub := range s.Subject {
+		if strings.HasPrefix(sub.Name, "image/") {
+			images[strings.TrimPrefix(sub.Name, "image/")] = sub.Digest["sha256"]
+		}
+	}
+
+	t := c.Table("SERVICE", "IMAGE", "SBOM")
+
+	for _, sub := range s.Subject {
+		if !strings.HasPrefix(sub.Name, "sbom/") {
+			continue
+		}
+
+		service := strings.TrimSuffix(filepath.Base(sub.Name), ".json")
+
+		r, err := rack.BuildSbom(app(c), b.Id, structs.BuildSbomOptions{Service: options.String(service)})
+		if err != nil {
+			return err
+		}
+
+		digest, err := attest.Digest(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+
+		if digest != sub.Digest["sha256"] {
+			return fmt.Errorf("sbom for %s does not match the attestation of build %s", service, b.Id)
+		}
+
+		t.AddRow(service, common.CoalesceString(images[service], "-"), digest)
+	}
+
+	id, err := attest.KeyId(key)
+	if err != nil {
+		return err
+	}
+
+	i := c.Info()
+
+	i.Add("Build", b.Id)
+	i.Add("Rack", s.Predicate.Rack)
+	i.Add("Key", id)
+	i.Add("Signed", common.Ago(s.Predicate.Created))
+	i.Add("Status", "verified")
+
+	if err := i.Print(); err != nil {
+		return err
+	}
+
+	c.Writef("\n")
+
+	return t.Print()
+}
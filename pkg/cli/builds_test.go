@@ -5,9 +5,11 @@ import (
 	"io"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/convox/convox/pkg/attest"
 	"github.com/convox/convox/pkg/cli"
 	mocksdk "github.com/convox/convox/pkg/mock/sdk"
 	"github.com/convox/convox/pkg/options"
@@ -270,6 +272,36 @@ func TestBuildsInfo(t *testing.T) {
 	})
 }
 
+func TestBuildsInfoScan(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		b := fxBuild()
+		b.Scan = &structs.BuildScanSummary{
+			Counts: map[string]int{"critical": 1, "medium": 1},
+			Images: map[string]string{"web": "repo1:web.build1"},
+		}
+		i.On("BuildGet", "app1", "build1").Return(b, nil)
+		i.On("ObjectFetch", "app1", "build/build1/scan.json").Return(io.NopCloser(strings.NewReader(`{"images":{"web":"repo1:web.build1"},"vulnerabilities":[{"id":"CVE-2024-0001","fixed":"3.0.14","installed":"3.0.13","package":"openssl","service":"web","severity":"critical"},{"id":"CVE-2024-0002","installed":"1.2.3","package":"zlib","service":"web","severity":"medium"}]}`)), nil)
+
+		res, err := testExecute(e, "builds info build1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Id           build1",
+			"Status       complete",
+			"Release      release1",
+			"Description  desc",
+			"Started      2 days ago",
+			"Elapsed      2m0s",
+			"Scan         1 critical, 1 medium",
+			"",
+			"SERVICE  SEVERITY  ID             PACKAGE  INSTALLED  FIXED",
+			"web      critical  CVE-2024-0001  openssl  3.0.13     3.0.14",
+			"web      medium    CVE-2024-0002  zlib     1.2.3      ",
+		})
+	})
+}
+
 func TestBuildsInfoError(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("BuildGet", "app1", "build1").Return(nil, fmt.Errorf("err1"))
@@ -310,3 +342,141 @@ func TestBuildsLogsError(t *testing.T) {
 		res.RequireStdout(t, []string{""})
 	})
 }
+
+func TestBuildsSbom(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("BuildSbom", "app1", "build1", structs.BuildSbomOptions{Service: options.String("web")}).Return(ioutil.NopCloser(strings.NewReader("sbom1\n")), nil)
+
+		res, err := testExecute(e, "builds sbom build1 -a app1 -s web", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"sbom1"})
+	})
+}
+
+func TestBuildsSbomError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("BuildSbom", "app1", "build1", structs.BuildSbomOptions{}).Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "builds sbom build1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestBuildsVerify(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		public, a := testBuildAttestation(t, fxBuild().Manifest)
+
+		id, err := attest.KeyId(public)
+		require.NoError(t, err)
+
+		i.On("BuildGet", "app1", "build1").Return(fxBuild(), nil)
+		i.On("SystemSigningKey").Return(public, nil)
+		i.On("BuildAttestation", "app1", "build1").Return(a, nil)
+		i.On("BuildSbom", "app1", "build1", structs.BuildSbomOptions{Service: options.String("web")}).Return(ioutil.NopCloser(strings.NewReader("sbom-web")), nil)
+		i.On("BuildSbom", "app1", "build1", structs.BuildSbomOptions{Service: options.String("worker")}).Return(ioutil.NopCloser(strings.NewReader("sbom-worker")), nil)
+
+		res, err := testExecute(e, "builds verify build1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Build   build1",
+			"Rack    rack1",
+			"Key     " + id,
+			"Signed  2 days ago",
+			"Status  verified",
+			"",
+			"SERVICE  IMAGE                                                             SBOM",
+			"web      " + testDigest(t, "image-web") + "  " + testDigest(t, "sbom-web"),
+			"worker   -                                                                 " + testDigest(t, "sbom-worker"),
+		})
+	})
+}
+
+func TestBuildsVerifyMismatch(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		public, a := testBuildAttestation(t, fxBuild().Manifest)
+
+		i.On("BuildGet", "app1", "build1").Return(fxBuild(), nil)
+		i.On("SystemSigningKey").Return(public, nil)
+		i.On("BuildAttestation", "app1", "build1").Return(a, nil)
+		i.On("BuildSbom", "app1", "build1", structs.BuildSbomOptions{Service: options.String("web")}).Return(ioutil.NopCloser(strings.NewReader("tampered")), nil)
+
+		res, err := testExecute(e, "builds verify build1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: sbom for web does not match the attestation of build build1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestBuildsVerifyManifest(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		public, a := testBuildAttestation(t, "other")
+
+		i.On("BuildGet", "app1", "build1").Return(fxBuild(), nil)
+		i.On("SystemSigningKey").Return(public, nil)
+		i.On("BuildAttestation", "app1", "build1").Return(a, nil)
+
+		res, err := testExecute(e, "builds verify build1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: manifest of build build1 does not match its attestation"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestBuildsVerifyError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("BuildGet", "app1", "build1").Return(fxBuild(), nil)
+		i.On("SystemSigningKey").Return("key1", nil)
+		i.On("BuildAttestation", "app1", "build1").Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "builds verify build1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+// testBuildAttestation signs an attestation for build1 covering the web image
+// and a web and worker sbom and returns it with the public key that verifies it
+func testBuildAttestation(t *testing.T, manifest string) (string, *structs.BuildAttestation) {
+	key, err := attest.GenerateKey()
+	require.NoError(t, err)
+
+	public, err := attest.PublicKey(key)
+	require.NoError(t, err)
+
+	s := attest.NewStatement(attest.Predicate{
+		App:      "app1",
+		Build:    "build1",
+		Created:  fxStarted,
+		Manifest: testDigest(t, manifest),
+		Rack:     "rack1",
+	})
+
+	s.Subject = append(s.Subject, attest.Subject{Name: "image/web", Digest: map[string]string{"sha256": testDigest(t, "image-web")}})
+
+	for _, service := range []string{"web", "worker"} {
+		s.Subject = append(s.Subject, attest.Subject{Name: "sbom/" + service + ".json", Digest: map[string]string{"sha256": testDigest(t, "sbom-"+service)}})
+	}
+
+	a, err := attest.Sign(key, s)
+	require.NoError(t, err)
+
+	return public, a
+}
+
+func testDigest(t *testing.T, data string) string {
+	digest, err := attest.Digest(strings.NewReader(data))
+	require.NoError(t, err)
+
+	return digest
+}
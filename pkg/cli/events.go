@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/sdk"
+	"github.com/convox/stdcli"
+)
+
+func init() {
+	register("events", "list rack events", Events, stdcli.CommandOptions{
+		Flags:    append(stdcli.OptionFlags(structs.EventStreamOptions{}), flagRack, flagLogsOutput),
+		Validate: stdcli.Args(0),
+	})
+}
+
+func Events(rack sdk.Interface, c *stdcli.Context) error {
+	var opts structs.EventStreamOptions
+
+	if err := c.Options(&opts); err != nil {
+		return err
+	}
+
+	switch c.String("output") {
+	case "", "json":
+	default:
+		return fmt.Errorf("unknown output format: %s", c.String("output"))
+	}
+
+	r, err := rack.EventStream(opts)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	for s.Scan() {
+		line := s.Text()
+
+		// the rack sends empty lines to keep a followed stream alive
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "ERROR: ") {
+			return fmt.Errorf("%s", strings.TrimPrefix(line, "ERROR: "))
+		}
+
+		if c.String("output") == "json" {
+			fmt.Fprintln(c, line)
+			continue
+		}
+
+		var e structs.Event
+
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(c, eventLine(e))
+	}
+
+	return s.Err()
+}
+
+// eventLine renders an event as its time, action and status followed by its
+// data, the rack is left out as every event comes from the current rack
+func eventLine(e structs.Event) string {
+	parts := []string{e.Timestamp.UTC().Format(time.RFC3339), e.Action, e.Status}
+
+	keys := []string{}
+
+	for k := range e.Data {
+		if k != "rack" {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := e.Data[k]
+
+		if v == "" || strings.ContainsAny(v, " \t\"") {
+			v = strconv.Quote(v)
+		}
+
+		parts = append(parts, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return strings.Join(parts, " ")
+}
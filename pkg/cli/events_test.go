@@ -0,0 +1,81 @@
+package cli_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/cli"
+	mocksdk "github.com/convox/convox/pkg/mock/sdk"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/stretchr/testify/require"
+)
+
+var fxEvents = []string{
+	`{"action":"release:promote","data":{"app":"app1","id":"R1","rack":"rack1"},"status":"start","timestamp":"2020-01-02T03:04:05Z"}`,
+	`{"action":"release:promote","data":{"app":"app1","id":"R1","message":"release failed and was rolled back","rack":"rack1"},"status":"error","timestamp":"2020-01-02T03:09:05Z"}`,
+}
+
+func testEvents(lines []string) io.ReadCloser {
+	return io.NopCloser(strings.NewReader(strings.Join(lines, "\n") + "\n"))
+}
+
+func TestEvents(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("EventStream", structs.EventStreamOptions{}).Return(testEvents([]string{fxEvents[0], "", fxEvents[1]}), nil)
+
+		res, err := testExecute(e, "events", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"2020-01-02T03:04:05Z release:promote start app=app1 id=R1",
+			`2020-01-02T03:09:05Z release:promote error app=app1 id=R1 message="release failed and was rolled back"`,
+		})
+	})
+}
+
+func TestEventsOptions(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		opts := structs.EventStreamOptions{
+			Action: options.String("release:promote"),
+			App:    options.String("app1"),
+			Follow: options.Bool(true),
+			Since:  options.Duration(10 * time.Minute),
+		}
+		i.On("EventStream", opts).Return(testEvents([]string{"", fxEvents[0], "", fxEvents[1]}), nil)
+
+		res, err := testExecute(e, "events -a app1 --action release:promote --follow --since 10m -o json", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, fxEvents)
+	})
+}
+
+func TestEventsError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("EventStream", structs.EventStreamOptions{}).Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "events", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestEventsStreamError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("EventStream", structs.EventStreamOptions{}).Return(testEvents([]string{"ERROR: err1"}), nil)
+
+		res, err := testExecute(e, "events", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
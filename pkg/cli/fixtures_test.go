@@ -70,6 +70,33 @@ func fxAppUpdating() *structs.App {
 	}
 }
 
+func fxAuditLog() *structs.AuditLog {
+	return &structs.AuditLog{
+		Id:        "audit1",
+		Action:    "ReleasePromote",
+		App:       "app1",
+		Method:    "POST",
+		Result:    "success",
+		Route:     "/apps/{app}/releases/{id}/promote",
+		Timestamp: time.Now().UTC().Add(-49 * time.Hour),
+		User:      "user1",
+	}
+}
+
+func fxAuditLogError() *structs.AuditLog {
+	return &structs.AuditLog{
+		Id:        "audit2",
+		Action:    "AppDelete",
+		App:       "app1",
+		Error:     "err1",
+		Method:    "DELETE",
+		Result:    "error",
+		Route:     "/apps/{name}",
+		Timestamp: time.Now().UTC().Add(-49 * time.Hour),
+		User:      "user2",
+	}
+}
+
 func fxBuild() *structs.Build {
 	return &structs.Build{
 		App:         "app1",
@@ -197,6 +224,13 @@ func fxProcessPending() *structs.Process {
 	}
 }
 
+func fxPruneObjects() structs.PruneObjects {
+	return structs.PruneObjects{
+		{Created: fxStarted, Id: "build1", Images: []string{"web.build1", "worker.build1"}, Type: "build"},
+		{Created: fxStarted, Id: "release1", Type: "release"},
+	}
+}
+
 func fxRegistry() *structs.Registry {
 	return &structs.Registry{
 		Server:   "registry1",
@@ -239,6 +273,21 @@ func fxRelease3() *structs.Release {
 	}
 }
 
+func fxReleaseDiff() *structs.ReleaseDiff {
+	return &structs.ReleaseDiff{
+		App:  "app1",
+		From: "release2",
+		To:   "release1",
+		Changes: structs.ReleaseDiffChanges{
+			{Action: "changed", Kind: "build", From: "build2", To: "build1"},
+			{Action: "added", Kind: "env", Name: "FOO"},
+			{Action: "changed", Kind: "scale", Name: "web", Field: "count.min", From: "1", To: "3"},
+			{Action: "changed", Kind: "service", Name: "web", Field: "domain", To: "example.org"},
+			{Action: "removed", Kind: "timer", Name: "cleanup"},
+		},
+	}
+}
+
 func fxReleaseList() structs.Releases {
 	return structs.Releases{*fxRelease(), *fxRelease2(), *fxRelease3()}
 }
@@ -364,3 +413,61 @@ func fxRuntime() *structs.Runtime {
 		Title: "533267189958",
 	}
 }
+
+func fxTimer() *structs.Timer {
+	return &structs.Timer{
+		Name:     "timer1",
+		Command:  "bin/cleanup",
+		LastRun:  fxStarted,
+		Release:  "release1",
+		Schedule: "0 * * * *",
+		Service:  "web",
+	}
+}
+
+func fxTimerRun() *structs.TimerRun {
+	return &structs.TimerRun{
+		Id:      "timer-timer1-28500000",
+		Ended:   fxStarted.Add(2 * time.Minute),
+		Release: "release1",
+		Started: fxStarted,
+		Status:  structs.TimerRunSucceeded,
+		Timer:   "timer1",
+	}
+}
+
+func fxTimerRunFailed() *structs.TimerRun {
+	return &structs.TimerRun{
+		Id:      "timer-timer1-1709719200",
+		Ended:   fxStarted.Add(30 * time.Second),
+		Error:   "BackoffLimitExceeded",
+		Manual:  true,
+		Release: "release1",
+		Started: fxStarted,
+		Status:  structs.TimerRunFailed,
+		Timer:   "timer1",
+	}
+}
+
+func fxWebhook() *structs.Webhook {
+	return &structs.Webhook{
+		Name:   "webhook1",
+		Apps:   []string{"app1"},
+		Events: []string{"app:*", "release:promote"},
+		Kind:   "webhook",
+		Signed: true,
+		Url:    "https://example.org/hook",
+	}
+}
+
+func fxWebhookDelivery() *structs.WebhookDelivery {
+	return &structs.WebhookDelivery{
+		Id:       "delivery1",
+		Attempts: 8,
+		Created:  time.Now().UTC().Add(-49 * time.Hour),
+		Error:    "unexpected response: 502 Bad Gateway",
+		Event:    "release:promote",
+		Status:   structs.WebhookDeliveryDead,
+		Webhook:  "webhook1",
+	}
+}
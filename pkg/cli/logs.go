@@ -1,20 +1,29 @@
 package cli
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/convox/convox/pkg/logquery"
 	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/convox/convox/sdk"
 	"github.com/convox/stdcli"
 )
 
+var flagLogsOutput = stdcli.StringFlag("output", "o", "output format (json)")
+
 func init() {
 	register("logs", "get logs for an app", Logs, stdcli.CommandOptions{
-		Flags: append(stdcli.OptionFlags(structs.LogsOptions{}), flagApp, flagNoFollow, flagRack,
+		Flags: append(stdcli.OptionFlags(structs.LogsOptions{}), flagApp, flagLogsOutput, flagNoFollow, flagRack,
 			stdcli.StringFlag("service", "s", "service name"),
 		),
-		Validate: stdcli.Args(0),
+		Usage: "[query]...",
 	})
 }
 
@@ -31,21 +40,128 @@ func Logs(rack sdk.Interface, c *stdcli.Context) error {
 
 	opts.Prefix = options.Bool(true)
 
+	if err := logsQuery(&opts, c.Args); err != nil {
+		return err
+	}
+
 	var r io.ReadCloser
 	var err error
-	if c.String("service") != "" {
+	switch {
+	case c.String("service") != "" && opts.Query != nil:
+		// service logs come straight from the cluster so search the app logs
+		// for the service instead
+		opts.Query = options.String(fmt.Sprintf("service=%s %s", c.String("service"), *opts.Query))
+
+		r, err = rack.AppLogs(app(c), opts)
+		if err != nil {
+			return err
+		}
+	case c.String("service") != "":
 		r, err = rack.ServiceLogs(app(c), c.String("service"), opts)
 		if err != nil {
 			return err
 		}
-	} else {
+	default:
 		r, err = rack.AppLogs(app(c), opts)
 		if err != nil {
 			return err
 		}
 	}
 
-	_, err = io.Copy(c, r)
+	return copyLogs(c, r, c.String("output"))
+}
+
+// logsQuery combines the query flag and arguments into opts.Query and checks
+// that the result parses before it is sent to the rack
+func logsQuery(opts *structs.LogsOptions, args []string) error {
+	terms := []string{}
+
+	if opts.Query != nil && *opts.Query != "" {
+		terms = append(terms, *opts.Query)
+	}
+
+	for _, a := range args {
+		terms = append(terms, logsQueryArg(a))
+	}
+
+	if len(terms) == 0 {
+		opts.Query = nil
+		return nil
+	}
+
+	q := strings.Join(terms, " ")
+
+	if _, err := logquery.Parse(q); err != nil {
+		return err
+	}
+
+	opts.Query = options.String(q)
 
 	return nil
 }
+
+// logsQueryArg restores the quoting that the shell removed from an argument
+// that contains whitespace
+func logsQueryArg(a string) string {
+	if !strings.ContainsAny(a, " \t") {
+		return a
+	}
+
+	if i := strings.IndexAny(a, "=<>"); i > 0 && !strings.ContainsAny(a[:i], " \t") {
+		j := i + 1
+		for j < len(a) && strings.ContainsRune("=<>", rune(a[j])) {
+			j++
+		}
+		return a[:j] + strconv.Quote(a[j:])
+	}
+
+	return strconv.Quote(a)
+}
+
+type logLine struct {
+	Timestamp string                 `json:"timestamp,omitempty"`
+	Stream    string                 `json:"stream,omitempty"`
+	Message   string                 `json:"message"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+func copyLogs(w io.Writer, r io.Reader, output string) error {
+	switch output {
+	case "":
+		io.Copy(w, r)
+		return nil
+	case "json":
+	default:
+		return fmt.Errorf("unknown output format: %s", output)
+	}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	e := json.NewEncoder(w)
+
+	for s.Scan() {
+		if err := e.Encode(parseLogLine(s.Text())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseLogLine splits a prefixed log line into its timestamp, stream and message
+func parseLogLine(line string) logLine {
+	l := logLine{Message: line}
+
+	if parts := strings.SplitN(line, " ", 3); len(parts) == 3 {
+		if _, err := time.Parse(time.RFC3339, parts[0]); err == nil {
+			l.Timestamp = parts[0]
+			l.Stream = parts[1]
+			l.Message = parts[2]
+		}
+	}
+
+	l.Fields = logquery.JSON(l.Message)
+
+	return l
+}
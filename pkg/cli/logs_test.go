@@ -3,6 +3,7 @@ package cli_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/convox/convox/pkg/cli"
 	mocksdk "github.com/convox/convox/pkg/mock/sdk"
@@ -37,3 +38,85 @@ func TestLogsError(t *testing.T) {
 		res.RequireStdout(t, []string{""})
 	})
 }
+
+func TestLogsQuery(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		opts := structs.LogsOptions{
+			Prefix: options.Bool(true),
+			Query:  options.String(`level=error status>=500 "timed out"`),
+			Until:  options.Duration(time.Hour),
+		}
+		i.On("AppLogs", "app1", opts).Return(testLogs(fxLogs()), nil)
+
+		res, err := testExecute(e, "logs -a app1 --until 1h level=error status>=500 'timed out'", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			fxLogs()[0],
+			fxLogs()[1],
+		})
+	})
+}
+
+func TestLogsQueryService(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		opts := structs.LogsOptions{
+			Prefix: options.Bool(true),
+			Query:  options.String("service=web level=error"),
+		}
+		i.On("AppLogs", "app1", opts).Return(testLogs(fxLogs()), nil)
+
+		res, err := testExecute(e, "logs -a app1 -s web --query level=error", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			fxLogs()[0],
+			fxLogs()[1],
+		})
+	})
+}
+
+func TestLogsQueryInvalid(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		res, err := testExecute(e, "logs -a app1 status<", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: invalid term: status<"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestLogsOutputJson(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		logs := []string{
+			`2020-02-10T13:37:22Z service/web/pid1 {"level":"error","status":503}`,
+			"2020-02-10T13:37:23Z service/web/pid1 plain line",
+			"unprefixed",
+		}
+		i.On("AppLogs", "app1", structs.LogsOptions{Prefix: options.Bool(true)}).Return(testLogs(logs), nil)
+
+		res, err := testExecute(e, "logs -a app1 --output json", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			`{"timestamp":"2020-02-10T13:37:22Z","stream":"service/web/pid1","message":"{\"level\":\"error\",\"status\":503}","fields":{"level":"error","status":503}}`,
+			`{"timestamp":"2020-02-10T13:37:23Z","stream":"service/web/pid1","message":"plain line"}`,
+			`{"message":"unprefixed"}`,
+		})
+	})
+}
+
+func TestLogsOutputUnknown(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AppLogs", "app1", structs.LogsOptions{Prefix: options.Bool(true)}).Return(testLogs(fxLogs()), nil)
+
+		res, err := testExecute(e, "logs -a app1 --output xml", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: unknown output format: xml"})
+		res.RequireStdout(t, []string{""})
+	})
+}
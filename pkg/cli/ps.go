@@ -36,12 +36,30 @@ func Ps(rack sdk.Interface, c *stdcli.Context) error {
 		return err
 	}
 
+	a, err := rack.AppGet(app(c))
+	if err != nil {
+		return err
+	}
+
 	t := c.Table("ID", "SERVICE", "STATUS", "RELEASE", "STARTED", "COMMAND")
 
 	for _, p := range ps {
 		t.AddRow(p.Id, p.Name, p.Status, p.Release, common.Ago(p.Started), p.Command)
 	}
 
+	// idle services have no processes until their next request
+	for _, ai := range a.Idle {
+		if opts.Service != nil && *opts.Service != ai.Service {
+			continue
+		}
+
+		if opts.Release != nil && *opts.Release != a.Release {
+			continue
+		}
+
+		t.AddRow("", ai.Service, "idle", a.Release, common.Ago(ai.Since), "")
+	}
+
 	return t.Print()
 }
 
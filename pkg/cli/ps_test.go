@@ -3,9 +3,11 @@ package cli_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/convox/convox/pkg/cli"
 	mocksdk "github.com/convox/convox/pkg/mock/sdk"
+	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/stretchr/testify/require"
 )
@@ -13,6 +15,7 @@ import (
 func TestPs(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("ProcessList", "app1", structs.ProcessListOptions{}).Return(structs.Processes{*fxProcess(), *fxProcessPending()}, nil)
+		i.On("AppGet", "app1").Return(fxApp(), nil)
 
 		res, err := testExecute(e, "ps -a app1", nil)
 		require.NoError(t, err)
@@ -26,6 +29,40 @@ func TestPs(t *testing.T) {
 	})
 }
 
+func TestPsIdle(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		a := fxApp()
+		a.Idle = structs.AppIdles{
+			{Service: "web", Since: time.Now().UTC().Add(-2 * time.Hour)},
+			{Service: "worker", Since: time.Now().UTC().Add(-3 * time.Hour)},
+		}
+
+		i.On("ProcessList", "app1", structs.ProcessListOptions{}).Return(structs.Processes{*fxProcess()}, nil)
+		i.On("ProcessList", "app1", structs.ProcessListOptions{Service: options.String("web")}).Return(structs.Processes{}, nil)
+		i.On("AppGet", "app1").Return(a, nil)
+
+		res, err := testExecute(e, "ps -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"ID    SERVICE  STATUS   RELEASE   STARTED      COMMAND",
+			"pid1  name     running  release1  2 days ago   command",
+			"      web      idle     release1  2 hours ago  ",
+			"      worker   idle     release1  3 hours ago  ",
+		})
+
+		res, err = testExecute(e, "ps -a app1 -s web", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"ID  SERVICE  STATUS  RELEASE   STARTED      COMMAND",
+			"    web      idle    release1  2 hours ago  ",
+		})
+	})
+}
+
 func TestPsError(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("ProcessList", "app1", structs.ProcessListOptions{}).Return(nil, fmt.Errorf("err1"))
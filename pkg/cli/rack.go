@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/convox/convox/pkg/common"
@@ -37,11 +38,53 @@ func init() {
 		Validate: stdcli.Args(0),
 	})
 
+	register("rack access create", "create a rack access credential scoped to a role", RackAccessCreate, stdcli.CommandOptions{
+		Flags: []stdcli.Flag{
+			flagRack,
+			stdcli.StringFlag("apps", "", "comma-separated app patterns to restrict access to"),
+			stdcli.StringFlag("role", "", "access role"),
+			stdcli.StringFlag("ttl", "", "how long the credential is valid (default 24h)"),
+			stdcli.StringFlag("user", "", "user or label the credential is issued to"),
+		},
+		Validate: stdcli.Args(0),
+	})
+
+	register("rack access roles", "list access roles", RackAccessRoles, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagRack},
+		Validate: stdcli.Args(0),
+	})
+
+	register("rack access roles create", "create an access role", RackAccessRolesCreate, stdcli.CommandOptions{
+		Flags:    append(stdcli.OptionFlags(structs.PolicyCreateOptions{}), flagRack),
+		Usage:    "<name>",
+		Validate: stdcli.Args(1),
+	})
+
+	register("rack access roles delete", "delete an access role", RackAccessRolesDelete, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagRack},
+		Usage:    "<name>",
+		Validate: stdcli.Args(1),
+	})
+
 	register("rack access key rotate", "rotate access key", RackAccessKeyRotate, stdcli.CommandOptions{
 		Flags:    []stdcli.Flag{flagRack},
 		Validate: stdcli.Args(0),
 	})
 
+	register("rack audit", "list the rack audit log", RackAudit, stdcli.CommandOptions{
+		Flags:    append(stdcli.OptionFlags(structs.AuditLogListOptions{}), flagRack),
+		Validate: stdcli.Args(0),
+	})
+
+	register("rack capacity", "show rack capacity and plan if a release fits", RackCapacity, stdcli.CommandOptions{
+		Flags: append(stdcli.OptionFlags(structs.CapacityPlanOptions{}),
+			flagApp,
+			flagRack,
+			stdcli.StringFlag("scale", "", "process counts to plan for, e.g. web=10,worker=2"),
+		),
+		Validate: stdcli.Args(0),
+	})
+
 	registerWithoutProvider("rack install", "install a new rack", RackInstall, stdcli.CommandOptions{
 		Flags: []stdcli.Flag{
 			stdcli.BoolFlag("prepare", "", "prepare the install but don't run it"),
@@ -58,7 +101,17 @@ func init() {
 	})
 
 	register("rack logs", "get logs for the rack", RackLogs, stdcli.CommandOptions{
-		Flags:    append(stdcli.OptionFlags(structs.LogsOptions{}), flagNoFollow, flagRack),
+		Flags: append(stdcli.OptionFlags(structs.LogsOptions{}), flagLogsOutput, flagNoFollow, flagRack),
+		Usage: "[query]...",
+	})
+
+	registerWithoutProvider("rack migrate", "migrate apps and settings to another rack", RackMigrate, stdcli.CommandOptions{
+		Flags: []stdcli.Flag{
+			stdcli.StringFlag("apps", "", "only migrate these apps (comma separated)"),
+			stdcli.BoolFlag("dry-run", "", "print the migration plan without running it"),
+			stdcli.StringFlag("from", "", "rack to migrate from"),
+			stdcli.StringFlag("to", "", "rack to migrate to"),
+		},
 		Validate: stdcli.Args(0),
 	})
 
@@ -122,6 +175,23 @@ func init() {
 		Usage:    "[version]",
 		Validate: stdcli.ArgsMax(1),
 	})
+
+	register("rack webhooks list", "list rack webhooks", RackWebhooksList, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagRack},
+		Validate: stdcli.Args(0),
+	})
+
+	register("rack webhooks deliveries", "list recent deliveries for a webhook", RackWebhooksDeliveries, stdcli.CommandOptions{
+		Flags:    append(stdcli.OptionFlags(structs.WebhookDeliveryListOptions{}), flagRack),
+		Usage:    "<name>",
+		Validate: stdcli.Args(1),
+	})
+
+	register("rack webhooks redeliver", "queue a webhook delivery to be sent again", RackWebhooksRedeliver, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagRack},
+		Usage:    "<name> <id>",
+		Validate: stdcli.Args(2),
+	})
 }
 
 type NodeGroupConfigParam struct {
@@ -345,6 +415,228 @@ func RackAccess(rack sdk.Interface, c *stdcli.Context) error {
 	return c.Writef("RACK_URL=https://jwt:%s@%s\n", jwtTk.Token, rData.RackDomain)
 }
 
+func RackAudit(rack sdk.Interface, c *stdcli.Context) error {
+	var opts structs.AuditLogListOptions
+
+	if err := c.Options(&opts); err != nil {
+		return err
+	}
+
+	ls, err := rack.AuditLogList(opts)
+	if err != nil {
+		return err
+	}
+
+	t := c.Table("TIME", "USER", "ACTION", "APP", "ROUTE", "RESULT")
+
+	for _, l := range ls {
+		result := l.Result
+
+		if l.Error != "" {
+			result = fmt.Sprintf("%s: %s", l.Result, l.Error)
+		}
+
+		t.AddRow(common.Ago(l.Timestamp), l.User, l.Action, l.App, fmt.Sprintf("%s %s", l.Method, l.Route), result)
+	}
+
+	return t.Print()
+}
+
+func RackCapacity(rack sdk.Interface, c *stdcli.Context) error {
+	var opts structs.CapacityPlanOptions
+
+	if err := c.Options(&opts); err != nil {
+		return err
+	}
+
+	if scale := c.String("scale"); scale != "" {
+		opts.Scale = map[string]string{}
+
+		for _, s := range strings.Split(scale, ",") {
+			parts := strings.SplitN(s, "=", 2)
+
+			if len(parts) != 2 {
+				return fmt.Errorf("scale must be service=count: %s", s)
+			}
+
+			opts.Scale[parts[0]] = parts[1]
+		}
+	}
+
+	if opts.Release != nil || opts.Scale != nil {
+		return rackCapacityPlan(rack, c, opts)
+	}
+
+	cp, err := rack.CapacityGet()
+	if err != nil {
+		return err
+	}
+
+	i := c.Info()
+
+	i.Add("CPU", fmt.Sprintf("%d/%dm", cp.ProcessCPU, cp.ClusterCPU))
+	i.Add("Memory", fmt.Sprintf("%d/%dMB", cp.ProcessMemory, cp.ClusterMemory))
+	i.Add("Processes", fmt.Sprintf("%d", cp.ProcessCount))
+	i.Add("Fragmentation", fmt.Sprintf("%d%%", cp.Fragmentation))
+
+	if err := i.Print(); err != nil {
+		return err
+	}
+
+	c.Writef("\n")
+
+	t := c.Table("GROUP", "NODES", "CPU", "MEMORY")
+
+	for _, g := range cp.NodeGroups {
+		t.AddRow(g.Name, fmt.Sprintf("%d", g.Nodes), capacityUsage(g.RequestedCPU, g.AllocatableCPU, "m"), capacityUsage(g.RequestedMemory, g.AllocatableMemory, "MB"))
+	}
+
+	if err := t.Print(); err != nil {
+		return err
+	}
+
+	c.Writef("\n")
+
+	t = c.Table("NODE", "GROUP", "CPU", "MEMORY", "PODS", "STATUS")
+
+	for _, n := range cp.Nodes {
+		status := "ready"
+
+		if !n.Schedulable {
+			status = "unschedulable"
+		}
+
+		t.AddRow(n.Name, n.Group, capacityUsage(n.RequestedCPU, n.AllocatableCPU, "m"), capacityUsage(n.RequestedMemory, n.AllocatableMemory, "MB"), fmt.Sprintf("%d/%d", n.Pods, n.AllocatablePods), status)
+	}
+
+	return t.Print()
+}
+
+func rackCapacityPlan(rack sdk.Interface, c *stdcli.Context, opts structs.CapacityPlanOptions) error {
+	p, err := rack.CapacityPlan(app(c), opts)
+	if err != nil {
+		return err
+	}
+
+	t := c.Table("SERVICE", "COUNT", "CPU", "MEMORY", "PENDING", "SURGE", "REASON")
+
+	for _, s := range p.Services {
+		t.AddRow(s.Name, fmt.Sprintf("%d", s.Count), fmt.Sprintf("%dm", s.CPU), fmt.Sprintf("%dMB", s.Memory), fmt.Sprintf("%d", s.Pending), capacitySurge(s), s.Reason)
+	}
+
+	if err := t.Print(); err != nil {
+		return err
+	}
+
+	c.Writef("\n")
+
+	if !p.Fits {
+		return fmt.Errorf("release %s of %s does not fit on the rack", p.Release, p.App)
+	}
+
+	return c.Writef("Release <release>%s</release> fits on the rack\n", p.Release)
+}
+
+// capacitySurge renders the surge processes of a service that could not be
+// placed out of the surge of its rollout
+func capacitySurge(s structs.CapacityPlanService) string {
+	if s.SurgePending > 0 {
+		return fmt.Sprintf("%d/%d pending", s.SurgePending, s.Surge)
+	}
+
+	return fmt.Sprintf("%d", s.Surge)
+}
+
+// capacityUsage renders requested out of allocatable with its percentage
+func capacityUsage(requested, allocatable int64, unit string) string {
+	pct := int64(0)
+
+	if allocatable > 0 {
+		pct = requested * 100 / allocatable
+	}
+
+	return fmt.Sprintf("%d/%d%s (%d%%)", requested, allocatable, unit, pct)
+}
+
+func RackAccessCreate(rack sdk.Interface, c *stdcli.Context) error {
+	role := c.String("role")
+	if role == "" {
+		return fmt.Errorf("role is required")
+	}
+
+	ttl := coalesce(c.String("ttl"), "24h")
+
+	if _, err := time.ParseDuration(ttl); err != nil {
+		return fmt.Errorf("invalid ttl: %s", ttl)
+	}
+
+	rData, err := rack.SystemGet()
+	if err != nil {
+		return err
+	}
+
+	opts := structs.SystemJwtOptions{
+		Role: options.String(role),
+		Ttl:  options.String(ttl),
+	}
+
+	if apps := c.String("apps"); apps != "" {
+		opts.Apps = options.String(apps)
+	}
+
+	if user := c.String("user"); user != "" {
+		opts.User = options.String(user)
+	}
+
+	jwtTk, err := rack.SystemJwtToken(opts)
+	if err != nil {
+		return err
+	}
+
+	return c.Writef("RACK_URL=https://jwt:%s@%s\n", jwtTk.Token, rData.RackDomain)
+}
+
+func RackAccessRoles(rack sdk.Interface, c *stdcli.Context) error {
+	ps, err := rack.PolicyList()
+	if err != nil {
+		return err
+	}
+
+	t := c.Table("NAME", "VERBS", "APPS", "BUILTIN")
+
+	for _, p := range ps {
+		t.AddRow(p.Name, strings.Join(p.Verbs, ","), strings.Join(p.Apps, ","), fmt.Sprintf("%t", p.Builtin))
+	}
+
+	return t.Print()
+}
+
+func RackAccessRolesCreate(rack sdk.Interface, c *stdcli.Context) error {
+	var opts structs.PolicyCreateOptions
+
+	if err := c.Options(&opts); err != nil {
+		return err
+	}
+
+	c.Startf("Creating role <id>%s</id>", c.Arg(0))
+
+	if _, err := rack.PolicyCreate(c.Arg(0), opts); err != nil {
+		return err
+	}
+
+	return c.OK()
+}
+
+func RackAccessRolesDelete(rack sdk.Interface, c *stdcli.Context) error {
+	c.Startf("Deleting role <id>%s</id>", c.Arg(0))
+
+	if err := rack.PolicyDelete(c.Arg(0)); err != nil {
+		return err
+	}
+
+	return c.OK()
+}
+
 func RackAccessKeyRotate(rack sdk.Interface, c *stdcli.Context) error {
 	_, err := rack.SystemJwtSignKeyRotate()
 	if err != nil {
@@ -463,14 +755,87 @@ func RackLogs(rack sdk.Interface, c *stdcli.Context) error {
 
 	opts.Prefix = options.Bool(true)
 
+	if err := logsQuery(&opts, c.Args); err != nil {
+		return err
+	}
+
 	r, err := rack.SystemLogs(opts)
 	if err != nil {
 		return err
 	}
 
-	io.Copy(c, r)
+	return copyLogs(c, r, c.String("output"))
+}
 
-	return nil
+func RackMigrate(_ sdk.Interface, c *stdcli.Context) error {
+	from, to := c.String("from"), c.String("to")
+
+	if from == "" || to == "" {
+		return fmt.Errorf("--from and --to are required")
+	}
+
+	if from == to {
+		return fmt.Errorf("can not migrate a rack to itself")
+	}
+
+	m, err := newRackMigration(c, from, to)
+	if err != nil {
+		return err
+	}
+
+	apps := []string{}
+
+	if v := c.String("apps"); v != "" {
+		apps = strings.Split(v, ",")
+	}
+
+	steps, err := m.plan(apps)
+	if err != nil {
+		return err
+	}
+
+	c.Writef("Migrating <rack>%s</rack> to <rack>%s</rack>\n", from, to)
+
+	pending := 0
+
+	for _, s := range steps {
+		done := s.done()
+
+		for _, l := range s.lines {
+			if done {
+				l = strings.Replace(l, fmt.Sprintf("%s ", strings.TrimSpace(l)[:1]), "= ", 1)
+			}
+
+			c.Writef("%s\n", l)
+		}
+
+		if !done {
+			pending++
+		}
+	}
+
+	if pending == 0 {
+		c.Writef("Nothing to migrate\n")
+		return m.remove()
+	}
+
+	if c.Bool("dry-run") {
+		return nil
+	}
+
+	c.Writef("\n")
+
+	for _, s := range steps {
+		if s.done() {
+			continue
+		}
+
+		if err := s.run(); err != nil {
+			return err
+		}
+	}
+
+	return m.remove()
 }
 
 func RackMv(_ sdk.Interface, c *stdcli.Context) error {
@@ -798,3 +1163,603 @@ func RackUpdate(_ sdk.Interface, c *stdcli.Context) error {
 
 	return nil
 }
+
+func RackWebhooksDeliveries(rack sdk.Interface, c *stdcli.Context) error {
+	var opts structs.WebhookDeliveryListOptions
+
+	if err := c.Options(&opts); err != nil {
+		return err
+	}
+
+	ds, err := rack.WebhookDeliveryList(c.Arg(0), opts)
+	if err != nil {
+		return err
+	}
+
+	t := c.Table("ID", "EVENT", "STATUS", "ATTEMPTS", "CREATED", "ERROR")
+
+	for _, d := range ds {
+		t.AddRow(d.Id, d.Event, d.Status, strconv.Itoa(d.Attempts), common.Ago(d.Created), d.Error)
+	}
+
+	return t.Print()
+}
+
+func RackWebhooksList(rack sdk.Interface, c *stdcli.Context) error {
+	ws, err := rack.WebhookList()
+	if err != nil {
+		return err
+	}
+
+	t := c.Table("NAME", "KIND", "URL", "APPS", "EVENTS", "SIGNED")
+
+	for _, w := range ws {
+		t.AddRow(w.Name, w.Kind, w.Url, strings.Join(w.Apps, ","), strings.Join(w.Events, ","), fmt.Sprintf("%t", w.Signed))
+	}
+
+	return t.Print()
+}
+
+func RackWebhooksRedeliver(rack sdk.Interface, c *stdcli.Context) error {
+	c.Startf("Redelivering <id>%s</id> to <id>%s</id>", c.Arg(1), c.Arg(0))
+
+	if err := rack.WebhookRedeliver(c.Arg(0), c.Arg(1)); err != nil {
+		return err
+	}
+
+	return c.OK()
+}
+
+// rackMigration copies apps and rack settings from one rack to another and
+// keeps its progress in a local setting so it can be resumed
+type rackMigration struct {
+	c       *stdcli.Context
+	from    sdk.Interface
+	setting string
+	state   map[string]string
+	to      sdk.Interface
+}
+
+// rackMigrationStep is one change of a migration, its lines are the plan
+// of the change in diff style
+type rackMigrationStep struct {
+	done  func() bool
+	lines []string
+	run   func() error
+}
+
+func newRackMigration(c *stdcli.Context, from, to string) (*rackMigration, error) {
+	fc, err := rackClient(c, from)
+	if err != nil {
+		return nil, err
+	}
+
+	tc, err := rackClient(c, to)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &rackMigration{
+		c:       c,
+		from:    fc,
+		setting: fmt.Sprintf("migrations/%s", strings.ReplaceAll(fmt.Sprintf("%s..%s", from, to), "/", "_")),
+		state:   map[string]string{},
+		to:      tc,
+	}
+
+	data, err := c.SettingRead(m.setting)
+	if err != nil {
+		return nil, err
+	}
+
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), &m.state); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (m *rackMigration) complete(key, value string) error {
+	m.state[key] = value
+
+	return m.c.SettingWriteKey(m.setting, key, value)
+}
+
+func (m *rackMigration) plan(apps []string) ([]rackMigrationStep, error) {
+	steps := []rackMigrationStep{}
+
+	ss, err := m.planRegistries()
+	if err != nil {
+		return nil, err
+	}
+
+	steps = append(steps, ss...)
+
+	ss, err = m.planCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	steps = append(steps, ss...)
+
+	fas, err := m.from.AppList()
+	if err != nil {
+		return nil, err
+	}
+
+	tas, err := m.to.AppList()
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]structs.App{}
+
+	for _, a := range tas {
+		existing[a.Name] = a
+	}
+
+	selected := map[string]bool{}
+
+	for _, a := range apps {
+		selected[strings.TrimSpace(a)] = true
+	}
+
+	for _, a := range fas {
+		if len(selected) > 0 && !selected[a.Name] {
+			continue
+		}
+
+		delete(selected, a.Name)
+
+		var ta *structs.App
+
+		if e, ok := existing[a.Name]; ok {
+			ta = &e
+		}
+
+		ss, err := m.planApp(a, ta)
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, ss...)
+	}
+
+	for a := range selected {
+		return nil, fmt.Errorf("app not found: %s", a)
+	}
+
+	return steps, nil
+}
+
+func (m *rackMigration) planApp(a structs.App, ta *structs.App) ([]rackMigrationStep, error) {
+	app := a.Name
+
+	// the release last created on the target and the last one promoted
+	release := fmt.Sprintf("apps/%s/release", app)
+	promoted := fmt.Sprintf("apps/%s/promote", app)
+
+	steps := []rackMigrationStep{}
+
+	created := ta == nil
+
+	if created {
+		steps = append(steps, rackMigrationStep{
+			done:  func() bool { return false },
+			lines: []string{fmt.Sprintf("+ app %s", app)},
+			run: func() error {
+				m.c.Startf("Creating app <app>%s</app>", app)
+
+				if _, err := m.to.AppCreate(app, structs.AppCreateOptions{Generation: options.String(a.Generation)}); err != nil {
+					return err
+				}
+
+				if err := common.WaitForAppRunning(m.to, app); err != nil {
+					return err
+				}
+
+				return m.c.OK()
+			},
+		})
+
+		ta = &structs.App{Name: app, Parameters: map[string]string{}}
+	}
+
+	if ps := rackMigrationParams(a.Parameters, ta.Parameters); len(ps) > 0 {
+		keys := []string{}
+
+		for k := range ps {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		lines := []string{}
+
+		for _, k := range keys {
+			if v, ok := ta.Parameters[k]; ok {
+				lines = append(lines, fmt.Sprintf("  ~ param %s: %s => %s", k, v, ps[k]))
+			} else {
+				lines = append(lines, fmt.Sprintf("  + param %s=%s", k, ps[k]))
+			}
+		}
+
+		steps = append(steps, rackMigrationStep{
+			done:  func() bool { return false },
+			lines: lines,
+			run: func() error {
+				// parameters differ between providers, only set the ones the
+				// target rack knows about
+				ae, err := m.to.AppGet(app)
+				if err != nil {
+					return err
+				}
+
+				params := map[string]string{}
+
+				for k, v := range rackMigrationParams(a.Parameters, ae.Parameters) {
+					if _, ok := ae.Parameters[k]; ok {
+						params[k] = v
+					}
+				}
+
+				if len(params) == 0 {
+					return nil
+				}
+
+				m.c.Startf("Updating parameters of <app>%s</app>", app)
+
+				if err := m.to.AppUpdate(app, structs.AppUpdateOptions{Parameters: params}); err != nil {
+					return err
+				}
+
+				if err := common.WaitForAppRunning(m.to, app); err != nil {
+					return err
+				}
+
+				return m.c.OK()
+			},
+		})
+	}
+
+	// the number of steps that create a release to promote
+	releases := 0
+
+	if a.Release != "" {
+		r, err := m.from.ReleaseGet(app, a.Release)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Build != "" {
+			key := fmt.Sprintf("apps/%s/build", app)
+			build := r.Build
+
+			steps = append(steps, rackMigrationStep{
+				done:  func() bool { return m.state[key] == build },
+				lines: []string{fmt.Sprintf("  + build %s", build)},
+				run: func() error {
+					m.c.Startf("Copying build <build>%s</build> of <app>%s</app>", build, app)
+
+					pr, pw := io.Pipe()
+
+					go func() {
+						pw.CloseWithError(m.from.BuildExport(app, build, pw))
+					}()
+
+					b, err := m.to.BuildImport(app, pr)
+					pr.Close()
+					if err != nil {
+						return err
+					}
+
+					if err := m.complete(release, b.Release); err != nil {
+						return err
+					}
+
+					if err := m.complete(key, build); err != nil {
+						return err
+					}
+
+					return m.c.OK(b.Release)
+				},
+			})
+
+			releases++
+		}
+
+		tenv := structs.Environment{}
+
+		if ta.Release != "" {
+			tr, err := m.to.ReleaseGet(app, ta.Release)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := tenv.Load([]byte(tr.Env)); err != nil {
+				return nil, err
+			}
+		}
+
+		env := structs.Environment{}
+
+		if err := env.Load([]byte(r.Env)); err != nil {
+			return nil, err
+		}
+
+		key := fmt.Sprintf("apps/%s/env", app)
+		lines := rackMigrationEnv(env, tenv)
+
+		if len(lines) > 0 {
+			steps = append(steps, rackMigrationStep{
+				done:  func() bool { return m.state[key] == r.Id },
+				lines: lines,
+				run: func() error {
+					m.c.Startf("Copying env of <app>%s</app>", app)
+
+					rr, err := m.to.ReleaseCreate(app, structs.ReleaseCreateOptions{Env: options.String(r.Env)})
+					if err != nil {
+						return err
+					}
+
+					if err := m.complete(release, rr.Id); err != nil {
+						return err
+					}
+
+					if err := m.complete(key, r.Id); err != nil {
+						return err
+					}
+
+					return m.c.OK(rr.Id)
+				},
+			})
+
+			releases++
+		}
+	}
+
+	if releases > 0 || m.state[release] != m.state[promoted] {
+		steps = append(steps, rackMigrationStep{
+			done:  func() bool { return m.state[release] != "" && m.state[release] == m.state[promoted] },
+			lines: []string{"  + promote"},
+			run: func() error {
+				m.c.Startf("Promoting <release>%s</release> of <app>%s</app>", m.state[release], app)
+
+				if err := m.to.ReleasePromote(app, m.state[release], structs.ReleasePromoteOptions{}); err != nil {
+					return err
+				}
+
+				if err := common.WaitForAppRunning(m.to, app); err != nil {
+					return err
+				}
+
+				if err := m.complete(promoted, m.state[release]); err != nil {
+					return err
+				}
+
+				return m.c.OK()
+			},
+		})
+	}
+
+	rs, err := m.from.ResourceList(app)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rs {
+		key := fmt.Sprintf("apps/%s/resources/%s", app, r.Name)
+		name := r.Name
+
+		steps = append(steps, rackMigrationStep{
+			done:  func() bool { return m.state[key] != "" },
+			lines: []string{fmt.Sprintf("  + resource %s", name)},
+			run: func() error {
+				m.c.Startf("Copying resource <resource>%s</resource> of <app>%s</app>", name, app)
+
+				rr, err := m.from.ResourceExport(app, name)
+				if err != nil {
+					return err
+				}
+				defer rr.Close()
+
+				if err := m.to.ResourceImport(app, name, rr); err != nil {
+					return err
+				}
+
+				if err := m.complete(key, time.Now().UTC().Format(time.RFC3339)); err != nil {
+					return err
+				}
+
+				return m.c.OK()
+			},
+		})
+	}
+
+	if created || len(steps) == 0 {
+		return steps, nil
+	}
+
+	changes := steps
+
+	header := rackMigrationStep{
+		done: func() bool {
+			for _, s := range changes {
+				if !s.done() {
+					return false
+				}
+			}
+			return true
+		},
+		lines: []string{fmt.Sprintf("~ app %s", app)},
+		run:   func() error { return nil },
+	}
+
+	return append([]rackMigrationStep{header}, steps...), nil
+}
+
+func (m *rackMigration) planCertificates() ([]rackMigrationStep, error) {
+	generated := structs.CertificateListOptions{Generated: options.Bool(true)}
+
+	fcs, err := m.from.CertificateList(generated)
+	if err != nil {
+		return nil, err
+	}
+
+	tcs, err := m.to.CertificateList(generated)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]bool{}
+
+	for _, tc := range tcs {
+		existing[strings.Join(tc.Domains, ",")] = true
+	}
+
+	steps := []rackMigrationStep{}
+
+	for _, fc := range fcs {
+		domains := fc.Domains
+
+		if existing[strings.Join(domains, ",")] {
+			continue
+		}
+
+		steps = append(steps, rackMigrationStep{
+			done:  func() bool { return false },
+			lines: []string{fmt.Sprintf("+ certificate %s", strings.Join(domains, ","))},
+			run: func() error {
+				m.c.Startf("Generating certificate for <id>%s</id>", strings.Join(domains, ","))
+
+				c, err := m.to.CertificateGenerate(domains, structs.CertificateGenerateOptions{Issuer: options.String("letsencrypt")})
+				if err != nil {
+					return err
+				}
+
+				return m.c.OK(c.Id)
+			},
+		})
+	}
+
+	return steps, nil
+}
+
+func (m *rackMigration) planRegistries() ([]rackMigrationStep, error) {
+	frs, err := m.from.RegistryList()
+	if err != nil {
+		return nil, err
+	}
+
+	trs, err := m.to.RegistryList()
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]structs.Registry{}
+
+	for _, r := range trs {
+		existing[r.Server] = r
+	}
+
+	sort.Sort(frs)
+
+	steps := []rackMigrationStep{}
+
+	for _, r := range frs {
+		r := r
+
+		action := "+"
+
+		if e, ok := existing[r.Server]; ok {
+			if e.Username == r.Username && e.Password == r.Password {
+				continue
+			}
+
+			action = "~"
+		}
+
+		steps = append(steps, rackMigrationStep{
+			done:  func() bool { return false },
+			lines: []string{fmt.Sprintf("%s registry %s", action, r.Server)},
+			run: func() error {
+				m.c.Startf("Adding registry <id>%s</id>", r.Server)
+
+				if _, err := m.to.RegistryAdd(r.Server, r.Username, r.Password); err != nil {
+					return err
+				}
+
+				return m.c.OK()
+			},
+		})
+	}
+
+	return steps, nil
+}
+
+func (m *rackMigration) remove() error {
+	return m.c.SettingDelete(m.setting)
+}
+
+func rackClient(c *stdcli.Context, name string) (sdk.Interface, error) {
+	r, err := rack.Match(c, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Client()
+}
+
+// rackMigrationEnv returns the plan lines of the keys that differ between
+// two environments, values are left out as they are often secret
+func rackMigrationEnv(env, target structs.Environment) []string {
+	keys := []string{}
+
+	for k := range env {
+		keys = append(keys, k)
+	}
+
+	for k := range target {
+		if _, ok := env[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	lines := []string{}
+
+	for _, k := range keys {
+		v, ok := env[k]
+		tv, tok := target[k]
+
+		switch {
+		case !tok:
+			lines = append(lines, fmt.Sprintf("  + env %s", k))
+		case !ok:
+			lines = append(lines, fmt.Sprintf("  - env %s", k))
+		case v != tv:
+			lines = append(lines, fmt.Sprintf("  ~ env %s", k))
+		}
+	}
+
+	return lines
+}
+
+// rackMigrationParams returns the parameters of an app that differ on the
+// target, masked values can not be copied
+func rackMigrationParams(params, target map[string]string) map[string]string {
+	ps := map[string]string{}
+
+	for k, v := range params {
+		if v == "****" || target[k] == v {
+			continue
+		}
+
+		ps[k] = v
+	}
+
+	return ps
+}
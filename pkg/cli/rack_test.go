@@ -2,6 +2,7 @@ package cli_test
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -15,6 +16,8 @@ import (
 	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/rack"
 	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/sdk"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -49,6 +52,240 @@ func TestRackError(t *testing.T) {
 	})
 }
 
+func TestRackAccessCreate(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("SystemGet").Return(fxSystem(), nil)
+		i.On("SystemJwtToken", structs.SystemJwtOptions{
+			Apps: options.String("staging-*"),
+			Role: options.String("deployer"),
+			Ttl:  options.String("24h"),
+			User: options.String("ci"),
+		}).Return(&structs.SystemJwt{Token: "token1"}, nil)
+
+		res, err := testExecute(e, "rack access create --role deployer --apps staging-* --user ci", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"RACK_URL=https://jwt:token1@"})
+	})
+}
+
+func TestRackAccessCreateInvalidTtl(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		res, err := testExecute(e, "rack access create --role deployer --ttl forever", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: invalid ttl: forever"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestRackAccessRoles(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("PolicyList").Return(structs.Policies{
+			{Name: "deployer", Builtin: true, Verbs: []string{"deploy", "read"}},
+			{Name: "operator", Apps: []string{"staging-*"}, Verbs: []string{"exec", "read"}},
+		}, nil)
+
+		res, err := testExecute(e, "rack access roles", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"NAME      VERBS        APPS       BUILTIN",
+			"deployer  deploy,read             true",
+			"operator  exec,read    staging-*  false",
+		})
+	})
+}
+
+func TestRackAccessRolesCreate(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("PolicyCreate", "operator", structs.PolicyCreateOptions{
+			Apps:  options.String("staging-*"),
+			Verbs: options.String("exec,read"),
+		}).Return(&structs.Policy{Name: "operator"}, nil)
+
+		res, err := testExecute(e, "rack access roles create operator --verbs exec,read --apps staging-*", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"Creating role operator... OK"})
+	})
+}
+
+func TestRackAccessRolesCreateError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("PolicyCreate", "operator", structs.PolicyCreateOptions{}).Return(nil, fmt.Errorf("verbs required"))
+
+		res, err := testExecute(e, "rack access roles create operator", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: verbs required"})
+		res.RequireStdout(t, []string{"Creating role operator... "})
+	})
+}
+
+func TestRackAccessRolesDelete(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("PolicyDelete", "operator").Return(nil)
+
+		res, err := testExecute(e, "rack access roles delete operator", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"Deleting role operator... OK"})
+	})
+}
+
+func TestRackAudit(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AuditLogList", structs.AuditLogListOptions{}).Return(structs.AuditLogs{*fxAuditLog(), *fxAuditLogError()}, nil)
+
+		res, err := testExecute(e, "rack audit", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"TIME        USER   ACTION          APP   ROUTE                                   RESULT",
+			"2 days ago  user1  ReleasePromote  app1  POST /apps/{app}/releases/{id}/promote  success",
+			"2 days ago  user2  AppDelete       app1  DELETE /apps/{name}                     error: err1",
+		})
+	})
+}
+
+func TestRackAuditFilters(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AuditLogList", structs.AuditLogListOptions{
+			App:   options.String("app1"),
+			Since: options.Duration(2 * time.Hour),
+			User:  options.String("user1"),
+		}).Return(structs.AuditLogs{*fxAuditLog()}, nil)
+
+		res, err := testExecute(e, "rack audit --app app1 --since 2h --user user1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"TIME        USER   ACTION          APP   ROUTE                                   RESULT",
+			"2 days ago  user1  ReleasePromote  app1  POST /apps/{app}/releases/{id}/promote  success",
+		})
+	})
+}
+
+func TestRackCapacity(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("CapacityGet").Return(&structs.Capacity{
+			ClusterCPU:    4000,
+			ClusterMemory: 8000,
+			ProcessCount:  3,
+			ProcessCPU:    2500,
+			ProcessMemory: 4000,
+			Fragmentation: 25,
+			NodeGroups: structs.CapacityNodeGroups{
+				{Name: "default", AllocatableCPU: 4000, AllocatableMemory: 8000, Nodes: 2, RequestedCPU: 2500, RequestedMemory: 4000},
+			},
+			Nodes: structs.CapacityNodes{
+				{Name: "node1", AllocatableCPU: 2000, AllocatableMemory: 4000, AllocatablePods: 110, Group: "default", Pods: 2, RequestedCPU: 1500, RequestedMemory: 1000, Schedulable: true},
+				{Name: "node2", AllocatableCPU: 2000, AllocatableMemory: 4000, AllocatablePods: 110, Group: "default", Pods: 1, RequestedCPU: 1000, RequestedMemory: 3000},
+			},
+		}, nil)
+
+		res, err := testExecute(e, "rack capacity", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"CPU            2500/4000m",
+			"Memory         4000/8000MB",
+			"Processes      3",
+			"Fragmentation  25%",
+			"",
+			"GROUP    NODES  CPU               MEMORY",
+			"default  2      2500/4000m (62%)  4000/8000MB (50%)",
+			"",
+			"NODE   GROUP    CPU               MEMORY             PODS   STATUS",
+			"node1  default  1500/2000m (75%)  1000/4000MB (25%)  2/110  ready",
+			"node2  default  1000/2000m (50%)  3000/4000MB (75%)  1/110  unschedulable",
+		})
+	})
+}
+
+func TestRackCapacityPlan(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("CapacityPlan", "app1", structs.CapacityPlanOptions{
+			Release: options.String("release1"),
+			Scale:   map[string]string{"web": "10"},
+		}).Return(&structs.CapacityPlan{
+			App:     "app1",
+			Fits:    true,
+			Release: "release1",
+			Services: structs.CapacityPlanServices{
+				{Name: "web", Count: 10, CPU: 256, Memory: 512, Surge: 10, SurgePending: 2, Reason: "insufficient cpu during rollout"},
+			},
+		}, nil)
+
+		res, err := testExecute(e, "rack capacity -a app1 --release release1 --scale web=10", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"SERVICE  COUNT  CPU   MEMORY  PENDING  SURGE         REASON",
+			"web      10     256m  512MB   0        2/10 pending  insufficient cpu during rollout",
+			"",
+			"Release release1 fits on the rack",
+		})
+	})
+}
+
+func TestRackCapacityPlanPending(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("CapacityPlan", "app1", structs.CapacityPlanOptions{
+			Scale: map[string]string{"web": "20"},
+		}).Return(&structs.CapacityPlan{
+			App:     "app1",
+			Release: "release1",
+			Services: structs.CapacityPlanServices{
+				{Name: "web", Count: 20, CPU: 256, Memory: 512, Pending: 4, Reason: "insufficient cpu", Surge: 20, SurgePending: 20},
+			},
+		}, nil)
+
+		res, err := testExecute(e, "rack capacity -a app1 --scale web=20", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: release release1 of app1 does not fit on the rack"})
+		res.RequireStdout(t, []string{
+			"SERVICE  COUNT  CPU   MEMORY  PENDING  SURGE          REASON",
+			"web      20     256m  512MB   4        20/20 pending  insufficient cpu",
+			"",
+		})
+	})
+}
+
+func TestRackCapacityError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("CapacityGet").Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "rack capacity", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestRackAuditError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("AuditLogList", structs.AuditLogListOptions{}).Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "rack audit", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
 func TestRackInstall(t *testing.T) {
 	testClientWait(t, 50*time.Millisecond, func(e *cli.Engine, i *mocksdk.Interface) {
 		rack.TestLatest = "foo"
@@ -318,6 +555,179 @@ func TestRackLogsError(t *testing.T) {
 	})
 }
 
+func TestRackMigrateDryRun(t *testing.T) {
+	testRackMigrate(t, func(e *cli.Engine, from, to *mocksdk.Interface) {
+		testRackMigrateSource(from)
+		to.On("RegistryList").Return(structs.Registries{}, nil)
+		to.On("CertificateList", structs.CertificateListOptions{Generated: options.Bool(true)}).Return(structs.Certificates{}, nil)
+		to.On("AppList").Return(structs.Apps{}, nil)
+
+		res, err := testExecute(e, "rack migrate --from rack1 --to rack2 --dry-run", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Migrating rack1 to rack2",
+			"+ registry index.docker.io",
+			"+ certificate example.org",
+			"+ app app1",
+			"  + param ParamFoo=value1",
+			"  + build build1",
+			"  + env BAZ",
+			"  + env FOO",
+			"  + promote",
+			"  + resource resource1",
+		})
+	})
+}
+
+func TestRackMigrate(t *testing.T) {
+	testRackMigrate(t, func(e *cli.Engine, from, to *mocksdk.Interface) {
+		testRackMigrateSource(from)
+		from.On("BuildExport", "app1", "build1", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(2).(io.Writer).Write([]byte("build"))
+		})
+		from.On("ResourceExport", "app1", "resource1").Return(ioutil.NopCloser(strings.NewReader("resource")), nil)
+
+		to.On("RegistryList").Return(structs.Registries{}, nil)
+		to.On("CertificateList", structs.CertificateListOptions{Generated: options.Bool(true)}).Return(structs.Certificates{}, nil)
+		to.On("AppList").Return(structs.Apps{}, nil)
+		to.On("RegistryAdd", "index.docker.io", "user1", "pass1").Return(&structs.Registry{}, nil)
+		to.On("CertificateGenerate", []string{"example.org"}, structs.CertificateGenerateOptions{Issuer: options.String("letsencrypt")}).Return(&structs.Certificate{Id: "cert1"}, nil)
+		to.On("AppCreate", "app1", structs.AppCreateOptions{Generation: options.String("2")}).Return(fxApp(), nil)
+		to.On("AppGet", "app1").Return(&structs.App{Name: "app1", Status: "running", Parameters: map[string]string{"ParamFoo": "default"}}, nil)
+		to.On("AppUpdate", "app1", structs.AppUpdateOptions{Parameters: map[string]string{"ParamFoo": "value1"}}).Return(nil)
+		to.On("BuildImport", "app1", mock.Anything).Return(&structs.Build{Id: "build2", Release: "release2"}, nil).Run(func(args mock.Arguments) {
+			data, err := ioutil.ReadAll(args.Get(1).(io.Reader))
+			require.NoError(t, err)
+			require.Equal(t, "build", string(data))
+		})
+		to.On("ReleaseCreate", "app1", structs.ReleaseCreateOptions{Env: options.String("FOO=bar\nBAZ=quux")}).Return(&structs.Release{Id: "release3"}, nil)
+		to.On("ReleasePromote", "app1", "release3", structs.ReleasePromoteOptions{}).Return(nil)
+		to.On("ResourceImport", "app1", "resource1", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			data, err := ioutil.ReadAll(args.Get(2).(io.Reader))
+			require.NoError(t, err)
+			require.Equal(t, "resource", string(data))
+		})
+
+		res, err := testExecute(e, "rack migrate --from rack1 --to rack2", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Migrating rack1 to rack2",
+			"+ registry index.docker.io",
+			"+ certificate example.org",
+			"+ app app1",
+			"  + param ParamFoo=value1",
+			"  + build build1",
+			"  + env BAZ",
+			"  + env FOO",
+			"  + promote",
+			"  + resource resource1",
+			"",
+			"Adding registry index.docker.io... OK",
+			"Generating certificate for example.org... OK, cert1",
+			"Creating app app1... OK",
+			"Updating parameters of app1... OK",
+			"Copying build build1 of app1... OK, release2",
+			"Copying env of app1... OK, release3",
+			"Promoting release3 of app1... OK",
+			"Copying resource resource1 of app1... OK",
+		})
+
+		_, err = os.Stat(filepath.Join(e.Settings, "migrations", "rack1..rack2"))
+		require.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestRackMigrateResume(t *testing.T) {
+	testRackMigrate(t, func(e *cli.Engine, from, to *mocksdk.Interface) {
+		testRackMigrateSource(from)
+		from.On("ResourceExport", "app1", "resource1").Return(ioutil.NopCloser(strings.NewReader("resource")), nil)
+
+		to.On("RegistryList").Return(structs.Registries{{Server: "index.docker.io", Username: "user1", Password: "pass1"}}, nil)
+		to.On("CertificateList", structs.CertificateListOptions{Generated: options.Bool(true)}).Return(structs.Certificates{{Id: "cert1", Domains: []string{"example.org"}}}, nil)
+		to.On("AppList").Return(structs.Apps{{Name: "app1", Status: "running", Parameters: map[string]string{"ParamFoo": "value1"}}}, nil)
+		to.On("AppGet", "app1").Return(fxApp(), nil)
+		to.On("ReleaseCreate", "app1", structs.ReleaseCreateOptions{Env: options.String("FOO=bar\nBAZ=quux")}).Return(&structs.Release{Id: "release3"}, nil)
+		to.On("ReleasePromote", "app1", "release3", structs.ReleasePromoteOptions{}).Return(nil)
+		to.On("ResourceImport", "app1", "resource1", mock.Anything).Return(nil)
+
+		// a migration that was interrupted after copying the build
+		require.NoError(t, os.MkdirAll(filepath.Join(e.Settings, "migrations"), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(e.Settings, "migrations", "rack1..rack2"), []byte(`{"apps/app1/build":"build1","apps/app1/release":"release2"}`), 0600))
+
+		res, err := testExecute(e, "rack migrate --from rack1 --to rack2", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Migrating rack1 to rack2",
+			"~ app app1",
+			"  = build build1",
+			"  + env BAZ",
+			"  + env FOO",
+			"  + promote",
+			"  + resource resource1",
+			"",
+			"Copying env of app1... OK, release3",
+			"Promoting release3 of app1... OK",
+			"Copying resource resource1 of app1... OK",
+		})
+
+		from.AssertNotCalled(t, "BuildExport", "app1", "build1", mock.Anything)
+	})
+}
+
+func TestRackMigrateUnknownApp(t *testing.T) {
+	testRackMigrate(t, func(e *cli.Engine, from, to *mocksdk.Interface) {
+		from.On("RegistryList").Return(structs.Registries{}, nil)
+		from.On("CertificateList", structs.CertificateListOptions{Generated: options.Bool(true)}).Return(structs.Certificates{}, nil)
+		from.On("AppList").Return(structs.Apps{*fxApp()}, nil)
+		to.On("RegistryList").Return(structs.Registries{}, nil)
+		to.On("CertificateList", structs.CertificateListOptions{Generated: options.Bool(true)}).Return(structs.Certificates{}, nil)
+		to.On("AppList").Return(structs.Apps{}, nil)
+
+		res, err := testExecute(e, "rack migrate --from rack1 --to rack2 --apps app2", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: app not found: app2"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestRackMigrateSame(t *testing.T) {
+	testRackMigrate(t, func(e *cli.Engine, from, to *mocksdk.Interface) {
+		res, err := testExecute(e, "rack migrate --from rack1 --to rack1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: can not migrate a rack to itself"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func testRackMigrate(t *testing.T, fn func(e *cli.Engine, from, to *mocksdk.Interface)) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		to := &mocksdk.Interface{}
+
+		rack.TestClients = map[string]sdk.Interface{"rack1": i, "rack2": to}
+		defer func() { rack.TestClients = nil }()
+
+		fn(e, i, to)
+
+		to.AssertExpectations(t)
+	})
+}
+
+func testRackMigrateSource(from *mocksdk.Interface) {
+	from.On("RegistryList").Return(structs.Registries{{Server: "index.docker.io", Username: "user1", Password: "pass1"}}, nil)
+	from.On("CertificateList", structs.CertificateListOptions{Generated: options.Bool(true)}).Return(structs.Certificates{{Id: "cert1", Domains: []string{"example.org"}}}, nil)
+	from.On("AppList").Return(structs.Apps{{Name: "app1", Generation: "2", Release: "release1", Status: "running", Parameters: map[string]string{"ParamFoo": "value1", "Password": "****"}}}, nil)
+	from.On("ReleaseGet", "app1", "release1").Return(fxRelease(), nil)
+	from.On("ResourceList", "app1").Return(structs.Resources{*fxResource()}, nil)
+}
+
 func TestRackParams(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("SystemGet").Return(fxSystem(), nil)
@@ -645,3 +1055,70 @@ func TestRackUpdateForce(t *testing.T) {
 		require.Equal(t, 0, res.Code)
 	})
 }
+
+func TestRackWebhooksList(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("WebhookList").Return(structs.Webhooks{*fxWebhook(), {Name: "webhook2", Kind: "slack", Url: "https://hooks.slack.com/services/T1"}}, nil)
+
+		res, err := testExecute(e, "rack webhooks list", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"NAME      KIND     URL                                  APPS  EVENTS                 SIGNED",
+			"webhook1  webhook  https://example.org/hook             app1  app:*,release:promote  true",
+			"webhook2  slack    https://hooks.slack.com/services/T1                               false",
+		})
+	})
+}
+
+func TestRackWebhooksListError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("WebhookList").Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "rack webhooks list", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestRackWebhooksDeliveries(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("WebhookDeliveryList", "webhook1", structs.WebhookDeliveryListOptions{Status: options.String("dead")}).Return(structs.WebhookDeliveries{*fxWebhookDelivery()}, nil)
+
+		res, err := testExecute(e, "rack webhooks deliveries webhook1 --status dead", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"ID         EVENT            STATUS  ATTEMPTS  CREATED     ERROR",
+			"delivery1  release:promote  dead    8         2 days ago  unexpected response: 502 Bad Gateway",
+		})
+	})
+}
+
+func TestRackWebhooksRedeliver(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("WebhookRedeliver", "webhook1", "delivery1").Return(nil)
+
+		res, err := testExecute(e, "rack webhooks redeliver webhook1 delivery1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"Redelivering delivery1 to webhook1... OK"})
+	})
+}
+
+func TestRackWebhooksRedeliverError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("WebhookRedeliver", "webhook1", "delivery1").Return(fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "rack webhooks redeliver webhook1 delivery1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{"Redelivering delivery1 to webhook1... "})
+	})
+}
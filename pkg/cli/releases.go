@@ -20,11 +20,22 @@ func init() {
 		Validate: stdcli.Args(0),
 	})
 
+	register("releases approve", "approve a release for promotion", ReleasesApprove, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagApp, flagRack},
+		Validate: stdcli.Args(1),
+	})
+
 	register("releases create-from", "create a new release using the build from one release and the environment from another for an app", ReleasesCreateFrom, stdcli.CommandOptions{
 		Flags:    append(stdcli.OptionFlags(structs.ReleaseCreateFromOptions{}), flagRack, flagApp),
 		Validate: stdcli.Args(0),
 	})
 
+	register("releases diff", "compare two releases of an app", ReleasesDiff, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagApp, flagRack},
+		Usage:    "<release> [release]",
+		Validate: stdcli.ArgsBetween(1, 2),
+	})
+
 	register("releases info", "get information about a release", ReleasesInfo, stdcli.CommandOptions{
 		Flags:    []stdcli.Flag{flagApp, flagRack},
 		Validate: stdcli.Args(1),
@@ -36,7 +47,15 @@ func init() {
 	})
 
 	register("releases promote", "promote a release", ReleasesPromote, stdcli.CommandOptions{
-		Flags:    []stdcli.Flag{flagApp, flagRack, flagForce},
+		Flags: []stdcli.Flag{
+			flagApp,
+			flagRack,
+			flagForce,
+			stdcli.IntFlag("canary", "", "percentage of traffic to send to the release before shifting the rest in steps"),
+			stdcli.BoolFlag("dry-run", "", "show what the promote would change without applying it"),
+			stdcli.StringFlag("interval", "", "time between canary traffic steps (e.g. 5m)"),
+			stdcli.IntFlag("step", "", "percentage of traffic to shift on each canary step"),
+		},
 		Validate: stdcli.ArgsMax(1),
 	})
 
@@ -139,6 +158,87 @@ func ReleasesCreateFrom(rack sdk.Interface, c *stdcli.Context) error {
 	return c.OK()
 }
 
+func ReleasesApprove(rack sdk.Interface, c *stdcli.Context) error {
+	c.Startf("Approving <release>%s</release>", c.Arg(0))
+
+	r, err := rack.ReleaseApprove(app(c), c.Arg(0), structs.ReleaseApproveOptions{})
+	if err != nil {
+		return err
+	}
+
+	return c.OK(fmt.Sprintf("%d approvals", len(r.Approvals)))
+}
+
+func ReleasesDiff(rack sdk.Interface, c *stdcli.Context) error {
+	var opts structs.ReleaseDiffOptions
+
+	id := c.Arg(0)
+
+	if len(c.Args) > 1 {
+		opts.From = options.String(c.Arg(0))
+		id = c.Arg(1)
+	}
+
+	d, err := rack.ReleaseDiff(app(c), id, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(d.Changes) == 0 {
+		c.Writef("No changes\n")
+		return nil
+	}
+
+	releaseDiffPrint(c, d)
+
+	return nil
+}
+
+// releaseDiffPreview shows what promoting a release will change, racks that
+// can not compute a diff are promoted without one
+func releaseDiffPreview(rack sdk.Interface, c *stdcli.Context, app, id string) {
+	if d, err := rack.ReleaseDiff(app, id, structs.ReleaseDiffOptions{}); err == nil {
+		releaseDiffPrint(c, d)
+	}
+}
+
+func releaseDiffPrint(c *stdcli.Context, d *structs.ReleaseDiff) {
+	symbols := map[string]string{
+		structs.ReleaseDiffAdded:   "+",
+		structs.ReleaseDiffChanged: "~",
+		structs.ReleaseDiffRemoved: "-",
+	}
+
+	for _, ch := range d.Changes {
+		line := fmt.Sprintf("%s %s", symbols[ch.Action], ch.Kind)
+
+		for _, part := range []string{ch.Name, ch.Field} {
+			if part != "" {
+				line = fmt.Sprintf("%s %s", line, part)
+			}
+		}
+
+		switch {
+		case ch.Action == structs.ReleaseDiffChanged && (ch.From != "" || ch.To != ""):
+			line = fmt.Sprintf("%s: %s => %s", line, releaseDiffValue(ch.From), releaseDiffValue(ch.To))
+		case ch.Action == structs.ReleaseDiffAdded && ch.To != "":
+			line = fmt.Sprintf("%s: %s", line, ch.To)
+		case ch.Action == structs.ReleaseDiffRemoved && ch.From != "":
+			line = fmt.Sprintf("%s: %s", line, ch.From)
+		}
+
+		c.Writef("%s\n", line)
+	}
+}
+
+func releaseDiffValue(v string) string {
+	if v == "" {
+		return `""`
+	}
+
+	return v
+}
+
 func ReleasesInfo(rack sdk.Interface, c *stdcli.Context) error {
 	r, err := rack.ReleaseGet(app(c), c.Arg(0))
 	if err != nil {
@@ -153,6 +253,16 @@ func ReleasesInfo(rack sdk.Interface, c *stdcli.Context) error {
 	i.Add("Description", r.Description)
 	i.Add("Env", r.Env)
 
+	if len(r.Approvals) > 0 {
+		as := []string{}
+
+		for _, ra := range r.Approvals {
+			as = append(as, fmt.Sprintf("%s (%s)", ra.User, common.Ago(ra.Time)))
+		}
+
+		i.Add("Approvals", strings.Join(as, ", "))
+	}
+
 	return i.Print()
 }
 
@@ -194,9 +304,86 @@ func ReleasesPromote(rack sdk.Interface, c *stdcli.Context) error {
 		release = rs[0].Id
 	}
 
+	releaseDiffPreview(rack, c, app(c), release)
+
+	if c.Bool("dry-run") {
+		if _, ok := c.Value("canary").(int); ok {
+			return fmt.Errorf("--dry-run can not be combined with --canary")
+		}
+
+		return releasePlan(rack, c, app(c), release)
+	}
+
+	if canary, ok := c.Value("canary").(int); ok {
+		return releasePromoteCanary(rack, c, app(c), release, canary)
+	}
+
 	return releasePromote(rack, c, app(c), release, c.Bool("force"))
 }
 
+func releasePlan(rack sdk.Interface, c *stdcli.Context, app, id string) error {
+	var opts structs.ReleasePromoteOptions
+
+	if c.Bool("force") {
+		opts.Force = options.Bool(true)
+	}
+
+	p, err := rack.ReleasePlan(app, id, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(p.Operations) == 0 {
+		c.Writef("No changes\n")
+	}
+
+	symbols := map[string]string{
+		structs.ReleasePlanCreate: "+",
+		structs.ReleasePlanDelete: "-",
+		structs.ReleasePlanUpdate: "~",
+	}
+
+	for _, op := range p.Operations {
+		c.Writef("%s %s %s\n", symbols[op.Action], op.Kind, op.Name)
+
+		for _, ch := range op.Changes {
+			c.Writef("    %s: %s => %s\n", ch.Field, releaseDiffValue(ch.From), releaseDiffValue(ch.To))
+		}
+	}
+
+	for _, w := range p.Warnings {
+		c.Writef("WARNING: %s\n", w)
+	}
+
+	return nil
+}
+
+func releasePromoteCanary(rack sdk.Interface, c *stdcli.Context, app, id string, canary int) error {
+	opts := structs.ReleasePromoteOptions{
+		Canary: options.Int(canary),
+	}
+
+	if c.Bool("force") {
+		opts.Force = options.Bool(true)
+	}
+
+	if v := c.String("interval"); v != "" {
+		opts.Interval = options.String(v)
+	}
+
+	if v, ok := c.Value("step").(int); ok {
+		opts.Step = options.Int(v)
+	}
+
+	c.Startf("Starting canary of <release>%s</release> at <id>%d%%</id>", id, canary)
+
+	if err := rack.ReleasePromote(app, id, opts); err != nil {
+		return err
+	}
+
+	return c.OK()
+}
+
 func releasePromote(rack sdk.Interface, c *stdcli.Context, app, id string, force bool) error {
 	if id == "" {
 		return fmt.Errorf("no release to promote")
@@ -259,6 +446,8 @@ func ReleasesRollback(rack sdk.Interface, c *stdcli.Context) error {
 
 	release := c.Arg(0)
 
+	releaseDiffPreview(rack, c, app(c), release)
+
 	c.Startf("Rolling back to <release>%s</release>", release)
 
 	ro, err := rack.ReleaseGet(app(c), release)
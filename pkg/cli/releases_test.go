@@ -43,6 +43,74 @@ func TestReleasesError(t *testing.T) {
 	})
 }
 
+func TestReleasesApprove(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		r := fxRelease()
+		r.Approvals = structs.ReleaseApprovals{{User: "user1", Time: time.Now().UTC()}, {User: "user2", Time: time.Now().UTC()}}
+		i.On("ReleaseApprove", "app1", "release1", structs.ReleaseApproveOptions{}).Return(r, nil)
+
+		res, err := testExecute(e, "releases approve release1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"Approving release1... OK, 2 approvals"})
+	})
+}
+
+func TestReleasesApproveError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseApprove", "app1", "release1", structs.ReleaseApproveOptions{}).Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "releases approve release1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{"Approving release1... "})
+	})
+}
+
+func TestReleasesDiff(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{From: options.String("release2")}).Return(fxReleaseDiff(), nil)
+
+		res, err := testExecute(e, "releases diff release2 release1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"~ build: build2 => build1",
+			"+ env FOO",
+			"~ scale web count.min: 1 => 3",
+			"~ service web domain: \"\" => example.org",
+			"- timer cleanup",
+		})
+	})
+}
+
+func TestReleasesDiffCurrent(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{}).Return(&structs.ReleaseDiff{App: "app1", From: "release1", To: "release1"}, nil)
+
+		res, err := testExecute(e, "releases diff release1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"No changes"})
+	})
+}
+
+func TestReleasesDiffError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{From: options.String("release2")}).Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "releases diff release2 release1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
 func TestReleasesInfo(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("ReleaseGet", "app1", "release1").Return(fxRelease(), nil)
@@ -62,6 +130,28 @@ func TestReleasesInfo(t *testing.T) {
 	})
 }
 
+func TestReleasesInfoApprovals(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		r := fxRelease()
+		r.Approvals = structs.ReleaseApprovals{{User: "user1", Time: time.Now().UTC().Add(-2 * time.Hour)}}
+		i.On("ReleaseGet", "app1", "release1").Return(r, nil)
+
+		res, err := testExecute(e, "releases info release1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"Id           release1",
+			"Build        build1",
+			fmt.Sprintf("Created      %s", r.Created.Format(time.RFC3339)),
+			"Description  description1",
+			"Env          FOO=bar",
+			"             BAZ=quux",
+			"Approvals    user1 (2 hours ago)",
+		})
+	})
+}
+
 func TestReleasesInfoError(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("ReleaseGet", "app1", "release1").Return(nil, fmt.Errorf("err1"))
@@ -104,6 +194,7 @@ func TestReleasesManifestError(t *testing.T) {
 
 func TestReleasesPromote(t *testing.T) {
 	testClientWait(t, 100*time.Millisecond, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{}).Return(fxReleaseDiff(), nil)
 		i.On("AppGet", "app1").Return(fxApp(), nil).Once()
 		i.On("ReleasePromote", "app1", "release1", structs.ReleasePromoteOptions{
 			Force: options.Bool(false),
@@ -117,6 +208,11 @@ func TestReleasesPromote(t *testing.T) {
 		require.Equal(t, 0, res.Code)
 		res.RequireStderr(t, []string{""})
 		res.RequireStdout(t, []string{
+			"~ build: build2 => build1",
+			"+ env FOO",
+			"~ scale web count.min: 1 => 3",
+			"~ service web domain: \"\" => example.org",
+			"- timer cleanup",
 			"Promoting release1... ",
 			"TIME system/aws/component log1",
 			"TIME system/aws/component log2",
@@ -127,6 +223,7 @@ func TestReleasesPromote(t *testing.T) {
 
 func TestReleasesPromoteError(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{}).Return(&structs.ReleaseDiff{}, nil)
 		i.On("AppGet", "app1").Return(fxApp(), nil)
 		i.On("ReleasePromote", "app1", "release1", structs.ReleasePromoteOptions{
 			Force: options.Bool(false),
@@ -140,8 +237,41 @@ func TestReleasesPromoteError(t *testing.T) {
 	})
 }
 
+func TestReleasesPromoteCanary(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{}).Return(&structs.ReleaseDiff{}, nil)
+		i.On("ReleasePromote", "app1", "release1", structs.ReleasePromoteOptions{
+			Canary:   options.Int(10),
+			Interval: options.String("5m"),
+			Step:     options.Int(20),
+		}).Return(nil)
+
+		res, err := testExecute(e, "releases promote release1 -a app1 --canary 10 --step 20 --interval 5m", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"Starting canary of release1 at 10%... OK"})
+	})
+}
+
+func TestReleasesPromoteCanaryError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{}).Return(&structs.ReleaseDiff{}, nil)
+		i.On("ReleasePromote", "app1", "release1", structs.ReleasePromoteOptions{
+			Canary: options.Int(10),
+		}).Return(fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "releases promote release1 -a app1 --canary 10", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{"Starting canary of release1 at 10%... "})
+	})
+}
+
 func TestReleasesPromoteAlreadyUpdating(t *testing.T) {
 	testClientWait(t, 50*time.Millisecond, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{}).Return(&structs.ReleaseDiff{}, nil)
 		i.On("AppGet", "app1").Return(fxAppUpdating(), nil).Twice()
 		i.On("AppGet", "app1").Return(fxApp(), nil).Once()
 		i.On("ReleasePromote", "app1", "release1", structs.ReleasePromoteOptions{
@@ -166,8 +296,79 @@ func TestReleasesPromoteAlreadyUpdating(t *testing.T) {
 	})
 }
 
+func TestReleasesPromoteDryRun(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{}).Return(&structs.ReleaseDiff{}, nil)
+		i.On("ReleasePlan", "app1", "release1", structs.ReleasePromoteOptions{}).Return(&structs.ReleasePlan{
+			App:     "app1",
+			Release: "release1",
+			Operations: structs.ReleasePlanOperations{
+				{Action: "create", Kind: "Deployment", Name: "resource-db"},
+				{Action: "update", Kind: "PersistentVolumeClaim", Name: "resource-db", Changes: structs.ReleasePlanChanges{
+					{Field: "spec.accessModes[0]", From: "ReadWriteMany", To: "ReadWriteOnce"},
+					{Field: "metadata.labels.resource", To: "db"},
+				}},
+				{Action: "delete", Kind: "Deployment", Name: "worker"},
+			},
+			Warnings: []string{"this will recreate the persistent volume claim resource-db and lose its data"},
+		}, nil)
+
+		res, err := testExecute(e, "releases promote release1 -a app1 --dry-run", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"+ Deployment resource-db",
+			"~ PersistentVolumeClaim resource-db",
+			"    spec.accessModes[0]: ReadWriteMany => ReadWriteOnce",
+			"    metadata.labels.resource: \"\" => db",
+			"- Deployment worker",
+			"WARNING: this will recreate the persistent volume claim resource-db and lose its data",
+		})
+	})
+}
+
+func TestReleasesPromoteDryRunNoChanges(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{}).Return(&structs.ReleaseDiff{}, nil)
+		i.On("ReleasePlan", "app1", "release1", structs.ReleasePromoteOptions{}).Return(&structs.ReleasePlan{App: "app1", Release: "release1"}, nil)
+
+		res, err := testExecute(e, "releases promote release1 -a app1 --dry-run", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"No changes"})
+	})
+}
+
+func TestReleasesPromoteDryRunCanary(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{}).Return(&structs.ReleaseDiff{}, nil)
+
+		res, err := testExecute(e, "releases promote release1 -a app1 --dry-run --canary 10", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: --dry-run can not be combined with --canary"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestReleasesPromoteDryRunError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release1", structs.ReleaseDiffOptions{}).Return(&structs.ReleaseDiff{}, nil)
+		i.On("ReleasePlan", "app1", "release1", structs.ReleasePromoteOptions{}).Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "releases promote release1 -a app1 --dry-run", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
 func TestReleasesRollback(t *testing.T) {
 	testClientWait(t, 50*time.Millisecond, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release2", structs.ReleaseDiffOptions{}).Return(fxReleaseDiff(), nil)
 		i.On("ReleaseGet", "app1", "release2").Return(fxRelease2(), nil)
 		i.On("ReleaseCreate", "app1", structs.ReleaseCreateOptions{Build: options.String(fxRelease2().Build), Env: options.String(fxRelease2().Env)}).Return(fxRelease3(), nil)
 		i.On("ReleasePromote", "app1", "release3", structs.ReleasePromoteOptions{
@@ -182,6 +383,11 @@ func TestReleasesRollback(t *testing.T) {
 		// require.Equal(t, 0, res.Code)
 		res.RequireStderr(t, []string{""})
 		res.RequireStdout(t, []string{
+			"~ build: build2 => build1",
+			"+ env FOO",
+			"~ scale web count.min: 1 => 3",
+			"~ service web domain: \"\" => example.org",
+			"- timer cleanup",
 			"Rolling back to release2... OK, release3",
 			"Promoting release3... ",
 			"TIME system/aws/component log1",
@@ -193,6 +399,7 @@ func TestReleasesRollback(t *testing.T) {
 
 func TestReleasesRollbackErrorCreate(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release2", structs.ReleaseDiffOptions{}).Return(nil, fmt.Errorf("err2"))
 		i.On("ReleaseGet", "app1", "release2").Return(fxRelease2(), nil)
 		i.On("ReleaseCreate", "app1", structs.ReleaseCreateOptions{Build: options.String(fxRelease2().Build), Env: options.String(fxRelease2().Env)}).Return(nil, fmt.Errorf("err1"))
 
@@ -206,6 +413,7 @@ func TestReleasesRollbackErrorCreate(t *testing.T) {
 
 func TestReleasesRollbackErrorPromote(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ReleaseDiff", "app1", "release2", structs.ReleaseDiffOptions{}).Return(&structs.ReleaseDiff{}, nil)
 		i.On("ReleaseGet", "app1", "release2").Return(fxRelease2(), nil)
 		i.On("ReleaseCreate", "app1", structs.ReleaseCreateOptions{Build: options.String(fxRelease2().Build), Env: options.String(fxRelease2().Env)}).Return(fxRelease3(), nil)
 		i.On("ReleasePromote", "app1", "release3", structs.ReleasePromoteOptions{
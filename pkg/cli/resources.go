@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/convox/convox/pkg/common"
 	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/convox/convox/sdk"
@@ -21,6 +22,12 @@ func init() {
 		Validate: stdcli.Args(0),
 	})
 
+	register("resources backups", "list backups for a resource", ResourcesBackups, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagRack, flagApp},
+		Usage:    "<resource>",
+		Validate: stdcli.Args(1),
+	})
+
 	register("resources console", "start a console for a resource", ResourcesConsole, stdcli.CommandOptions{
 		Flags:    []stdcli.Flag{flagRack, flagApp},
 		Usage:    "<resource>",
@@ -63,6 +70,16 @@ func init() {
 		Validate: stdcli.Args(1),
 	})
 
+	register("resources restore", "restore a resource from a backup", ResourcesRestore, stdcli.CommandOptions{
+		Flags: []stdcli.Flag{
+			flagRack,
+			flagApp,
+			stdcli.StringFlag("backup", "b", "backup id"),
+		},
+		Usage:    "<resource>",
+		Validate: stdcli.Args(1),
+	})
+
 	register("resources url", "get url for a resource", ResourcesUrl, stdcli.CommandOptions{
 		Flags:    []stdcli.Flag{flagRack, flagApp},
 		Usage:    "<resource>",
@@ -176,6 +193,21 @@ func Resources(rack sdk.Interface, c *stdcli.Context) error {
 	return t.Print()
 }
 
+func ResourcesBackups(rack sdk.Interface, c *stdcli.Context) error {
+	bs, err := rack.ResourceBackupList(app(c), c.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	t := c.Table("ID", "CREATED")
+
+	for _, b := range bs {
+		t.AddRow(b.Id, common.Ago(b.Created))
+	}
+
+	return t.Print()
+}
+
 func ResourcesConsole(rack sdk.Interface, c *stdcli.Context) error {
 	opts := structs.ResourceConsoleOptions{}
 
@@ -342,6 +374,22 @@ func ResourcesProxy(rack sdk.Interface, c *stdcli.Context) error {
 	return nil
 }
 
+func ResourcesRestore(rack sdk.Interface, c *stdcli.Context) error {
+	backup := c.String("backup")
+
+	if backup == "" {
+		return fmt.Errorf("--backup flag is required")
+	}
+
+	c.Startf("Restoring <resource>%s</resource> from %s", c.Arg(0), backup)
+
+	if err := rack.ResourceRestore(app(c), c.Arg(0), backup); err != nil {
+		return err
+	}
+
+	return c.OK()
+}
+
 func ResourcesUrl(rack sdk.Interface, c *stdcli.Context) error {
 	s, err := rack.SystemGet()
 	if err != nil {
@@ -48,6 +48,37 @@ func TestResourcesError(t *testing.T) {
 	})
 }
 
+func TestResourcesBackups(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ResourceBackupList", "app1", "resource1").Return(structs.ResourceBackups{
+			{Id: "20240306T030000Z", Created: fxStarted, Resource: "resource1"},
+			{Id: "20240305T030000Z", Created: fxStarted.Add(-24 * time.Hour), Resource: "resource1"},
+		}, nil)
+
+		res, err := testExecute(e, "resources backups resource1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"ID                CREATED",
+			"20240306T030000Z  2 days ago",
+			"20240305T030000Z  3 days ago",
+		})
+	})
+}
+
+func TestResourcesBackupsError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ResourceBackupList", "app1", "resource1").Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "resources backups resource1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
 func TestResourcesInfo(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("SystemGet").Return(fxSystem(), nil)
@@ -133,6 +164,40 @@ func TestResourcesProxy(t *testing.T) {
 	})
 }
 
+func TestResourcesRestore(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ResourceRestore", "app1", "resource1", "20240306T030000Z").Return(nil)
+
+		res, err := testExecute(e, "resources restore resource1 -a app1 --backup 20240306T030000Z", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"Restoring resource1 from 20240306T030000Z... OK"})
+	})
+}
+
+func TestResourcesRestoreError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("ResourceRestore", "app1", "resource1", "20240306T030000Z").Return(fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "resources restore resource1 -a app1 --backup 20240306T030000Z", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{"Restoring resource1 from 20240306T030000Z... "})
+	})
+}
+
+func TestResourcesRestoreNoBackup(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		res, err := testExecute(e, "resources restore resource1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: --backup flag is required"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
 func TestResourcesUrl(t *testing.T) {
 	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
 		i.On("SystemGet").Return(fxSystem(), nil)
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/sdk"
+	"github.com/convox/stdcli"
+)
+
+func init() {
+	register("timers", "list timers for an app", Timers, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagApp, flagRack},
+		Validate: stdcli.Args(0),
+	})
+
+	register("timers run", "run a timer now", TimersRun, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagApp, flagRack},
+		Usage:    "<timer>",
+		Validate: stdcli.Args(1),
+	})
+
+	register("timers runs", "list recent runs of a timer", TimersRuns, stdcli.CommandOptions{
+		Flags:    []stdcli.Flag{flagApp, flagRack},
+		Usage:    "<timer>",
+		Validate: stdcli.Args(1),
+	})
+}
+
+func Timers(rack sdk.Interface, c *stdcli.Context) error {
+	ts, err := rack.TimerList(app(c))
+	if err != nil {
+		return err
+	}
+
+	t := c.Table("TIMER", "SERVICE", "SCHEDULE", "LAST RUN", "COMMAND")
+
+	for _, tm := range ts {
+		schedule := tm.Schedule
+
+		if tm.Suspended {
+			schedule += " (suspended)"
+		}
+
+		t.AddRow(tm.Name, tm.Service, schedule, common.Ago(tm.LastRun), tm.Command)
+	}
+
+	return t.Print()
+}
+
+func TimersRun(rack sdk.Interface, c *stdcli.Context) error {
+	name := c.Arg(0)
+
+	c.Startf("Running timer %s", name)
+
+	r, err := rack.TimerRun(app(c), name)
+	if err != nil {
+		return err
+	}
+
+	return c.OK(r.Id)
+}
+
+func TimersRuns(rack sdk.Interface, c *stdcli.Context) error {
+	rs, err := rack.TimerRunList(app(c), c.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	t := c.Table("ID", "STATUS", "TRIGGER", "RELEASE", "STARTED", "ELAPSED", "ERROR")
+
+	for _, r := range rs {
+		trigger := "schedule"
+
+		if r.Manual {
+			trigger = "manual"
+		}
+
+		t.AddRow(r.Id, r.Status, trigger, r.Release, common.Ago(r.Started), common.Duration(r.Started, r.Ended), r.Error)
+	}
+
+	return t.Print()
+}
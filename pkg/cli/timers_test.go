@@ -0,0 +1,96 @@
+package cli_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/convox/convox/pkg/cli"
+	mocksdk "github.com/convox/convox/pkg/mock/sdk"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimers(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		suspended := fxTimer()
+		suspended.Name = "timer2"
+		suspended.LastRun = suspended.LastRun.AddDate(0, 0, -1)
+		suspended.Suspended = true
+
+		i.On("TimerList", "app1").Return(structs.Timers{*fxTimer(), *suspended}, nil)
+
+		res, err := testExecute(e, "timers -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"TIMER   SERVICE  SCHEDULE               LAST RUN    COMMAND",
+			"timer1  web      0 * * * *              2 days ago  bin/cleanup",
+			"timer2  web      0 * * * * (suspended)  3 days ago  bin/cleanup",
+		})
+	})
+}
+
+func TestTimersError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("TimerList", "app1").Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "timers -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
+
+func TestTimersRun(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("TimerRun", "app1", "timer1").Return(fxTimerRunFailed(), nil)
+
+		res, err := testExecute(e, "timers run timer1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{"Running timer timer1... OK, timer-timer1-1709719200"})
+	})
+}
+
+func TestTimersRunError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("TimerRun", "app1", "timer1").Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "timers run timer1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{"Running timer timer1... "})
+	})
+}
+
+func TestTimersRuns(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("TimerRunList", "app1", "timer1").Return(structs.TimerRuns{*fxTimerRunFailed(), *fxTimerRun()}, nil)
+
+		res, err := testExecute(e, "timers runs timer1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Code)
+		res.RequireStderr(t, []string{""})
+		res.RequireStdout(t, []string{
+			"ID                       STATUS     TRIGGER   RELEASE   STARTED     ELAPSED  ERROR",
+			"timer-timer1-1709719200  failed     manual    release1  2 days ago  30s      BackoffLimitExceeded",
+			"timer-timer1-28500000    succeeded  schedule  release1  2 days ago  2m0s     ",
+		})
+	})
+}
+
+func TestTimersRunsError(t *testing.T) {
+	testClient(t, func(e *cli.Engine, i *mocksdk.Interface) {
+		i.On("TimerRunList", "app1", "timer1").Return(nil, fmt.Errorf("err1"))
+
+		res, err := testExecute(e, "timers runs timer1 -a app1", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Code)
+		res.RequireStderr(t, []string{"ERROR: err1"})
+		res.RequireStdout(t, []string{""})
+	})
+}
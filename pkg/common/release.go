@@ -0,0 +1,398 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/convox/convox/pkg/manifest"
+	"github.com/convox/convox/pkg/structs"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var regexpEnvRef = regexp.MustCompile(`\$\{([^}]*?)\}`)
+
+// ReleaseDiff compares the build, images, env and manifest of two releases of
+// an app. from is nil when the app has no release yet and images are skipped
+// when repo is empty. Env changes only carry the key, never the values, and
+// manifest fields that interpolate the env are masked on the side that does.
+func ReleaseDiff(from, to *structs.Release, repo string) (*structs.ReleaseDiff, error) {
+	if from == nil {
+		from = &structs.Release{App: to.App}
+	}
+
+	d := &structs.ReleaseDiff{
+		App:     to.App,
+		From:    from.Id,
+		To:      to.Id,
+		Changes: structs.ReleaseDiffChanges{},
+	}
+
+	if from.Build != to.Build {
+		d.Changes = append(d.Changes, releaseDiffChange("build", "", from.Build, to.Build))
+	}
+
+	fe, err := releaseEnv(from)
+	if err != nil {
+		return nil, err
+	}
+
+	te, err := releaseEnv(to)
+	if err != nil {
+		return nil, err
+	}
+
+	fm, err := releaseManifest(from, fe)
+	if err != nil {
+		return nil, err
+	}
+
+	tm, err := releaseManifest(to, te)
+	if err != nil {
+		return nil, err
+	}
+
+	fr, err := releaseManifestRefs(from)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := releaseManifestRefs(to)
+	if err != nil {
+		return nil, err
+	}
+
+	if repo != "" {
+		d.Changes = append(d.Changes, releaseDiffImages(repo, from, to, fm, tm)...)
+	}
+
+	for _, k := range diffKeys(fe, te) {
+		fv, fok := fe[k]
+		tv, tok := te[k]
+
+		switch {
+		case !fok:
+			d.Changes = append(d.Changes, structs.ReleaseDiffChange{Action: structs.ReleaseDiffAdded, Kind: "env", Name: k})
+		case !tok:
+			d.Changes = append(d.Changes, structs.ReleaseDiffChange{Action: structs.ReleaseDiffRemoved, Kind: "env", Name: k})
+		case fv != tv:
+			d.Changes = append(d.Changes, structs.ReleaseDiffChange{Action: structs.ReleaseDiffChanged, Kind: "env", Name: k})
+		}
+	}
+
+	d.Changes = append(d.Changes, releaseDiffNamed("service", fm.Services, tm.Services, fr, tr)...)
+	d.Changes = append(d.Changes, releaseDiffNamed("resource", fm.Resources, tm.Resources, fr, tr)...)
+	d.Changes = append(d.Changes, releaseDiffNamed("timer", fm.Timers, tm.Timers, fr, tr)...)
+
+	return d, nil
+}
+
+func releaseEnv(r *structs.Release) (structs.Environment, error) {
+	env := structs.Environment{}
+
+	if err := env.Load([]byte(strings.TrimSpace(r.Env))); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func releaseManifest(r *structs.Release, env structs.Environment) (*manifest.Manifest, error) {
+	if strings.TrimSpace(r.Manifest) == "" {
+		return &manifest.Manifest{}, nil
+	}
+
+	return manifest.Load([]byte(r.Manifest), env)
+}
+
+// releaseManifestRefs returns the fields of the raw manifest of r that
+// reference the env as kind/name:field, fields holding a list or map are
+// listed once if any value under them does
+func releaseManifestRefs(r *structs.Release) (map[string]bool, error) {
+	refs := map[string]bool{}
+
+	if strings.TrimSpace(r.Manifest) == "" {
+		return refs, nil
+	}
+
+	var raw map[string]interface{}
+
+	if err := yaml.Unmarshal([]byte(r.Manifest), &raw); err != nil {
+		return nil, err
+	}
+
+	for section, kind := range map[string]string{"resources": "resource", "services": "service", "timers": "timer"} {
+		entries, _ := raw[section].(map[interface{}]interface{})
+
+		for name, v := range entries {
+			yamlRefs(fmt.Sprintf("%s/%v", kind, name), "", v, refs)
+		}
+	}
+
+	return refs, nil
+}
+
+func yamlRefs(entry, field string, v interface{}, refs map[string]bool) bool {
+	found := false
+
+	switch t := v.(type) {
+	case string:
+		found = regexpEnvRef.MatchString(t)
+	case []interface{}:
+		for _, iv := range t {
+			if yamlRefs(entry, "", iv, map[string]bool{}) {
+				found = true
+			}
+		}
+	case map[interface{}]interface{}:
+		for k, iv := range t {
+			f := fmt.Sprint(k)
+
+			if field != "" {
+				f = fmt.Sprintf("%s.%s", field, k)
+			}
+
+			if yamlRefs(entry, f, iv, refs) {
+				found = true
+			}
+		}
+
+		return found
+	}
+
+	if found && field != "" {
+		refs[fmt.Sprintf("%s:%s", entry, field)] = true
+	}
+
+	return found
+}
+
+// releaseRefMasked is true when field of an entry, or a field it is nested in
+// or that is nested in it, references the env
+func releaseRefMasked(refs map[string]bool, kind, name, field string) bool {
+	entry := fmt.Sprintf("%s/%s:", kind, name)
+
+	for ref := range refs {
+		if !strings.HasPrefix(ref, entry) {
+			continue
+		}
+
+		rf := strings.TrimPrefix(ref, entry)
+
+		if rf == field || strings.HasPrefix(field, rf+".") || strings.HasPrefix(rf, field+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func releaseMask(v string) string {
+	if v == "" {
+		return ""
+	}
+
+	return "****"
+}
+
+func releaseDiffChange(kind, name, from, to string) structs.ReleaseDiffChange {
+	c := structs.ReleaseDiffChange{Action: structs.ReleaseDiffChanged, Kind: kind, Name: name, From: from, To: to}
+
+	switch {
+	case from == "":
+		c.Action = structs.ReleaseDiffAdded
+	case to == "":
+		c.Action = structs.ReleaseDiffRemoved
+	}
+
+	return c
+}
+
+func releaseDiffImages(repo string, from, to *structs.Release, fm, tm *manifest.Manifest) structs.ReleaseDiffChanges {
+	fi := map[string]string{}
+	ti := map[string]string{}
+
+	if from.Build != "" {
+		for _, s := range fm.Services {
+			fi[s.Name] = fmt.Sprintf("%s:%s.%s", repo, s.Name, from.Build)
+		}
+	}
+
+	if to.Build != "" {
+		for _, s := range tm.Services {
+			ti[s.Name] = fmt.Sprintf("%s:%s.%s", repo, s.Name, to.Build)
+		}
+	}
+
+	cs := structs.ReleaseDiffChanges{}
+
+	for _, k := range diffKeys(fi, ti) {
+		if fi[k] != ti[k] {
+			cs = append(cs, releaseDiffChange("image", k, fi[k], ti[k]))
+		}
+	}
+
+	return cs
+}
+
+// releaseDiffNamed compares two lists of manifest entries by name and then
+// field by field, scale changes of a service are reported on their own
+func releaseDiffNamed(kind string, from, to interface{}, frefs, trefs map[string]bool) structs.ReleaseDiffChanges {
+	fv := namedValues(from)
+	tv := namedValues(to)
+
+	names := map[string]bool{}
+
+	for n := range fv {
+		names[n] = true
+	}
+
+	for n := range tv {
+		names[n] = true
+	}
+
+	sorted := []string{}
+
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+
+	sort.Strings(sorted)
+
+	cs := structs.ReleaseDiffChanges{}
+
+	for _, n := range sorted {
+		a, aok := fv[n]
+		b, bok := tv[n]
+
+		switch {
+		case !aok:
+			cs = append(cs, structs.ReleaseDiffChange{Action: structs.ReleaseDiffAdded, Kind: kind, Name: n})
+		case !bok:
+			cs = append(cs, structs.ReleaseDiffChange{Action: structs.ReleaseDiffRemoved, Kind: kind, Name: n})
+		default:
+			diffFields("", a, b, func(field, from, to string) {
+				if releaseRefMasked(frefs, kind, n, field) {
+					from = releaseMask(from)
+				}
+
+				if releaseRefMasked(trefs, kind, n, field) {
+					to = releaseMask(to)
+				}
+
+				c := structs.ReleaseDiffChange{Action: structs.ReleaseDiffChanged, Kind: kind, Name: n, Field: field, From: from, To: to}
+
+				if kind == "service" && strings.HasPrefix(field, "scale.") {
+					c.Kind = "scale"
+					c.Field = strings.TrimPrefix(field, "scale.")
+				}
+
+				cs = append(cs, c)
+			})
+		}
+	}
+
+	return cs
+}
+
+func namedValues(list interface{}) map[string]reflect.Value {
+	vs := map[string]reflect.Value{}
+
+	lv := reflect.ValueOf(list)
+
+	for i := 0; i < lv.Len(); i++ {
+		v := lv.Index(i)
+		vs[v.FieldByName("Name").String()] = v
+	}
+
+	return vs
+}
+
+// diffFields walks two structs of the same type and calls fn with the yaml
+// path of every leaf that differs, declared environment is left to the env
+// diff so defaults do not leak
+func diffFields(prefix string, a, b reflect.Value, fn func(field, from, to string)) {
+	t := a.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+
+		switch name {
+		case "-", "environment":
+			continue
+		case "":
+			name = strings.ToLower(f.Name)
+		}
+
+		if prefix != "" {
+			name = fmt.Sprintf("%s.%s", prefix, name)
+		}
+
+		av := a.Field(i)
+		bv := b.Field(i)
+
+		if f.Type.Kind() == reflect.Struct {
+			diffFields(name, av, bv, fn)
+			continue
+		}
+
+		if !reflect.DeepEqual(av.Interface(), bv.Interface()) {
+			fn(name, diffValue(av), diffValue(bv))
+		}
+	}
+}
+
+func diffValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool, reflect.Float32, reflect.Float64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprint(v.Interface())
+	case reflect.Map, reflect.Slice:
+		if v.Len() == 0 {
+			return ""
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ""
+		}
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+
+	return string(data)
+}
+
+func diffKeys(a, b map[string]string) []string {
+	keys := map[string]bool{}
+
+	for k := range a {
+		keys[k] = true
+	}
+
+	for k := range b {
+		keys[k] = true
+	}
+
+	sorted := []string{}
+
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+
+	sort.Strings(sorted)
+
+	return sorted
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/logquery"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/elastic/go-elasticsearch/v6"
 )
@@ -51,6 +52,12 @@ func New(url string) (*Client, error) {
 func (c *Client) Stream(ctx context.Context, w io.WriteCloser, index string, opts structs.LogsOptions) {
 	defer w.Close()
 
+	q, err := logquery.Parse(common.DefaultString(opts.Query, ""))
+	if err != nil {
+		fmt.Fprintf(w, "error: %v\n", err)
+		return
+	}
+
 	follow := common.DefaultBool(opts.Follow, true)
 	now := time.Now().UTC()
 	since := time.Time{}
@@ -59,6 +66,12 @@ func (c *Client) Stream(ctx context.Context, w io.WriteCloser, index string, opt
 		since = time.Now().UTC().Add(*opts.Since * -1)
 	}
 
+	// a bounded window never follows
+	if opts.Until != nil {
+		now = now.Add(*opts.Until * -1)
+		follow = false
+	}
+
 	for {
 		// check for closed writer
 		if _, err := w.Write([]byte{}); err != nil {
@@ -78,11 +91,7 @@ func (c *Client) Stream(ctx context.Context, w io.WriteCloser, index string, opt
 			}
 
 			body := map[string]interface{}{
-				"query": map[string]interface{}{
-					"range": map[string]interface{}{
-						"@timestamp": timestamp,
-					},
-				},
+				"query": searchQuery(q, timestamp),
 			}
 
 			data, err := json.Marshal(body)
@@ -150,14 +159,38 @@ func (c *Client) Write(index string, ts time.Time, message string, tags map[stri
 		body[k] = v
 	}
 
+	if fields := logquery.JSON(message); fields != nil {
+		body["fields"] = fields
+	}
+
+	if err := c.index(index, body); err != nil {
+		// fields that conflict with the index mapping reject the whole
+		// document so fall back to indexing the line as plain text
+		if _, ok := body["fields"]; ok {
+			delete(body, "fields")
+			return c.index(index, body)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) index(index string, body map[string]interface{}) error {
 	data, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
 
-	if _, err := c.client.Index(index, bytes.NewReader(data)); err != nil {
+	res, err := c.client.Index(index, bytes.NewReader(data))
+	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("could not index log: %s", res.Status())
+	}
 
 	return nil
 }
@@ -0,0 +1,119 @@
+package elastic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/convox/convox/pkg/logquery"
+)
+
+var rangeOps = map[string]string{
+	logquery.OpGreater:      "gt",
+	logquery.OpGreaterEqual: "gte",
+	logquery.OpLess:         "lt",
+	logquery.OpLessEqual:    "lte",
+}
+
+// searchQuery translates a log query into an elasticsearch bool query so that
+// matching happens in the cluster rather than after the fact
+func searchQuery(q *logquery.Query, timestamp map[string]interface{}) map[string]interface{} {
+	filter := []interface{}{
+		map[string]interface{}{
+			"range": map[string]interface{}{
+				"@timestamp": timestamp,
+			},
+		},
+	}
+
+	mustNot := []interface{}{}
+
+	if q != nil {
+		for _, t := range q.Terms {
+			c := termQuery(t)
+
+			if t.Op == logquery.OpNotEqual {
+				mustNot = append(mustNot, c)
+			} else {
+				filter = append(filter, c)
+			}
+		}
+	}
+
+	b := map[string]interface{}{
+		"filter": filter,
+	}
+
+	if len(mustNot) > 0 {
+		b["must_not"] = mustNot
+	}
+
+	return map[string]interface{}{
+		"bool": b,
+	}
+}
+
+func termQuery(t logquery.Term) map[string]interface{} {
+	switch t.Field {
+	case "":
+		return map[string]interface{}{
+			"match_phrase": map[string]interface{}{"log": t.Value},
+		}
+	case "service":
+		return streamQuery(fmt.Sprintf("service%s%s%s.*", streamSeparator, regexpEscape(t.Value), streamSeparator))
+	case "stream":
+		return streamQuery(strings.Join(regexpEscapeAll(strings.Split(t.Value, "/")), streamSeparator))
+	}
+
+	field := fmt.Sprintf("fields.%s", t.Field)
+
+	if op, ok := rangeOps[t.Op]; ok {
+		var v interface{} = t.Value
+
+		if f, ok := t.Numeric(); ok {
+			v = f
+		}
+
+		return map[string]interface{}{
+			"range": map[string]interface{}{
+				field: map[string]interface{}{op: v},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"match_phrase": map[string]interface{}{field: t.Value},
+	}
+}
+
+// stream names are stored with either dots or slashes as separators depending
+// on how the log was shipped
+const streamSeparator = "[./]"
+
+func streamQuery(pattern string) map[string]interface{} {
+	return map[string]interface{}{
+		"regexp": map[string]interface{}{"stream.keyword": pattern},
+	}
+}
+
+func regexpEscape(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		if strings.ContainsRune(`.?+*|{}[]()"\#@&<>~`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func regexpEscapeAll(ss []string) []string {
+	es := make([]string, len(ss))
+
+	for i, s := range ss {
+		es[i] = regexpEscape(s)
+	}
+
+	return es
+}
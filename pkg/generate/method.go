@@ -10,10 +10,12 @@ import (
 	"strings"
 
 	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/sdk"
 )
 
 var (
 	providerType   = reflect.TypeOf((*structs.Provider)(nil)).Elem()
+	sdkType        = reflect.TypeOf((*sdk.Interface)(nil)).Elem()
 	readerType     = reflect.TypeOf((*io.Reader)(nil)).Elem()
 	readWriterType = reflect.TypeOf((*io.ReadWriter)(nil)).Elem()
 	writerType     = reflect.TypeOf((*io.Writer)(nil)).Elem()
@@ -62,7 +64,7 @@ func Methods() ([]Method, error) {
 		route := routes[name]
 		routeParts := strings.SplitN(route, " ", 2)
 
-		args, returns, err := signature(data, name)
+		args, returns, err := signature(providerType, data, name)
 		if err != nil {
 			return nil, err
 		}
@@ -202,8 +204,8 @@ func (a *Arg) Stream() bool {
 	return a.Type.Implements(readerType) || a.Type.Implements(writerType)
 }
 
-func signature(data []byte, name string) ([]Arg, []reflect.Type, error) {
-	m, ok := providerType.MethodByName(name)
+func signature(t reflect.Type, data []byte, name string) ([]Arg, []reflect.Type, error) {
+	m, ok := t.MethodByName(name)
 	if !ok {
 		return nil, nil, fmt.Errorf("no provider method: %s", name)
 	}
@@ -0,0 +1,546 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+var reApiRoute = regexp.MustCompile(`r\.Route\("([A-Z]*)", "([^"]*)", s\.(\w+)\)`)
+
+// apiRoutes are served by the api itself instead of a provider method, their
+// signature is read from the sdk
+var apiRoutes = map[string]string{
+	"SystemJwtToken": "POST /system/jwt/token",
+}
+
+// jsonBodies are the methods whose options are sent as a json body
+var jsonBodies = map[string]bool{
+	"LetsEncryptConfigApply": true,
+}
+
+type openapiDoc struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       map[string]string                `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components map[string]interface{}           `json:"components"`
+	Security   []map[string][]string            `json:"security"`
+}
+
+type operation struct {
+	OperationId string                 `json:"operationId"`
+	Tags        []string               `json:"tags,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  []parameter            `json:"parameters,omitempty"`
+	RequestBody map[string]interface{} `json:"requestBody,omitempty"`
+	Responses   map[string]interface{} `json:"responses"`
+	Websocket   bool                   `json:"x-websocket,omitempty"`
+}
+
+type parameter struct {
+	Name     string                 `json:"name"`
+	In       string                 `json:"in"`
+	Required bool                   `json:"required,omitempty"`
+	Explode  *bool                  `json:"explode,omitempty"`
+	Schema   map[string]interface{} `json:"schema"`
+}
+
+// OpenAPI describes every provider method that has a route as an OpenAPI 3
+// document, following the same conventions the controllers and sdk are
+// generated with. It fails when the api serves a route the document does not
+// describe.
+func OpenAPI() ([]byte, error) {
+	ms, err := Methods()
+	if err != nil {
+		return nil, err
+	}
+
+	ams, err := apiMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	ms = append(ms, ams...)
+
+	schemas := map[string]interface{}{}
+
+	doc := openapiDoc{
+		OpenAPI: "3.0.3",
+		Info: map[string]string{
+			"title":   "Convox Rack API",
+			"version": "dev",
+		},
+		Paths: map[string]map[string]*operation{},
+		Components: map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"basic": map[string]string{"type": "http", "scheme": "basic"},
+			},
+		},
+		Security: []map[string][]string{{"basic": {}}},
+	}
+
+	for _, m := range ms {
+		if m.Route.Method == "" || m.Any() {
+			continue
+		}
+
+		op, err := m.operation(schemas)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", m.Name, err)
+		}
+
+		path := rePathVars.ReplaceAllString(m.Route.Path, "{$1}")
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*operation{}
+		}
+
+		method := strings.ToLower(m.Route.Method)
+
+		if m.Socket() {
+			method = "get"
+		}
+
+		if _, ok := doc.Paths[path][method]; ok {
+			return nil, fmt.Errorf("%s: duplicate operation for %s %s", m.Name, method, path)
+		}
+
+		doc.Paths[path][method] = op
+	}
+
+	if err := openapiRoutesCovered(doc); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func apiMethods() ([]Method, error) {
+	data, err := ioutil.ReadFile("sdk/interface.go")
+	if err != nil {
+		return nil, err
+	}
+
+	ms := []Method{}
+
+	for name, route := range apiRoutes {
+		parts := strings.SplitN(route, " ", 2)
+
+		args, returns, err := signature(sdkType, data, name)
+		if err != nil {
+			return nil, err
+		}
+
+		ms = append(ms, Method{Name: name, Route: Route{Method: parts[0], Path: parts[1]}, Args: args, Returns: returns})
+	}
+
+	return ms, nil
+}
+
+// openapiRoutesCovered checks that every route of the api has an operation
+func openapiRoutesCovered(doc openapiDoc) error {
+	data, err := ioutil.ReadFile("pkg/api/routes.go")
+	if err != nil {
+		return err
+	}
+
+	for _, m := range reApiRoute.FindAllStringSubmatch(string(data), -1) {
+		method, path, name := strings.ToLower(m[1]), rePathVars.ReplaceAllString(m[2], "{$1}"), m[3]
+
+		switch method {
+		case "", "any":
+			continue
+		case "socket":
+			method = "get"
+		}
+
+		if op, ok := doc.Paths[path][method]; !ok || op.OperationId != name {
+			return fmt.Errorf("%s: no operation for route %s %s, add it to pkg/structs/routes.go", name, m[1], m[2])
+		}
+	}
+
+	return nil
+}
+
+func (m *Method) operation(schemas map[string]interface{}) (*operation, error) {
+	op := &operation{
+		OperationId: m.Name,
+		Parameters:  []parameter{},
+		Tags:        []string{strings.Split(strings.TrimPrefix(m.Route.Path, "/"), "/")[0]},
+	}
+
+	if m.Socket() {
+		op.Description = "Upgrades to a websocket that carries the request and response streams"
+		op.Websocket = true
+	}
+
+	form := map[string]interface{}{}
+	body := false
+
+	var jsonBody map[string]interface{}
+
+	for _, a := range m.Args {
+		switch {
+		case a.Path(*m):
+			op.Parameters = append(op.Parameters, parameter{Name: a.Name, In: "path", Required: true, Schema: valueSchema(a.Type)})
+		case a.Option() && jsonBodies[m.Name]:
+			s, err := typeSchema(a.Type, schemas)
+			if err != nil {
+				return nil, err
+			}
+			jsonBody = s
+		case a.Option():
+			m.optionParameters(op, form, a.Type)
+		case a.Stream():
+			body = !m.Socket()
+		default:
+			if in := m.argLocation(); in != "" {
+				op.Parameters = append(op.Parameters, m.argParameter(a.Name, in, a.Type, ""))
+			} else {
+				form[a.Name] = valueSchema(a.Type)
+			}
+		}
+	}
+
+	switch {
+	case jsonBody != nil:
+		op.RequestBody = map[string]interface{}{
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": jsonBody},
+			},
+		}
+	case body:
+		op.RequestBody = map[string]interface{}{
+			"content": map[string]interface{}{
+				"application/octet-stream": map[string]interface{}{
+					"schema": map[string]string{"type": "string", "format": "binary"},
+				},
+			},
+		}
+	case len(form) > 0:
+		op.RequestBody = map[string]interface{}{
+			"content": map[string]interface{}{
+				"application/x-www-form-urlencoded": map[string]interface{}{
+					"schema": map[string]interface{}{"type": "object", "properties": form},
+				},
+			},
+		}
+	}
+
+	res, err := m.response(schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	op.Responses = map[string]interface{}{
+		"200": res,
+		"default": map[string]interface{}{
+			"description": "error",
+			"content": map[string]interface{}{
+				"text/plain": map[string]interface{}{"schema": map[string]string{"type": "string"}},
+			},
+		},
+	}
+
+	return op, nil
+}
+
+// argLocation is where a plain argument travels, an empty location means
+// the form body
+func (m *Method) argLocation() string {
+	switch m.Route.Method {
+	case "SOCKET":
+		return "header"
+	case "DELETE", "GET", "HEAD", "OPTIONS":
+		return "query"
+	default:
+		return ""
+	}
+}
+
+func (m *Method) argParameter(name, in string, t reflect.Type, def string) parameter {
+	p := parameter{Name: name, In: in, Schema: defaultSchema(t, def)}
+
+	if p.Schema["type"] == "array" && in == "query" {
+		explode := false
+		p.Explode = &explode
+	}
+
+	return p
+}
+
+// optionParameters maps the header, param and query tags of an options
+// struct the way stdapi.UnmarshalOptions reads them
+func (m *Method) optionParameters(op *operation, form map[string]interface{}, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		def := f.Tag.Get("default")
+
+		if n := f.Tag.Get("header"); n != "" {
+			op.Parameters = append(op.Parameters, m.argParameter(n, "header", f.Type, def))
+			continue
+		}
+
+		if n := f.Tag.Get("param"); n != "" {
+			if in := m.argLocation(); in == "" {
+				form[n] = defaultSchema(f.Type, def)
+			} else {
+				op.Parameters = append(op.Parameters, m.argParameter(n, "query", f.Type, def))
+			}
+			continue
+		}
+
+		if n := f.Tag.Get("query"); n != "" {
+			op.Parameters = append(op.Parameters, m.argParameter(n, "query", f.Type, def))
+			continue
+		}
+	}
+}
+
+func (m *Method) response(schemas map[string]interface{}) (map[string]interface{}, error) {
+	content := func(typ string, schema interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"description": "success",
+			"content":     map[string]interface{}{typ: map[string]interface{}{"schema": schema}},
+		}
+	}
+
+	binary := map[string]string{"type": "string", "format": "binary"}
+
+	rt, err := m.ReturnType()
+	if err != nil {
+		return nil, err
+	}
+
+	if rt == nil {
+		if m.Writer() != "" {
+			return content("application/octet-stream", binary), nil
+		}
+
+		return content("text/plain", map[string]interface{}{"type": "string", "example": "ok"}), nil
+	}
+
+	switch rt.Kind() {
+	case reflect.Bool, reflect.Ptr, reflect.Slice:
+		s, err := typeSchema(rt, schemas)
+		if err != nil {
+			return nil, err
+		}
+		return content("application/json", s), nil
+	case reflect.Int:
+		if m.Socket() {
+			return map[string]interface{}{"description": "the exit code is sent as the last message before the websocket closes"}, nil
+		}
+		return content("text/plain", map[string]string{"type": "integer"}), nil
+	case reflect.Interface:
+		return content("application/octet-stream", binary), nil
+	case reflect.String:
+		return content("text/plain", map[string]string{"type": "string"}), nil
+	default:
+		return nil, fmt.Errorf("unknown return type: %s", rt.Kind())
+	}
+}
+
+// valueSchema describes a value sent as a string in a path, header, query or
+// form field
+func valueSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		return map[string]interface{}{"type": "string", "format": "duration", "example": "1h"}
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": valueSchema(t.Elem())}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": valueSchema(t.Elem())}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// defaultSchema is the valueSchema of t with the default tag def converted
+// to the type of the schema
+func defaultSchema(t reflect.Type, def string) map[string]interface{} {
+	s := valueSchema(t)
+
+	if def == "" {
+		return s
+	}
+
+	s["default"] = def
+
+	switch s["type"] {
+	case "boolean":
+		if v, err := strconv.ParseBool(def); err == nil {
+			s["default"] = v
+		}
+	case "integer":
+		if v, err := strconv.Atoi(def); err == nil {
+			s["default"] = v
+		}
+	}
+
+	return s
+}
+
+// typeSchema describes t as it is rendered to JSON, named structs and slices
+// are added to schemas once and referenced from everywhere else
+func typeSchema(t reflect.Type, schemas map[string]interface{}) (map[string]interface{}, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		return map[string]interface{}{"type": "integer", "description": "nanoseconds"}, nil
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Map:
+		s, err := typeSchema(t.Elem(), schemas)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": s}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}, nil
+		}
+		return namedSchema(t, schemas, func() (map[string]interface{}, error) {
+			s, err := typeSchema(t.Elem(), schemas)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"type": "array", "items": s}, nil
+		})
+	case reflect.Struct:
+		return namedSchema(t, schemas, func() (map[string]interface{}, error) {
+			return structSchema(t, schemas)
+		})
+	default:
+		return nil, fmt.Errorf("unknown schema type: %s", t)
+	}
+}
+
+func namedSchema(t reflect.Type, schemas map[string]interface{}, build func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	if t.Name() == "" {
+		return build()
+	}
+
+	name := t.Name()
+	ref := map[string]interface{}{"$ref": fmt.Sprintf("#/components/schemas/%s", name)}
+
+	if s, ok := schemas[name]; ok {
+		if st, ok := s.(reflect.Type); ok && st != t {
+			return nil, fmt.Errorf("schema name conflict: %s and %s", st, t)
+		}
+		return ref, nil
+	}
+
+	// hold the name while building so that recursive types end in a reference
+	schemas[name] = t
+
+	s, err := build()
+	if err != nil {
+		return nil, err
+	}
+
+	schemas[name] = s
+
+	return ref, nil
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) (map[string]interface{}, error) {
+	props := map[string]interface{}{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s, err := structSchema(ft, schemas)
+				if err != nil {
+					return nil, err
+				}
+				for k, v := range s["properties"].(map[string]interface{}) {
+					props[k] = v
+				}
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		s, err := typeSchema(f.Type, schemas)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %s", t.Name(), f.Name, err)
+		}
+
+		props[name] = s
+	}
+
+	s := map[string]interface{}{"type": "object"}
+
+	if len(props) > 0 {
+		s["properties"] = props
+	}
+
+	return s, nil
+}
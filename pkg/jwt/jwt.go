@@ -1,6 +1,9 @@
 package jwt
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -11,6 +14,8 @@ import (
 type TokenData struct {
 	User      string
 	Role      string
+	Policy    string
+	Apps      []string
 	ExpiresAt time.Time
 }
 
@@ -54,6 +59,37 @@ func (j *JwtManager) WriteToken(duration time.Duration) (string, error) {
 	return tokenString, nil
 }
 
+// PolicyToken mints a token for user whose access is governed by the named
+// policy, optionally narrowed further to the given app patterns
+func (j *JwtManager) PolicyToken(user, policy string, apps []string, duration time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user":      user,
+		"role":      "",
+		"policy":    policy,
+		"apps":      apps,
+		"expiresAt": time.Now().UTC().Add(duration).Unix(),
+	})
+
+	tokenString, err := token.SignedString(j.signKey)
+	if err != nil {
+		return "", err
+	}
+	return tokenString, nil
+}
+
+// BuildToken is presented by the build process of a build to store the
+// objects of its build and attest it, see VerifyBuildToken
+func (j *JwtManager) BuildToken(app, id string) string {
+	mac := hmac.New(sha256.New, j.signKey)
+	mac.Write([]byte(fmt.Sprintf("build:%s/%s", app, id)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (j *JwtManager) VerifyBuildToken(app, id, token string) bool {
+	return hmac.Equal([]byte(j.BuildToken(app, id)), []byte(token))
+}
+
 func (j *JwtManager) Verify(token string) (*TokenData, error) {
 	d := &TokenData{}
 	tk, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
@@ -73,6 +109,16 @@ func (j *JwtManager) Verify(token string) (*TokenData, error) {
 	if claims, ok := tk.Claims.(jwt.MapClaims); ok {
 		d.User = claims["user"].(string)
 		d.Role = claims["role"].(string)
+		if policy, ok := claims["policy"].(string); ok {
+			d.Policy = policy
+		}
+		if apps, ok := claims["apps"].([]interface{}); ok {
+			for _, app := range apps {
+				if s, ok := app.(string); ok {
+					d.Apps = append(d.Apps, s)
+				}
+			}
+		}
 		expiresAt := (int64)(claims["expiresAt"].(float64))
 		d.ExpiresAt = time.Unix(expiresAt, 0)
 		if d.ExpiresAt.UTC().Before(time.Now().UTC()) {
@@ -54,3 +54,29 @@ func TestJwtWriteToken(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, data.Role, structs.ConvoxRoleReadWrite)
 }
+
+func TestJwtPolicyToken(t *testing.T) {
+	jm := jwt.NewJwtManager("TEST")
+
+	tk, err := jm.PolicyToken("ci", "deployer", []string{"staging-*"}, time.Hour)
+	assert.NoError(t, err, "no error")
+
+	data, err := jm.Verify(tk)
+	assert.NoError(t, err)
+	assert.Equal(t, "", data.Role)
+	assert.Equal(t, "deployer", data.Policy)
+	assert.Equal(t, []string{"staging-*"}, data.Apps)
+	assert.Equal(t, "ci", data.User)
+}
+
+func TestJwtBuildToken(t *testing.T) {
+	jm := jwt.NewJwtManager("TEST")
+
+	tk := jm.BuildToken("app1", "B1")
+
+	assert.True(t, jm.VerifyBuildToken("app1", "B1", tk))
+	assert.False(t, jm.VerifyBuildToken("app1", "B2", tk))
+	assert.False(t, jm.VerifyBuildToken("app2", "B1", tk))
+	assert.False(t, jwt.NewJwtManager("OTHER").VerifyBuildToken("app1", "B1", tk))
+	assert.False(t, jm.VerifyBuildToken("app1", "B1", ""))
+}
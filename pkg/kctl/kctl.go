@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/convox/convox/pkg/metrics"
 	ac "k8s.io/api/core/v1"
 	am "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -19,6 +20,11 @@ import (
 	"k8s.io/client-go/tools/record"
 )
 
+var (
+	controllerEvents = metrics.NewCounter("convox_controller_events_total", "Informer events handled by each controller", "controller", "event")
+	controllerErrors = metrics.NewCounter("convox_controller_errors_total", "Informer events each controller failed to handle", "controller", "event")
+)
+
 type Controller struct {
 	Handler    ControllerHandler
 	Identifier string
@@ -101,13 +107,19 @@ func (c *Controller) leaderStop() {
 }
 
 func (c *Controller) addHandler(obj interface{}) {
-	if err := c.Handler.Add(obj); err != nil {
-		c.errch <- err
-	}
+	c.observe("add", c.Handler.Add(obj))
 }
 
 func (c *Controller) deleteHandler(obj interface{}) {
-	if err := c.Handler.Delete(obj); err != nil {
+	c.observe("delete", c.Handler.Delete(obj))
+}
+
+// observe counts an informer event and whether the handler failed it
+func (c *Controller) observe(event string, err error) {
+	controllerEvents.Inc(c.Name, event)
+
+	if err != nil {
+		controllerErrors.Inc(c.Name, event)
 		c.errch <- err
 	}
 }
@@ -161,7 +173,5 @@ func (c *Controller) start() {
 }
 
 func (c *Controller) updateHandler(prev, cur interface{}) {
-	if err := c.Handler.Update(prev, cur); err != nil {
-		c.errch <- err
-	}
+	c.observe("update", c.Handler.Update(prev, cur))
 }
@@ -0,0 +1,262 @@
+package logquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	OpEqual        = "="
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpNotEqual     = "!="
+)
+
+var reFieldTerm = regexp.MustCompile(`^([A-Za-z0-9_.@-]+)(!=|>=|<=|=|>|<)(.*)$`)
+
+// Query is a set of terms that must all match a log line
+//
+//	service=web level=error status>=500 "timed out"
+//
+// Terms with a field compare against the fields of a log line, terms without
+// a field match the message text case insensitively
+type Query struct {
+	Terms []Term
+}
+
+type Term struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Parse reads a query, an empty string results in a query that matches
+// everything
+func Parse(s string) (*Query, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Query{}
+
+	for _, t := range tokens {
+		if strings.HasPrefix(t, `"`) {
+			v, err := strconv.Unquote(t)
+			if err != nil {
+				return nil, fmt.Errorf("invalid term: %s", t)
+			}
+
+			q.Terms = append(q.Terms, Term{Value: v})
+			continue
+		}
+
+		m := reFieldTerm.FindStringSubmatch(t)
+
+		if m == nil {
+			if strings.ContainsAny(t, "=<>") {
+				return nil, fmt.Errorf("invalid term: %s", t)
+			}
+
+			q.Terms = append(q.Terms, Term{Value: t})
+			continue
+		}
+
+		v := m[3]
+
+		if strings.HasPrefix(v, `"`) {
+			uv, err := strconv.Unquote(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid term: %s", t)
+			}
+			v = uv
+		}
+
+		if v == "" {
+			return nil, fmt.Errorf("invalid term: %s", t)
+		}
+
+		q.Terms = append(q.Terms, Term{Field: m[1], Op: m[2], Value: v})
+	}
+
+	return q, nil
+}
+
+// Empty returns true if the query matches every line
+func (q *Query) Empty() bool {
+	return q == nil || len(q.Terms) == 0
+}
+
+// Match returns true if a message on a stream satisfies every term
+func (q *Query) Match(message, stream string) bool {
+	if q.Empty() {
+		return true
+	}
+
+	fields := Fields(message, stream)
+
+	for _, t := range q.Terms {
+		if !t.match(message, fields) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Numeric returns the value of a term as a number if it is one
+func (t Term) Numeric() (float64, bool) {
+	f, err := strconv.ParseFloat(t.Value, 64)
+	return f, err == nil
+}
+
+func (t Term) match(message string, fields map[string]string) bool {
+	if t.Field == "" {
+		return strings.Contains(strings.ToLower(message), strings.ToLower(t.Value))
+	}
+
+	v, ok := fields[t.Field]
+	if !ok {
+		return t.Op == OpNotEqual
+	}
+
+	c := compare(v, t.Value)
+
+	switch t.Op {
+	case OpEqual:
+		return c == 0
+	case OpNotEqual:
+		return c != 0
+	case OpGreater:
+		return c > 0
+	case OpGreaterEqual:
+		return c >= 0
+	case OpLess:
+		return c < 0
+	case OpLessEqual:
+		return c <= 0
+	}
+
+	return false
+}
+
+// Fields returns the fields of a JSON message flattened with dots along with
+// the fields derived from the stream name
+func Fields(message, stream string) map[string]string {
+	fields := map[string]string{}
+
+	for k, v := range JSON(message) {
+		flatten(fields, k, v)
+	}
+
+	if stream != "" {
+		fields["stream"] = stream
+
+		if parts := strings.Split(stream, "/"); len(parts) > 1 && parts[0] == "service" {
+			fields["service"] = parts[1]
+		}
+	}
+
+	return fields
+}
+
+// JSON returns the decoded message if it is a JSON object
+func JSON(message string) map[string]interface{} {
+	message = strings.TrimSpace(message)
+
+	if !strings.HasPrefix(message, "{") {
+		return nil
+	}
+
+	d := json.NewDecoder(bytes.NewReader([]byte(message)))
+	d.UseNumber()
+
+	var v map[string]interface{}
+
+	if err := d.Decode(&v); err != nil {
+		return nil
+	}
+
+	return v
+}
+
+func compare(a, b string) int {
+	fa, aerr := strconv.ParseFloat(a, 64)
+	fb, berr := strconv.ParseFloat(b, 64)
+
+	if aerr == nil && berr == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(a, b)
+}
+
+func flatten(fields map[string]string, key string, v interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, v := range t {
+			flatten(fields, fmt.Sprintf("%s.%s", key, k), v)
+		}
+	case string:
+		fields[key] = t
+	case json.Number:
+		fields[key] = t.String()
+	case bool:
+		fields[key] = strconv.FormatBool(t)
+	case nil:
+	default:
+		data, _ := json.Marshal(t)
+		fields[key] = string(data)
+	}
+}
+
+func tokenize(s string) ([]string, error) {
+	tokens := []string{}
+
+	var cur strings.Builder
+	quoted := false
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && unicode.IsSpace(r):
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+
+		cur.WriteRune(r)
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in query: %s", s)
+	}
+
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+
+	return tokens, nil
+}
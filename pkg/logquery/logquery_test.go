@@ -0,0 +1,68 @@
+package logquery_test
+
+import (
+	"testing"
+
+	"github.com/convox/convox/pkg/logquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	q, err := logquery.Parse(`service=web level!=debug status>=500 latency<1.5 "timed out" msg="a b" panic`)
+	require.NoError(t, err)
+	require.Equal(t, []logquery.Term{
+		{Field: "service", Op: "=", Value: "web"},
+		{Field: "level", Op: "!=", Value: "debug"},
+		{Field: "status", Op: ">=", Value: "500"},
+		{Field: "latency", Op: "<", Value: "1.5"},
+		{Value: "timed out"},
+		{Field: "msg", Op: "=", Value: "a b"},
+		{Value: "panic"},
+	}, q.Terms)
+}
+
+func TestParseEmpty(t *testing.T) {
+	q, err := logquery.Parse("  ")
+	require.NoError(t, err)
+	require.True(t, q.Empty())
+	require.True(t, q.Match("anything", ""))
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{`"unterminated`, `=web`, `level=`, `status<`} {
+		_, err := logquery.Parse(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	q, err := logquery.Parse(`service=web level=error status>=500 "timeout"`)
+	require.NoError(t, err)
+
+	require.True(t, q.Match(`{"level":"error","status":503,"msg":"upstream Timeout"}`, "service/web/pid1"))
+	require.False(t, q.Match(`{"level":"error","status":404,"msg":"upstream timeout"}`, "service/web/pid1"))
+	require.False(t, q.Match(`{"level":"error","status":503,"msg":"upstream timeout"}`, "service/worker/pid1"))
+	require.False(t, q.Match(`{"level":"info","status":503,"msg":"timeout"}`, "service/web/pid1"))
+	require.False(t, q.Match(`level=error status=503 timeout`, "service/web/pid1"))
+}
+
+func TestMatchNested(t *testing.T) {
+	q, err := logquery.Parse(`http.status>499 user.admin=true region!=us-east-1`)
+	require.NoError(t, err)
+
+	require.True(t, q.Match(`{"http":{"status":500},"user":{"admin":true}}`, ""))
+	require.False(t, q.Match(`{"http":{"status":500},"user":{"admin":true},"region":"us-east-1"}`, ""))
+	require.False(t, q.Match(`{"http":{"status":200},"user":{"admin":true}}`, ""))
+}
+
+func TestFields(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"level":   "warn",
+		"n":       "3",
+		"tags":    `["a","b"]`,
+		"stream":  "service/web/pid1",
+		"service": "web",
+	}, logquery.Fields(`{"level":"warn","n":3,"tags":["a","b"],"none":null}`, "service/web/pid1"))
+
+	require.Equal(t, map[string]string{"stream": "system/k8s/api"}, logquery.Fields("plain text", "system/k8s/api"))
+}
@@ -0,0 +1,99 @@
+package logstorage
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Backend persists the logs of a Store
+type Backend interface {
+	// Append stores a log line for a stream
+	Append(stream string, l Log) error
+
+	// Cleanup removes logs that fall outside of the backend retention
+	Cleanup(now time.Time) error
+
+	// Read returns a Reader over the logs of a stream at or after start as
+	// they exist at the time of the call
+	Read(stream string, start time.Time) (Reader, error)
+}
+
+// Reader iterates over a snapshot of a stream in timestamp order
+type Reader interface {
+	Each(fn func(Log) error) error
+}
+
+type memoryBackend struct {
+	lock      sync.Mutex
+	retention time.Duration
+	streams   map[string][]Log
+}
+
+// NewMemoryBackend keeps logs in memory for the given retention
+func NewMemoryBackend(retention time.Duration) Backend {
+	return &memoryBackend{
+		retention: retention,
+		streams:   map[string][]Log{},
+	}
+}
+
+func (b *memoryBackend) Append(stream string, l Log) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	ls := b.streams[stream]
+
+	n := sort.Search(len(ls), func(i int) bool { return ls[i].Timestamp.After(l.Timestamp) })
+
+	ls = append(ls, Log{})
+	copy(ls[n+1:], ls[n:])
+	ls[n] = l
+
+	b.streams[stream] = ls
+
+	return nil
+}
+
+func (b *memoryBackend) Cleanup(now time.Time) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	cutoff := now.Add(-1 * b.retention)
+
+	for name, ls := range b.streams {
+		n := sort.Search(len(ls), func(i int) bool { return !ls[i].Timestamp.Before(cutoff) })
+
+		if n == len(ls) {
+			delete(b.streams, name)
+			continue
+		}
+
+		b.streams[name] = append([]Log{}, ls[n:]...)
+	}
+
+	return nil
+}
+
+func (b *memoryBackend) Read(stream string, start time.Time) (Reader, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	ls := b.streams[stream]
+
+	n := sort.Search(len(ls), func(i int) bool { return !ls[i].Timestamp.Before(start) })
+
+	return logSlice(append([]Log{}, ls[n:]...)), nil
+}
+
+type logSlice []Log
+
+func (ls logSlice) Each(fn func(Log) error) error {
+	for _, l := range ls {
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,507 @@
+package logstorage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DiskDefaultIndexInterval = 64 * 1024
+	DiskDefaultMaxAge        = 24 * time.Hour
+	DiskDefaultMaxSize       = 256 * 1024 * 1024
+	DiskDefaultSegmentAge    = 1 * time.Hour
+	DiskDefaultSegmentSize   = 8 * 1024 * 1024
+
+	diskIdleTimeout   = 1 * time.Minute
+	diskMaxLineLength = 4 * 1024 * 1024
+	diskSegmentSuffix = ".log"
+)
+
+// Retention limits how much of a stream is kept on disk, zero values fall
+// back to the backend defaults
+type Retention struct {
+	MaxAge  time.Duration
+	MaxSize int64
+}
+
+type DiskOptions struct {
+	// Dir is the directory holding one subdirectory of segments per stream
+	Dir string
+
+	// IndexInterval is the number of bytes between sparse index entries
+	IndexInterval int64
+
+	// Retention applies to every stream without an override in Streams
+	Retention Retention
+
+	// SegmentAge and SegmentSize control when a new segment is started
+	SegmentAge  time.Duration
+	SegmentSize int64
+
+	// Streams overrides Retention for a stream, keyed by the full stream name
+	// or by the app portion of the stream name (everything before the first /)
+	Streams map[string]Retention
+}
+
+type diskBackend struct {
+	lock    sync.Mutex
+	opts    DiskOptions
+	streams map[string]*diskStream
+}
+
+type diskStream struct {
+	dir      string
+	file     *os.File
+	segments []*diskSegment
+	written  time.Time
+}
+
+type diskSegment struct {
+	created time.Time
+	first   time.Time
+	index   []diskIndexEntry
+	last    time.Time
+	path    string
+	size    int64
+}
+
+// diskIndexEntry records that every log before offset has a timestamp no
+// later than max which allows seeking in segments with out of order logs
+type diskIndexEntry struct {
+	max    time.Time
+	offset int64
+}
+
+type diskRecord struct {
+	Message   string `json:"m"`
+	Prefix    string `json:"p"`
+	Timestamp int64  `json:"t"`
+}
+
+// NewDiskBackend stores logs in segmented files below opts.Dir and reloads any
+// existing segments so that history survives a restart
+func NewDiskBackend(opts DiskOptions) (Backend, error) {
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("dir required")
+	}
+
+	if opts.IndexInterval <= 0 {
+		opts.IndexInterval = DiskDefaultIndexInterval
+	}
+
+	if opts.SegmentAge <= 0 {
+		opts.SegmentAge = DiskDefaultSegmentAge
+	}
+
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DiskDefaultSegmentSize
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	b := &diskBackend{
+		opts:    opts,
+		streams: map[string]*diskStream{},
+	}
+
+	fis, err := ioutil.ReadDir(opts.Dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+
+		name, err := url.PathUnescape(fi.Name())
+		if err != nil {
+			continue
+		}
+
+		s, err := b.loadStream(filepath.Join(opts.Dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		b.streams[name] = s
+	}
+
+	return b, nil
+}
+
+func (b *diskBackend) Append(stream string, l Log) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	s, err := b.stream(stream)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	seg, err := b.activeSegment(s, now)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(diskRecord{Message: l.Message, Prefix: l.Prefix, Timestamp: l.Timestamp.UnixNano()})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	data = append(data, '\n')
+
+	if _, err := s.file.Write(data); err != nil {
+		return errors.WithStack(err)
+	}
+
+	b.track(seg, l.Timestamp, int64(len(data)))
+
+	s.written = now
+
+	return nil
+}
+
+func (b *diskBackend) Cleanup(now time.Time) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for name, s := range b.streams {
+		if s.file != nil && now.Sub(s.written) > diskIdleTimeout {
+			s.file.Close()
+			s.file = nil
+		}
+
+		r := b.retention(name)
+
+		var total int64
+
+		for _, seg := range s.segments {
+			total += seg.size
+		}
+
+		keep := []*diskSegment{}
+
+		for i, seg := range s.segments {
+			expired := r.MaxAge > 0 && seg.last.Before(now.Add(-1*r.MaxAge))
+			oversize := r.MaxSize > 0 && total > r.MaxSize
+
+			// never remove the segment that is still being written to
+			active := i == len(s.segments)-1 && s.file != nil
+
+			if (expired || oversize) && !active {
+				if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+					return errors.WithStack(err)
+				}
+				total -= seg.size
+				continue
+			}
+
+			keep = append(keep, seg)
+		}
+
+		s.segments = keep
+
+		if len(s.segments) == 0 {
+			if err := os.RemoveAll(s.dir); err != nil {
+				return errors.WithStack(err)
+			}
+			delete(b.streams, name)
+		}
+	}
+
+	return nil
+}
+
+func (b *diskBackend) Read(stream string, start time.Time) (Reader, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	s, ok := b.streams[stream]
+	if !ok {
+		return logSlice{}, nil
+	}
+
+	r := &diskReader{start: start}
+
+	for _, seg := range s.segments {
+		if seg.size == 0 || seg.last.Before(start) {
+			continue
+		}
+
+		r.segments = append(r.segments, diskSegmentView{
+			offset: seg.seek(start),
+			path:   seg.path,
+			size:   seg.size,
+		})
+	}
+
+	return r, nil
+}
+
+func (b *diskBackend) activeSegment(s *diskStream, now time.Time) (*diskSegment, error) {
+	if n := len(s.segments); n > 0 {
+		seg := s.segments[n-1]
+
+		if seg.size < b.opts.SegmentSize && now.Sub(seg.created) < b.opts.SegmentAge {
+			if s.file == nil {
+				fd, err := os.OpenFile(seg.path, os.O_APPEND|os.O_WRONLY, 0600)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				s.file = fd
+			}
+
+			return seg, nil
+		}
+	}
+
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	seg := &diskSegment{
+		created: now,
+		path:    filepath.Join(s.dir, fmt.Sprintf("%020d%s", now.UnixNano(), diskSegmentSuffix)),
+	}
+
+	fd, err := os.OpenFile(seg.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	s.file = fd
+	s.segments = append(s.segments, seg)
+
+	return seg, nil
+}
+
+func (b *diskBackend) loadSegment(path string) (*diskSegment, error) {
+	created, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(path), diskSegmentSuffix), 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+
+	seg := &diskSegment{
+		created: time.Unix(0, created).UTC(),
+		path:    path,
+	}
+
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer fd.Close()
+
+	br := bufio.NewReader(fd)
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		var r diskRecord
+
+		if err := json.Unmarshal(line, &r); err != nil {
+			break
+		}
+
+		b.track(seg, time.Unix(0, r.Timestamp).UTC(), int64(len(line)))
+	}
+
+	// drop a partially written trailing line so that appends stay aligned
+	if err := os.Truncate(path, seg.size); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return seg, nil
+}
+
+func (b *diskBackend) loadStream(dir string) (*diskStream, error) {
+	s := &diskStream{dir: dir}
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, fi := range fis {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), diskSegmentSuffix) {
+			continue
+		}
+
+		seg, err := b.loadSegment(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if seg == nil {
+			continue
+		}
+
+		s.segments = append(s.segments, seg)
+	}
+
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].created.Before(s.segments[j].created) })
+
+	return s, nil
+}
+
+func (b *diskBackend) retention(stream string) Retention {
+	r, ok := b.opts.Streams[stream]
+	if !ok {
+		r, ok = b.opts.Streams[strings.SplitN(stream, "/", 2)[0]]
+	}
+	if !ok {
+		r = b.opts.Retention
+	}
+
+	if r.MaxAge == 0 {
+		r.MaxAge = DiskDefaultMaxAge
+	}
+
+	if r.MaxSize == 0 {
+		r.MaxSize = DiskDefaultMaxSize
+	}
+
+	return r
+}
+
+func (b *diskBackend) stream(name string) (*diskStream, error) {
+	if s, ok := b.streams[name]; ok {
+		return s, nil
+	}
+
+	s := &diskStream{dir: filepath.Join(b.opts.Dir, url.PathEscape(name))}
+
+	b.streams[name] = s
+
+	return s, nil
+}
+
+func (b *diskBackend) track(seg *diskSegment, ts time.Time, size int64) {
+	if n := len(seg.index); n == 0 || seg.size-seg.index[n-1].offset >= b.opts.IndexInterval {
+		seg.index = append(seg.index, diskIndexEntry{max: seg.last, offset: seg.size})
+	}
+
+	if seg.first.IsZero() || ts.Before(seg.first) {
+		seg.first = ts
+	}
+
+	if ts.After(seg.last) {
+		seg.last = ts
+	}
+
+	seg.size += size
+}
+
+// seek returns the offset of the first index entry that may hold a log at or
+// after start
+func (seg *diskSegment) seek(start time.Time) int64 {
+	n := sort.Search(len(seg.index), func(i int) bool { return !seg.index[i].max.Before(start) })
+
+	if n == 0 {
+		return 0
+	}
+
+	return seg.index[n-1].offset
+}
+
+type diskReader struct {
+	segments []diskSegmentView
+	start    time.Time
+}
+
+type diskSegmentView struct {
+	offset int64
+	path   string
+	size   int64
+}
+
+// Each reads one segment at a time so memory use is bounded by the segment
+// size rather than the amount of history requested
+func (r *diskReader) Each(fn func(Log) error) error {
+	for _, seg := range r.segments {
+		ls, err := r.readSegment(seg)
+		if err != nil {
+			return err
+		}
+
+		for _, l := range ls {
+			if err := fn(l); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *diskReader) readSegment(seg diskSegmentView) ([]Log, error) {
+	fd, err := os.Open(seg.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer fd.Close()
+
+	if _, err := fd.Seek(seg.offset, io.SeekStart); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	s := bufio.NewScanner(io.LimitReader(fd, seg.size-seg.offset))
+	s.Buffer(make([]byte, 64*1024), diskMaxLineLength)
+
+	ls := []Log{}
+
+	for s.Scan() {
+		var dr diskRecord
+
+		if err := json.Unmarshal(s.Bytes(), &dr); err != nil {
+			continue
+		}
+
+		ts := time.Unix(0, dr.Timestamp).UTC()
+
+		if ts.Before(r.start) {
+			continue
+		}
+
+		ls = append(ls, Log{Message: dr.Message, Prefix: dr.Prefix, Timestamp: ts})
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sort.SliceStable(ls, func(i, j int) bool { return ls[i].Timestamp.Before(ls[j].Timestamp) })
+
+	return ls, nil
+}
@@ -0,0 +1,147 @@
+package logstorage_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/logstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, b logstorage.Backend, stream string, start time.Time) []logstorage.Log {
+	r, err := b.Read(stream, start)
+	require.NoError(t, err)
+
+	ls := []logstorage.Log{}
+
+	require.NoError(t, r.Each(func(l logstorage.Log) error {
+		ls = append(ls, l)
+		return nil
+	}))
+
+	return ls
+}
+
+func TestDiskPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b, err := logstorage.NewDiskBackend(logstorage.DiskOptions{Dir: dir})
+	require.NoError(t, err)
+
+	require.NoError(t, b.Append("app/web", logstorage.Log{Prefix: "p2", Message: "two", Timestamp: time2}))
+	require.NoError(t, b.Append("app/web", logstorage.Log{Prefix: "p1", Message: "one", Timestamp: time1}))
+	require.NoError(t, b.Append("app/web", logstorage.Log{Prefix: "p3", Message: "three", Timestamp: time3}))
+
+	b2, err := logstorage.NewDiskBackend(logstorage.DiskOptions{Dir: dir})
+	require.NoError(t, err)
+
+	ls := readAll(t, b2, "app/web", time2)
+	require.Equal(t, []logstorage.Log{
+		{Prefix: "p2", Message: "two", Timestamp: time2},
+		{Prefix: "p3", Message: "three", Timestamp: time3},
+	}, ls)
+
+	require.Empty(t, readAll(t, b2, "app/other", time1))
+}
+
+func TestDiskIndexedSeek(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b, err := logstorage.NewDiskBackend(logstorage.DiskOptions{Dir: dir, IndexInterval: 64})
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, b.Append("foo", logstorage.Log{Message: "line", Timestamp: time1.Add(time.Duration(i) * time.Second)}))
+	}
+
+	ls := readAll(t, b, "foo", time1.Add(90*time.Second))
+	require.Len(t, ls, 10)
+	require.Equal(t, time1.Add(90*time.Second), ls[0].Timestamp)
+	require.Equal(t, time1.Add(99*time.Second), ls[9].Timestamp)
+}
+
+func TestDiskSegments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b, err := logstorage.NewDiskBackend(logstorage.DiskOptions{Dir: dir, SegmentSize: 100})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, b.Append("foo", logstorage.Log{Message: "line", Timestamp: time1.Add(time.Duration(i) * time.Second)}))
+	}
+
+	segments, err := filepath.Glob(filepath.Join(dir, "foo", "*.log"))
+	require.NoError(t, err)
+	require.True(t, len(segments) > 1)
+
+	require.Len(t, readAll(t, b, "foo", time1), 10)
+}
+
+func TestDiskRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b, err := logstorage.NewDiskBackend(logstorage.DiskOptions{
+		Dir:         dir,
+		Retention:   logstorage.Retention{MaxAge: time.Hour},
+		SegmentSize: 1,
+		Streams: map[string]logstorage.Retention{
+			"keep": {MaxAge: 48 * time.Hour},
+		},
+	})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+
+	for _, stream := range []string{"drop/web", "keep/web"} {
+		require.NoError(t, b.Append(stream, logstorage.Log{Message: "old", Timestamp: now.Add(-2 * time.Hour)}))
+		require.NoError(t, b.Append(stream, logstorage.Log{Message: "new", Timestamp: now}))
+	}
+
+	require.NoError(t, b.Cleanup(now))
+
+	ls := readAll(t, b, "drop/web", now.Add(-24*time.Hour))
+	require.Len(t, ls, 1)
+	require.Equal(t, "new", ls[0].Message)
+
+	require.Len(t, readAll(t, b, "keep/web", now.Add(-24*time.Hour)), 2)
+}
+
+func TestDiskStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b, err := logstorage.NewDiskBackend(logstorage.DiskOptions{Dir: dir})
+	require.NoError(t, err)
+
+	s := logstorage.NewWithBackend(b)
+
+	s.Append("foo", time1, "p1", "one")
+	s.Append("foo", time2, "p2", "two")
+
+	ch := make(chan logstorage.Log)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.Subscribe(ctx, ch, "foo", time2, false)
+
+	log, ok := <-ch
+	require.True(t, ok)
+	require.Equal(t, "two", log.Message)
+
+	_, ok = <-ch
+	require.False(t, ok)
+}
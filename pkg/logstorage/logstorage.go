@@ -4,14 +4,17 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
-	"sort"
+	"os"
 	"sync"
 	"time"
 )
 
+// MemoryRetention is how long the default in-memory store keeps logs
+const MemoryRetention = 30 * time.Second
+
 type Store struct {
+	backend       Backend
 	lock          sync.Mutex
-	streams       map[string][]Log
 	subscriptions subscriptions
 }
 
@@ -28,9 +31,13 @@ func init() {
 }
 
 func New() Store {
-	s := Store{streams: map[string][]Log{}}
+	return NewWithBackend(NewMemoryBackend(MemoryRetention))
+}
+
+func NewWithBackend(b Backend) Store {
+	s := Store{backend: b}
 
-	go s.startCleaner()
+	go startCleaner(b)
 
 	return s
 }
@@ -41,19 +48,10 @@ func (s *Store) Append(stream string, ts time.Time, prefix, message string) {
 
 	log := Log{Message: message, Prefix: prefix, Timestamp: ts}
 
-	ls, ok := s.streams[stream]
-	if !ok {
-		ls = []Log{}
+	if err := s.backend.Append(stream, log); err != nil {
+		fmt.Fprintf(os.Stderr, "ns=logstorage at=append stream=%q error=%q\n", stream, err)
 	}
 
-	n := sort.Search(len(ls), func(i int) bool { return ls[i].Timestamp.After(ts) })
-
-	ls = append(ls, Log{})
-	copy(ls[n+1:], ls[n:])
-	ls[n] = log
-
-	s.streams[stream] = ls
-
 	s.subscriptions.send(stream, log)
 }
 
@@ -61,34 +59,28 @@ func (s *Store) Subscribe(ctx context.Context, ch Receiver, stream string, start
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	if ls, ok := s.streams[stream]; ok {
-		n := sort.Search(len(ls), func(i int) bool { return !ls[i].Timestamp.Before(start) })
-		go sendMultiple(ch, ls[n:], func() {
-			if !follow {
-				close(ch)
-			}
-		})
+	r, err := s.backend.Read(stream, start)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ns=logstorage at=read stream=%q error=%q\n", stream, err)
+		r = logSlice{}
 	}
 
+	go sendReader(ctx, ch, r, func() {
+		if !follow {
+			close(ch)
+		}
+	})
+
 	if follow {
 		s.subscriptions.Subscribe(ctx, ch, stream, start)
 	}
 }
 
-func (s *Store) cleanupLogs() {
-	s.lock.Lock()
-	defer s.lock.Unlock()
-
-	for name := range s.streams {
-		ls := s.streams[name]
-		n := sort.Search(len(ls), func(i int) bool { return ls[i].Timestamp.After(time.Now().Add(30 * time.Second)) })
-		s.streams[name] = ls[n:]
-	}
-}
-
-func (s *Store) startCleaner() {
+func startCleaner(b Backend) {
 	for range time.Tick(30 * time.Second) {
-		s.cleanupLogs()
+		if err := b.Cleanup(time.Now().UTC()); err != nil {
+			fmt.Fprintf(os.Stderr, "ns=logstorage at=cleanup error=%q\n", err)
+		}
 	}
 }
 
@@ -190,9 +182,15 @@ func (s *subscription) flush() {
 	s.queue = s.queue[:0]
 }
 
-func sendMultiple(ch Receiver, ls []Log, done func()) {
+func sendReader(ctx context.Context, ch Receiver, r Reader, done func()) {
 	defer done()
-	for _, l := range ls {
-		ch <- l
-	}
+
+	r.Each(func(l Log) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ch <- l:
+			return nil
+		}
+	})
 }
@@ -0,0 +1,232 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// timezones of scale schedules must resolve without a system zoneinfo
+	_ "time/tzdata"
+)
+
+// cronLookback bounds the search for a previous run, long enough to find a
+// schedule that only fires on february 29th
+const cronLookback = 5 * 366 * 24 * time.Hour
+
+var cronMacros = map[string]string{
+	"@annually": "0 0 1 1 *",
+	"@daily":    "0 0 * * *",
+	"@hourly":   "0 * * * *",
+	"@midnight": "0 0 * * *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@yearly":   "0 0 1 1 *",
+}
+
+var cronNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// Cron is a parsed 5 field cron schedule
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+
+	// standard cron matches either day field when both are restricted
+	domAny, dowAny bool
+}
+
+// NormalizeSchedule converts a cron schedule to the 5 fields used by v3,
+// dropping any seconds or year field and replacing ? from v2 aws syntax
+func NormalizeSchedule(schedule string) string {
+	parts := strings.Fields(schedule)
+
+	// v3 uses only 5 fields for schedules
+	if len(parts) > 5 {
+		parts = parts[0:5]
+	}
+
+	// replace ? with * from v2 aws syntax
+	for j := range parts {
+		if strings.TrimSpace(parts[j]) == "?" {
+			parts[j] = "*"
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func ParseCron(schedule string) (*Cron, error) {
+	s := strings.TrimSpace(schedule)
+
+	if m, ok := cronMacros[strings.ToLower(s)]; ok {
+		s = m
+	}
+
+	parts := strings.Fields(s)
+
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields", schedule)
+	}
+
+	c := &Cron{
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}
+
+	fields := []struct {
+		bits     *uint64
+		min, max int
+	}{
+		{&c.minute, 0, 59},
+		{&c.hour, 0, 23},
+		{&c.dom, 1, 31},
+		{&c.month, 1, 12},
+		{&c.dow, 0, 7},
+	}
+
+	for i, f := range fields {
+		bits, err := parseCronField(parts[i], f.min, f.max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %s", schedule, err)
+		}
+
+		*f.bits = bits
+	}
+
+	// 7 is also sunday
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	return c, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+
+		if parts := strings.SplitN(item, "/", 2); len(parts) == 2 {
+			n, err := strconv.Atoi(parts[1])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step: %s", item)
+			}
+
+			item, step = parts[0], n
+		}
+
+		lo, hi := min, max
+
+		switch {
+		case item == "*":
+		case strings.Contains(item, "-"):
+			parts := strings.SplitN(item, "-", 2)
+
+			a, err := parseCronValue(parts[0])
+			if err != nil {
+				return 0, err
+			}
+
+			b, err := parseCronValue(parts[1])
+			if err != nil {
+				return 0, err
+			}
+
+			lo, hi = a, b
+		default:
+			v, err := parseCronValue(item)
+			if err != nil {
+				return 0, err
+			}
+
+			lo, hi = v, v
+
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value out of range: %s", item)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseCronValue(s string) (int, error) {
+	if v, ok := cronNames[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value: %s", s)
+	}
+
+	return v, nil
+}
+
+// Match returns true if the schedule fires in the minute of t
+func (c *Cron) Match(t time.Time) bool {
+	return c.has(c.minute, t.Minute()) && c.has(c.hour, t.Hour()) && c.matchDay(t)
+}
+
+// Prev returns the most recent time at or before t that the schedule fired,
+// or the zero time if it has not fired within the lookback period
+func (c *Cron) Prev(t time.Time) time.Time {
+	t = t.Truncate(time.Minute)
+	limit := t.Add(-cronLookback)
+
+	for t.After(limit) {
+		if !c.has(c.month, int(t.Month())) || !c.matchDay(t) {
+			// last minute of the previous day
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+			continue
+		}
+
+		if !c.has(c.hour, t.Hour()) {
+			// last minute of the previous hour
+			t = t.Add(-time.Duration(t.Minute()+1) * time.Minute)
+			continue
+		}
+
+		for m := t.Minute(); m >= 0; m-- {
+			if c.has(c.minute, m) {
+				return t.Add(-time.Duration(t.Minute()-m) * time.Minute)
+			}
+		}
+
+		t = t.Add(-time.Duration(t.Minute()+1) * time.Minute)
+	}
+
+	return time.Time{}
+}
+
+func (c *Cron) has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
+
+func (c *Cron) matchDay(t time.Time) bool {
+	dom := c.has(c.dom, t.Day())
+	dow := c.has(c.dow, int(t.Weekday()))
+
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
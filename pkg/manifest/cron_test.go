@@ -0,0 +1,63 @@
+package manifest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, s := range []string{"* * * * *", "*/15 8-18 1,15 jan-jun mon-fri", "5/10 * * * 7", "@daily"} {
+		_, err := manifest.ParseCron(s)
+		require.NoError(t, err, s)
+	}
+
+	for _, s := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := manifest.ParseCron(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestCronPrev(t *testing.T) {
+	now := time.Date(2024, 3, 6, 10, 17, 42, 0, time.UTC) // a wednesday
+
+	tests := map[string]time.Time{
+		"* * * * *":        time.Date(2024, 3, 6, 10, 17, 0, 0, time.UTC),
+		"*/15 * * * *":     time.Date(2024, 3, 6, 10, 15, 0, 0, time.UTC),
+		"0 8 * * mon-fri":  time.Date(2024, 3, 6, 8, 0, 0, 0, time.UTC),
+		"0 18 * * mon-fri": time.Date(2024, 3, 5, 18, 0, 0, 0, time.UTC),
+		"30 23 * * 0":      time.Date(2024, 3, 3, 23, 30, 0, 0, time.UTC),
+		"0 0 29 2 *":       time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		"0 0 1 1 *":        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"0 12 15 * fri":    time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	for s, prev := range tests {
+		c, err := manifest.ParseCron(s)
+		require.NoError(t, err, s)
+		require.Equal(t, prev, c.Prev(now), s)
+	}
+}
+
+func TestServiceScaleScheduledCount(t *testing.T) {
+	s := manifest.ServiceScale{
+		Count: manifest.ServiceScaleCount{Min: 1, Max: 2},
+		Schedules: manifest.ServiceScaleSchedules{
+			{
+				Count:    manifest.ServiceScaleCount{Min: 5, Max: 20},
+				End:      "0 18 * * mon-fri",
+				Start:    "0 8 * * mon-fri",
+				Timezone: "America/New_York",
+			},
+		},
+	}
+
+	// 08:00 in new york is 13:00 utc in march before daylight saving
+	require.Equal(t, manifest.ServiceScaleCount{Min: 1, Max: 2}, s.ScheduledCount(time.Date(2024, 3, 6, 12, 59, 0, 0, time.UTC)))
+	require.Equal(t, manifest.ServiceScaleCount{Min: 5, Max: 20}, s.ScheduledCount(time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC)))
+	require.Equal(t, manifest.ServiceScaleCount{Min: 5, Max: 20}, s.ScheduledCount(time.Date(2024, 3, 6, 22, 59, 0, 0, time.UTC)))
+	require.Equal(t, manifest.ServiceScaleCount{Min: 1, Max: 2}, s.ScheduledCount(time.Date(2024, 3, 6, 23, 0, 0, 0, time.UTC)))
+	require.Equal(t, manifest.ServiceScaleCount{Min: 1, Max: 2}, s.ScheduledCount(time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC)))
+}
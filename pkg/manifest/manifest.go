@@ -99,21 +99,15 @@ func (m *Manifest) Agents() []string {
 
 func (m *Manifest) ApplyCompatibility() error {
 	for i := range m.Timers {
-		parts := strings.Fields(m.Timers[i].Schedule)
-
-		// v3 uses only 5 fields for schedules
-		if len(parts) > 5 {
-			parts = parts[0:5]
-		}
+		m.Timers[i].Schedule = NormalizeSchedule(m.Timers[i].Schedule)
+	}
 
-		// replace ? with * from v2 aws syntax
-		for j := range parts {
-			if strings.TrimSpace(parts[j]) == "?" {
-				parts[j] = "*"
-			}
+	for i := range m.Services {
+		for j := range m.Services[i].Scale.Schedules {
+			ss := &m.Services[i].Scale.Schedules[j]
+			ss.Start = NormalizeSchedule(ss.Start)
+			ss.End = NormalizeSchedule(ss.End)
 		}
-
-		m.Timers[i].Schedule = strings.Join(parts, " ")
 	}
 
 	return nil
@@ -245,6 +239,12 @@ func (m *Manifest) ApplyDefaults() error {
 		}
 	}
 
+	for i, r := range m.Resources {
+		if r.Backup.Schedule != "" && r.Backup.Retain == 0 {
+			m.Resources[i].Backup.Retain = ResourceBackupRetainDefault
+		}
+	}
+
 	return nil
 }
 
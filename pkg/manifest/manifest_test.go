@@ -54,6 +54,10 @@ func TestManifestLoad(t *testing.T) {
 			manifest.Resource{
 				Name: "database",
 				Type: "postgres",
+				Backup: manifest.ResourceBackup{
+					Retain:   7,
+					Schedule: "0 3 * * *",
+				},
 				Options: map[string]string{
 					"size": "db.t2.large",
 				},
@@ -314,6 +318,20 @@ func TestManifestLoad(t *testing.T) {
 					Count:  manifest.ServiceScaleCount{Min: 1, Max: 5},
 					Cpu:    256,
 					Memory: 512,
+					Schedules: manifest.ServiceScaleSchedules{
+						{
+							Name:     "business-hours",
+							Count:    manifest.ServiceScaleCount{Min: 5, Max: 20},
+							End:      "0 18 * * mon-fri",
+							Start:    "0 8 * * mon-fri",
+							Timezone: "America/New_York",
+						},
+						{
+							Count: manifest.ServiceScaleCount{Min: 2, Max: 2},
+							End:   "0 6 1 * *",
+							Start: "0 0 1 * *",
+						},
+					},
 					Targets: manifest.ServiceScaleTargets{
 						Cpu:      50,
 						Memory:   75,
@@ -455,6 +473,8 @@ func TestManifestLoad(t *testing.T) {
 		"params.Foo",
 		"resources",
 		"resources.database",
+		"resources.database.backup",
+		"resources.database.backup.schedule",
 		"resources.database.options",
 		"resources.database.options.size",
 		"resources.database.type",
@@ -533,6 +553,7 @@ func TestManifestLoad(t *testing.T) {
 		"services.scaler",
 		"services.scaler.scale",
 		"services.scaler.scale.count",
+		"services.scaler.scale.schedules",
 		"services.scaler.scale.targets",
 		"services.scaler.scale.targets.cpu",
 		"services.scaler.scale.targets.custom",
@@ -701,6 +722,10 @@ func TestManifestValidate(t *testing.T) {
 		"balancer alpha whitelist 1.1.1.1 is not a valid cidr range",
 		"balancer bravo refers to unknown service nosuch",
 		"resource name 1resource invalid, must contain only lowercase alphanumeric and dashes",
+		"resource backup-invalid of type redis can not be backed up",
+		"resource backup-invalid backup invalid schedule \"0 25 * * *\": value out of range: 25",
+		"resource backup-invalid backup retain can not be less than 0",
+		"resource backup-unscheduled backup requires a schedule",
 		"service deployment-invalid-low deployment minimum can not be less than 0",
 		"service deployment-invalid-low deployment maximum can not be less than 100",
 		"service deployment-invalid-high deployment minimum can not be greater than 100",
@@ -708,6 +733,10 @@ func TestManifestValidate(t *testing.T) {
 		"service internal-router-invalid can not have both internal and internalRouter set as true",
 		"service name serviceF invalid, must contain only lowercase alphanumeric and dashes",
 		"service serviceF references a resource that does not exist: foo",
+		"service schedule-invalid scale schedule nights start invalid schedule \"0 25 * * *\": value out of range: 25",
+		"service schedule-invalid scale schedule nights end invalid schedule \"0 6 * *\": expected 5 fields",
+		"service schedule-invalid scale schedule nights has unknown timezone: Mars/Olympus",
+		"service schedule-invalid scale schedule nights count must have 0 <= min <= max",
 		"timer name timer_1 invalid, must contain only lowercase alphanumeric and dashes",
 		"timer timer_1 references a service that does not exist: someservice",
 	}
@@ -8,6 +8,8 @@ import (
 
 const (
 	DEFAULT_RESOURCE_ENV_NAME = "URL"
+
+	ResourceBackupRetainDefault = 7
 )
 
 var (
@@ -26,12 +28,20 @@ var (
 type Resource struct {
 	Name    string            `yaml:"-"`
 	Type    string            `yaml:"type"`
+	Backup  ResourceBackup    `yaml:"backup,omitempty"`
 	Image   string            `yaml:"image,omitempty"`
 	Options map[string]string `yaml:"options"`
 }
 
 type Resources []Resource
 
+// ResourceBackup dumps a database resource on a schedule and keeps the most
+// recent dumps
+type ResourceBackup struct {
+	Retain   int    `yaml:"retain,omitempty"`
+	Schedule string `yaml:"schedule"`
+}
+
 func (r Resource) DefaultEnv() string {
 	return fmt.Sprintf("%s_URL", strings.Replace(strings.ToUpper(r.Name), "-", "_", -1))
 }
@@ -44,6 +54,16 @@ func (r Resource) LoadEnv() []string {
 	return envs
 }
 
+// Backupable returns true if the resource type can be exported as a dump
+func (r Resource) Backupable() bool {
+	switch r.Type {
+	case "mariadb", "mysql", "postgis", "postgres", "rds-mariadb", "rds-mysql", "rds-postgres":
+		return true
+	default:
+		return false
+	}
+}
+
 func (r Resource) GetName() string {
 	return r.Name
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 )
 
 const (
@@ -218,12 +219,13 @@ type ServicePortScheme struct {
 }
 
 type ServiceScale struct {
-	Count   ServiceScaleCount
-	Cpu     int
-	Gpu     ServiceScaleGpu `yaml:"gpu,omitempty"`
-	Memory  int
-	Limit   ServiceResourceLimit `yaml:"limit,omitempty"`
-	Targets ServiceScaleTargets  `yaml:"targets,omitempty"`
+	Count     ServiceScaleCount
+	Cpu       int
+	Gpu       ServiceScaleGpu `yaml:"gpu,omitempty"`
+	Memory    int
+	Limit     ServiceResourceLimit  `yaml:"limit,omitempty"`
+	Schedules ServiceScaleSchedules `yaml:"schedules,omitempty"`
+	Targets   ServiceScaleTargets   `yaml:"targets,omitempty"`
 }
 
 type ServiceResourceLimit struct {
@@ -259,6 +261,57 @@ type ServiceScaleMetric struct {
 
 type ServiceScaleMetrics []ServiceScaleMetric
 
+// ServiceScaleSchedule overrides the scale count of a service from each run of
+// Start until the following run of End
+type ServiceScaleSchedule struct {
+	Name     string            `yaml:"name,omitempty"`
+	Count    ServiceScaleCount `yaml:"count"`
+	End      string            `yaml:"end"`
+	Start    string            `yaml:"start"`
+	Timezone string            `yaml:"timezone,omitempty"`
+}
+
+type ServiceScaleSchedules []ServiceScaleSchedule
+
+// Active returns true if t falls within a window of the schedule
+func (s ServiceScaleSchedule) Active(t time.Time) (bool, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return false, err
+	}
+
+	start, err := ParseCron(s.Start)
+	if err != nil {
+		return false, err
+	}
+
+	end, err := ParseCron(s.End)
+	if err != nil {
+		return false, err
+	}
+
+	t = t.In(loc)
+
+	started := start.Prev(t)
+	if started.IsZero() {
+		return false, nil
+	}
+
+	return started.After(end.Prev(t)), nil
+}
+
+// ScheduledCount returns the count of the first schedule that is active at t,
+// or the default count outside of every schedule
+func (s ServiceScale) ScheduledCount(t time.Time) ServiceScaleCount {
+	for _, ss := range s.Schedules {
+		if active, err := ss.Active(t); err == nil && active {
+			return ss.Count
+		}
+	}
+
+	return s.Count
+}
+
 type ServiceScaleTargets struct {
 	Cpu      int
 	Custom   ServiceScaleMetrics
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -92,6 +94,22 @@ func (m *Manifest) validateResources() []error {
 		if strings.TrimSpace(r.Type) == "" {
 			errs = append(errs, fmt.Errorf("resource %q has blank type", r.Name))
 		}
+
+		if r.Backup.Schedule != "" {
+			if !r.Backupable() {
+				errs = append(errs, fmt.Errorf("resource %s of type %s can not be backed up", r.Name, r.Type))
+			}
+
+			if _, err := ParseCron(r.Backup.Schedule); err != nil {
+				errs = append(errs, fmt.Errorf("resource %s backup %s", r.Name, err))
+			}
+
+			if r.Backup.Retain < 0 {
+				errs = append(errs, fmt.Errorf("resource %s backup retain can not be less than 0", r.Name))
+			}
+		} else if r.Backup.Retain != 0 {
+			errs = append(errs, fmt.Errorf("resource %s backup requires a schedule", r.Name))
+		}
 	}
 
 	return errs
@@ -138,6 +156,33 @@ func (m *Manifest) validateServices() []error {
 			}
 		}
 
+		for i, ss := range s.Scale.Schedules {
+			name := ss.Name
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+
+			if _, err := ParseCron(ss.Start); err != nil {
+				errs = append(errs, fmt.Errorf("service %s scale schedule %s start %s", s.Name, name, err))
+			}
+
+			if _, err := ParseCron(ss.End); err != nil {
+				errs = append(errs, fmt.Errorf("service %s scale schedule %s end %s", s.Name, name, err))
+			}
+
+			if _, err := time.LoadLocation(ss.Timezone); err != nil {
+				errs = append(errs, fmt.Errorf("service %s scale schedule %s has unknown timezone: %s", s.Name, name, ss.Timezone))
+			}
+
+			if ss.Count.Min < 0 || ss.Count.Max < ss.Count.Min {
+				errs = append(errs, fmt.Errorf("service %s scale schedule %s count must have 0 <= min <= max", s.Name, name))
+			}
+
+			if s.Agent.Enabled {
+				errs = append(errs, fmt.Errorf("service %s scale schedule %s can not be used with an agent", s.Name, name))
+			}
+		}
+
 		for i := range s.VolumeOptions {
 			if err := s.VolumeOptions[i].Validate(); err != nil {
 				errs = append(errs, err)
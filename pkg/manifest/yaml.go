@@ -459,6 +459,13 @@ func (v *ServiceScale) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		if w, ok := t["memory"].(int); ok {
 			v.Memory = w
 		}
+		if w, ok := t["schedules"].(interface{}); ok {
+			var ss ServiceScaleSchedules
+			if err := remarshal(w, &ss); err != nil {
+				return err
+			}
+			v.Schedules = ss
+		}
 		if w, ok := t["targets"].(interface{}); ok {
 			var t ServiceScaleTargets
 			if err := remarshal(w, &t); err != nil {
@@ -0,0 +1,355 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds in seconds used for durations
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Default is the registry served by Handler
+var Default = NewRegistry()
+
+type collector interface {
+	name() string
+	write(w io.Writer) error
+}
+
+// Registry holds metrics and writes them in the Prometheus text exposition
+// format
+type Registry struct {
+	collectors map[string]collector
+	lock       sync.Mutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]collector{}}
+}
+
+// Handler serves the metrics of the default registry
+func Handler() http.Handler {
+	return Default
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := r.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Write writes every metric of the registry sorted by name
+func (r *Registry) Write(w io.Writer) error {
+	r.lock.Lock()
+
+	cs := make([]collector, 0, len(r.collectors))
+
+	for _, c := range r.collectors {
+		cs = append(cs, c)
+	}
+
+	r.lock.Unlock()
+
+	sort.Slice(cs, func(i, j int) bool { return cs[i].name() < cs[j].name() })
+
+	for _, c := range cs {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// register adds c to the registry, replacing a metric of the same name so
+// that components which are set up again keep reporting
+func (r *Registry) register(c collector) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.collectors[c.name()] = c
+}
+
+// series keeps one value per set of label values
+type series struct {
+	help   string
+	labels []string
+	metric string
+
+	lock   sync.Mutex
+	values map[string][]string
+}
+
+func (s *series) name() string {
+	return s.metric
+}
+
+func (s *series) key(values []string) string {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", s.metric, len(s.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\x00")
+
+	if _, ok := s.values[key]; !ok {
+		s.values[key] = append([]string{}, values...)
+	}
+
+	return key
+}
+
+func (s *series) keys() []string {
+	keys := make([]string, 0, len(s.values))
+
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (s *series) header(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", s.metric, escapeHelp(s.help), s.metric, kind)
+	return err
+}
+
+// Counter is a cumulative value such as a number of requests
+type Counter struct {
+	series
+	counts map[string]float64
+}
+
+// NewCounter registers a counter with the given label names on the default
+// registry
+func NewCounter(name, help string, labels ...string) *Counter {
+	return Default.NewCounter(name, help, labels...)
+}
+
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		series: series{help: help, labels: labels, metric: name, values: map[string][]string{}},
+		counts: map[string]float64{},
+	}
+
+	r.register(c)
+
+	return c
+}
+
+// Inc adds one to the counter for the given label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *Counter) Add(v float64, values ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.counts[c.key(values)] += v
+}
+
+func (c *Counter) write(w io.Writer) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.header(w, "counter"); err != nil {
+		return err
+	}
+
+	for _, k := range c.keys() {
+		if err := sample(w, c.metric, c.labels, c.values[k], c.counts[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Histogram counts observations such as durations in buckets
+type Histogram struct {
+	series
+	buckets []float64
+	counts  map[string][]uint64
+	sums    map[string]float64
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds and
+// label names on the default registry
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return Default.NewHistogram(name, help, buckets, labels...)
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	bs := append([]float64{}, buckets...)
+
+	sort.Float64s(bs)
+
+	h := &Histogram{
+		series:  series{help: help, labels: labels, metric: name, values: map[string][]string{}},
+		buckets: bs,
+		counts:  map[string][]uint64{},
+		sums:    map[string]float64{},
+	}
+
+	r.register(h)
+
+	return h
+}
+
+// Observe records v for the given label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	k := h.key(values)
+
+	if _, ok := h.counts[k]; !ok {
+		h.counts[k] = make([]uint64, len(h.buckets)+1)
+	}
+
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.counts[k][i]++
+	h.sums[k] += v
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if err := h.header(w, "histogram"); err != nil {
+		return err
+	}
+
+	labels := append(append([]string{}, h.labels...), "le")
+
+	for _, k := range h.keys() {
+		total := uint64(0)
+
+		for i, b := range append(append([]float64{}, h.buckets...), math.Inf(1)) {
+			total += h.counts[k][i]
+
+			if err := sample(w, h.metric+"_bucket", labels, append(append([]string{}, h.values[k]...), formatFloat(b)), float64(total)); err != nil {
+				return err
+			}
+		}
+
+		if err := sample(w, h.metric+"_sum", h.labels, h.values[k], h.sums[k]); err != nil {
+			return err
+		}
+
+		if err := sample(w, h.metric+"_count", h.labels, h.values[k], float64(total)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GaugeFunc reads its values when the metrics are collected, fn returns a
+// value for each value of the single label
+type GaugeFunc struct {
+	fn    func() (map[string]float64, error)
+	help  string
+	label string
+	gauge string
+}
+
+// NewGaugeFunc registers a gauge on the default registry that is read from fn
+// whenever the metrics are collected
+func NewGaugeFunc(name, help, label string, fn func() (map[string]float64, error)) *GaugeFunc {
+	return Default.NewGaugeFunc(name, help, label, fn)
+}
+
+func (r *Registry) NewGaugeFunc(name, help, label string, fn func() (map[string]float64, error)) *GaugeFunc {
+	g := &GaugeFunc{fn: fn, help: help, label: label, gauge: name}
+
+	r.register(g)
+
+	return g
+}
+
+func (g *GaugeFunc) name() string {
+	return g.gauge
+}
+
+// write skips the gauge when it can not be read so that the other metrics are
+// still served
+func (g *GaugeFunc) write(w io.Writer) error {
+	vs, err := g.fn()
+	if err != nil {
+		return nil
+	}
+
+	s := series{help: g.help, metric: g.gauge}
+
+	if err := s.header(w, "gauge"); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(vs))
+
+	for k := range vs {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		labels, values := []string{g.label}, []string{k}
+
+		if g.label == "" {
+			labels, values = nil, nil
+		}
+
+		if err := sample(w, g.gauge, labels, values, vs[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sample(w io.Writer, name string, labels, values []string, v float64) error {
+	ls := make([]string, len(labels))
+
+	for i := range labels {
+		ls[i] = fmt.Sprintf(`%s="%s"`, labels[i], escapeLabel(values[i]))
+	}
+
+	if len(ls) > 0 {
+		name = fmt.Sprintf("%s{%s}", name, strings.Join(ls, ","))
+	}
+
+	_, err := fmt.Fprintf(w, "%s %s\n", name, formatFloat(v))
+	return err
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
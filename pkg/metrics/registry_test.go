@@ -0,0 +1,94 @@
+package metrics_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/convox/convox/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	c := r.NewCounter("test_requests_total", "Requests served", "route", "code")
+	c.Inc("/apps", "200")
+	c.Inc("/apps", "200")
+	c.Add(3, `/apps/{name}`, "404")
+
+	h := r.NewHistogram("test_duration_seconds", "Time taken", []float64{1, 0.1}, "method")
+	h.Observe(0.05, "AppList")
+	h.Observe(0.1, "AppList")
+	h.Observe(5, "AppList")
+
+	r.NewGaugeFunc("test_depth", "Items waiting", "status", func() (map[string]float64, error) {
+		return map[string]float64{"running": 2, "created": 1}, nil
+	})
+
+	r.NewGaugeFunc("test_broken", "Can not be read", "", func() (map[string]float64, error) {
+		return nil, fmt.Errorf("broken")
+	})
+
+	var buf bytes.Buffer
+
+	require.NoError(t, r.Write(&buf))
+
+	require.Equal(t, `# HELP test_depth Items waiting
+# TYPE test_depth gauge
+test_depth{status="created"} 1
+test_depth{status="running"} 2
+# HELP test_duration_seconds Time taken
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{method="AppList",le="0.1"} 2
+test_duration_seconds_bucket{method="AppList",le="1"} 2
+test_duration_seconds_bucket{method="AppList",le="+Inf"} 3
+test_duration_seconds_sum{method="AppList"} 5.15
+test_duration_seconds_count{method="AppList"} 3
+# HELP test_requests_total Requests served
+# TYPE test_requests_total counter
+test_requests_total{route="/apps",code="200"} 2
+test_requests_total{route="/apps/{name}",code="404"} 3
+`, buf.String())
+}
+
+func TestRegistryEscape(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	r.NewCounter("test_total", "Line\nbreak", "path").Inc("a\"b\\c\nd")
+
+	var buf bytes.Buffer
+
+	require.NoError(t, r.Write(&buf))
+
+	require.Equal(t, `# HELP test_total Line\nbreak
+# TYPE test_total counter
+test_total{path="a\"b\\c\nd"} 1
+`, buf.String())
+}
+
+func TestRegistryReplace(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	r.NewCounter("test_total", "First").Inc()
+	r.NewCounter("test_total", "Second").Add(2)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, r.Write(&buf))
+
+	require.Equal(t, "# HELP test_total Second\n# TYPE test_total counter\ntest_total 2\n", buf.String())
+}
+
+func TestRegistryHandler(t *testing.T) {
+	metrics.NewCounter("test_handler_total", "Handler test").Inc()
+
+	w := httptest.NewRecorder()
+
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	require.Equal(t, 200, w.Code)
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Contains(t, w.Body.String(), "test_handler_total 1\n")
+}
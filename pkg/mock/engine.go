@@ -59,6 +59,10 @@ func (*TestEngine) RepositoryPrefix() string {
 	return ""
 }
 
+func (*TestEngine) RepositoryUntag(_ string, _ []string) error {
+	return nil
+}
+
 func (*TestEngine) ResolverHost() (string, error) {
 	return "", errors.WithStack(fmt.Errorf("no resolver"))
 }
@@ -262,6 +262,29 @@ func (_m *Interface) AppParametersSet(_a0 string, _a1 map[string]string) error {
 	return r0
 }
 
+// AppPrune provides a mock function with given fields: name, opts
+func (_m *Interface) AppPrune(name string, opts structs.AppPruneOptions) (structs.PruneObjects, error) {
+	ret := _m.Called(name, opts)
+
+	var r0 structs.PruneObjects
+	if rf, ok := ret.Get(0).(func(string, structs.AppPruneOptions) structs.PruneObjects); ok {
+		r0 = rf(name, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(structs.PruneObjects)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, structs.AppPruneOptions) error); ok {
+		r1 = rf(name, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AppUpdate provides a mock function with given fields: name, opts
 func (_m *Interface) AppUpdate(name string, opts structs.AppUpdateOptions) error {
 	ret := _m.Called(name, opts)
@@ -276,6 +299,43 @@ func (_m *Interface) AppUpdate(name string, opts structs.AppUpdateOptions) error
 	return r0
 }
 
+// AuditLogAppend provides a mock function with given fields: log
+func (_m *Interface) AuditLogAppend(log structs.AuditLog) error {
+	ret := _m.Called(log)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(structs.AuditLog) error); ok {
+		r0 = rf(log)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AuditLogList provides a mock function with given fields: opts
+func (_m *Interface) AuditLogList(opts structs.AuditLogListOptions) (structs.AuditLogs, error) {
+	ret := _m.Called(opts)
+
+	var r0 structs.AuditLogs
+	if rf, ok := ret.Get(0).(func(structs.AuditLogListOptions) structs.AuditLogs); ok {
+		r0 = rf(opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(structs.AuditLogs)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(structs.AuditLogListOptions) error); ok {
+		r1 = rf(opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BalancerList provides a mock function with given fields: app
 func (_m *Interface) BalancerList(app string) (structs.Balancers, error) {
 	ret := _m.Called(app)
@@ -299,6 +359,52 @@ func (_m *Interface) BalancerList(app string) (structs.Balancers, error) {
 	return r0, r1
 }
 
+// BuildAttest provides a mock function with given fields: app, id
+func (_m *Interface) BuildAttest(app string, id string) (*structs.BuildAttestation, error) {
+	ret := _m.Called(app, id)
+
+	var r0 *structs.BuildAttestation
+	if rf, ok := ret.Get(0).(func(string, string) *structs.BuildAttestation); ok {
+		r0 = rf(app, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*structs.BuildAttestation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(app, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BuildAttestation provides a mock function with given fields: app, id
+func (_m *Interface) BuildAttestation(app string, id string) (*structs.BuildAttestation, error) {
+	ret := _m.Called(app, id)
+
+	var r0 *structs.BuildAttestation
+	if rf, ok := ret.Get(0).(func(string, string) *structs.BuildAttestation); ok {
+		r0 = rf(app, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*structs.BuildAttestation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(app, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BuildCreate provides a mock function with given fields: app, _a1, opts
 func (_m *Interface) BuildCreate(app string, _a1 string, opts structs.BuildCreateOptions) (*structs.Build, error) {
 	ret := _m.Called(app, _a1, opts)
@@ -497,6 +603,29 @@ func (_m *Interface) BuildLogs(app string, id string, opts structs.LogsOptions)
 	return r0, r1
 }
 
+// BuildSbom provides a mock function with given fields: app, id, opts
+func (_m *Interface) BuildSbom(app string, id string, opts structs.BuildSbomOptions) (io.ReadCloser, error) {
+	ret := _m.Called(app, id, opts)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(string, string, structs.BuildSbomOptions) io.ReadCloser); ok {
+		r0 = rf(app, id, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, structs.BuildSbomOptions) error); ok {
+		r1 = rf(app, id, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BuildUpdate provides a mock function with given fields: app, id, opts
 func (_m *Interface) BuildUpdate(app string, id string, opts structs.BuildUpdateOptions) (*structs.Build, error) {
 	ret := _m.Called(app, id, opts)
@@ -543,6 +672,29 @@ func (_m *Interface) CapacityGet() (*structs.Capacity, error) {
 	return r0, r1
 }
 
+// CapacityPlan provides a mock function with given fields: app, opts
+func (_m *Interface) CapacityPlan(app string, opts structs.CapacityPlanOptions) (*structs.CapacityPlan, error) {
+	ret := _m.Called(app, opts)
+
+	var r0 *structs.CapacityPlan
+	if rf, ok := ret.Get(0).(func(string, structs.CapacityPlanOptions) *structs.CapacityPlan); ok {
+		r0 = rf(app, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*structs.CapacityPlan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, structs.CapacityPlanOptions) error); ok {
+		r1 = rf(app, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CertificateApply provides a mock function with given fields: app, service, port, id
 func (_m *Interface) CertificateApply(app string, service string, port int, id string) error {
 	ret := _m.Called(app, service, port, id)
@@ -760,6 +912,29 @@ func (_m *Interface) EventSend(action string, opts structs.EventSendOptions) err
 	return r0
 }
 
+// EventStream provides a mock function with given fields: opts
+func (_m *Interface) EventStream(opts structs.EventStreamOptions) (io.ReadCloser, error) {
+	ret := _m.Called(opts)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(structs.EventStreamOptions) io.ReadCloser); ok {
+		r0 = rf(opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(structs.EventStreamOptions) error); ok {
+		r1 = rf(opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FilesDelete provides a mock function with given fields: app, pid, files
 func (_m *Interface) FilesDelete(app string, pid string, files []string) error {
 	ret := _m.Called(app, pid, files)
@@ -1119,6 +1294,89 @@ func (_m *Interface) ObjectStore(app string, key string, r io.Reader, opts struc
 	return r0, r1
 }
 
+// PolicyCreate provides a mock function with given fields: name, opts
+func (_m *Interface) PolicyCreate(name string, opts structs.PolicyCreateOptions) (*structs.Policy, error) {
+	ret := _m.Called(name, opts)
+
+	var r0 *structs.Policy
+	if rf, ok := ret.Get(0).(func(string, structs.PolicyCreateOptions) *structs.Policy); ok {
+		r0 = rf(name, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*structs.Policy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, structs.PolicyCreateOptions) error); ok {
+		r1 = rf(name, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PolicyDelete provides a mock function with given fields: name
+func (_m *Interface) PolicyDelete(name string) error {
+	ret := _m.Called(name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PolicyGet provides a mock function with given fields: name
+func (_m *Interface) PolicyGet(name string) (*structs.Policy, error) {
+	ret := _m.Called(name)
+
+	var r0 *structs.Policy
+	if rf, ok := ret.Get(0).(func(string) *structs.Policy); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*structs.Policy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PolicyList provides a mock function with given fields:
+func (_m *Interface) PolicyList() (structs.Policies, error) {
+	ret := _m.Called()
+
+	var r0 structs.Policies
+	if rf, ok := ret.Get(0).(func() structs.Policies); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(structs.Policies)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ProcessExec provides a mock function with given fields: app, pid, command, rw, opts
 func (_m *Interface) ProcessExec(app string, pid string, command string, rw io.ReadWriter, opts structs.ProcessExecOptions) (int, error) {
 	ret := _m.Called(app, pid, command, rw, opts)
@@ -1411,6 +1669,29 @@ func (_m *Interface) RegistryRemoveClassic(_a0 string) error {
 	return r0
 }
 
+// ReleaseApprove provides a mock function with given fields: app, id, opts
+func (_m *Interface) ReleaseApprove(app string, id string, opts structs.ReleaseApproveOptions) (*structs.Release, error) {
+	ret := _m.Called(app, id, opts)
+
+	var r0 *structs.Release
+	if rf, ok := ret.Get(0).(func(string, string, structs.ReleaseApproveOptions) *structs.Release); ok {
+		r0 = rf(app, id, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*structs.Release)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, structs.ReleaseApproveOptions) error); ok {
+		r1 = rf(app, id, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ReleaseCreate provides a mock function with given fields: app, opts
 func (_m *Interface) ReleaseCreate(app string, opts structs.ReleaseCreateOptions) (*structs.Release, error) {
 	ret := _m.Called(app, opts)
@@ -1434,6 +1715,29 @@ func (_m *Interface) ReleaseCreate(app string, opts structs.ReleaseCreateOptions
 	return r0, r1
 }
 
+// ReleaseDiff provides a mock function with given fields: app, id, opts
+func (_m *Interface) ReleaseDiff(app string, id string, opts structs.ReleaseDiffOptions) (*structs.ReleaseDiff, error) {
+	ret := _m.Called(app, id, opts)
+
+	var r0 *structs.ReleaseDiff
+	if rf, ok := ret.Get(0).(func(string, string, structs.ReleaseDiffOptions) *structs.ReleaseDiff); ok {
+		r0 = rf(app, id, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*structs.ReleaseDiff)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, structs.ReleaseDiffOptions) error); ok {
+		r1 = rf(app, id, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ReleaseGet provides a mock function with given fields: app, id
 func (_m *Interface) ReleaseGet(app string, id string) (*structs.Release, error) {
 	ret := _m.Called(app, id)
@@ -1480,6 +1784,29 @@ func (_m *Interface) ReleaseList(app string, opts structs.ReleaseListOptions) (s
 	return r0, r1
 }
 
+// ReleasePlan provides a mock function with given fields: app, id, opts
+func (_m *Interface) ReleasePlan(app string, id string, opts structs.ReleasePromoteOptions) (*structs.ReleasePlan, error) {
+	ret := _m.Called(app, id, opts)
+
+	var r0 *structs.ReleasePlan
+	if rf, ok := ret.Get(0).(func(string, string, structs.ReleasePromoteOptions) *structs.ReleasePlan); ok {
+		r0 = rf(app, id, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*structs.ReleasePlan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, structs.ReleasePromoteOptions) error); ok {
+		r1 = rf(app, id, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ReleasePromote provides a mock function with given fields: app, id, opts
 func (_m *Interface) ReleasePromote(app string, id string, opts structs.ReleasePromoteOptions) error {
 	ret := _m.Called(app, id, opts)
@@ -1494,6 +1821,29 @@ func (_m *Interface) ReleasePromote(app string, id string, opts structs.ReleaseP
 	return r0
 }
 
+// ResourceBackupList provides a mock function with given fields: app, name
+func (_m *Interface) ResourceBackupList(app string, name string) (structs.ResourceBackups, error) {
+	ret := _m.Called(app, name)
+
+	var r0 structs.ResourceBackups
+	if rf, ok := ret.Get(0).(func(string, string) structs.ResourceBackups); ok {
+		r0 = rf(app, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(structs.ResourceBackups)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(app, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ResourceConsole provides a mock function with given fields: app, name, rw, opts
 func (_m *Interface) ResourceConsole(app string, name string, rw io.ReadWriter, opts structs.ResourceConsoleOptions) error {
 	ret := _m.Called(app, name, rw, opts)
@@ -1614,6 +1964,20 @@ func (_m *Interface) ResourceList(app string) (structs.Resources, error) {
 	return r0, r1
 }
 
+// ResourceRestore provides a mock function with given fields: app, name, backup
+func (_m *Interface) ResourceRestore(app string, name string, backup string) error {
+	ret := _m.Called(app, name, backup)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(app, name, backup)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ResourceUpdateClassic provides a mock function with given fields: _a0, _a1
 func (_m *Interface) ResourceUpdateClassic(_a0 string, _a1 structs.ResourceUpdateOptions) (*structs.Resource, error) {
 	ret := _m.Called(_a0, _a1)
@@ -2313,6 +2677,27 @@ func (_m *Interface) SystemResourceUpdateClassic(_a0 string, _a1 structs.Resourc
 	return r0, r1
 }
 
+// SystemSigningKey provides a mock function with given fields:
+func (_m *Interface) SystemSigningKey() (string, error) {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SystemUninstall provides a mock function with given fields: name, w, opts
 func (_m *Interface) SystemUninstall(name string, w io.Writer, opts structs.SystemUninstallOptions) error {
 	ret := _m.Called(name, w, opts)
@@ -2341,6 +2726,135 @@ func (_m *Interface) SystemUpdate(opts structs.SystemUpdateOptions) error {
 	return r0
 }
 
+// TimerList provides a mock function with given fields: app
+func (_m *Interface) TimerList(app string) (structs.Timers, error) {
+	ret := _m.Called(app)
+
+	var r0 structs.Timers
+	if rf, ok := ret.Get(0).(func(string) structs.Timers); ok {
+		r0 = rf(app)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(structs.Timers)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(app)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TimerRun provides a mock function with given fields: app, name
+func (_m *Interface) TimerRun(app string, name string) (*structs.TimerRun, error) {
+	ret := _m.Called(app, name)
+
+	var r0 *structs.TimerRun
+	if rf, ok := ret.Get(0).(func(string, string) *structs.TimerRun); ok {
+		r0 = rf(app, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*structs.TimerRun)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(app, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TimerRunList provides a mock function with given fields: app, name
+func (_m *Interface) TimerRunList(app string, name string) (structs.TimerRuns, error) {
+	ret := _m.Called(app, name)
+
+	var r0 structs.TimerRuns
+	if rf, ok := ret.Get(0).(func(string, string) structs.TimerRuns); ok {
+		r0 = rf(app, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(structs.TimerRuns)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(app, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WebhookDeliveryList provides a mock function with given fields: name, opts
+func (_m *Interface) WebhookDeliveryList(name string, opts structs.WebhookDeliveryListOptions) (structs.WebhookDeliveries, error) {
+	ret := _m.Called(name, opts)
+
+	var r0 structs.WebhookDeliveries
+	if rf, ok := ret.Get(0).(func(string, structs.WebhookDeliveryListOptions) structs.WebhookDeliveries); ok {
+		r0 = rf(name, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(structs.WebhookDeliveries)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, structs.WebhookDeliveryListOptions) error); ok {
+		r1 = rf(name, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WebhookList provides a mock function with given fields:
+func (_m *Interface) WebhookList() (structs.Webhooks, error) {
+	ret := _m.Called()
+
+	var r0 structs.Webhooks
+	if rf, ok := ret.Get(0).(func() structs.Webhooks); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(structs.Webhooks)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WebhookRedeliver provides a mock function with given fields: name, id
+func (_m *Interface) WebhookRedeliver(name string, id string) error {
+	ret := _m.Called(name, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(name, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WithContext provides a mock function with given fields: ctx
 func (_m *Interface) WithContext(ctx context.Context) structs.Provider {
 	ret := _m.Called(ctx)
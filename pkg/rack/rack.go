@@ -132,6 +132,15 @@ func List(c *stdcli.Context) ([]Rack, error) {
 		rs = append(rs, cr)
 	}
 
+	tts, err := listTest(c)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tr := range tts {
+		rs = append(rs, tr)
+	}
+
 	sort.Slice(rs, func(i, j int) bool {
 		switch {
 		case !rs[i].Remote() && rs[j].Remote():
@@ -11,6 +11,9 @@ import (
 
 var (
 	TestClient sdk.Interface
+
+	// TestClients are listed as racks by name
+	TestClients map[string]sdk.Interface
 )
 
 type Test struct {
@@ -23,7 +26,21 @@ func LoadTest(c *stdcli.Context, name string) (*Test, error) {
 	return &Test{name: name}, nil
 }
 
+func listTest(c *stdcli.Context) ([]Test, error) {
+	ts := []Test{}
+
+	for name := range TestClients {
+		ts = append(ts, Test{name: name})
+	}
+
+	return ts, nil
+}
+
 func (t Test) Client() (sdk.Interface, error) {
+	if tc, ok := TestClients[t.name]; ok {
+		return tc, nil
+	}
+
 	return TestClient, nil
 }
 
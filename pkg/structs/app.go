@@ -1,18 +1,31 @@
 package structs
 
+import "time"
+
 const (
-	AppParamBuildLabels = "BuildLabels"
-	AppParamBuildCpu    = "BuildCpu"
-	AppParamBuildMem    = "BuildMem"
+	AppParamBuildLabels        = "BuildLabels"
+	AppParamBuildCpu           = "BuildCpu"
+	AppParamBuildMem           = "BuildMem"
+	AppParamIdleTimeout        = "IdleTimeout"
+	AppParamPromoteApprovals   = "PromoteApprovals"
+	AppParamPromoteRequireTest = "PromoteRequireTest"
+	AppParamPromoteWindow      = "PromoteWindow"
+	AppParamRetainBuilds       = "RetainBuilds"
+	AppParamRetainDays         = "RetainDays"
+	AppParamRetainReleases     = "RetainReleases"
+	AppParamScanAction         = "ScanAction"
+	AppParamScanImages         = "ScanImages"
+	AppParamScanThreshold      = "ScanThreshold"
 )
 
 type App struct {
-	Generation string `json:"generation,omitempty"`
-	Locked     bool   `json:"locked"`
-	Name       string `json:"name"`
-	Release    string `json:"release"`
-	Router     string `json:"router"`
-	Status     string `json:"status"`
+	Generation string   `json:"generation,omitempty"`
+	Idle       AppIdles `json:"idle,omitempty"`
+	Locked     bool     `json:"locked"`
+	Name       string   `json:"name"`
+	Release    string   `json:"release"`
+	Router     string   `json:"router"`
+	Status     string   `json:"status"`
 
 	Outputs    map[string]string `json:"-"`
 	Parameters map[string]string `json:"parameters"`
@@ -21,11 +34,23 @@ type App struct {
 
 type Apps []App
 
+// AppIdle is a service scaled to zero until its next request
+type AppIdle struct {
+	Service string    `json:"service"`
+	Since   time.Time `json:"since"`
+}
+
+type AppIdles []AppIdle
+
 type AppCreateOptions struct {
 	Generation *string `default:"2" flag:"generation,g" param:"generation"`
 	Timeout    *int    `flag:"timeout" param:"timeout"`
 }
 
+type AppPruneOptions struct {
+	DryRun *bool `flag:"dry-run" param:"dry-run"`
+}
+
 type AppUpdateOptions struct {
 	Lock       *bool             `param:"lock"`
 	Parameters map[string]string `param:"parameters"`
@@ -39,3 +64,21 @@ type AppConfig struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
 }
+
+// PruneObject is a build or release removed by the retention policy of an app
+type PruneObject struct {
+	Created time.Time `json:"created"`
+	Id      string    `json:"id"`
+	Images  []string  `json:"images"`
+	Type    string    `json:"type"`
+}
+
+type PruneObjects []PruneObject
+
+func (ps PruneObjects) Less(i, j int) bool {
+	if ps[i].Type != ps[j].Type {
+		return ps[i].Type < ps[j].Type
+	}
+
+	return ps[i].Created.After(ps[j].Created)
+}
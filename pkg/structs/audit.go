@@ -0,0 +1,35 @@
+package structs
+
+import "time"
+
+type AuditLog struct {
+	Id        string            `json:"id"`
+	Action    string            `json:"action"`
+	App       string            `json:"app,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Method    string            `json:"method"`
+	Params    map[string]string `json:"params,omitempty"`
+	Result    string            `json:"result"`
+	Route     string            `json:"route"`
+	Timestamp time.Time         `json:"timestamp"`
+	User      string            `json:"user"`
+}
+
+type AuditLogs []AuditLog
+
+type AuditLogListOptions struct {
+	App   *string        `flag:"app,a" query:"app"`
+	Since *time.Duration `default:"24h" flag:"since" query:"since"`
+	User  *string        `flag:"user" query:"user"`
+}
+
+func NewAuditLog() *AuditLog {
+	return &AuditLog{
+		Id:        id("A", 10),
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+func (as AuditLogs) Less(i, j int) bool {
+	return as[i].Timestamp.Before(as[j].Timestamp)
+}
@@ -1,9 +1,20 @@
 package structs
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
+const (
+	BuildScanActionBlock = "block"
+	BuildScanActionFail  = "fail"
+)
+
+// BuildScanSeverities are the severities a scanner can report, most severe
+// first
+var BuildScanSeverities = []string{"critical", "high", "medium", "low", "unknown"}
+
 type Build struct {
 	Id          string `json:"id"`
 	App         string `json:"app"`
@@ -18,14 +29,57 @@ type Build struct {
 	Repository  string `json:"repository"`
 	Status      string `json:"status"`
 
-	Started time.Time `json:"started"`
-	Ended   time.Time `json:"ended"`
+	Scan *BuildScanSummary `json:"scan,omitempty"`
+
+	Started time.Time  `json:"started"`
+	Ended   time.Time  `json:"ended"`
+	Tested  *time.Time `json:"tested,omitempty"`
 
 	Tags map[string]string `json:"-"`
 }
 
 type Builds []Build
 
+// BuildAttestation is a signed provenance statement for a build in the
+// envelope format of DSSE
+type BuildAttestation struct {
+	Payload     string                      `json:"payload"`
+	PayloadType string                      `json:"payloadType"`
+	Signatures  []BuildAttestationSignature `json:"signatures"`
+}
+
+type BuildAttestationSignature struct {
+	KeyId string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// BuildScan is the full report of an image scan, it is stored as an object
+// of the app next to the build
+type BuildScan struct {
+	Images          map[string]string    `json:"images"`
+	Scanned         time.Time            `json:"scanned"`
+	Vulnerabilities BuildVulnerabilities `json:"vulnerabilities"`
+}
+
+// BuildScanSummary is what a build keeps of its image scan
+type BuildScanSummary struct {
+	Counts  map[string]int    `json:"counts"`
+	Images  map[string]string `json:"images"`
+	Scanned time.Time         `json:"scanned"`
+}
+
+type BuildVulnerability struct {
+	Id        string `json:"id"`
+	Fixed     string `json:"fixed"`
+	Installed string `json:"installed"`
+	Package   string `json:"package"`
+	Service   string `json:"service"`
+	Severity  string `json:"severity"`
+	Title     string `json:"title"`
+}
+
+type BuildVulnerabilities []BuildVulnerability
+
 type BuildCreateOptions struct {
 	BuildArgs      *[]string `flag:"build-args" param:"build-args"`
 	Description    *string   `flag:"description,d" param:"description"`
@@ -38,6 +92,10 @@ type BuildCreateOptions struct {
 	GitSha *string `param:"git-sha"`
 }
 
+type BuildSbomOptions struct {
+	Service *string `flag:"service,s" query:"service"`
+}
+
 type BuildListOptions struct {
 	Limit *int `flag:"limit,l" query:"limit"`
 }
@@ -48,6 +106,7 @@ type BuildUpdateOptions struct {
 	Logs       *string    `param:"logs"`
 	Manifest   *string    `param:"manifest"`
 	Release    *string    `param:"release"`
+	Scan       *string    `param:"scan"`
 	Started    *time.Time `param:"started"`
 	Status     *string    `param:"status"`
 }
@@ -60,3 +119,120 @@ func NewBuild(app string) *Build {
 		Tags:   map[string]string{},
 	}
 }
+
+// Counts returns the number of findings of each severity
+func (s *BuildScan) Counts() map[string]int {
+	counts := map[string]int{}
+
+	for _, v := range s.Vulnerabilities {
+		counts[v.Severity]++
+	}
+
+	return counts
+}
+
+// Exceeding returns the findings at or above the threshold severity
+func (s *BuildScan) Exceeding(threshold string) (BuildVulnerabilities, error) {
+	rank := buildScanSeverityRank(threshold)
+	if rank < 0 {
+		return nil, fmt.Errorf("invalid scan threshold: %s", threshold)
+	}
+
+	vs := BuildVulnerabilities{}
+
+	for _, v := range s.Vulnerabilities {
+		if r := buildScanSeverityRank(v.Severity); r >= 0 && r <= rank {
+			vs = append(vs, v)
+		}
+	}
+
+	return vs, nil
+}
+
+// Summarize keeps the number of findings of each severity
+func (s *BuildScan) Summarize() *BuildScanSummary {
+	return &BuildScanSummary{
+		Counts:  s.Counts(),
+		Images:  s.Images,
+		Scanned: s.Scanned,
+	}
+}
+
+// Summary describes the findings as counts by severity
+func (s *BuildScan) Summary() string {
+	return s.Summarize().Summary()
+}
+
+// Exceeding returns the number of findings at or above the threshold severity
+func (s *BuildScanSummary) Exceeding(threshold string) (int, error) {
+	rank := buildScanSeverityRank(threshold)
+	if rank < 0 {
+		return 0, fmt.Errorf("invalid scan threshold: %s", threshold)
+	}
+
+	n := 0
+
+	for sev, count := range s.Counts {
+		if r := buildScanSeverityRank(sev); r >= 0 && r <= rank {
+			n += count
+		}
+	}
+
+	return n, nil
+}
+
+// Total returns the number of findings
+func (s *BuildScanSummary) Total() int {
+	n := 0
+
+	for _, count := range s.Counts {
+		n += count
+	}
+
+	return n
+}
+
+// Summary describes the findings as counts by severity
+func (s *BuildScanSummary) Summary() string {
+	parts := []string{}
+
+	for _, sev := range BuildScanSeverities {
+		if n := s.Counts[sev]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, sev))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "no vulnerabilities"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (vs BuildVulnerabilities) Less(i, j int) bool {
+	ri, rj := buildScanSeverityRank(vs[i].Severity), buildScanSeverityRank(vs[j].Severity)
+
+	if ri != rj {
+		return ri < rj
+	}
+
+	if vs[i].Service != vs[j].Service {
+		return vs[i].Service < vs[j].Service
+	}
+
+	if vs[i].Package != vs[j].Package {
+		return vs[i].Package < vs[j].Package
+	}
+
+	return vs[i].Id < vs[j].Id
+}
+
+func buildScanSeverityRank(severity string) int {
+	for i, s := range BuildScanSeverities {
+		if strings.EqualFold(s, severity) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -6,4 +6,73 @@ type Capacity struct {
 	ProcessCount  int64 `json:"process-count"`
 	ProcessCPU    int64 `json:"process-cpu"`
 	ProcessMemory int64 `json:"process-memory"`
+
+	// Fragmentation is the percentage of free cpu and memory that is not on
+	// the node with the most free, a high value means large processes may
+	// not fit even though the cluster has room for them
+	Fragmentation int64              `json:"fragmentation"`
+	NodeGroups    CapacityNodeGroups `json:"node-groups"`
+	Nodes         CapacityNodes      `json:"nodes"`
+}
+
+type CapacityNode struct {
+	Name              string `json:"name"`
+	AllocatableCPU    int64  `json:"allocatable-cpu"`
+	AllocatableMemory int64  `json:"allocatable-memory"`
+	AllocatablePods   int64  `json:"allocatable-pods"`
+	Group             string `json:"group"`
+	Pods              int64  `json:"pods"`
+	RequestedCPU      int64  `json:"requested-cpu"`
+	RequestedMemory   int64  `json:"requested-memory"`
+	Schedulable       bool   `json:"schedulable"`
+}
+
+type CapacityNodes []CapacityNode
+
+type CapacityNodeGroup struct {
+	Name              string `json:"name"`
+	AllocatableCPU    int64  `json:"allocatable-cpu"`
+	AllocatableMemory int64  `json:"allocatable-memory"`
+	Nodes             int64  `json:"nodes"`
+	RequestedCPU      int64  `json:"requested-cpu"`
+	RequestedMemory   int64  `json:"requested-memory"`
+}
+
+type CapacityNodeGroups []CapacityNodeGroup
+
+type CapacityPlan struct {
+	App      string               `json:"app"`
+	Fits     bool                 `json:"fits"`
+	Release  string               `json:"release"`
+	Services CapacityPlanServices `json:"services"`
+}
+
+type CapacityPlanService struct {
+	Name         string `json:"name"`
+	Count        int    `json:"count"`
+	CPU          int64  `json:"cpu"`
+	Memory       int64  `json:"memory"`
+	Pending      int    `json:"pending"`
+	Reason       string `json:"reason,omitempty"`
+	Surge        int    `json:"surge"`
+	SurgePending int    `json:"surge-pending"`
+}
+
+type CapacityPlanServices []CapacityPlanService
+
+type CapacityPlanOptions struct {
+	Release *string           `flag:"release" query:"release"`
+	Scale   map[string]string `query:"scale"`
+}
+
+func (ns CapacityNodes) Less(i, j int) bool {
+	if ns[i].Group == ns[j].Group {
+		return ns[i].Name < ns[j].Name
+	}
+
+	return ns[i].Group < ns[j].Group
+}
+
+func (gs CapacityNodeGroups) Less(i, j int) bool {
+	return gs[i].Name < gs[j].Name
 }
@@ -1,7 +1,10 @@
 package structs
 
 const (
+	ConvoxAppsParam     = "CONVOX_APPS"
+	ConvoxPolicyParam   = "CONVOX_POLICY"
 	ConvoxRoleParam     = "CONVOX_ROLE"
 	ConvoxRoleRead      = "r"
 	ConvoxRoleReadWrite = "rw"
+	ConvoxUserParam     = "CONVOX_USER"
 )
@@ -1,14 +1,27 @@
 package structs
 
-// type Event struct {
-//   Action    string            `json:"action"` // app:create, release:create, release:promote, etc.
-//   Status    string            `json:"status"` // success or error
-//   Data      map[string]string `json:"data"`   // {"rack": "example-rack", "app": "example-app", "id": "R123456789", "message": "unable to load release"}
-//   Timestamp time.Time         `json:"timestamp"`
-// }
+import "time"
+
+type Event struct {
+	Action    string            `json:"action"` // app:create, release:create, release:promote, etc.
+	Data      map[string]string `json:"data"`   // {"rack": "example-rack", "app": "example-app", "id": "R123456789", "message": "unable to load release"}
+	Status    string            `json:"status"` // success or error
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+type Events []Event
 
 type EventSendOptions struct {
 	Data   map[string]string `param:"data"`
 	Error  *string           `param:"error"`
 	Status *string           `param:"status"`
 }
+
+// EventStreamOptions filter events by comma separated patterns such as
+// release:*, the same way webhook filters do
+type EventStreamOptions struct {
+	Action *string        `flag:"action" header:"Action"`
+	App    *string        `flag:"app,a" header:"App"`
+	Follow *bool          `flag:"follow,f" header:"Follow"`
+	Since  *time.Duration `default:"1h" flag:"since" header:"Since"`
+}
@@ -6,7 +6,9 @@ type LogsOptions struct {
 	Filter   *string        `flag:"filter" header:"Filter"`
 	Follow   *bool          `header:"Follow"`
 	Prefix   *bool          `header:"Prefix"`
+	Query    *string        `flag:"query" header:"Query"`
 	Since    *time.Duration `default:"2m" flag:"since" header:"Since"`
+	Until    *time.Duration `flag:"until" header:"Until"`
 	Previous *bool          `flag:"allow-previous" header:"Previous"`
 	Tail     *int           `flag:"tail" header:"Tail"`
 }
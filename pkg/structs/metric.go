@@ -46,4 +46,5 @@ type ScraperMetricList struct {
 type ScraperMetric struct {
 	MetricPoints []MetricPoint `json:"metricPoints"`
 	MetricName   string        `json:"metricName"`
+	UIDs         []string      `json:"uids"`
 }
@@ -219,6 +219,29 @@ func (_m *MockProvider) AppMetrics(name string, opts MetricsOptions) (Metrics, e
 	return r0, r1
 }
 
+// AppPrune provides a mock function with given fields: name, opts
+func (_m *MockProvider) AppPrune(name string, opts AppPruneOptions) (PruneObjects, error) {
+	ret := _m.Called(name, opts)
+
+	var r0 PruneObjects
+	if rf, ok := ret.Get(0).(func(string, AppPruneOptions) PruneObjects); ok {
+		r0 = rf(name, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(PruneObjects)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, AppPruneOptions) error); ok {
+		r1 = rf(name, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AppUpdate provides a mock function with given fields: name, opts
 func (_m *MockProvider) AppUpdate(name string, opts AppUpdateOptions) error {
 	ret := _m.Called(name, opts)
@@ -233,6 +256,43 @@ func (_m *MockProvider) AppUpdate(name string, opts AppUpdateOptions) error {
 	return r0
 }
 
+// AuditLogAppend provides a mock function with given fields: log
+func (_m *MockProvider) AuditLogAppend(log AuditLog) error {
+	ret := _m.Called(log)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(AuditLog) error); ok {
+		r0 = rf(log)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AuditLogList provides a mock function with given fields: opts
+func (_m *MockProvider) AuditLogList(opts AuditLogListOptions) (AuditLogs, error) {
+	ret := _m.Called(opts)
+
+	var r0 AuditLogs
+	if rf, ok := ret.Get(0).(func(AuditLogListOptions) AuditLogs); ok {
+		r0 = rf(opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(AuditLogs)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(AuditLogListOptions) error); ok {
+		r1 = rf(opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BalancerList provides a mock function with given fields: app
 func (_m *MockProvider) BalancerList(app string) (Balancers, error) {
 	ret := _m.Called(app)
@@ -256,6 +316,52 @@ func (_m *MockProvider) BalancerList(app string) (Balancers, error) {
 	return r0, r1
 }
 
+// BuildAttest provides a mock function with given fields: app, id
+func (_m *MockProvider) BuildAttest(app string, id string) (*BuildAttestation, error) {
+	ret := _m.Called(app, id)
+
+	var r0 *BuildAttestation
+	if rf, ok := ret.Get(0).(func(string, string) *BuildAttestation); ok {
+		r0 = rf(app, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*BuildAttestation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(app, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BuildAttestation provides a mock function with given fields: app, id
+func (_m *MockProvider) BuildAttestation(app string, id string) (*BuildAttestation, error) {
+	ret := _m.Called(app, id)
+
+	var r0 *BuildAttestation
+	if rf, ok := ret.Get(0).(func(string, string) *BuildAttestation); ok {
+		r0 = rf(app, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*BuildAttestation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(app, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BuildCreate provides a mock function with given fields: app, url, opts
 func (_m *MockProvider) BuildCreate(app string, url string, opts BuildCreateOptions) (*Build, error) {
 	ret := _m.Called(app, url, opts)
@@ -385,6 +491,29 @@ func (_m *MockProvider) BuildLogs(app string, id string, opts LogsOptions) (io.R
 	return r0, r1
 }
 
+// BuildSbom provides a mock function with given fields: app, id, opts
+func (_m *MockProvider) BuildSbom(app string, id string, opts BuildSbomOptions) (io.ReadCloser, error) {
+	ret := _m.Called(app, id, opts)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(string, string, BuildSbomOptions) io.ReadCloser); ok {
+		r0 = rf(app, id, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, BuildSbomOptions) error); ok {
+		r1 = rf(app, id, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BuildUpdate provides a mock function with given fields: app, id, opts
 func (_m *MockProvider) BuildUpdate(app string, id string, opts BuildUpdateOptions) (*Build, error) {
 	ret := _m.Called(app, id, opts)
@@ -431,6 +560,29 @@ func (_m *MockProvider) CapacityGet() (*Capacity, error) {
 	return r0, r1
 }
 
+// CapacityPlan provides a mock function with given fields: app, opts
+func (_m *MockProvider) CapacityPlan(app string, opts CapacityPlanOptions) (*CapacityPlan, error) {
+	ret := _m.Called(app, opts)
+
+	var r0 *CapacityPlan
+	if rf, ok := ret.Get(0).(func(string, CapacityPlanOptions) *CapacityPlan); ok {
+		r0 = rf(app, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*CapacityPlan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, CapacityPlanOptions) error); ok {
+		r1 = rf(app, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CertificateApply provides a mock function with given fields: app, service, port, id
 func (_m *MockProvider) CertificateApply(app string, service string, port int, id string) error {
 	ret := _m.Called(app, service, port, id)
@@ -556,6 +708,29 @@ func (_m *MockProvider) EventSend(action string, opts EventSendOptions) error {
 	return r0
 }
 
+// EventStream provides a mock function with given fields: opts
+func (_m *MockProvider) EventStream(opts EventStreamOptions) (io.ReadCloser, error) {
+	ret := _m.Called(opts)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(EventStreamOptions) io.ReadCloser); ok {
+		r0 = rf(opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(EventStreamOptions) error); ok {
+		r1 = rf(opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FilesDelete provides a mock function with given fields: app, pid, files
 func (_m *MockProvider) FilesDelete(app string, pid string, files []string) error {
 	ret := _m.Called(app, pid, files)
@@ -843,6 +1018,89 @@ func (_m *MockProvider) ObjectStore(app string, key string, r io.Reader, opts Ob
 	return r0, r1
 }
 
+// PolicyCreate provides a mock function with given fields: name, opts
+func (_m *MockProvider) PolicyCreate(name string, opts PolicyCreateOptions) (*Policy, error) {
+	ret := _m.Called(name, opts)
+
+	var r0 *Policy
+	if rf, ok := ret.Get(0).(func(string, PolicyCreateOptions) *Policy); ok {
+		r0 = rf(name, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Policy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, PolicyCreateOptions) error); ok {
+		r1 = rf(name, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PolicyDelete provides a mock function with given fields: name
+func (_m *MockProvider) PolicyDelete(name string) error {
+	ret := _m.Called(name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PolicyGet provides a mock function with given fields: name
+func (_m *MockProvider) PolicyGet(name string) (*Policy, error) {
+	ret := _m.Called(name)
+
+	var r0 *Policy
+	if rf, ok := ret.Get(0).(func(string) *Policy); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Policy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PolicyList provides a mock function with given fields:
+func (_m *MockProvider) PolicyList() (Policies, error) {
+	ret := _m.Called()
+
+	var r0 Policies
+	if rf, ok := ret.Get(0).(func() Policies); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Policies)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ProcessExec provides a mock function with given fields: app, pid, command, rw, opts
 func (_m *MockProvider) ProcessExec(app string, pid string, command string, rw io.ReadWriter, opts ProcessExecOptions) (int, error) {
 	ret := _m.Called(app, pid, command, rw, opts)
@@ -1058,6 +1316,29 @@ func (_m *MockProvider) RegistryRemove(server string) error {
 	return r0
 }
 
+// ReleaseApprove provides a mock function with given fields: app, id, opts
+func (_m *MockProvider) ReleaseApprove(app string, id string, opts ReleaseApproveOptions) (*Release, error) {
+	ret := _m.Called(app, id, opts)
+
+	var r0 *Release
+	if rf, ok := ret.Get(0).(func(string, string, ReleaseApproveOptions) *Release); ok {
+		r0 = rf(app, id, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Release)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, ReleaseApproveOptions) error); ok {
+		r1 = rf(app, id, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ReleaseCreate provides a mock function with given fields: app, opts
 func (_m *MockProvider) ReleaseCreate(app string, opts ReleaseCreateOptions) (*Release, error) {
 	ret := _m.Called(app, opts)
@@ -1081,6 +1362,29 @@ func (_m *MockProvider) ReleaseCreate(app string, opts ReleaseCreateOptions) (*R
 	return r0, r1
 }
 
+// ReleaseDiff provides a mock function with given fields: app, id, opts
+func (_m *MockProvider) ReleaseDiff(app string, id string, opts ReleaseDiffOptions) (*ReleaseDiff, error) {
+	ret := _m.Called(app, id, opts)
+
+	var r0 *ReleaseDiff
+	if rf, ok := ret.Get(0).(func(string, string, ReleaseDiffOptions) *ReleaseDiff); ok {
+		r0 = rf(app, id, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ReleaseDiff)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, ReleaseDiffOptions) error); ok {
+		r1 = rf(app, id, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ReleaseGet provides a mock function with given fields: app, id
 func (_m *MockProvider) ReleaseGet(app string, id string) (*Release, error) {
 	ret := _m.Called(app, id)
@@ -1127,6 +1431,29 @@ func (_m *MockProvider) ReleaseList(app string, opts ReleaseListOptions) (Releas
 	return r0, r1
 }
 
+// ReleasePlan provides a mock function with given fields: app, id, opts
+func (_m *MockProvider) ReleasePlan(app string, id string, opts ReleasePromoteOptions) (*ReleasePlan, error) {
+	ret := _m.Called(app, id, opts)
+
+	var r0 *ReleasePlan
+	if rf, ok := ret.Get(0).(func(string, string, ReleasePromoteOptions) *ReleasePlan); ok {
+		r0 = rf(app, id, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ReleasePlan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, ReleasePromoteOptions) error); ok {
+		r1 = rf(app, id, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ReleasePromote provides a mock function with given fields: app, id, opts
 func (_m *MockProvider) ReleasePromote(app string, id string, opts ReleasePromoteOptions) error {
 	ret := _m.Called(app, id, opts)
@@ -1141,6 +1468,29 @@ func (_m *MockProvider) ReleasePromote(app string, id string, opts ReleasePromot
 	return r0
 }
 
+// ResourceBackupList provides a mock function with given fields: app, name
+func (_m *MockProvider) ResourceBackupList(app string, name string) (ResourceBackups, error) {
+	ret := _m.Called(app, name)
+
+	var r0 ResourceBackups
+	if rf, ok := ret.Get(0).(func(string, string) ResourceBackups); ok {
+		r0 = rf(app, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(ResourceBackups)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(app, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ResourceConsole provides a mock function with given fields: app, name, rw, opts
 func (_m *MockProvider) ResourceConsole(app string, name string, rw io.ReadWriter, opts ResourceConsoleOptions) error {
 	ret := _m.Called(app, name, rw, opts)
@@ -1238,6 +1588,20 @@ func (_m *MockProvider) ResourceList(app string) (Resources, error) {
 	return r0, r1
 }
 
+// ResourceRestore provides a mock function with given fields: app, name, backup
+func (_m *MockProvider) ResourceRestore(app string, name string, backup string) error {
+	ret := _m.Called(app, name, backup)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(app, name, backup)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ServiceList provides a mock function with given fields: app
 func (_m *MockProvider) ServiceList(app string) (Services, error) {
 	ret := _m.Called(app)
@@ -1679,6 +2043,27 @@ func (_m *MockProvider) SystemResourceUpdate(name string, opts ResourceUpdateOpt
 	return r0, r1
 }
 
+// SystemSigningKey provides a mock function with given fields:
+func (_m *MockProvider) SystemSigningKey() (string, error) {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SystemUninstall provides a mock function with given fields: name, w, opts
 func (_m *MockProvider) SystemUninstall(name string, w io.Writer, opts SystemUninstallOptions) error {
 	ret := _m.Called(name, w, opts)
@@ -1707,6 +2092,135 @@ func (_m *MockProvider) SystemUpdate(opts SystemUpdateOptions) error {
 	return r0
 }
 
+// TimerList provides a mock function with given fields: app
+func (_m *MockProvider) TimerList(app string) (Timers, error) {
+	ret := _m.Called(app)
+
+	var r0 Timers
+	if rf, ok := ret.Get(0).(func(string) Timers); ok {
+		r0 = rf(app)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Timers)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(app)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TimerRun provides a mock function with given fields: app, name
+func (_m *MockProvider) TimerRun(app string, name string) (*TimerRun, error) {
+	ret := _m.Called(app, name)
+
+	var r0 *TimerRun
+	if rf, ok := ret.Get(0).(func(string, string) *TimerRun); ok {
+		r0 = rf(app, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*TimerRun)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(app, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TimerRunList provides a mock function with given fields: app, name
+func (_m *MockProvider) TimerRunList(app string, name string) (TimerRuns, error) {
+	ret := _m.Called(app, name)
+
+	var r0 TimerRuns
+	if rf, ok := ret.Get(0).(func(string, string) TimerRuns); ok {
+		r0 = rf(app, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(TimerRuns)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(app, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WebhookDeliveryList provides a mock function with given fields: name, opts
+func (_m *MockProvider) WebhookDeliveryList(name string, opts WebhookDeliveryListOptions) (WebhookDeliveries, error) {
+	ret := _m.Called(name, opts)
+
+	var r0 WebhookDeliveries
+	if rf, ok := ret.Get(0).(func(string, WebhookDeliveryListOptions) WebhookDeliveries); ok {
+		r0 = rf(name, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(WebhookDeliveries)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, WebhookDeliveryListOptions) error); ok {
+		r1 = rf(name, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WebhookList provides a mock function with given fields:
+func (_m *MockProvider) WebhookList() (Webhooks, error) {
+	ret := _m.Called()
+
+	var r0 Webhooks
+	if rf, ok := ret.Get(0).(func() Webhooks); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Webhooks)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WebhookRedeliver provides a mock function with given fields: name, id
+func (_m *MockProvider) WebhookRedeliver(name string, id string) error {
+	ret := _m.Called(name, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(name, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WithContext provides a mock function with given fields: ctx
 func (_m *MockProvider) WithContext(ctx context.Context) Provider {
 	ret := _m.Called(ctx)
@@ -0,0 +1,174 @@
+package structs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+const (
+	PolicyVerbAdmin     = "admin"
+	PolicyVerbAll       = "*"
+	PolicyVerbDeploy    = "deploy"
+	PolicyVerbEnv       = "env"
+	PolicyVerbExec      = "exec"
+	PolicyVerbRead      = "read"
+	PolicyVerbResources = "resources"
+	PolicyVerbRun       = "run"
+	PolicyVerbScale     = "scale"
+)
+
+var PolicyVerbs = []string{
+	PolicyVerbAdmin,
+	PolicyVerbDeploy,
+	PolicyVerbEnv,
+	PolicyVerbExec,
+	PolicyVerbRead,
+	PolicyVerbResources,
+	PolicyVerbRun,
+	PolicyVerbScale,
+}
+
+// BuiltinPolicies are always available and can not be changed or removed
+var BuiltinPolicies = Policies{
+	{Name: "deployer", Builtin: true, Verbs: []string{PolicyVerbDeploy, PolicyVerbRead}},
+	{Name: "read", Builtin: true, Verbs: []string{PolicyVerbRead}},
+	{Name: "write", Builtin: true, Verbs: []string{PolicyVerbAll}},
+}
+
+type Policy struct {
+	Name    string   `json:"name"`
+	Apps    []string `json:"apps,omitempty"`
+	Builtin bool     `json:"builtin"`
+	Verbs   []string `json:"verbs"`
+}
+
+type Policies []Policy
+
+type PolicyCreateOptions struct {
+	Apps  *string `flag:"apps" param:"apps"`
+	Verbs *string `flag:"verbs" param:"verbs"`
+}
+
+func (ps Policies) Less(i, j int) bool { return ps[i].Name < ps[j].Name }
+
+func BuiltinPolicy(name string) (*Policy, bool) {
+	for _, p := range BuiltinPolicies {
+		if p.Name == name {
+			pp := p
+			return &pp, true
+		}
+	}
+
+	return nil, false
+}
+
+func NewPolicy(name string, opts PolicyCreateOptions) (*Policy, error) {
+	if name == "" {
+		return nil, fmt.Errorf("name required")
+	}
+
+	if _, ok := BuiltinPolicy(name); ok {
+		return nil, fmt.Errorf("can not override builtin policy: %s", name)
+	}
+
+	p := &Policy{
+		Name:  name,
+		Apps:  splitList(opts.Apps),
+		Verbs: splitList(opts.Verbs),
+	}
+
+	if len(p.Verbs) == 0 {
+		return nil, fmt.Errorf("verbs required")
+	}
+
+	for _, v := range p.Verbs {
+		if v == PolicyVerbAll {
+			continue
+		}
+
+		if i := sort.SearchStrings(PolicyVerbs, v); i == len(PolicyVerbs) || PolicyVerbs[i] != v {
+			return nil, fmt.Errorf("unknown verb: %s", v)
+		}
+	}
+
+	for _, a := range p.Apps {
+		if _, err := glob.Compile(a); err != nil {
+			return nil, fmt.Errorf("invalid app pattern: %s", a)
+		}
+	}
+
+	return p, nil
+}
+
+// Allows reports whether the policy grants verb against app. Policies scoped
+// to apps never allow anything outside of an app.
+func (p *Policy) Allows(verb, app string) bool {
+	if !p.AllowsVerb(verb) {
+		return false
+	}
+
+	if app == "" {
+		return len(p.Apps) == 0
+	}
+
+	return MatchApps(p.Apps, app)
+}
+
+// AllowsVerb reports whether the policy grants verb regardless of app
+func (p *Policy) AllowsVerb(verb string) bool {
+	for _, v := range p.Verbs {
+		if v == PolicyVerbAll || v == verb {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchApps reports whether app matches any of the patterns, an empty list
+// matches every app
+func MatchApps(patterns []string, app string) bool {
+	return matchGlobs(patterns, app)
+}
+
+func matchGlobs(patterns []string, s string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern)
+		if err != nil {
+			continue
+		}
+
+		if g.Match(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(s *string) []string {
+	if s == nil {
+		return nil
+	}
+
+	items := []string{}
+
+	for _, item := range strings.Split(*s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	return items
+}
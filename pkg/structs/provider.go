@@ -15,25 +15,33 @@ type Provider interface {
 	AppCreate(name string, opts AppCreateOptions) (*App, error)
 	AppConfigGet(app, name string) (*AppConfig, error)
 	AppConfigList(app string) ([]AppConfig, error)
-	AppConfigSet(app, name, valueBase64 string) error
+	AppConfigSet(app, name, value string) error
 	AppGet(name string) (*App, error)
 	AppDelete(name string) error
 	AppList() (Apps, error)
 	AppLogs(name string, opts LogsOptions) (io.ReadCloser, error)
 	AppMetrics(name string, opts MetricsOptions) (Metrics, error)
+	AppPrune(name string, opts AppPruneOptions) (PruneObjects, error)
 	AppUpdate(name string, opts AppUpdateOptions) error
 
+	AuditLogAppend(log AuditLog) error
+	AuditLogList(opts AuditLogListOptions) (AuditLogs, error)
+
 	BalancerList(app string) (Balancers, error)
 
+	BuildAttest(app, id string) (*BuildAttestation, error)
+	BuildAttestation(app, id string) (*BuildAttestation, error)
 	BuildCreate(app, url string, opts BuildCreateOptions) (*Build, error)
 	BuildExport(app, id string, w io.Writer) error
 	BuildGet(app, id string) (*Build, error)
 	BuildImport(app string, r io.Reader) (*Build, error)
 	BuildLogs(app, id string, opts LogsOptions) (io.ReadCloser, error)
 	BuildList(app string, opts BuildListOptions) (Builds, error)
+	BuildSbom(app, id string, opts BuildSbomOptions) (io.ReadCloser, error)
 	BuildUpdate(app, id string, opts BuildUpdateOptions) (*Build, error)
 
 	CapacityGet() (*Capacity, error)
+	CapacityPlan(app string, opts CapacityPlanOptions) (*CapacityPlan, error)
 
 	CertificateApply(app, service string, port int, id string) error
 	CertificateCreate(pub, key string, opts CertificateCreateOptions) (*Certificate, error)
@@ -46,6 +54,7 @@ type Provider interface {
 	LetsEncryptConfigApply(config LetsEncryptConfig) error
 
 	EventSend(action string, opts EventSendOptions) error
+	EventStream(opts EventStreamOptions) (io.ReadCloser, error)
 
 	FilesDelete(app, pid string, files []string) error
 	FilesDownload(app, pid string, file string) (io.Reader, error)
@@ -62,6 +71,11 @@ type Provider interface {
 	ObjectList(app, prefix string) ([]string, error)
 	ObjectStore(app, key string, r io.Reader, opts ObjectStoreOptions) (*Object, error)
 
+	PolicyCreate(name string, opts PolicyCreateOptions) (*Policy, error)
+	PolicyDelete(name string) error
+	PolicyGet(name string) (*Policy, error)
+	PolicyList() (Policies, error)
+
 	ProcessExec(app, pid, command string, rw io.ReadWriter, opts ProcessExecOptions) (int, error)
 	ProcessGet(app, pid string) (*Process, error)
 	ProcessList(app string, opts ProcessListOptions) (Processes, error)
@@ -76,16 +90,21 @@ type Provider interface {
 	RegistryProxy(ctx *stdapi.Context) error
 	RegistryRemove(server string) error
 
+	ReleaseApprove(app, id string, opts ReleaseApproveOptions) (*Release, error)
 	ReleaseCreate(app string, opts ReleaseCreateOptions) (*Release, error)
+	ReleaseDiff(app, id string, opts ReleaseDiffOptions) (*ReleaseDiff, error)
 	ReleaseGet(app, id string) (*Release, error)
 	ReleaseList(app string, opts ReleaseListOptions) (Releases, error)
+	ReleasePlan(app, id string, opts ReleasePromoteOptions) (*ReleasePlan, error)
 	ReleasePromote(app, id string, opts ReleasePromoteOptions) error
 
+	ResourceBackupList(app, name string) (ResourceBackups, error)
 	ResourceConsole(app, name string, rw io.ReadWriter, opts ResourceConsoleOptions) error
 	ResourceExport(app, name string) (io.ReadCloser, error)
 	ResourceGet(app, name string) (*Resource, error)
 	ResourceImport(app, name string, r io.Reader) error
 	ResourceList(app string) (Resources, error)
+	ResourceRestore(app, name, backup string) error
 
 	ServiceList(app string) (Services, error)
 	ServiceRestart(app, name string) error
@@ -96,6 +115,7 @@ type Provider interface {
 	SystemInstall(w io.Writer, opts SystemInstallOptions) (string, error)
 	SystemJwtSignKey() (string, error)
 	SystemJwtSignKeyRotate() (string, error)
+	SystemSigningKey() (string, error)
 	SystemLogs(opts LogsOptions) (io.ReadCloser, error)
 	SystemMetrics(opts MetricsOptions) (Metrics, error)
 	SystemProcesses(opts SystemProcessesOptions) (Processes, error)
@@ -112,6 +132,14 @@ type Provider interface {
 	SystemResourceUnlink(name, app string) (*Resource, error)
 	SystemResourceUpdate(name string, opts ResourceUpdateOptions) (*Resource, error)
 
+	TimerList(app string) (Timers, error)
+	TimerRun(app, name string) (*TimerRun, error)
+	TimerRunList(app, name string) (TimerRuns, error)
+
+	WebhookDeliveryList(name string, opts WebhookDeliveryListOptions) (WebhookDeliveries, error)
+	WebhookList() (Webhooks, error)
+	WebhookRedeliver(name, id string) error
+
 	WithContext(ctx context.Context) Provider
 
 	Workers() error
@@ -12,10 +12,45 @@ type Release struct {
 	Description string `json:"description"`
 
 	Created time.Time `json:"created"`
+
+	Approvals ReleaseApprovals `json:"approvals,omitempty"`
 }
 
 type Releases []Release
 
+type ReleaseApproval struct {
+	User string    `json:"user"`
+	Time time.Time `json:"time"`
+}
+
+type ReleaseApprovals []ReleaseApproval
+
+const (
+	ReleaseDiffAdded   = "added"
+	ReleaseDiffChanged = "changed"
+	ReleaseDiffRemoved = "removed"
+)
+
+// ReleaseDiff describes what changes when an app moves from one release to
+// another, env values are never included
+type ReleaseDiff struct {
+	App     string             `json:"app"`
+	From    string             `json:"from"`
+	To      string             `json:"to"`
+	Changes ReleaseDiffChanges `json:"changes"`
+}
+
+type ReleaseDiffChange struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name,omitempty"`
+	Field  string `json:"field,omitempty"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+type ReleaseDiffChanges []ReleaseDiffChange
+
 type ReleaseCreateOptions struct {
 	Build         *string `param:"build"`
 	Description   *string `param:"description"`
@@ -31,17 +66,62 @@ type ReleaseCreateFromOptions struct {
 	Promote               *bool   `flag:"promote"`
 }
 
+const (
+	ReleasePlanCreate = "create"
+	ReleasePlanDelete = "delete"
+	ReleasePlanUpdate = "update"
+)
+
+// ReleasePlan is what promoting a release would create, update and delete in
+// the app namespace, nothing is applied to compute it
+type ReleasePlan struct {
+	App        string                `json:"app"`
+	Release    string                `json:"release"`
+	Operations ReleasePlanOperations `json:"operations"`
+	Warnings   []string              `json:"warnings"`
+}
+
+type ReleasePlanOperation struct {
+	Action    string             `json:"action"`
+	Kind      string             `json:"kind"`
+	Name      string             `json:"name"`
+	Namespace string             `json:"namespace,omitempty"`
+	Changes   ReleasePlanChanges `json:"changes,omitempty"`
+}
+
+type ReleasePlanOperations []ReleasePlanOperation
+
+type ReleasePlanChange struct {
+	Field string `json:"field"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+type ReleasePlanChanges []ReleasePlanChange
+
+type ReleaseApproveOptions struct {
+	User *string `header:"User"`
+}
+
+type ReleaseDiffOptions struct {
+	From *string `flag:"from" query:"from"`
+}
+
 type ReleaseListOptions struct {
 	Limit *int `flag:"limit,l" query:"limit"`
 }
 
 type ReleasePromoteOptions struct {
-	Development *bool `param:"development"`
-	Force       *bool `param:"force"`
-	Idle        *bool `param:"idle"`
-	Min         *int  `param:"min"`
-	Max         *int  `param:"max"`
-	Timeout     *int  `param:"timeout"`
+	Canary      *int    `param:"canary"`
+	Development *bool   `param:"development"`
+	Force       *bool   `param:"force"`
+	Idle        *bool   `param:"idle"`
+	Interval    *string `param:"interval"`
+	Min         *int    `param:"min"`
+	Max         *int    `param:"max"`
+	Step        *int    `param:"step"`
+	Timeout     *int    `param:"timeout"`
+	User        *string `header:"User"`
 }
 
 func NewRelease(app string) *Release {
@@ -6,28 +6,40 @@ func init() {
 	routes["Initialize"] = ""
 	routes["Start"] = ""
 	routes["AppCancel"] = "POST /apps/{name}/cancel"
+	routes["AppConfigGet"] = "GET /apps/{app}/configs/{name}"
+	routes["AppConfigList"] = "GET /apps/{app}/configs"
+	routes["AppConfigSet"] = "PUT /apps/{app}/configs/{name}"
 	routes["AppCreate"] = "POST /apps"
 	routes["AppDelete"] = "DELETE /apps/{name}"
 	routes["AppGet"] = "GET /apps/{name}"
 	routes["AppList"] = "GET /apps"
 	routes["AppLogs"] = "SOCKET /apps/{name}/logs"
 	routes["AppMetrics"] = "GET /apps/{name}/metrics"
+	routes["AppPrune"] = "POST /apps/{name}/prune"
 	routes["AppUpdate"] = "PUT /apps/{name}"
+	routes["AuditLogAppend"] = ""
+	routes["AuditLogList"] = "GET /system/audit"
 	routes["BalancerList"] = "GET /apps/{app}/balancers"
+	routes["BuildAttest"] = "POST /apps/{app}/builds/{id}/attestation"
+	routes["BuildAttestation"] = "GET /apps/{app}/builds/{id}/attestation"
 	routes["BuildCreate"] = "POST /apps/{app}/builds"
 	routes["BuildExport"] = "GET /apps/{app}/builds/{id}.tgz"
 	routes["BuildGet"] = "GET /apps/{app}/builds/{id}"
 	routes["BuildImport"] = "POST /apps/{app}/builds/import"
 	routes["BuildLogs"] = "SOCKET /apps/{app}/builds/{id}/logs"
 	routes["BuildList"] = "GET /apps/{app}/builds"
+	routes["BuildSbom"] = "GET /apps/{app}/builds/{id}/sbom"
 	routes["BuildUpdate"] = "PUT /apps/{app}/builds/{id}"
 	routes["CapacityGet"] = "GET /system/capacity"
+	routes["CapacityPlan"] = "GET /apps/{app}/capacity"
 	routes["CertificateApply"] = "PUT /apps/{app}/ssl/{service}/{port}"
 	routes["CertificateCreate"] = "POST /certificates"
 	routes["CertificateDelete"] = "DELETE /certificates/{id}"
 	routes["CertificateGenerate"] = "POST /certificates/generate"
 	routes["CertificateList"] = "GET /certificates"
+	routes["CertificateRenew"] = "POST /certificates/{id}/renew"
 	routes["EventSend"] = "POST /events"
+	routes["EventStream"] = "SOCKET /events"
 	routes["FilesDelete"] = "DELETE /apps/{app}/processes/{pid}/files"
 	routes["FilesDownload"] = "GET /apps/{app}/processes/{pid}/files"
 	routes["FilesUpload"] = "POST /apps/{app}/processes/{pid}/files"
@@ -35,11 +47,17 @@ func init() {
 	routes["InstanceList"] = "GET /instances"
 	routes["InstanceShell"] = "SOCKET /instances/{id}/shell"
 	routes["InstanceTerminate"] = "DELETE /instances/{id}"
+	routes["LetsEncryptConfigApply"] = "PUT /letsencrypt/config"
+	routes["LetsEncryptConfigGet"] = "GET /letsencrypt/config"
 	routes["ObjectDelete"] = "DELETE /apps/{app}/objects/{key:.*}"
 	routes["ObjectExists"] = "HEAD /apps/{app}/objects/{key:.*}"
 	routes["ObjectFetch"] = "GET /apps/{app}/objects/{key:.*}"
 	routes["ObjectList"] = "GET /apps/{app}/objects"
 	routes["ObjectStore"] = "POST /apps/{app}/objects/{key:.*}"
+	routes["PolicyCreate"] = "POST /system/policies"
+	routes["PolicyDelete"] = "DELETE /system/policies/{name}"
+	routes["PolicyGet"] = "GET /system/policies/{name}"
+	routes["PolicyList"] = "GET /system/policies"
 	routes["ProcessExec"] = "SOCKET /apps/{app}/processes/{pid}/exec"
 	routes["ProcessGet"] = "GET /apps/{app}/processes/{pid}"
 	routes["ProcessList"] = "GET /apps/{app}/processes"
@@ -47,28 +65,36 @@ func init() {
 	routes["ProcessRun"] = "POST /apps/{app}/services/{service}/processes"
 	routes["ProcessStop"] = "DELETE /apps/{app}/processes/{pid}"
 	routes["Proxy"] = "SOCKET /proxy/{host}/{port}"
+	routes["ReleaseApprove"] = "POST /apps/{app}/releases/{id}/approve"
 	routes["ReleaseCreate"] = "POST /apps/{app}/releases"
+	routes["ReleaseDiff"] = "GET /apps/{app}/releases/{id}/diff"
 	routes["ReleaseGet"] = "GET /apps/{app}/releases/{id}"
 	routes["ReleaseList"] = "GET /apps/{app}/releases"
+	routes["ReleasePlan"] = "POST /apps/{app}/releases/{id}/plan"
 	routes["ReleasePromote"] = "POST /apps/{app}/releases/{id}/promote"
 	routes["RegistryAdd"] = "POST /registries"
 	routes["RegistryList"] = "GET /registries"
 	routes["RegistryProxy"] = "ANY /v2/{path:.*}"
 	routes["RegistryRemove"] = "DELETE /registries/{server:.*}"
+	routes["ResourceBackupList"] = "GET /apps/{app}/resources/{name}/backups"
 	routes["ResourceConsole"] = "SOCKET /apps/{app}/resources/{name}/console"
 	routes["ResourceExport"] = "GET /apps/{app}/resources/{name}/data"
 	routes["ResourceGet"] = "GET /apps/{app}/resources/{name}"
 	routes["ResourceImport"] = "PUT /apps/{app}/resources/{name}/data"
 	routes["ResourceList"] = "GET /apps/{app}/resources"
+	routes["ResourceRestore"] = "POST /apps/{app}/resources/{name}/backups/{backup}/restore"
 	routes["ServiceList"] = "GET /apps/{app}/services"
+	routes["ServiceLogs"] = "SOCKET /apps/{app}/services/{name}/logs"
 	routes["ServiceRestart"] = "POST /apps/{app}/services/{name}/restart"
 	routes["ServiceUpdate"] = "PUT /apps/{app}/services/{name}"
 	routes["SystemGet"] = "GET /system"
 	routes["SystemLogs"] = "SOCKET /system/logs"
 	routes["SystemInstall"] = ""
+	routes["SystemJwtSignKeyRotate"] = "PUT /system/jwt/rotate"
 	routes["SystemMetrics"] = "GET /system/metrics"
 	routes["SystemProcesses"] = "GET /system/processes"
 	routes["SystemReleases"] = "GET /system/releases"
+	routes["SystemSigningKey"] = "GET /system/signing-key"
 	routes["SystemResourceCreate"] = "POST /resources"
 	routes["SystemResourceDelete"] = "DELETE /resources/{name}"
 	routes["SystemResourceGet"] = "GET /resources/{name}"
@@ -79,6 +105,12 @@ func init() {
 	routes["SystemResourceUpdate"] = "PUT /resources/{name}"
 	routes["SystemUninstall"] = ""
 	routes["SystemUpdate"] = "PUT /system"
+	routes["TimerList"] = "GET /apps/{app}/timers"
+	routes["TimerRun"] = "POST /apps/{app}/timers/{name}/runs"
+	routes["TimerRunList"] = "GET /apps/{app}/timers/{name}/runs"
+	routes["WebhookDeliveryList"] = "GET /system/webhooks/{name}/deliveries"
+	routes["WebhookList"] = "GET /system/webhooks"
+	routes["WebhookRedeliver"] = "POST /system/webhooks/{name}/deliveries/{id}/redeliver"
 	routes["Workers"] = ""
 }
 
@@ -43,8 +43,11 @@ type SystemUpdateOptions struct {
 }
 
 type SystemJwtOptions struct {
+	Apps           *string `param:"apps"`
 	Role           *string `param:"role"`
 	DurationInHour *string `param:"durationInHour"`
+	Ttl            *string `param:"ttl"`
+	User           *string `param:"user"`
 }
 
 type SystemJwt struct {
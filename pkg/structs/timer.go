@@ -0,0 +1,43 @@
+package structs
+
+import "time"
+
+const (
+	TimerRunFailed    = "failed"
+	TimerRunRunning   = "running"
+	TimerRunSucceeded = "succeeded"
+)
+
+type Timer struct {
+	Name string `json:"name"`
+
+	Command     string    `json:"command"`
+	Concurrency string    `json:"concurrency"`
+	LastRun     time.Time `json:"last-run"`
+	Release     string    `json:"release"`
+	Schedule    string    `json:"schedule"`
+	Service     string    `json:"service"`
+	Suspended   bool      `json:"suspended"`
+}
+
+type Timers []Timer
+
+// TimerRun is a single job started by a timer, either on its schedule or on
+// demand
+type TimerRun struct {
+	Id string `json:"id"`
+
+	Ended   time.Time `json:"ended"`
+	Error   string    `json:"error,omitempty"`
+	Manual  bool      `json:"manual"`
+	Release string    `json:"release"`
+	Started time.Time `json:"started"`
+	Status  string    `json:"status"`
+	Timer   string    `json:"timer"`
+}
+
+type TimerRuns []TimerRun
+
+func (ts Timers) Less(i, j int) bool { return ts[i].Name < ts[j].Name }
+
+func (rs TimerRuns) Less(i, j int) bool { return rs[i].Started.After(rs[j].Started) }
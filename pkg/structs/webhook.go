@@ -0,0 +1,77 @@
+package structs
+
+import "time"
+
+const (
+	WebhookDeliveryDead      = "dead"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryPending   = "pending"
+
+	WebhookKindPagerDuty = "pagerduty"
+	WebhookKindSlack     = "slack"
+	WebhookKindTeams     = "teams"
+	WebhookKindWebhook   = "webhook"
+)
+
+// Webhook receives rack events, either as raw json or formatted for a
+// notification service such as slack
+type Webhook struct {
+	Name   string   `json:"name"`
+	Apps   []string `json:"apps,omitempty"`
+	Events []string `json:"events,omitempty"`
+	Kind   string   `json:"kind"`
+	Signed bool     `json:"signed"`
+	Url    string   `json:"url"`
+}
+
+type Webhooks []Webhook
+
+// WebhookDelivery is a single event queued for a webhook, deliveries that run
+// out of attempts are kept as dead until they are redelivered
+type WebhookDelivery struct {
+	Id        string    `json:"id"`
+	Attempts  int       `json:"attempts"`
+	Created   time.Time `json:"created"`
+	Delivered time.Time `json:"delivered"`
+	Error     string    `json:"error,omitempty"`
+	Event     string    `json:"event"`
+	Next      time.Time `json:"next"`
+	Payload   string    `json:"payload"`
+	Status    string    `json:"status"`
+	Webhook   string    `json:"webhook"`
+}
+
+type WebhookDeliveries []WebhookDelivery
+
+type WebhookDeliveryListOptions struct {
+	Status *string `flag:"status" query:"status"`
+}
+
+func NewWebhookDelivery(webhook, event string, payload []byte) *WebhookDelivery {
+	now := time.Now().UTC()
+
+	return &WebhookDelivery{
+		Id:      id("D", 10),
+		Created: now,
+		Event:   event,
+		Next:    now,
+		Payload: string(payload),
+		Status:  WebhookDeliveryPending,
+		Webhook: webhook,
+	}
+}
+
+// Accepts reports whether the webhook wants an event for an app, a webhook
+// without filters receives every event. Webhooks filtered by app do not
+// receive events that are not about an app.
+func (w Webhook) Accepts(event, app string) bool {
+	if len(w.Apps) > 0 && app == "" {
+		return false
+	}
+
+	return matchGlobs(w.Events, event) && matchGlobs(w.Apps, app)
+}
+
+func (ws Webhooks) Less(i, j int) bool { return ws[i].Name < ws[j].Name }
+
+func (ds WebhookDeliveries) Less(i, j int) bool { return ds[i].Created.After(ds[j].Created) }
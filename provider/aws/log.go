@@ -12,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/logquery"
 	"github.com/convox/convox/pkg/structs"
 )
 
@@ -176,6 +177,12 @@ func (p *Provider) nextSequenceToken(group, stream string) (string, error) {
 func (p *Provider) streamLogs(ctx context.Context, w io.WriteCloser, group, stream string, opts structs.LogsOptions) error {
 	defer w.Close()
 
+	q, err := logquery.Parse(common.DefaultString(opts.Query, ""))
+	if err != nil {
+		fmt.Fprintf(w, "error: %v\n", err)
+		return err
+	}
+
 	req := &cloudwatchlogs.FilterLogEventsInput{
 		LogGroupName: aws.String(group),
 	}
@@ -193,6 +200,11 @@ func (p *Provider) streamLogs(ctx context.Context, w io.WriteCloser, group, stre
 		req.StartTime = aws.Int64(start)
 	}
 
+	if opts.Until != nil {
+		req.EndTime = aws.Int64(time.Now().UTC().Add((*opts.Until)*-1).UnixNano() / int64(time.Millisecond))
+		follow = false
+	}
+
 	if stream != "" {
 		req.LogStreamNames = []*string{aws.String(stream)}
 	} else {
@@ -226,7 +238,9 @@ func (p *Provider) streamLogs(ctx context.Context, w io.WriteCloser, group, stre
 
 			for _, e := range res.Events {
 				if !seen[*e.EventId] {
-					es = append(es, e)
+					if q.Match(aws.StringValue(e.Message), aws.StringValue(e.LogStreamName)) {
+						es = append(es, e)
+					}
 					seen[*e.EventId] = true
 				}
 
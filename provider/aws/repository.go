@@ -1,6 +1,11 @@
 package aws
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+)
 
 func (p *Provider) RepositoryAuth(app string) (string, string, error) {
 	host, _, err := p.RepositoryHost(app)
@@ -23,3 +28,38 @@ func (p *Provider) RepositoryHost(app string) (string, bool, error) {
 func (p *Provider) RepositoryPrefix() string {
 	return fmt.Sprintf("%s/", p.Name)
 }
+
+// RepositoryUntag removes tags from the app repository, ecr deletes an image
+// once its last tag is gone
+func (p *Provider) RepositoryUntag(app string, tags []string) error {
+	ids := []*ecr.ImageIdentifier{}
+
+	for _, t := range tags {
+		ids = append(ids, &ecr.ImageIdentifier{ImageTag: aws.String(t)})
+	}
+
+	// batch delete accepts at most 100 images per call
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > 100 {
+			n = 100
+		}
+
+		_, err := p.ECR.BatchDeleteImage(&ecr.BatchDeleteImageInput{
+			ImageIds:       ids[0:n],
+			RepositoryName: aws.String(fmt.Sprintf("%s%s", p.RepositoryPrefix(), app)),
+		})
+		if err != nil {
+			switch awsErrorCode(err) {
+			case "RepositoryNotFoundException":
+				return nil
+			default:
+				return err
+			}
+		}
+
+		ids = ids[n:]
+	}
+
+	return nil
+}
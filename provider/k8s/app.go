@@ -186,7 +186,17 @@ func (p *Provider) AppGet(name string) (*structs.App, error) {
 }
 
 func (p *Provider) AppIdles(name string) (bool, error) {
-	return false, nil
+	a, err := p.AppGet(name)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	timeout, err := appIdleTimeout(a)
+	if err != nil {
+		return false, err
+	}
+
+	return timeout > 0, nil
 }
 
 func (p *Provider) AppList() (structs.Apps, error) {
@@ -213,10 +223,6 @@ func (p *Provider) AppLogs(name string, opts structs.LogsOptions) (io.ReadCloser
 	return nil, errors.WithStack(fmt.Errorf("unimplemented"))
 }
 
-func (p *Provider) AppMetrics(name string, opts structs.MetricsOptions) (structs.Metrics, error) {
-	return nil, errors.WithStack(fmt.Errorf("unimplemented"))
-}
-
 func (p *Provider) AppNamespace(app string) string {
 	switch app {
 	case "system":
@@ -241,9 +247,19 @@ func (p *Provider) NamespaceApp(namespace string) (string, error) {
 
 func (p *Provider) AppParameters() map[string]string {
 	return map[string]string{
-		structs.AppParamBuildCpu:    "",
-		structs.AppParamBuildMem:    "",
-		structs.AppParamBuildLabels: "",
+		structs.AppParamBuildCpu:           "",
+		structs.AppParamBuildMem:           "",
+		structs.AppParamBuildLabels:        "",
+		structs.AppParamIdleTimeout:        "",
+		structs.AppParamPromoteApprovals:   "",
+		structs.AppParamPromoteRequireTest: "",
+		structs.AppParamPromoteWindow:      "",
+		structs.AppParamRetainBuilds:       "",
+		structs.AppParamRetainDays:         "",
+		structs.AppParamRetainReleases:     "",
+		structs.AppParamScanAction:         "",
+		structs.AppParamScanImages:         "",
+		structs.AppParamScanThreshold:      "",
 	}
 }
 
@@ -294,6 +310,7 @@ func (p *Provider) appFromNamespace(ns ac.Namespace) (*structs.App, error) {
 
 	a := &structs.App{
 		Generation: "3",
+		Idle:       idleServices(&ns),
 		Locked:     ns.Annotations["convox.com/lock"] == "true",
 		Name:       name,
 		Release:    release,
@@ -350,6 +367,7 @@ func (p *Provider) appFromNamespaceOnly(ns ac.Namespace) (*structs.App, error) {
 
 	a := &structs.App{
 		Generation: "3",
+		Idle:       idleServices(&ns),
 		Locked:     ns.Annotations["convox.com/lock"] == "true",
 		Name:       name,
 		Release:    ns.Annotations["convox.com/app-release"],
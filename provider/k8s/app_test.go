@@ -250,14 +250,6 @@ func TestAppLogs(t *testing.T) {
 	})
 }
 
-func TestAppMetrics(t *testing.T) {
-	testProvider(t, func(p *k8s.Provider) {
-		ms, err := p.AppMetrics("app1", structs.MetricsOptions{})
-		require.EqualError(t, err, "unimplemented")
-		require.Nil(t, ms)
-	})
-}
-
 func TestAppNamespace(t *testing.T) {
 	testProvider(t, func(p *k8s.Provider) {
 		ns := p.AppNamespace("app1")
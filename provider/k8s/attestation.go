@@ -0,0 +1,320 @@
+package k8s
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/convox/convox/pkg/attest"
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+)
+
+// repositoryManifestTypes are the manifests accepted when looking up the
+// digest of an image, the digest of an index covers every platform
+var repositoryManifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// repositoryRootCAs verify the certificate of the app repository, nil uses
+// the system roots
+var repositoryRootCAs *x509.CertPool
+
+// BuildAttest signs a provenance statement covering the images a build pushed
+// to the app repository and the sboms the build process stored for them. A
+// build is attested once, by its build process while it is running.
+func (p *Provider) BuildAttest(app, id string) (*structs.BuildAttestation, error) {
+	b, err := p.BuildGet(app, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if b.Status != "running" {
+		return nil, errors.WithStack(fmt.Errorf("can not attest build %s with status: %s", b.Id, b.Status))
+	}
+
+	objects, err := p.storage().ObjectList(app, buildObjectPrefix(b.Id))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, k := range objects {
+		if k == buildAttestationKey(b.Id) {
+			return nil, errors.WithStack(fmt.Errorf("build %s is already attested", b.Id))
+		}
+	}
+
+	keys, err := p.buildSboms(app, b.Id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.WithStack(fmt.Errorf("no sbom found for build: %s", b.Id))
+	}
+
+	manifest, err := attest.Digest(strings.NewReader(b.Manifest))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	s := attest.NewStatement(attest.Predicate{
+		App:      app,
+		Build:    b.Id,
+		Created:  time.Now().UTC(),
+		GitSha:   b.GitSha,
+		Manifest: manifest,
+		Rack:     p.Name,
+	})
+
+	tags := buildImageTags(*b)
+
+	sort.Strings(tags)
+
+	// the digests come from the repository rather than the build so that a
+	// statement can only cover images that were actually pushed
+	for _, tag := range tags {
+		digest, err := p.repositoryDigest(app, tag)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		s.Subject = append(s.Subject, attest.Subject{
+			Name:   fmt.Sprintf("image/%s", strings.Split(tag, ".")[0]),
+			Digest: map[string]string{"sha256": strings.TrimPrefix(digest, "sha256:")},
+		})
+	}
+
+	for _, k := range keys {
+		digest, err := p.objectDigest(app, k)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		s.Subject = append(s.Subject, attest.Subject{
+			Name:   strings.TrimPrefix(k, buildObjectPrefix(b.Id)),
+			Digest: map[string]string{"sha256": digest},
+		})
+	}
+
+	key, err := p.signingKey()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	a, err := attest.Sign(key, s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if _, err := p.storage().ObjectStore(app, buildAttestationKey(b.Id), bytes.NewReader(data), structs.ObjectStoreOptions{}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return a, nil
+}
+
+func (p *Provider) BuildAttestation(app, id string) (*structs.BuildAttestation, error) {
+	b, err := p.BuildGet(app, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	keys, err := p.storage().ObjectList(app, buildObjectPrefix(b.Id))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	found := false
+
+	for _, k := range keys {
+		if k == buildAttestationKey(b.Id) {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, errors.WithStack(fmt.Errorf("no attestation found for build: %s", b.Id))
+	}
+
+	r, err := p.storage().ObjectFetch(app, buildAttestationKey(b.Id))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer r.Close()
+
+	var a structs.BuildAttestation
+
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &a, nil
+}
+
+func (p *Provider) BuildSbom(app, id string, opts structs.BuildSbomOptions) (io.ReadCloser, error) {
+	b, err := p.BuildGet(app, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	keys, err := p.buildSboms(app, b.Id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	services := []string{}
+
+	for _, k := range keys {
+		services = append(services, strings.TrimSuffix(path.Base(k), ".json"))
+	}
+
+	var service string
+
+	switch {
+	case len(services) == 0:
+		return nil, errors.WithStack(fmt.Errorf("no sbom found for build: %s", b.Id))
+	case opts.Service != nil:
+		service = *opts.Service
+	case len(services) == 1:
+		service = services[0]
+	default:
+		return nil, errors.WithStack(fmt.Errorf("build %s has an sbom for each of %s, specify one with --service", b.Id, strings.Join(services, ", ")))
+	}
+
+	key := buildSbomKey(b.Id, service)
+
+	for _, k := range keys {
+		if k == key {
+			return p.storage().ObjectFetch(app, key)
+		}
+	}
+
+	return nil, errors.WithStack(fmt.Errorf("no sbom found for service: %s", service))
+}
+
+// buildSboms returns the object keys of the sboms of a build
+func (p *Provider) buildSboms(app, id string) ([]string, error) {
+	keys, err := p.storage().ObjectList(app, buildSbomPrefix(id))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sboms := []string{}
+
+	for _, k := range keys {
+		if strings.HasSuffix(k, ".json") {
+			sboms = append(sboms, k)
+		}
+	}
+
+	sort.Strings(sboms)
+
+	return sboms, nil
+}
+
+func (p *Provider) objectDigest(app, key string) (string, error) {
+	r, err := p.storage().ObjectFetch(app, key)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer r.Close()
+
+	return attest.Digest(r)
+}
+
+// repositoryDigest returns the digest of the manifest a tag of the app
+// repository points at
+func (p *Provider) repositoryDigest(app, tag string) (string, error) {
+	repo, _, err := p.Engine.RepositoryHost(app)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	user, pass, err := p.Engine.RepositoryAuth(app)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 {
+		return "", errors.WithStack(fmt.Errorf("invalid repository: %s", repo))
+	}
+
+	req, err := http.NewRequest("HEAD", fmt.Sprintf("https://%s/v2/%s/manifests/%s", parts[0], parts[1], tag), nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	req.Header.Set("Accept", strings.Join(repositoryManifestTypes, ", "))
+	req.SetBasicAuth(user, pass)
+
+	t := common.NewDefaultTransport()
+	t.TLSClientConfig = &tls.Config{RootCAs: repositoryRootCAs}
+
+	// the rack registry serves a self-signed certificate from inside the cluster
+	if p.repositoryInternal(parts[0]) {
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // skipcq: GSC-G402
+	}
+
+	res, err := (&http.Client{Transport: t, Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.WithStack(fmt.Errorf("could not find image %s:%s: %s", repo, tag, res.Status))
+	}
+
+	digest := res.Header.Get("Docker-Content-Digest")
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", errors.WithStack(fmt.Errorf("no digest for image %s:%s", repo, tag))
+	}
+
+	return digest, nil
+}
+
+// repositoryInternal is true for the registry the rack runs in its own cluster
+func (p *Provider) repositoryInternal(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return p.Domain != "" && host == fmt.Sprintf("registry.%s", p.Domain)
+}
+
+func buildAttestationKey(id string) string {
+	return buildObjectPrefix(id) + "attestation.json"
+}
+
+func buildObjectPrefix(id string) string {
+	return fmt.Sprintf("build/%s/", id)
+}
+
+func buildSbomKey(id, service string) string {
+	return fmt.Sprintf("%s%s.json", buildSbomPrefix(id), service)
+}
+
+func buildSbomPrefix(id string) string {
+	return buildObjectPrefix(id) + "sbom/"
+}
@@ -0,0 +1,214 @@
+package k8s_test
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/convox/convox/pkg/attest"
+	"github.com/convox/convox/pkg/mock"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// registryEngine points the app repository at a test registry
+type registryEngine struct {
+	*mock.TestEngine
+	host string
+}
+
+func (e registryEngine) RepositoryHost(app string) (string, bool, error) {
+	return fmt.Sprintf("%s/%s", e.host, app), true, nil
+}
+
+// testRegistry serves the digest of the images in digests keyed by path with
+// a certificate the provider trusts for the rest of the test
+func testRegistry(t *testing.T, digests map[string]string) *httptest.Server {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "un1" || pass != "pw1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		digest, ok := digests[r.URL.Path]
+		if !ok || r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Docker-Content-Digest", digest)
+	}))
+
+	t.Cleanup(s.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Certificate())
+
+	t.Cleanup(k8s.SetRepositoryRootCAs(pool))
+
+	return s
+}
+
+func attestationSetup(t *testing.T, p *k8s.Provider, services ...string) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	p.Storage = t.TempDir()
+
+	s := testRegistry(t, map[string]string{"/v2/app1/manifests/web.BUILD1": "sha256:abc123"})
+
+	p.Engine = registryEngine{TestEngine: &mock.TestEngine{}, host: strings.TrimPrefix(s.URL, "https://")}
+
+	require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+		"convox.com/app-release": "release1",
+		"convox.com/app-status":  "running",
+	}))
+	require.NoError(t, buildCreate(p.Convox, "rack1-app1", "build1", "basic"))
+
+	_, err := p.BuildUpdate("app1", "build1", structs.BuildUpdateOptions{Status: options.String("running")})
+	require.NoError(t, err)
+
+	for _, s := range services {
+		_, err := p.ObjectStore("app1", "build/BUILD1/sbom/"+s+".json", strings.NewReader("sbom-"+s), structs.ObjectStoreOptions{})
+		require.NoError(t, err)
+	}
+}
+
+func TestBuildAttest(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		attestationSetup(t, p, "web", "worker")
+
+		a, err := p.BuildAttest("app1", "build1")
+		require.NoError(t, err)
+
+		key, err := p.SystemSigningKey()
+		require.NoError(t, err)
+
+		s, err := attest.Verify(key, a)
+		require.NoError(t, err)
+		require.Equal(t, "app1", s.Predicate.App)
+		require.Equal(t, "BUILD1", s.Predicate.Build)
+		require.Equal(t, "rack1", s.Predicate.Rack)
+		require.Len(t, s.Subject, 3)
+		require.Equal(t, attest.Subject{Name: "image/web", Digest: map[string]string{"sha256": "abc123"}}, s.Subject[0])
+		require.Equal(t, "sbom/web.json", s.Subject[1].Name)
+		require.Equal(t, "sbom/worker.json", s.Subject[2].Name)
+
+		digest, err := attest.Digest(strings.NewReader("sbom-web"))
+		require.NoError(t, err)
+		require.Equal(t, digest, s.Subject[1].Digest["sha256"])
+
+		sa, err := p.BuildAttestation("app1", "build1")
+		require.NoError(t, err)
+		require.Equal(t, a, sa)
+
+		again, err := p.SystemSigningKey()
+		require.NoError(t, err)
+		require.Equal(t, key, again)
+
+		_, err = p.BuildAttest("app1", "build1")
+		require.EqualError(t, err, "build BUILD1 is already attested")
+	})
+}
+
+func TestBuildAttestNoSbom(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		attestationSetup(t, p)
+
+		_, err := p.BuildAttest("app1", "build1")
+		require.EqualError(t, err, "no sbom found for build: BUILD1")
+
+		_, err = p.BuildAttestation("app1", "build1")
+		require.EqualError(t, err, "no attestation found for build: BUILD1")
+	})
+}
+
+func TestBuildAttestStatus(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		attestationSetup(t, p, "web")
+
+		for _, status := range []string{"complete", "failed"} {
+			_, err := p.BuildUpdate("app1", "build1", structs.BuildUpdateOptions{Status: options.String(status)})
+			require.NoError(t, err)
+
+			_, err = p.BuildAttest("app1", "build1")
+			require.EqualError(t, err, fmt.Sprintf("can not attest build BUILD1 with status: %s", status))
+		}
+	})
+}
+
+func TestBuildAttestImageMissing(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		attestationSetup(t, p, "web")
+
+		s := testRegistry(t, map[string]string{})
+
+		p.Engine = registryEngine{TestEngine: &mock.TestEngine{}, host: strings.TrimPrefix(s.URL, "https://")}
+
+		_, err := p.BuildAttest("app1", "build1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "could not find image")
+	})
+}
+
+func TestBuildAttestRegistryUntrusted(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		attestationSetup(t, p, "web")
+
+		k8s.SetRepositoryRootCAs(nil)
+
+		_, err := p.BuildAttest("app1", "build1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "certificate")
+	})
+}
+
+func TestRepositoryInternal(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		require.True(t, p.RepositoryInternal("registry.domain1"))
+		require.True(t, p.RepositoryInternal("registry.domain1:443"))
+		require.False(t, p.RepositoryInternal("registry.domain2"))
+		require.False(t, p.RepositoryInternal("123456789012.dkr.ecr.us-east-1.amazonaws.com"))
+		require.False(t, p.RepositoryInternal("registry.domain1.example.org"))
+	})
+}
+
+func TestBuildSbom(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		attestationSetup(t, p, "web")
+
+		r, err := p.BuildSbom("app1", "build1", structs.BuildSbomOptions{})
+		require.NoError(t, err)
+		defer r.Close()
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "sbom-web", string(data))
+
+		_, err = p.BuildSbom("app1", "build1", structs.BuildSbomOptions{Service: options.String("other")})
+		require.EqualError(t, err, "no sbom found for service: other")
+	})
+}
+
+func TestBuildSbomMultiple(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		attestationSetup(t, p, "web", "worker")
+
+		_, err := p.BuildSbom("app1", "build1", structs.BuildSbomOptions{})
+		require.EqualError(t, err, "build BUILD1 has an sbom for each of web, worker, specify one with --service")
+
+		r, err := p.BuildSbom("app1", "build1", structs.BuildSbomOptions{Service: options.String("worker")})
+		require.NoError(t, err)
+		defer r.Close()
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "sbom-worker", string(data))
+	})
+}
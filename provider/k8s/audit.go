@@ -0,0 +1,199 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	ac "k8s.io/api/core/v1"
+	ae "k8s.io/apimachinery/pkg/api/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// audit entries are bucketed into configmaps per hour that roll over by size
+// to stay well under the 1MB configmap limit. Each bucket carries its hour in
+// a label so that a range of hours can be selected when listing.
+const (
+	auditBucketFormat = "2006010215"
+	bucketMaxRollover = 100
+	bucketMaxSize     = 512 * 1024
+)
+
+// AuditLogRetention is how long audit entries are kept unless the rack sets
+// AUDIT_LOG_RETENTION_IN_DAYS
+const AuditLogRetention = 30 * 24 * time.Hour
+
+func (p *Provider) AuditLogAppend(log structs.AuditLog) error {
+	data, err := json.Marshal(log)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	hour := log.Timestamp.UTC().Format(auditBucketFormat)
+
+	return p.bucketAppend(fmt.Sprintf("audit-%s", hour), "audit", hour, log.Id, data)
+}
+
+func (p *Provider) AuditLogList(opts structs.AuditLogListOptions) (structs.AuditLogs, error) {
+	since := time.Now().UTC().Add(-1 * common.DefaultDuration(opts.Since, 24*time.Hour))
+
+	cms, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).List(p.ctx, am.ListOptions{
+		LabelSelector: fmt.Sprintf("system=convox,type=audit,%s", bucketSince(since, auditBucketFormat)),
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ls := structs.AuditLogs{}
+
+	for _, cm := range cms.Items {
+		for _, data := range cm.Data {
+			var l structs.AuditLog
+
+			if err := json.Unmarshal([]byte(data), &l); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			if l.Timestamp.Before(since) {
+				continue
+			}
+
+			if opts.App != nil && l.App != *opts.App {
+				continue
+			}
+
+			if opts.User != nil && l.User != *opts.User {
+				continue
+			}
+
+			ls = append(ls, l)
+		}
+	}
+
+	return ls, nil
+}
+
+// auditPrune removes the audit buckets older than the audit log retention
+func (p *Provider) auditPrune() error {
+	retention := AuditLogRetention
+
+	if days, err := strconv.Atoi(p.AuditLogRetentionInDays); err == nil && days > 0 {
+		retention = time.Duration(days) * 24 * time.Hour
+	}
+
+	cms, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).List(p.ctx, am.ListOptions{
+		LabelSelector: "system=convox,type=audit",
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	first := fmt.Sprintf("audit-%s", time.Now().UTC().Add(-1*retention).Format(auditBucketFormat))
+
+	for _, cm := range cms.Items {
+		if cm.Name >= first {
+			continue
+		}
+
+		if err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Delete(p.ctx, cm.Name, am.DeleteOptions{}); err != nil && !ae.IsNotFound(err) {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// bucketSince selects the buckets from the hour holding since onward by
+// their hour label
+func bucketSince(since time.Time, format string) string {
+	hour, err := strconv.ParseInt(since.UTC().Format(format), 10, 64)
+	if err != nil {
+		return "hour"
+	}
+
+	return fmt.Sprintf("hour>%d", hour-1)
+}
+
+// bucketAppend adds key to the configmap bucket name holding entries of kind
+// such as audit for an hour, creating the bucket when it does not exist yet.
+// A bucket that would grow past bucketMaxSize rolls over to name-1, name-2
+// and so on.
+func (p *Provider) bucketAppend(name, kind, hour, key string, data []byte) error {
+	// the label is patched too so buckets written before it existed are found
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]string{"hour": hour}},
+		"data":     map[string]string{key: string(data)},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cms := p.Cluster.CoreV1().ConfigMaps(p.Namespace)
+
+	for i := 0; i < bucketMaxRollover; i++ {
+		bucket := name
+
+		if i > 0 {
+			bucket = fmt.Sprintf("%s-%d", name, i)
+		}
+
+		cm, err := cms.Get(p.ctx, bucket, am.GetOptions{})
+		if ae.IsNotFound(err) {
+			cm := &ac.ConfigMap{
+				ObjectMeta: am.ObjectMeta{
+					Namespace: p.Namespace,
+					Name:      bucket,
+					Labels: map[string]string{
+						"hour":   hour,
+						"system": "convox",
+						"type":   kind,
+					},
+				},
+				Data: map[string]string{key: string(data)},
+			}
+
+			if _, err := cms.Create(p.ctx, cm, am.CreateOptions{}); err == nil {
+				return nil
+			} else if !ae.IsAlreadyExists(err) {
+				return errors.WithStack(err)
+			}
+
+			// another api created the bucket first
+			if _, err := cms.Patch(p.ctx, bucket, types.MergePatchType, patch, am.PatchOptions{}); err != nil {
+				return errors.WithStack(err)
+			}
+
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if bucketSize(cm)+len(key)+len(data) > bucketMaxSize {
+			continue
+		}
+
+		if _, err := cms.Patch(p.ctx, bucket, types.MergePatchType, patch, am.PatchOptions{}); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}
+
+	return errors.WithStack(fmt.Errorf("bucket %s is full", name))
+}
+
+func bucketSize(cm *ac.ConfigMap) int {
+	size := 0
+
+	for k, v := range cm.Data {
+		size += len(k) + len(v)
+	}
+
+	return size
+}
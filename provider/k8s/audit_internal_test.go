@@ -0,0 +1,56 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/mock"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	cvfake "github.com/convox/convox/provider/k8s/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/require"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAuditPrune(t *testing.T) {
+	c := fake.NewSimpleClientset()
+
+	p := &Provider{
+		AuditLogRetentionInDays: "2",
+		Cluster:                 c,
+		Convox:                  cvfake.NewSimpleClientset(),
+		Engine:                  &mock.TestEngine{},
+		Name:                    "rack1",
+		Namespace:               "ns1",
+	}
+	require.NoError(t, p.Initialize(structs.ProviderOptions{}))
+
+	now := time.Now().UTC()
+
+	for id, age := range map[string]time.Duration{"audit1": time.Hour, "audit2": 47 * time.Hour, "audit3": 49 * time.Hour, "audit4": 30 * 24 * time.Hour} {
+		require.NoError(t, p.AuditLogAppend(structs.AuditLog{Id: id, Timestamp: now.Add(-1 * age)}))
+	}
+
+	require.NoError(t, p.auditPrune())
+
+	cms, err := c.CoreV1().ConfigMaps("ns1").List(context.TODO(), am.ListOptions{LabelSelector: "system=convox,type=audit"})
+	require.NoError(t, err)
+	require.Len(t, cms.Items, 2)
+
+	ls, err := p.AuditLogList(structs.AuditLogListOptions{Since: options.Duration(60 * 24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, ls, 2)
+	require.ElementsMatch(t, []string{"audit1", "audit2"}, []string{ls[0].Id, ls[1].Id})
+
+	// without a retention the default applies
+	p.AuditLogRetentionInDays = ""
+
+	require.NoError(t, p.AuditLogAppend(structs.AuditLog{Id: "old", Timestamp: now.Add(-31 * 24 * time.Hour)}))
+	require.NoError(t, p.auditPrune())
+
+	cms, err = c.CoreV1().ConfigMaps("ns1").List(context.TODO(), am.ListOptions{LabelSelector: "system=convox,type=audit"})
+	require.NoError(t, err)
+	require.Len(t, cms.Items, 2)
+}
@@ -0,0 +1,107 @@
+package k8s_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	"github.com/stretchr/testify/require"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAuditLogAppend(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		l1 := structs.AuditLog{Id: "audit1", Action: "AppCreate", Timestamp: ts, User: "user1"}
+		l2 := structs.AuditLog{Id: "audit2", Action: "AppDelete", Timestamp: ts.Add(time.Minute), User: "user1"}
+
+		require.NoError(t, p.AuditLogAppend(l1))
+		require.NoError(t, p.AuditLogAppend(l2))
+
+		fc := p.Cluster.(*fake.Clientset)
+
+		cm, err := fc.CoreV1().ConfigMaps(p.Namespace).Get(context.TODO(), "audit-2020010203", am.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "audit", cm.Labels["type"])
+		require.Equal(t, "2020010203", cm.Labels["hour"])
+		require.Len(t, cm.Data, 2)
+		require.Contains(t, cm.Data, "audit1")
+		require.Contains(t, cm.Data, "audit2")
+	})
+}
+
+func TestAuditLogList(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		now := time.Now().UTC()
+
+		ls := structs.AuditLogs{
+			{Id: "audit1", Action: "AppCreate", App: "app1", Timestamp: now.Add(-1 * time.Minute), User: "user1"},
+			{Id: "audit2", Action: "AppDelete", App: "app2", Timestamp: now.Add(-2 * time.Minute), User: "user2"},
+			{Id: "audit3", Action: "AppUpdate", App: "app1", Timestamp: now.Add(-48 * time.Hour), User: "user1"},
+		}
+
+		for _, l := range ls {
+			require.NoError(t, p.AuditLogAppend(l))
+		}
+
+		as, err := p.AuditLogList(structs.AuditLogListOptions{})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"audit1", "audit2"}, auditIds(as))
+
+		as, err = p.AuditLogList(structs.AuditLogListOptions{Since: options.Duration(72 * time.Hour)})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"audit1", "audit2", "audit3"}, auditIds(as))
+
+		as, err = p.AuditLogList(structs.AuditLogListOptions{App: options.String("app1"), Since: options.Duration(72 * time.Hour)})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"audit1", "audit3"}, auditIds(as))
+
+		as, err = p.AuditLogList(structs.AuditLogListOptions{User: options.String("user2")})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"audit2"}, auditIds(as))
+	})
+}
+
+func auditIds(ls structs.AuditLogs) []string {
+	ids := []string{}
+
+	for _, l := range ls {
+		ids = append(ids, l.Id)
+	}
+
+	return ids
+}
+
+func TestAuditLogAppendRollover(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		params := map[string]string{"data": strings.Repeat("x", 200*1024)}
+
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, p.AuditLogAppend(structs.AuditLog{Id: fmt.Sprintf("audit%d", i), Action: "ObjectStore", Params: params, Timestamp: ts, User: "user1"}))
+		}
+
+		fc := p.Cluster.(*fake.Clientset)
+
+		cm, err := fc.CoreV1().ConfigMaps(p.Namespace).Get(context.TODO(), "audit-2020010203", am.GetOptions{})
+		require.NoError(t, err)
+		require.Len(t, cm.Data, 2)
+
+		cm, err = fc.CoreV1().ConfigMaps(p.Namespace).Get(context.TODO(), "audit-2020010203-1", am.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "audit", cm.Labels["type"])
+		require.Contains(t, cm.Data, "audit3")
+
+		as, err := p.AuditLogList(structs.AuditLogListOptions{Since: options.Duration(time.Since(ts) + time.Hour)})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"audit1", "audit2", "audit3"}, auditIds(as))
+	})
+}
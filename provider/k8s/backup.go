@@ -0,0 +1,191 @@
+package k8s
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/manifest"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+)
+
+// backup ids are the time the dump was started so they sort and can be
+// parsed back without any metadata from the object store
+const resourceBackupIdFormat = "20060102T150405Z"
+
+// objectStorage is the part of a provider that stores objects, engines that
+// keep objects outside of the cluster are used so backups and attestations
+// survive the loss of a volume
+type objectStorage interface {
+	ObjectDelete(app, key string) error
+	ObjectFetch(app, key string) (io.ReadCloser, error)
+	ObjectList(app, prefix string) ([]string, error)
+	ObjectStore(app, key string, r io.Reader, opts structs.ObjectStoreOptions) (*structs.Object, error)
+}
+
+func (p *Provider) ResourceBackupList(app, name string) (structs.ResourceBackups, error) {
+	m, _, err := common.AppManifest(p, app)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if _, err := m.Resource(name); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return p.resourceBackups(app, name)
+}
+
+func (p *Provider) ResourceRestore(app, name, backup string) error {
+	bs, err := p.ResourceBackupList(app, name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	found := false
+
+	for _, b := range bs {
+		if b.Id == backup {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return errors.WithStack(fmt.Errorf("backup not found: %s", backup))
+	}
+
+	r, err := p.storage().ObjectFetch(app, resourceBackupKey(name, backup))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer r.Close()
+
+	if err := p.ResourceImport(app, name, r); err != nil {
+		p.EventSend("resource:restore", structs.EventSendOptions{Data: map[string]string{"app": app, "name": name, "id": backup}, Error: options.String(err.Error())})
+		return errors.WithStack(err)
+	}
+
+	p.EventSend("resource:restore", structs.EventSendOptions{Data: map[string]string{"app": app, "name": name, "id": backup}})
+
+	return nil
+}
+
+func (p *Provider) storage() objectStorage {
+	if s, ok := p.Engine.(objectStorage); ok {
+		return s
+	}
+
+	return p
+}
+
+// resourceBackup dumps a resource to a new backup and then removes the
+// oldest backups past its retention
+func (p *Provider) resourceBackup(app string, r manifest.Resource, now time.Time) (*structs.ResourceBackup, error) {
+	b := &structs.ResourceBackup{
+		Id:       now.UTC().Format(resourceBackupIdFormat),
+		Created:  now.UTC().Truncate(time.Second),
+		Resource: r.Name,
+	}
+
+	rc, err := p.ResourceExport(app, r.Name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rc.Close()
+
+	key := resourceBackupKey(r.Name, b.Id)
+
+	if _, err := p.storage().ObjectStore(app, key, rc, structs.ObjectStoreOptions{}); err != nil {
+		p.storage().ObjectDelete(app, key)
+		return nil, errors.WithStack(err)
+	}
+
+	if err := p.resourceBackupPrune(app, r.Name, r.Backup.Retain); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return b, nil
+}
+
+// resourceBackupDue returns the latest firing of the backup schedule of a
+// resource if there is no backup since then, or the zero time
+func (p *Provider) resourceBackupDue(app string, r manifest.Resource, now time.Time) (time.Time, error) {
+	c, err := manifest.ParseCron(r.Backup.Schedule)
+	if err != nil {
+		return time.Time{}, errors.WithStack(err)
+	}
+
+	prev := c.Prev(now.UTC())
+	if prev.IsZero() {
+		return time.Time{}, nil
+	}
+
+	bs, err := p.resourceBackups(app, r.Name)
+	if err != nil {
+		return time.Time{}, errors.WithStack(err)
+	}
+
+	if len(bs) > 0 && !bs[0].Created.Before(prev) {
+		return time.Time{}, nil
+	}
+
+	return prev, nil
+}
+
+func (p *Provider) resourceBackupPrune(app, name string, retain int) error {
+	bs, err := p.resourceBackups(app, name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if retain < 1 || len(bs) <= retain {
+		return nil
+	}
+
+	for _, b := range bs[retain:] {
+		if err := p.storage().ObjectDelete(app, resourceBackupKey(name, b.Id)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// resourceBackups returns the backups of a resource, newest first
+func (p *Provider) resourceBackups(app, name string) (structs.ResourceBackups, error) {
+	keys, err := p.storage().ObjectList(app, resourceBackupPrefix(name))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	bs := structs.ResourceBackups{}
+
+	for _, k := range keys {
+		id := path.Base(k)
+
+		t, err := time.Parse(resourceBackupIdFormat, id)
+		if err != nil {
+			continue
+		}
+
+		bs = append(bs, structs.ResourceBackup{Id: id, Created: t, Resource: name})
+	}
+
+	sort.Slice(bs, bs.Less)
+
+	return bs, nil
+}
+
+func resourceBackupKey(name, id string) string {
+	return resourceBackupPrefix(name) + id
+}
+
+func resourceBackupPrefix(name string) string {
+	return fmt.Sprintf("backups/%s/", name)
+}
@@ -0,0 +1,98 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	ca "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
+	"github.com/stretchr/testify/require"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const backupsManifest = `resources:
+  database:
+    type: postgres
+    backup:
+      schedule: "0 3 * * *"
+      retain: 2
+services:
+  web:
+    build: .
+    resources:
+      - database
+`
+
+func backupSetup(t *testing.T, p *k8s.Provider) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	p.Storage = t.TempDir()
+
+	require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+		"convox.com/app-release": "R1",
+		"convox.com/app-status":  "running",
+	}))
+
+	_, err := p.Convox.ConvoxV1().Releases("rack1-app1").Create(&ca.Release{
+		ObjectMeta: am.ObjectMeta{Name: "r1", Labels: map[string]string{"app": "app1"}},
+		Spec:       ca.ReleaseSpec{Build: "B1", Created: time.Now().UTC().Format(common.SortableTime), Manifest: backupsManifest},
+	})
+	require.NoError(t, err)
+
+	for _, key := range []string{
+		"backups/database/20240305T030000Z",
+		"backups/database/20240306T030000Z",
+		"backups/database/partial",
+		"backups/other/20240306T030000Z",
+	} {
+		_, err := p.ObjectStore("app1", key, strings.NewReader("dump"), structs.ObjectStoreOptions{})
+		require.NoError(t, err)
+	}
+}
+
+func TestResourceBackupList(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		backupSetup(t, p)
+
+		bs, err := p.ResourceBackupList("app1", "database")
+		require.NoError(t, err)
+		require.Equal(t, structs.ResourceBackups{
+			{Id: "20240306T030000Z", Created: time.Date(2024, 3, 6, 3, 0, 0, 0, time.UTC), Resource: "database"},
+			{Id: "20240305T030000Z", Created: time.Date(2024, 3, 5, 3, 0, 0, 0, time.UTC), Resource: "database"},
+		}, bs)
+
+		_, err = p.ResourceBackupList("app1", "missing")
+		require.EqualError(t, err, "no such resource: missing")
+	})
+}
+
+func TestResourceRestoreNotFound(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		backupSetup(t, p)
+
+		err := p.ResourceRestore("app1", "database", "20240101T030000Z")
+		require.EqualError(t, err, "backup not found: 20240101T030000Z")
+	})
+}
+
+func TestObjectList(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		backupSetup(t, p)
+
+		keys, err := p.ObjectList("app1", "backups/database/")
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"backups/database/20240305T030000Z",
+			"backups/database/20240306T030000Z",
+			"backups/database/partial",
+		}, keys)
+
+		keys, err = p.ObjectList("app1", "backups/missing/")
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/convox/convox/pkg/structs"
 	ca "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
 	"github.com/pkg/errors"
+	ac "k8s.io/api/core/v1"
 	am "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -78,6 +79,7 @@ func (p *Provider) BuildCreate(app, url string, opts structs.BuildCreateOptions)
 		"BUILD_ID":                     b.Id,
 		"BUILD_MANIFEST":               common.DefaultString(opts.Manifest, "convox.yml"),
 		"BUILD_RACK":                   p.Name,
+		"BUILD_SBOM":                   "true",
 		"BUILD_URL":                    url,
 		"BUILD_GIT_SHA":                b.GitSha,
 		"BUILDKIT_ENABLED":             p.BuildkitEnabled,
@@ -86,6 +88,10 @@ func (p *Provider) BuildCreate(app, url string, opts structs.BuildCreateOptions)
 		"RACK_URL":                     fmt.Sprintf("https://convox:%s@api.%s.svc.cluster.local:5443", p.Password, p.Namespace),
 	}
 
+	if p.JwtMngr != nil {
+		env["BUILD_TOKEN"] = p.JwtMngr.BuildToken(app, b.Id)
+	}
+
 	repo, _, err := p.Engine.RepositoryHost(app)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -128,6 +134,17 @@ func (p *Provider) BuildCreate(app, url string, opts structs.BuildCreateOptions)
 		psOpts.Memory = options.Int(int(v))
 	}
 
+	sp, err := appScanPolicy(appObj)
+	if err != nil {
+		return nil, err
+	}
+
+	if sp.Enabled {
+		env["BUILD_SCAN"] = "true"
+		env["BUILD_SCAN_ACTION"] = sp.Action
+		env["BUILD_SCAN_THRESHOLD"] = sp.Threshold
+	}
+
 	if p.BuildkitEnabled == "true" {
 		psOpts.Image = options.String(p.buildImage(os.Getenv("PROVIDER")))
 		psOpts.Privileged = options.Bool(p.buildPrivileged(os.Getenv("PROVIDER")))
@@ -450,6 +467,25 @@ func (p *Provider) BuildList(app string, opts structs.BuildListOptions) (structs
 	return bs, nil
 }
 
+// BuildQueueDepth counts the builds of every app that have not finished by
+// status, created builds are still waiting for their build process
+func (p *Provider) BuildQueueDepth() (map[string]float64, error) {
+	kbs, err := p.ListBuildsFromInformer(ac.NamespaceAll, "", 0)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	depth := map[string]float64{"created": 0, "running": 0}
+
+	for _, kb := range kbs.Items {
+		if _, ok := depth[kb.Spec.Status]; ok {
+			depth[kb.Spec.Status]++
+		}
+	}
+
+	return depth, nil
+}
+
 func (p *Provider) BuildUpdate(app, id string, opts structs.BuildUpdateOptions) (*structs.Build, error) {
 	b, err := p.BuildGet(app, id)
 	if err != nil {
@@ -476,6 +512,15 @@ func (p *Provider) BuildUpdate(app, id string, opts structs.BuildUpdateOptions)
 		b.Release = *opts.Release
 	}
 
+	if opts.Scan != nil {
+		s, err := buildScanSummary(*opts.Scan)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		b.Scan = s
+	}
+
 	if opts.Started != nil {
 		b.Started = *opts.Started
 	}
@@ -585,6 +630,20 @@ func (p *Provider) buildList(app string, limit int) (structs.Builds, error) {
 
 // skipcq
 func (p *Provider) buildMarshal(b *structs.Build) *ca.Build {
+	scan := ""
+
+	// a scan is plain data so marshaling it can not fail
+	if b.Scan != nil {
+		data, _ := json.Marshal(b.Scan)
+		scan = string(data)
+	}
+
+	tested := ""
+
+	if b.Tested != nil {
+		tested = b.Tested.UTC().Format(common.SortableTime)
+	}
+
 	return &ca.Build{
 		ObjectMeta: am.ObjectMeta{
 			Annotations: map[string]string{
@@ -606,8 +665,10 @@ func (p *Provider) buildMarshal(b *structs.Build) *ca.Build {
 			Manifest:    b.Manifest,
 			Process:     b.Process,
 			Release:     b.Release,
+			Scan:        scan,
 			Started:     b.Started.UTC().Format(common.SortableTime),
 			Status:      b.Status,
+			Tested:      tested,
 		},
 	}
 }
@@ -639,9 +700,49 @@ func (p *Provider) buildUnmarshal(kb *ca.Build) (*structs.Build, error) {
 		Status:      kb.Spec.Status,
 	}
 
+	if kb.Spec.Scan != "" {
+		s, err := buildScanSummary(kb.Spec.Scan)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		b.Scan = s
+	}
+
+	if kb.Spec.Tested != "" {
+		tested, err := time.Parse(common.SortableTime, kb.Spec.Tested)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		b.Tested = &tested
+	}
+
 	return b, nil
 }
 
+// buildScanSummary reads the scan summary of a build. Builds from before the
+// report was stored as an object kept all of it, only its counts are used.
+func buildScanSummary(data string) (*structs.BuildScanSummary, error) {
+	var s structs.BuildScanSummary
+
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		return nil, err
+	}
+
+	if s.Counts == nil {
+		var full structs.BuildScan
+
+		if err := json.Unmarshal([]byte(data), &full); err != nil {
+			return nil, err
+		}
+
+		s.Counts = full.Counts()
+	}
+
+	return &s, nil
+}
+
 // skipcq
 func (p *Provider) buildUpdate(b *structs.Build) (*structs.Build, error) {
 	kbo, err := p.GetBuildFromInformer(strings.ToLower(b.Id), p.AppNamespace(b.App))
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/convox/convox/pkg/atom"
+	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/convox/convox/provider/k8s"
 	ca "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
@@ -261,6 +262,36 @@ func TestBuildCreate(t *testing.T) {
 	})
 }
 
+func TestBuildQueueDepth(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kk := p.Cluster.(*fake.Clientset)
+
+		require.NoError(t, appCreate(kk, "rack1", "app1"))
+		require.NoError(t, appCreate(kk, "rack1", "app2"))
+
+		builds := map[string]string{
+			"rack1-app1/build1": "created",
+			"rack1-app1/build2": "running",
+			"rack1-app1/build3": "complete",
+			"rack1-app2/build4": "running",
+			"rack1-app2/build5": "failed",
+		}
+
+		for id, status := range builds {
+			parts := strings.Split(id, "/")
+
+			require.NoError(t, buildCreate(p.Convox, parts[0], parts[1], "basic"))
+
+			_, err := p.BuildUpdate(strings.TrimPrefix(parts[0], "rack1-"), parts[1], structs.BuildUpdateOptions{Status: options.String(status)})
+			require.NoError(t, err)
+		}
+
+		depth, err := p.BuildQueueDepth()
+		require.NoError(t, err)
+		require.Equal(t, map[string]float64{"created": 1, "running": 2}, depth)
+	})
+}
+
 func buildCreate(kc cv.Interface, ns, id, fixture string) error {
 	spec, err := buildFixture(fixture)
 	if err != nil {
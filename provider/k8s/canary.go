@@ -0,0 +1,816 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/manifest"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	ac "k8s.io/api/core/v1"
+	ae "k8s.io/apimachinery/pkg/api/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	CanaryDefaultInterval = 5 * time.Minute
+	CanaryDefaultStep     = 20
+	CanaryLease           = 5 * time.Minute
+	CanaryMaxErrorRate    = 0.1
+	CanaryProbeCount      = 3
+	CanaryResumeInterval  = 1 * time.Minute
+	CanarySuffix          = "-canary"
+)
+
+var errCanaryLost = errors.New("canary rollout is no longer owned by this api")
+
+// canaryRollout is kept in the canary-<app> configmap while it runs. The api
+// running it renews Expires, any api finding it expired takes it over.
+type canaryRollout struct {
+	App      string
+	Release  string
+	Services manifest.Services `json:"-"`
+	Weight   int
+	Step     int
+	Interval time.Duration
+	Timeout  time.Duration
+	Restarts map[string]int32
+	Requests map[string]map[string]routerCounts
+	Options  structs.ReleasePromoteOptions
+	Owner    string
+	Expires  time.Time
+}
+
+// releasePromoteCanary runs the services of the release next to the active
+// release and shifts ingress traffic over to them in steps
+func (p *Provider) releasePromoteCanary(a *structs.App, id string, opts structs.ReleasePromoteOptions) error {
+	if a.Release == "" {
+		return errors.WithStack(fmt.Errorf("canary promote requires an active release"))
+	}
+
+	if strings.EqualFold(a.Release, id) {
+		return errors.WithStack(fmt.Errorf("release is already active: %s", id))
+	}
+
+	cr, err := canaryRolloutFromOptions(a.Name, id, opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := p.canaryServices(cr); err != nil {
+		return err
+	}
+
+	_, r, err := common.ReleaseManifest(p, a.Name, id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	e, err := structs.NewEnvironment([]byte(r.Env))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	data, err := p.releaseTemplateCanary(a, e, r, cr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ldata, err := ApplyLabels(data, fmt.Sprintf("system=convox,provider=k8s,rack=%s,app=%s,release=%s,canary=true", p.Name, a.Name, id))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := p.canaryStateCreate(cr); err != nil {
+		return err
+	}
+
+	if err := Apply(ldata); err != nil {
+		p.canaryStateDelete(cr.App)
+		return errors.WithStack(err)
+	}
+
+	p.EventSend("release:canary", structs.EventSendOptions{Data: cr.eventData(), Status: options.String("start")})
+
+	// the rollout outlives the api request that started it
+	pp := p.WithContext(context.Background()).(*Provider)
+
+	go pp.canaryRun(cr)
+
+	return nil
+}
+
+// canaryServices sets the services of the release that take canary traffic
+func (p *Provider) canaryServices(cr *canaryRollout) error {
+	m, _, err := common.ReleaseManifest(p, cr.App, cr.Release)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cr.Services = manifest.Services{}
+
+	for _, s := range m.Services.Routable().External() {
+		if s.Agent.Enabled {
+			continue
+		}
+		cr.Services = append(cr.Services, s)
+	}
+
+	if len(cr.Services) == 0 {
+		return errors.WithStack(fmt.Errorf("canary promote requires at least one external service"))
+	}
+
+	return nil
+}
+
+func canaryRolloutFromOptions(app, id string, opts structs.ReleasePromoteOptions) (*canaryRollout, error) {
+	cr := &canaryRollout{
+		App:      app,
+		Release:  id,
+		Weight:   common.DefaultInt(opts.Canary, 0),
+		Step:     common.DefaultInt(opts.Step, CanaryDefaultStep),
+		Interval: CanaryDefaultInterval,
+		Timeout:  time.Duration(common.DefaultInt(opts.Timeout, 3000)) * time.Second,
+		Restarts: map[string]int32{},
+		Options:  opts,
+	}
+
+	if cr.Weight < 1 || cr.Weight > 99 {
+		return nil, fmt.Errorf("canary weight must be between 1 and 99")
+	}
+
+	if cr.Step < 1 || cr.Step > 100 {
+		return nil, fmt.Errorf("canary step must be between 1 and 100")
+	}
+
+	if opts.Interval != nil {
+		d, err := time.ParseDuration(*opts.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid canary interval: %s", *opts.Interval)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("canary interval must be positive")
+		}
+		cr.Interval = d
+	}
+
+	return cr, nil
+}
+
+func (cr *canaryRollout) eventData() map[string]string {
+	return map[string]string{
+		"app":    cr.App,
+		"id":     cr.Release,
+		"weight": strconv.Itoa(cr.Weight),
+	}
+}
+
+func (p *Provider) canaryRun(cr *canaryRollout) {
+	err := p.canaryRollout(cr)
+
+	if errors.Is(err, errCanaryLost) {
+		p.logger.At("canaryRun").Errorf("app=%s release=%s err=%q", cr.App, cr.Release, err)
+		return
+	}
+
+	if err != nil {
+		p.logger.At("canaryRun").Errorf("app=%s release=%s err=%q", cr.App, cr.Release, err)
+
+		if derr := p.canaryDelete(cr.App); derr != nil {
+			p.logger.At("canaryRun").Errorf("app=%s release=%s err=%q", cr.App, cr.Release, derr)
+		}
+	}
+
+	if derr := p.canaryStateDelete(cr.App); derr != nil {
+		p.logger.At("canaryRun").Errorf("app=%s release=%s err=%q", cr.App, cr.Release, derr)
+	}
+
+	if err != nil {
+		p.EventSend("release:canary", structs.EventSendOptions{Data: cr.eventData(), Error: options.String(fmt.Sprintf("rolled back: %s", err))})
+		return
+	}
+
+	p.EventSend("release:canary", structs.EventSendOptions{Data: cr.eventData(), Status: options.String("success")})
+}
+
+func (p *Provider) canaryRollout(cr *canaryRollout) error {
+	if len(cr.Services) == 0 {
+		return fmt.Errorf("no canary services")
+	}
+
+	if err := p.canaryWaitAvailable(cr); err != nil {
+		return err
+	}
+
+	for {
+		if err := p.canaryWeightSet(cr); err != nil {
+			return err
+		}
+
+		p.canaryRequestsMark(cr)
+
+		if err := p.canarySave(cr); err != nil {
+			return err
+		}
+
+		p.EventSend("release:canary", structs.EventSendOptions{Data: cr.eventData(), Status: options.String("step")})
+
+		if err := p.canarySleep(cr, cr.Interval); err != nil {
+			return err
+		}
+
+		if err := p.canaryCheck(cr); err != nil {
+			return err
+		}
+
+		if cr.Weight >= 100 {
+			break
+		}
+
+		cr.Weight = min(cr.Weight+cr.Step, 100)
+	}
+
+	popts := cr.Options
+	popts.Canary = nil
+	popts.Interval = nil
+	popts.Step = nil
+
+	// the release passed the checks of the app when the canary started
+	a, err := p.AppGet(cr.App)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := p.releasePromote(a, cr.Release, popts); err != nil {
+		return err
+	}
+
+	if err := p.canaryWaitPromoted(cr); err != nil {
+		return err
+	}
+
+	return p.canaryDelete(cr.App)
+}
+
+// canaryCheck fails when a canary deployment lost its replicas, when its
+// containers restarted since the rollout began, when too many of the
+// requests the routers sent it during the step failed or when it stopped
+// answering its health check
+func (p *Provider) canaryCheck(cr *canaryRollout) error {
+	ns := p.AppNamespace(cr.App)
+
+	requests, err := p.canaryRequests(cr)
+	if err != nil {
+		p.logger.At("canaryCheck").Errorf("app=%s release=%s err=%q", cr.App, cr.Release, err)
+	}
+
+	for _, s := range cr.Services {
+		name := s.Name + CanarySuffix
+
+		d, err := p.Cluster.AppsV1().Deployments(ns).Get(p.ctx, name, am.GetOptions{})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if d.Status.AvailableReplicas < 1 {
+			return fmt.Errorf("canary for %s has no available replicas", s.Name)
+		}
+
+		restarts, err := p.canaryRestarts(ns, name)
+		if err != nil {
+			return err
+		}
+
+		if restarts > cr.Restarts[s.Name] {
+			return fmt.Errorf("canary for %s restarted %d times", s.Name, restarts-cr.Restarts[s.Name])
+		}
+
+		if cr.Requests != nil && requests != nil {
+			if rate := canaryErrorRate(cr.Requests[s.Name], requests[s.Name]); rate > CanaryMaxErrorRate {
+				return fmt.Errorf("canary for %s error rate %.0f%% exceeds %.0f%%", s.Name, rate*100, CanaryMaxErrorRate*100)
+			}
+		}
+
+		if s.Health.Disable || s.Port.Port == 0 || s.Port.Scheme == "GRPC" {
+			continue
+		}
+
+		if err := canaryProbe(fmt.Sprintf("%s://%s.%s.svc.cluster.local:%d%s", s.Port.Scheme, name, ns, s.Port.Port, s.Health.Path)); err != nil {
+			return fmt.Errorf("canary for %s is not healthy: %s", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// canaryRequestsMark keeps the request counters of the canary services at
+// the start of a step. Without them the step is checked without its error
+// rate.
+func (p *Provider) canaryRequestsMark(cr *canaryRollout) {
+	requests, err := p.canaryRequests(cr)
+	if err != nil {
+		p.logger.At("canaryRequestsMark").Errorf("app=%s release=%s err=%q", cr.App, cr.Release, err)
+	}
+
+	cr.Requests = requests
+}
+
+// canaryRequests reads the request counters of each router for the canary
+// services by service and router
+func (p *Provider) canaryRequests(cr *canaryRollout) (map[string]map[string]routerCounts, error) {
+	rms, err := p.routerMetrics()
+	if err != nil {
+		return nil, err
+	}
+
+	ns := p.AppNamespace(cr.App)
+
+	requests := map[string]map[string]routerCounts{}
+
+	for _, s := range cr.Services {
+		requests[s.Name] = map[string]routerCounts{}
+	}
+
+	for router, data := range rms {
+		rcs := routerServiceRequests(data, ns)
+
+		for _, s := range cr.Services {
+			requests[s.Name][router] = rcs[s.Name+CanarySuffix]
+		}
+	}
+
+	return requests, nil
+}
+
+// canaryErrorRate is the share of the requests counted between start and now
+// that failed with a 5xx status. A router whose counters went down restarted
+// so all of its requests are new.
+func canaryErrorRate(start, now map[string]routerCounts) float64 {
+	var failures, total float64
+
+	for router, c := range now {
+		s := start[router]
+
+		if c.Total < s.Total || c.Errors < s.Errors {
+			s = routerCounts{}
+		}
+
+		failures += c.Errors - s.Errors
+		total += c.Total - s.Total
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return failures / total
+}
+
+// canaryProbe fails when none of a few requests against the health check of
+// a canary get a response without a 5xx status
+func canaryProbe(url string) error {
+	c := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // skipcq: GSC-G402
+		},
+	}
+
+	var err error
+
+	for i := 0; i < CanaryProbeCount; i++ {
+		var res *http.Response
+
+		res, err = c.Get(url)
+		if err != nil {
+			continue
+		}
+		res.Body.Close()
+
+		if res.StatusCode < 500 {
+			return nil
+		}
+
+		err = fmt.Errorf("unexpected response: %s", res.Status)
+	}
+
+	return err
+}
+
+func (p *Provider) canaryDelete(app string) error {
+	ns := p.AppNamespace(app)
+	lo := am.ListOptions{LabelSelector: fmt.Sprintf("system=convox,app=%s,canary=true", app)}
+
+	is, err := p.Cluster.NetworkingV1().Ingresses(ns).List(p.ctx, lo)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, i := range is.Items {
+		if err := p.Cluster.NetworkingV1().Ingresses(ns).Delete(p.ctx, i.Name, am.DeleteOptions{}); err != nil && !ae.IsNotFound(err) {
+			return errors.WithStack(err)
+		}
+	}
+
+	ds, err := p.Cluster.AppsV1().Deployments(ns).List(p.ctx, lo)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, d := range ds.Items {
+		if err := p.Cluster.AppsV1().Deployments(ns).Delete(p.ctx, d.Name, am.DeleteOptions{}); err != nil && !ae.IsNotFound(err) {
+			return errors.WithStack(err)
+		}
+	}
+
+	ss, err := p.Cluster.CoreV1().Services(ns).List(p.ctx, lo)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, s := range ss.Items {
+		if err := p.Cluster.CoreV1().Services(ns).Delete(p.ctx, s.Name, am.DeleteOptions{}); err != nil && !ae.IsNotFound(err) {
+			return errors.WithStack(err)
+		}
+	}
+
+	ks, err := p.Cluster.CoreV1().Secrets(ns).List(p.ctx, lo)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, s := range ks.Items {
+		if err := p.Cluster.CoreV1().Secrets(ns).Delete(p.ctx, s.Name, am.DeleteOptions{}); err != nil && !ae.IsNotFound(err) {
+			return errors.WithStack(err)
+		}
+	}
+
+	sas, err := p.Cluster.CoreV1().ServiceAccounts(ns).List(p.ctx, lo)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, sa := range sas.Items {
+		if err := p.Cluster.CoreV1().ServiceAccounts(ns).Delete(p.ctx, sa.Name, am.DeleteOptions{}); err != nil && !ae.IsNotFound(err) {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) canaryRestarts(ns, name string) (int32, error) {
+	ps, err := p.Cluster.CoreV1().Pods(ns).List(p.ctx, am.ListOptions{LabelSelector: fmt.Sprintf("service=%s", name)})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	var restarts int32
+
+	for _, pd := range ps.Items {
+		for _, cs := range pd.Status.ContainerStatuses {
+			restarts += cs.RestartCount
+		}
+	}
+
+	return restarts, nil
+}
+
+func (p *Provider) canaryWaitAvailable(cr *canaryRollout) error {
+	ns := p.AppNamespace(cr.App)
+	deadline := time.Now().Add(cr.Timeout)
+
+	for _, s := range cr.Services {
+		name := s.Name + CanarySuffix
+
+		for {
+			d, err := p.Cluster.AppsV1().Deployments(ns).Get(p.ctx, name, am.GetOptions{})
+			if err != nil && !ae.IsNotFound(err) {
+				return errors.WithStack(err)
+			}
+
+			if d != nil && d.Status.AvailableReplicas > 0 {
+				break
+			}
+
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout waiting for canary for %s to become available", s.Name)
+			}
+
+			if err := p.canarySleep(cr, 5*time.Second); err != nil {
+				return err
+			}
+		}
+
+		restarts, err := p.canaryRestarts(ns, name)
+		if err != nil {
+			return err
+		}
+
+		cr.Restarts[s.Name] = restarts
+	}
+
+	return nil
+}
+
+func (p *Provider) canaryWaitPromoted(cr *canaryRollout) error {
+	deadline := time.Now().Add(cr.Timeout)
+
+	for {
+		status, release, err := p.Atom.Status(p.AppNamespace(cr.App), "app")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if strings.EqualFold(release, cr.Release) {
+			switch common.AtomStatus(status) {
+			case "running":
+				return nil
+			case "rollback":
+				return fmt.Errorf("promote of %s rolled back", cr.Release)
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for promote of %s", cr.Release)
+		}
+
+		if err := p.canarySleep(cr, 5*time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Provider) canaryWeightSet(cr *canaryRollout) error {
+	ns := p.AppNamespace(cr.App)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				"nginx.ingress.kubernetes.io/canary-weight": strconv.Itoa(cr.Weight),
+			},
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, s := range cr.Services {
+		if _, err := p.Cluster.NetworkingV1().Ingresses(ns).Patch(p.ctx, s.Name+CanarySuffix, types.MergePatchType, patch, am.PatchOptions{}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) releaseTemplateCanary(a *structs.App, e structs.Environment, r *structs.Release, cr *canaryRollout) ([]byte, error) {
+	items := [][]byte{}
+
+	pss, err := p.ServiceList(a.Name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, s := range cr.Services {
+		// the canary can end up with all of the traffic before the release is
+		// promoted so it runs as many replicas as the service it shadows
+		count := 1
+
+		for _, ps := range pss {
+			if ps.Name == s.Name && ps.Count > count {
+				count = ps.Count
+			}
+		}
+
+		cs := s
+		cs.Name = s.Name + CanarySuffix
+		cs.Scale.Count = manifest.ServiceScaleCount{Min: count, Max: count}
+		cs.Scale.Schedules = nil
+
+		data, err := p.releaseTemplateServices(a, e, r, manifest.Services{cs}, structs.ReleasePromoteOptions{})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		items = append(items, data)
+
+		params := map[string]interface{}{
+			"App":       a.Name,
+			"Class":     p.Engine.IngressClass(),
+			"Host":      p.Engine.ServiceHost(a.Name, s),
+			"Namespace": p.AppNamespace(a.Name),
+			"Release":   r,
+			"Service":   cs,
+			"Weight":    cr.Weight,
+		}
+
+		data, err = p.RenderTemplate("app/ingress-canary", params)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		items = append(items, data)
+	}
+
+	return bytes.Join(items, []byte("---\n")), nil
+}
+
+// CanaryResume takes over canary rollouts whose api stopped renewing them and
+// carries them on from their last weight
+func (p *Provider) CanaryResume() error {
+	cms, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).List(p.ctx, am.ListOptions{
+		LabelSelector: "system=convox,type=canary",
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	owner := canaryOwner()
+
+	for i := range cms.Items {
+		cm := &cms.Items[i]
+
+		var cr canaryRollout
+
+		if err := json.Unmarshal([]byte(cm.Data["rollout"]), &cr); err != nil {
+			p.logger.At("CanaryResume").Errorf("name=%s err=%q", cm.Name, err)
+			continue
+		}
+
+		if time.Now().Before(cr.Expires) {
+			continue
+		}
+
+		cr.Owner = owner
+
+		// only one api wins the update when several find the same rollout
+		if err := p.canaryStateUpdate(cm, &cr); ae.IsConflict(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if cr.Restarts == nil {
+			cr.Restarts = map[string]int32{}
+		}
+
+		if err := p.canaryServices(&cr); err != nil {
+			p.logger.At("CanaryResume").Errorf("app=%s release=%s err=%q", cr.App, cr.Release, err)
+		}
+
+		pp := p.WithContext(context.Background()).(*Provider)
+
+		go pp.canaryRun(&cr)
+	}
+
+	return nil
+}
+
+// canaryActive returns the release an app is running a canary of
+func (p *Provider) canaryActive(app string) (string, error) {
+	cm, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Get(p.ctx, canaryStateName(app), am.GetOptions{})
+	if ae.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return cm.Annotations["convox.com/canary-release"], nil
+}
+
+func (p *Provider) canaryStateCreate(cr *canaryRollout) error {
+	cr.Owner = canaryOwner()
+	cr.Expires = time.Now().UTC().Add(CanaryLease)
+
+	data, err := json.Marshal(cr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cm := &ac.ConfigMap{
+		ObjectMeta: am.ObjectMeta{
+			Namespace: p.Namespace,
+			Name:      canaryStateName(cr.App),
+			Annotations: map[string]string{
+				"convox.com/canary-release": cr.Release,
+			},
+			Labels: map[string]string{
+				"app":    cr.App,
+				"system": "convox",
+				"type":   "canary",
+			},
+		},
+		Data: map[string]string{"rollout": string(data)},
+	}
+
+	if _, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Create(p.ctx, cm, am.CreateOptions{}); ae.IsAlreadyExists(err) {
+		return errors.WithStack(fmt.Errorf("canary rollout already in progress for app: %s", cr.App))
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (p *Provider) canaryStateDelete(app string) error {
+	err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Delete(p.ctx, canaryStateName(app), am.DeleteOptions{})
+	if err != nil && !ae.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// canarySave writes the rollout and renews its lease, it fails with
+// errCanaryLost once another api took the rollout over or it was removed
+func (p *Provider) canarySave(cr *canaryRollout) error {
+	cm, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Get(p.ctx, canaryStateName(cr.App), am.GetOptions{})
+	if ae.IsNotFound(err) {
+		return errCanaryLost
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var cur canaryRollout
+
+	if err := json.Unmarshal([]byte(cm.Data["rollout"]), &cur); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if cur.Owner != cr.Owner {
+		return errCanaryLost
+	}
+
+	if err := p.canaryStateUpdate(cm, cr); ae.IsConflict(err) {
+		return errCanaryLost
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// canaryStateUpdate renews the lease of cr and writes it over cm, failing
+// with a conflict when cm changed since it was read
+func (p *Provider) canaryStateUpdate(cm *ac.ConfigMap, cr *canaryRollout) error {
+	cr.Expires = time.Now().UTC().Add(CanaryLease)
+
+	data, err := json.Marshal(cr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cm = cm.DeepCopy()
+	cm.Data = map[string]string{"rollout": string(data)}
+
+	if _, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Update(p.ctx, cm, am.UpdateOptions{}); err != nil {
+		if ae.IsConflict(err) {
+			return err
+		}
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// canarySleep waits for d while keeping the lease on the rollout
+func (p *Provider) canarySleep(cr *canaryRollout, d time.Duration) error {
+	for d > 0 {
+		s := min(d, CanaryLease/4)
+
+		time.Sleep(s)
+
+		d -= s
+
+		if time.Until(cr.Expires) < CanaryLease/2 {
+			if err := p.canarySave(cr); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func canaryOwner() string {
+	host, _ := os.Hostname()
+	return host
+}
+
+func canaryStateName(app string) string {
+	return fmt.Sprintf("canary-%s", app)
+}
@@ -0,0 +1,222 @@
+package k8s_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/atom"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	tm "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	ac "k8s.io/api/core/v1"
+	nv1 "k8s.io/api/networking/v1"
+	ae "k8s.io/apimachinery/pkg/api/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	kt "k8s.io/client-go/testing"
+)
+
+type canaryState struct {
+	App      string
+	Release  string
+	Weight   int
+	Step     int
+	Interval time.Duration
+	Timeout  time.Duration
+	Owner    string
+	Expires  time.Time
+}
+
+func canarySetup(t *testing.T, p *k8s.Provider, cs canaryState) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	promoteSetup(t, p, map[string]string{})
+
+	require.NoError(t, releaseCreate(p.Convox, "rack1-app1", "release3", "canary"))
+
+	data, err := json.Marshal(cs)
+	require.NoError(t, err)
+
+	_, err = kk.CoreV1().ConfigMaps("ns1").Create(context.TODO(), &ac.ConfigMap{
+		ObjectMeta: am.ObjectMeta{
+			Name:        "canary-app1",
+			Annotations: map[string]string{"convox.com/canary-release": cs.Release},
+			Labels:      map[string]string{"app": "app1", "system": "convox", "type": "canary"},
+		},
+		Data: map[string]string{"rollout": string(data)},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = kk.NetworkingV1().Ingresses("rack1-app1").Create(context.TODO(), &nv1.Ingress{
+		ObjectMeta: am.ObjectMeta{
+			Name:   "web-canary",
+			Labels: map[string]string{"app": "app1", "canary": "true", "system": "convox"},
+		},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func canaryDeploymentCreate(t *testing.T, p *k8s.Provider, available int32) {
+	_, err := p.Cluster.AppsV1().Deployments("rack1-app1").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: am.ObjectMeta{
+			Name:   "web-canary",
+			Labels: map[string]string{"app": "app1", "canary": "true", "system": "convox", "type": "service"},
+		},
+		Status: appsv1.DeploymentStatus{AvailableReplicas: available},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+}
+
+// canaryRouter serves router metrics with the canary counters of before for
+// the first read at the start of a step and of after for every read since
+func canaryRouter(t *testing.T, p *k8s.Provider, before, after string) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	_, err := kk.CoreV1().Pods("ns1").Create(context.TODO(), &ac.Pod{
+		ObjectMeta: am.ObjectMeta{
+			Name:   "router-1",
+			Labels: map[string]string{"service": "ingress-nginx", "system": "convox"},
+		},
+		Status: ac.PodStatus{Phase: ac.PodRunning},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+
+	var reads int32
+
+	kk.PrependProxyReactor("pods", func(action kt.Action) (bool, restclient.ResponseWrapper, error) {
+		if atomic.AddInt32(&reads, 1) == 1 {
+			return true, proxyResponse(before), nil
+		}
+
+		return true, proxyResponse(after), nil
+	})
+}
+
+func canaryRouterMetrics(ok, failed int) string {
+	return fmt.Sprintf(`nginx_ingress_controller_requests{namespace="rack1-app1",service="web",status="500"} 900
+nginx_ingress_controller_requests{namespace="rack1-app1",service="web-canary",status="200"} %d
+nginx_ingress_controller_requests{namespace="rack1-app1",service="web-canary",status="503"} %d
+`, ok, failed)
+}
+
+func canaryRequireFinished(t *testing.T, p *k8s.Provider, status string) structs.Event {
+	require.Eventually(t, func() bool {
+		_, err := p.Cluster.CoreV1().ConfigMaps("ns1").Get(context.TODO(), "canary-app1", am.GetOptions{})
+		return ae.IsNotFound(err)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err := p.Cluster.NetworkingV1().Ingresses("rack1-app1").Get(context.TODO(), "web-canary", am.GetOptions{})
+	require.True(t, ae.IsNotFound(err))
+
+	_, err = p.Cluster.AppsV1().Deployments("rack1-app1").Get(context.TODO(), "web-canary", am.GetOptions{})
+	require.True(t, ae.IsNotFound(err))
+
+	r, err := p.EventStream(structs.EventStreamOptions{Action: options.String("release:canary")})
+	require.NoError(t, err)
+
+	var e structs.Event
+
+	// the last event announces how the rollout ended
+	for d := json.NewDecoder(r); d.More(); {
+		require.NoError(t, d.Decode(&e))
+	}
+
+	require.Equal(t, status, e.Status)
+	require.Equal(t, "release3", e.Data["id"])
+
+	return e
+}
+
+func TestReleasePromoteCanaryActive(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		canarySetup(t, p, canaryState{App: "app1", Release: "release3", Owner: "other", Expires: time.Now().Add(time.Hour)})
+
+		err := p.ReleasePromote("app1", "release2", structs.ReleasePromoteOptions{})
+		require.EqualError(t, err, "canary rollout of release3 in progress for app: app1")
+
+		err = p.ReleasePromote("app1", "release2", structs.ReleasePromoteOptions{Canary: options.Int(10)})
+		require.EqualError(t, err, "canary rollout of release3 in progress for app: app1")
+	})
+}
+
+func TestCanaryResumeOwned(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		canarySetup(t, p, canaryState{App: "app1", Release: "release3", Owner: "other", Expires: time.Now().Add(time.Hour)})
+
+		require.NoError(t, p.CanaryResume())
+
+		cm, err := p.Cluster.CoreV1().ConfigMaps("ns1").Get(context.TODO(), "canary-app1", am.GetOptions{})
+		require.NoError(t, err)
+
+		var cs canaryState
+		require.NoError(t, json.Unmarshal([]byte(cm.Data["rollout"]), &cs))
+		require.Equal(t, "other", cs.Owner)
+
+		_, err = p.Cluster.NetworkingV1().Ingresses("rack1-app1").Get(context.TODO(), "web-canary", am.GetOptions{})
+		require.NoError(t, err)
+	})
+}
+
+func TestCanaryResumePromote(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		canarySetup(t, p, canaryState{App: "app1", Release: "release3", Weight: 80, Step: 20, Interval: time.Millisecond, Timeout: time.Minute, Owner: "other", Expires: time.Now().Add(-1 * time.Minute)})
+		canaryDeploymentCreate(t, p, 2)
+
+		aa := p.Atom.(*atom.MockInterface)
+		aa.On("Apply", "rack1-app1", "app", tm.Anything).Return(nil).Once()
+		aa.On("Status", "rack1-app1", "app").Return("Running", "release3", nil)
+
+		require.NoError(t, p.CanaryResume())
+
+		canaryRequireFinished(t, p, "success")
+	})
+}
+
+func TestCanaryResumeRollback(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		canarySetup(t, p, canaryState{App: "app1", Release: "release3", Weight: 20, Step: 20, Interval: time.Millisecond, Owner: "other", Expires: time.Now().Add(-1 * time.Minute)})
+
+		require.NoError(t, p.CanaryResume())
+
+		canaryRequireFinished(t, p, "error")
+	})
+}
+
+func TestCanaryResumeErrorRate(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		canarySetup(t, p, canaryState{App: "app1", Release: "release3", Weight: 80, Step: 20, Interval: time.Millisecond, Timeout: time.Minute, Owner: "other", Expires: time.Now().Add(-1 * time.Minute)})
+		canaryDeploymentCreate(t, p, 2)
+		canaryRouter(t, p, canaryRouterMetrics(100, 50), canaryRouterMetrics(110, 70))
+
+		require.NoError(t, p.CanaryResume())
+
+		e := canaryRequireFinished(t, p, "error")
+		require.Equal(t, "rolled back: canary for web error rate 67% exceeds 10%", e.Data["message"])
+	})
+}
+
+func TestCanaryResumeErrorRateStable(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		canarySetup(t, p, canaryState{App: "app1", Release: "release3", Weight: 80, Step: 20, Interval: time.Millisecond, Timeout: time.Minute, Owner: "other", Expires: time.Now().Add(-1 * time.Minute)})
+		canaryDeploymentCreate(t, p, 2)
+
+		// failures from before the step and of the stable service do not count
+		canaryRouter(t, p, canaryRouterMetrics(100, 50), canaryRouterMetrics(200, 51))
+
+		aa := p.Atom.(*atom.MockInterface)
+		aa.On("Apply", "rack1-app1", "app", tm.Anything).Return(nil).Once()
+		aa.On("Status", "rack1-app1", "app").Return("Running", "release3", nil)
+
+		require.NoError(t, p.CanaryResume())
+
+		canaryRequireFinished(t, p, "success")
+	})
+}
@@ -1,13 +1,44 @@
 package k8s
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/manifest"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/pkg/errors"
+	ac "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// node labels that name the group a node belongs to, in order of preference
+var capacityNodeGroupLabels = []string{
+	"convox.io/label",
+	"eks.amazonaws.com/nodegroup",
+	"cloud.google.com/gke-nodepool",
+	"agentpool",
+}
+
+type capacityNode struct {
+	structs.CapacityNode
+
+	labels map[string]string
+	taints []ac.Taint
+}
+
+type capacityPod struct {
+	cpu     int64
+	memory  int64
+	node    string
+	service int
+	spec    *ac.PodSpec
+	surge   bool
+}
+
 func (p *Provider) CapacityGet() (*structs.Capacity, error) {
 	ns, err := p.ListNodesFromInformer("")
 	if err != nil {
@@ -40,5 +71,520 @@ func (p *Provider) CapacityGet() (*structs.Capacity, error) {
 		}
 	}
 
+	cns, err := p.capacityNodes()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c.Fragmentation = capacityFragmentation(cns)
+	c.NodeGroups = capacityNodeGroups(cns)
+	c.Nodes = structs.CapacityNodes{}
+
+	for _, cn := range cns {
+		c.Nodes = append(c.Nodes, cn.CapacityNode)
+	}
+
+	sort.Slice(c.Nodes, c.Nodes.Less)
+
 	return c, nil
 }
+
+// CapacityPlan simulates scheduling the services of a release onto the
+// current nodes to find out ahead of a deploy if it would leave processes
+// pending. The extra processes a rolling update starts above the scale of a
+// service are placed after every other process.
+func (p *Provider) CapacityPlan(app string, opts structs.CapacityPlanOptions) (*structs.CapacityPlan, error) {
+	a, err := p.AppGet(app)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	release := common.DefaultString(opts.Release, a.Release)
+	if release == "" {
+		return nil, errors.WithStack(fmt.Errorf("no release for app: %s", app))
+	}
+
+	m, _, err := common.ReleaseManifest(p, app, release)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	scale := map[string]int{}
+
+	for name, v := range opts.Scale {
+		if _, err := m.Service(name); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		count, err := strconv.Atoi(v)
+		if err != nil || count < 0 {
+			return nil, errors.WithStack(fmt.Errorf("invalid scale for %s: %s", name, v))
+		}
+
+		scale[name] = count
+	}
+
+	cns, err := p.capacityNodes()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	nodes := map[string]*capacityNode{}
+
+	for _, cn := range cns {
+		nodes[cn.Name] = cn
+	}
+
+	ns := p.AppNamespace(app)
+
+	// the release replaces the running processes of the app so their
+	// requests are given back before placing the new ones
+	running, err := p.Cluster.CoreV1().Pods(ns).List(p.ctx, am.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s,type=service", app),
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for i := range running.Items {
+		rp := &running.Items[i]
+
+		if cn, ok := nodes[rp.Spec.NodeName]; ok && podActive(rp) {
+			cpu, mem := podRequests(&rp.Spec)
+			cn.Pods--
+			cn.RequestedCPU -= cpu
+			cn.RequestedMemory -= mem
+		}
+	}
+
+	plan := &structs.CapacityPlan{
+		App:      app,
+		Fits:     true,
+		Release:  release,
+		Services: structs.CapacityPlanServices{},
+	}
+
+	pods := []capacityPod{}
+
+	for _, s := range m.Services {
+		ps, err := p.podSpecFromService(app, s.Name, release)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		capacityServiceSpec(ps, s)
+
+		cpu, mem := podRequests(ps)
+
+		count, ok := scale[s.Name]
+		if !ok {
+			count = p.capacityServiceCount(app, s)
+		}
+
+		// agents run one process on each node they can be scheduled on
+		if s.Agent.Enabled {
+			count = 0
+
+			for _, cn := range cns {
+				if capacityNodeMatches(cn, ps) {
+					pods = append(pods, capacityPod{cpu: cpu, memory: mem, node: cn.Name, service: len(plan.Services), spec: ps})
+					count++
+				}
+			}
+		} else {
+			for i := 0; i < count; i++ {
+				pods = append(pods, capacityPod{cpu: cpu, memory: mem, service: len(plan.Services), spec: ps})
+			}
+		}
+
+		surge := capacityServiceSurge(s, count)
+
+		for i := 0; i < surge; i++ {
+			pods = append(pods, capacityPod{cpu: cpu, memory: mem, service: len(plan.Services), spec: ps, surge: true})
+		}
+
+		plan.Services = append(plan.Services, structs.CapacityPlanService{
+			Name:   s.Name,
+			Count:  count,
+			CPU:    cpu,
+			Memory: mem,
+			Surge:  surge,
+		})
+	}
+
+	// place the largest processes first, agents are pinned so go before all
+	// and surge processes only exist during a rollout so go last
+	sort.SliceStable(pods, func(i, j int) bool {
+		if pods[i].surge != pods[j].surge {
+			return !pods[i].surge
+		}
+
+		if (pods[i].node != "") != (pods[j].node != "") {
+			return pods[i].node != ""
+		}
+
+		if pods[i].cpu == pods[j].cpu {
+			return pods[i].memory > pods[j].memory
+		}
+
+		return pods[i].cpu > pods[j].cpu
+	})
+
+	for _, cp := range pods {
+		candidates := cns
+
+		if cp.node != "" {
+			candidates = []*capacityNode{nodes[cp.node]}
+		}
+
+		reason := capacitySchedule(candidates, cp)
+		if reason == "" {
+			continue
+		}
+
+		ps := &plan.Services[cp.service]
+
+		if !cp.surge {
+			plan.Fits = false
+			ps.Pending++
+			ps.Reason = reason
+			continue
+		}
+
+		ps.SurgePending++
+
+		if ps.Reason == "" {
+			ps.Reason = fmt.Sprintf("%s during rollout", reason)
+		}
+
+		// without room for the surge a rollout that cannot stop any old
+		// processes first never makes progress
+		if ms, err := m.Service(ps.Name); err == nil && ms.Deployment.Minimum >= 100 {
+			plan.Fits = false
+		}
+	}
+
+	return plan, nil
+}
+
+// capacityServiceSurge returns how many processes a rolling update of a
+// service starts on top of its count, matching maxSurge on the deployment
+func capacityServiceSurge(s manifest.Service, count int) int {
+	if s.Agent.Enabled || s.Deployment.Maximum <= 100 {
+		return 0
+	}
+
+	return (count*(s.Deployment.Maximum-100) + 99) / 100
+}
+
+// capacityNodes returns the nodes of the cluster with the requests of the
+// processes running on each
+func (p *Provider) capacityNodes() ([]*capacityNode, error) {
+	ns, err := p.ListNodesFromInformer("")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cns := []*capacityNode{}
+	index := map[string]*capacityNode{}
+
+	for _, n := range ns.Items {
+		alloc := n.Status.Allocatable
+
+		if len(alloc) == 0 {
+			alloc = n.Status.Capacity
+		}
+
+		cn := &capacityNode{
+			CapacityNode: structs.CapacityNode{
+				Name:              n.Name,
+				AllocatableCPU:    alloc.Cpu().MilliValue(),
+				AllocatableMemory: alloc.Memory().ScaledValue(resource.Mega),
+				AllocatablePods:   alloc.Pods().Value(),
+				Group:             capacityNodeGroup(n.Labels),
+				Schedulable:       !n.Spec.Unschedulable && nodeReady(&n),
+			},
+			labels: n.Labels,
+			taints: n.Spec.Taints,
+		}
+
+		cns = append(cns, cn)
+		index[n.Name] = cn
+	}
+
+	ps, err := p.Cluster.CoreV1().Pods("").List(p.ctx, am.ListOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for i := range ps.Items {
+		pod := &ps.Items[i]
+
+		cn, ok := index[pod.Spec.NodeName]
+		if !ok || !podActive(pod) {
+			continue
+		}
+
+		cpu, mem := podRequests(&pod.Spec)
+
+		cn.Pods++
+		cn.RequestedCPU += cpu
+		cn.RequestedMemory += mem
+	}
+
+	return cns, nil
+}
+
+// capacityServiceCount returns the number of processes a service would run,
+// services already running keep their current count
+func (p *Provider) capacityServiceCount(app string, s manifest.Service) int {
+	if d, err := p.GetDeploymentFromInformer(s.Name, p.AppNamespace(app)); err == nil && d.Spec.Replicas != nil {
+		return int(*d.Spec.Replicas)
+	}
+
+	return s.Scale.Count.Min
+}
+
+// capacityServiceSpec adds the requests and placement of a service to a pod
+// spec the same way the service template does
+func capacityServiceSpec(ps *ac.PodSpec, s manifest.Service) {
+	c := &ps.Containers[0]
+
+	if c.Resources.Requests == nil {
+		c.Resources.Requests = ac.ResourceList{}
+	}
+
+	if s.Scale.Cpu > 0 {
+		c.Resources.Requests[ac.ResourceCPU] = resource.MustParse(fmt.Sprintf("%dm", s.Scale.Cpu))
+	}
+
+	if s.Scale.Memory > 0 {
+		c.Resources.Requests[ac.ResourceMemory] = resource.MustParse(fmt.Sprintf("%dMi", s.Scale.Memory))
+	}
+
+	if len(s.NodeSelectorLabels) > 0 {
+		ps.NodeSelector = map[string]string{}
+
+		for k, v := range s.NodeSelectorLabels {
+			ps.NodeSelector[k] = v
+
+			if k == "convox.io/label" {
+				ps.Tolerations = append(ps.Tolerations, ac.Toleration{
+					Key:      "dedicated-node",
+					Operator: ac.TolerationOpEqual,
+					Value:    v,
+					Effect:   ac.TaintEffectNoSchedule,
+				})
+			}
+		}
+	}
+}
+
+// capacitySchedule places a pod on the candidate with the most room left
+// like the default scheduler, returning why it could not be placed
+func capacitySchedule(candidates []*capacityNode, cp capacityPod) string {
+	var best *capacityNode
+	var bestScore float64
+
+	reason := "no schedulable nodes"
+
+	for _, cn := range candidates {
+		if !capacityNodeMatches(cn, cp.spec) {
+			continue
+		}
+
+		switch {
+		case cn.AllocatablePods > 0 && cn.Pods+1 > cn.AllocatablePods:
+			reason = "too many pods"
+			continue
+		case cn.RequestedCPU+cp.cpu > cn.AllocatableCPU:
+			reason = "insufficient cpu"
+			continue
+		case cn.RequestedMemory+cp.memory > cn.AllocatableMemory:
+			reason = "insufficient memory"
+			continue
+		}
+
+		score := capacityFree(cn.RequestedCPU+cp.cpu, cn.AllocatableCPU) + capacityFree(cn.RequestedMemory+cp.memory, cn.AllocatableMemory)
+
+		if best == nil || score > bestScore {
+			best = cn
+			bestScore = score
+		}
+	}
+
+	if best == nil {
+		return reason
+	}
+
+	best.Pods++
+	best.RequestedCPU += cp.cpu
+	best.RequestedMemory += cp.memory
+
+	return ""
+}
+
+func capacityFree(requested, allocatable int64) float64 {
+	if allocatable == 0 {
+		return 0
+	}
+
+	return float64(allocatable-requested) / float64(allocatable)
+}
+
+// capacityFragmentation returns the percentage of free cpu and memory that is
+// spread over nodes other than the one with the most free
+func capacityFragmentation(cns []*capacityNode) int64 {
+	var cpu, cpuMax, mem, memMax int64
+
+	for _, cn := range cns {
+		if !cn.Schedulable {
+			continue
+		}
+
+		fc := cn.AllocatableCPU - cn.RequestedCPU
+		fm := cn.AllocatableMemory - cn.RequestedMemory
+
+		if fc > 0 {
+			cpu += fc
+		}
+
+		if fm > 0 {
+			mem += fm
+		}
+
+		if fc > cpuMax {
+			cpuMax = fc
+		}
+
+		if fm > memMax {
+			memMax = fm
+		}
+	}
+
+	frag := func(total, max int64) float64 {
+		if total == 0 {
+			return 0
+		}
+
+		return 1 - float64(max)/float64(total)
+	}
+
+	return int64((frag(cpu, cpuMax) + frag(mem, memMax)) / 2 * 100)
+}
+
+func capacityNodeGroup(labels map[string]string) string {
+	for _, l := range capacityNodeGroupLabels {
+		if v := labels[l]; v != "" {
+			return v
+		}
+	}
+
+	return "default"
+}
+
+func capacityNodeGroups(cns []*capacityNode) structs.CapacityNodeGroups {
+	index := map[string]*structs.CapacityNodeGroup{}
+
+	for _, cn := range cns {
+		g, ok := index[cn.Group]
+		if !ok {
+			g = &structs.CapacityNodeGroup{Name: cn.Group}
+			index[cn.Group] = g
+		}
+
+		g.Nodes++
+		g.AllocatableCPU += cn.AllocatableCPU
+		g.AllocatableMemory += cn.AllocatableMemory
+		g.RequestedCPU += cn.RequestedCPU
+		g.RequestedMemory += cn.RequestedMemory
+	}
+
+	gs := structs.CapacityNodeGroups{}
+
+	for _, g := range index {
+		gs = append(gs, *g)
+	}
+
+	sort.Slice(gs, gs.Less)
+
+	return gs
+}
+
+func capacityNodeMatches(cn *capacityNode, ps *ac.PodSpec) bool {
+	if !cn.Schedulable {
+		return false
+	}
+
+	for k, v := range ps.NodeSelector {
+		if cn.labels[k] != v {
+			return false
+		}
+	}
+
+	for i := range cn.taints {
+		t := &cn.taints[i]
+
+		if t.Effect != ac.TaintEffectNoSchedule && t.Effect != ac.TaintEffectNoExecute {
+			continue
+		}
+
+		tolerated := false
+
+		for _, tol := range ps.Tolerations {
+			if tol.ToleratesTaint(t) {
+				tolerated = true
+				break
+			}
+		}
+
+		if !tolerated {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nodeReady treats a node without a ready condition as ready
+func nodeReady(n *ac.Node) bool {
+	for _, c := range n.Status.Conditions {
+		if c.Type == ac.NodeReady {
+			return c.Status == ac.ConditionTrue
+		}
+	}
+
+	return true
+}
+
+func podActive(pod *ac.Pod) bool {
+	return pod.Status.Phase != ac.PodSucceeded && pod.Status.Phase != ac.PodFailed
+}
+
+// podRequests returns the cpu and memory a pod reserves on its node, the
+// larger of its containers together or any one init container
+func podRequests(ps *ac.PodSpec) (int64, int64) {
+	var cpu, mem int64
+
+	for _, c := range ps.Containers {
+		cpu += c.Resources.Requests.Cpu().MilliValue()
+		mem += c.Resources.Requests.Memory().ScaledValue(resource.Mega)
+	}
+
+	for _, c := range ps.InitContainers {
+		if v := c.Resources.Requests.Cpu().MilliValue(); v > cpu {
+			cpu = v
+		}
+
+		if v := c.Resources.Requests.Memory().ScaledValue(resource.Mega); v > mem {
+			mem = v
+		}
+	}
+
+	if ps.Overhead != nil {
+		cpu += ps.Overhead.Cpu().MilliValue()
+		mem += ps.Overhead.Memory().ScaledValue(resource.Mega)
+	}
+
+	return cpu, mem
+}
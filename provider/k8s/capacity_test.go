@@ -2,9 +2,17 @@ package k8s_test
 
 import (
 	"testing"
+	"time"
 
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/structs"
 	"github.com/convox/convox/provider/k8s"
+	ca "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
 	"github.com/stretchr/testify/require"
+	ac "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
@@ -29,3 +37,163 @@ func TestCapacityGet(t *testing.T) {
 		require.Equal(t, int64(3), c.ProcessCount)
 	})
 }
+
+func capacityNodeCreate(kk kubernetes.Interface, name, group, cpu, mem string) error {
+	return nodeCreator(kk, name, func(n *ac.Node) {
+		n.Labels = map[string]string{"convox.io/label": group}
+		n.Status.Allocatable = ac.ResourceList{
+			ac.ResourceCPU:    resource.MustParse(cpu),
+			ac.ResourceMemory: resource.MustParse(mem),
+			ac.ResourcePods:   resource.MustParse("10"),
+		}
+		n.Status.Capacity = n.Status.Allocatable
+	})
+}
+
+func capacityPodCreate(kk kubernetes.Interface, ns, name, labels, node, cpu, mem string) error {
+	return processCreator(kk, ns, name, labels, func(p *ac.Pod) {
+		p.Spec.NodeName = node
+		p.Spec.Containers[0].Resources.Requests = ac.ResourceList{
+			ac.ResourceCPU:    resource.MustParse(cpu),
+			ac.ResourceMemory: resource.MustParse(mem),
+		}
+	})
+}
+
+func TestCapacityGetNodes(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kk := p.Cluster.(*fake.Clientset)
+
+		require.NoError(t, capacityNodeCreate(kk, "node1", "web", "2000m", "4000M"))
+		require.NoError(t, capacityNodeCreate(kk, "node2", "web", "2000m", "4000M"))
+		require.NoError(t, capacityNodeCreate(kk, "node3", "worker", "4000m", "8000M"))
+		require.NoError(t, appCreate(kk, "rack1", "app1"))
+		require.NoError(t, capacityPodCreate(kk, "rack1-app1", "pod1", "system=convox,rack=rack1,app=app1,service=web,type=service", "node1", "1500m", "1000M"))
+		require.NoError(t, capacityPodCreate(kk, "rack1-app1", "pod2", "system=convox,rack=rack1,app=app1,service=web,type=service", "node2", "1000m", "3000M"))
+		require.NoError(t, capacityPodCreate(kk, "kube-system", "pod3", "k8s-app=kube-dns", "node3", "500m", "1000M"))
+
+		c, err := p.CapacityGet()
+		require.NoError(t, err)
+		require.Equal(t, structs.CapacityNodes{
+			{Name: "node1", AllocatableCPU: 2000, AllocatableMemory: 4000, AllocatablePods: 10, Group: "web", Pods: 1, RequestedCPU: 1500, RequestedMemory: 1000, Schedulable: true},
+			{Name: "node2", AllocatableCPU: 2000, AllocatableMemory: 4000, AllocatablePods: 10, Group: "web", Pods: 1, RequestedCPU: 1000, RequestedMemory: 3000, Schedulable: true},
+			{Name: "node3", AllocatableCPU: 4000, AllocatableMemory: 8000, AllocatablePods: 10, Group: "worker", Pods: 1, RequestedCPU: 500, RequestedMemory: 1000, Schedulable: true},
+		}, c.Nodes)
+		require.Equal(t, structs.CapacityNodeGroups{
+			{Name: "web", AllocatableCPU: 4000, AllocatableMemory: 8000, Nodes: 2, RequestedCPU: 2500, RequestedMemory: 4000},
+			{Name: "worker", AllocatableCPU: 4000, AllocatableMemory: 8000, Nodes: 1, RequestedCPU: 500, RequestedMemory: 1000},
+		}, c.NodeGroups)
+
+		// free cpu 500+1000+3500 with 3500 on one node, free memory 3000+1000+7000 with 7000 on one node
+		require.Equal(t, int64(33), c.Fragmentation)
+	})
+}
+
+const capacityManifest = `services:
+  web:
+    build: .
+    scale:
+      count: 2
+      cpu: 600
+      memory: 512
+  worker:
+    build: .
+    nodeSelectorLabels:
+      convox.io/label: worker
+    scale:
+      cpu: 1000
+      memory: 1024
+`
+
+func capacityPlanSetup(t *testing.T, p *k8s.Provider) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	require.NoError(t, capacityNodeCreate(kk, "node1", "web", "2000m", "4000M"))
+	require.NoError(t, capacityNodeCreate(kk, "node2", "web", "2000m", "4000M"))
+	require.NoError(t, nodeCreator(kk, "node3", func(n *ac.Node) {
+		n.Labels = map[string]string{"convox.io/label": "worker"}
+		n.Spec.Taints = []ac.Taint{{Key: "dedicated-node", Value: "worker", Effect: ac.TaintEffectNoSchedule}}
+		n.Status.Allocatable = ac.ResourceList{
+			ac.ResourceCPU:    resource.MustParse("2000m"),
+			ac.ResourceMemory: resource.MustParse("4000M"),
+		}
+	}))
+
+	require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+		"convox.com/app-release": "R1",
+		"convox.com/app-status":  "running",
+	}))
+
+	now := time.Now().UTC().Format(common.SortableTime)
+
+	_, err := p.Convox.ConvoxV1().Builds("rack1-app1").Create(&ca.Build{
+		ObjectMeta: am.ObjectMeta{Name: "b1", Labels: map[string]string{"app": "app1"}},
+		Spec:       ca.BuildSpec{Ended: now, Manifest: capacityManifest, Started: now, Status: "complete"},
+	})
+	require.NoError(t, err)
+
+	_, err = p.Convox.ConvoxV1().Releases("rack1-app1").Create(&ca.Release{
+		ObjectMeta: am.ObjectMeta{Name: "r1", Labels: map[string]string{"app": "app1"}},
+		Spec:       ca.ReleaseSpec{Build: "B1", Created: now, Manifest: capacityManifest},
+	})
+	require.NoError(t, err)
+
+	// running processes of the app are replaced so do not count against the plan
+	require.NoError(t, capacityPodCreate(kk, "rack1-app1", "web1", "system=convox,rack=rack1,app=app1,service=web,type=service", "node1", "600m", "512Mi"))
+	require.NoError(t, capacityPodCreate(kk, "rack1-app1", "web2", "system=convox,rack=rack1,app=app1,service=web,type=service", "node2", "600m", "512Mi"))
+	require.NoError(t, capacityPodCreate(kk, "kube-system", "dns", "k8s-app=kube-dns", "node1", "500m", "1000M"))
+}
+
+func TestCapacityPlan(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		capacityPlanSetup(t, p)
+
+		plan, err := p.CapacityPlan("app1", structs.CapacityPlanOptions{})
+		require.NoError(t, err)
+		require.Equal(t, &structs.CapacityPlan{
+			App:     "app1",
+			Fits:    true,
+			Release: "R1",
+			Services: structs.CapacityPlanServices{
+				{Name: "web", Count: 2, CPU: 600, Memory: 537, Surge: 2},
+				{Name: "worker", Count: 1, CPU: 1000, Memory: 1074, Surge: 1},
+			},
+		}, plan)
+
+		plan, err = p.CapacityPlan("app1", structs.CapacityPlanOptions{Scale: map[string]string{"web": "6", "worker": "3"}})
+		require.NoError(t, err)
+		require.Equal(t, &structs.CapacityPlan{
+			App:     "app1",
+			Fits:    false,
+			Release: "R1",
+			Services: structs.CapacityPlanServices{
+				{Name: "web", Count: 6, CPU: 600, Memory: 537, Pending: 1, Reason: "insufficient cpu", Surge: 6, SurgePending: 6},
+				{Name: "worker", Count: 3, CPU: 1000, Memory: 1074, Pending: 1, Reason: "insufficient cpu", Surge: 3, SurgePending: 3},
+			},
+		}, plan)
+	})
+}
+
+func TestCapacityPlanSurge(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		capacityPlanSetup(t, p)
+
+		// three web processes fit but the three more started during a rollout do not
+		plan, err := p.CapacityPlan("app1", structs.CapacityPlanOptions{Scale: map[string]string{"web": "3"}})
+		require.NoError(t, err)
+		require.True(t, plan.Fits)
+		require.Equal(t, structs.CapacityPlanService{Name: "web", Count: 3, CPU: 600, Memory: 537, Reason: "insufficient cpu during rollout", Surge: 3, SurgePending: 1}, plan.Services[0])
+	})
+}
+
+func TestCapacityPlanError(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		capacityPlanSetup(t, p)
+
+		_, err := p.CapacityPlan("app1", structs.CapacityPlanOptions{Scale: map[string]string{"missing": "1"}})
+		require.EqualError(t, err, "no such service: missing")
+
+		_, err = p.CapacityPlan("app1", structs.CapacityPlanOptions{Scale: map[string]string{"web": "many"}})
+		require.EqualError(t, err, "invalid scale for web: many")
+	})
+}
@@ -301,8 +301,11 @@ func (p *Provider) CertificateRenew(app string) error {
 	for _, cert := range certs.Items {
 		if strings.Contains(cert.Name, "-domains") {
 			if err := p.renewCertificate(&cert); err != nil {
+				p.EventSend("cert:renew", structs.EventSendOptions{Data: map[string]string{"app": app, "id": cert.Name}, Error: options.String(err.Error())})
 				return err
 			}
+
+			p.EventSend("cert:renew", structs.EventSendOptions{Data: map[string]string{"app": app, "id": cert.Name}})
 		}
 	}
 
@@ -10,12 +10,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/convox/convox/pkg/atom"
 	atomv1 "github.com/convox/convox/pkg/atom/pkg/apis/atom/v1"
 	av "github.com/convox/convox/pkg/atom/pkg/client/clientset/versioned"
 	ic "github.com/convox/convox/pkg/atom/pkg/client/informers/externalversions/atom/v1"
 	"github.com/convox/convox/pkg/common"
 	"github.com/convox/convox/pkg/kctl"
 	"github.com/convox/convox/pkg/manifest"
+	"github.com/convox/convox/pkg/metrics"
+	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
 	convoxv1 "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
 	"github.com/convox/logger"
@@ -29,6 +32,8 @@ import (
 	"k8s.io/client-go/tools/cache"
 )
 
+var atomApplyDuration = metrics.NewHistogram("convox_atom_apply_duration_seconds", "Time from an atom apply starting until it reaches a final status", []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600}, "status")
+
 type AtomController struct {
 	provider   *Provider
 	controller *kctl.Controller
@@ -124,9 +129,50 @@ func (a *AtomController) Update(prev, cur interface{}) error {
 
 	a.logger.Logf("atom update: %s/%s\n", d.Namespace, d.Name)
 
+	if pd, err := assertAtom(prev); err == nil {
+		a.applyFinished(pd, d)
+	}
+
 	return a.syncAtom(d)
 }
 
+// applyFinished records how long an apply took and announces the end of a
+// promote once the atom leaves its rollout for a final status
+func (a *AtomController) applyFinished(prev, cur *atomv1.Atom) {
+	if prev.Status == cur.Status {
+		return
+	}
+
+	var failure *string
+
+	switch cur.Status {
+	case "Running":
+	case "Failure":
+		failure = options.String("release failed")
+	case "Reverted":
+		failure = options.String("release failed and was rolled back")
+	default:
+		return
+	}
+
+	if !cur.Started.IsZero() {
+		atomApplyDuration.Observe(time.Since(cur.Started.Time).Seconds(), string(cur.Status))
+	}
+
+	prefix := fmt.Sprintf("%s-", a.provider.Name)
+
+	if cur.Name != "app" || !strings.HasPrefix(cur.Namespace, prefix) {
+		return
+	}
+
+	_, release := atom.ParseAtomReleaseCache(cur.Spec.ReleaseCache)
+
+	a.provider.EventSend("release:promote", structs.EventSendOptions{
+		Data:  map[string]string{"app": strings.TrimPrefix(cur.Namespace, prefix), "id": release},
+		Error: failure,
+	})
+}
+
 func (a *AtomController) syncAtom(obj *atomv1.Atom) error {
 	a.logger.Logf("syncing atoms...")
 
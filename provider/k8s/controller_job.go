@@ -0,0 +1,127 @@
+package k8s
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/convox/convox/pkg/kctl"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	bv1 "k8s.io/api/batch/v1"
+	ac "k8s.io/api/core/v1"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ic "k8s.io/client-go/informers/batch/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// JobController watches the jobs started by timers and sends a timer:fail
+// event when one of them fails
+type JobController struct {
+	Controller *kctl.Controller
+	Provider   *Provider
+
+	start time.Time
+}
+
+func NewJobController(p *Provider) (*JobController, error) {
+	jc := &JobController{
+		Provider: p,
+		start:    time.Now().UTC(),
+	}
+
+	c, err := kctl.NewController(p.Namespace, "convox-k8s-job", jc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	jc.Controller = c
+
+	return jc, nil
+}
+
+func (c *JobController) Client() kubernetes.Interface {
+	return c.Provider.Cluster
+}
+
+func (c *JobController) Informer() cache.SharedInformer {
+	return ic.NewFilteredJobInformer(c.Provider.Cluster, ac.NamespaceAll, 0, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, c.ListOptions)
+}
+
+func (c *JobController) ListOptions(opts *am.ListOptions) {
+	opts.LabelSelector = fmt.Sprintf("system=convox,rack=%s,type=timer", c.Provider.Name)
+}
+
+func (c *JobController) Run() {
+	ch := make(chan error)
+
+	go c.Controller.Run(ch)
+
+	for err := range ch {
+		fmt.Printf("err = %+v\n", err)
+	}
+}
+
+func (c *JobController) Start() error {
+	c.start = time.Now().UTC()
+
+	return nil
+}
+
+func (c *JobController) Stop() error {
+	return nil
+}
+
+func (c *JobController) Add(obj interface{}) error {
+	return nil
+}
+
+func (c *JobController) Delete(obj interface{}) error {
+	return nil
+}
+
+func (c *JobController) Update(prev, cur interface{}) error {
+	pj, err := assertJob(prev)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cj, err := assertJob(cur)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// only report the transition so a restart does not resend old failures
+	if failed, _ := timerJobFailed(pj); failed {
+		return nil
+	}
+
+	failed, reason := timerJobFailed(cj)
+	if !failed {
+		return nil
+	}
+
+	fmt.Printf("job failed: %s/%s: %s\n", cj.Namespace, cj.Name, reason)
+
+	data := map[string]string{
+		"app":   cj.Labels["app"],
+		"id":    cj.Name,
+		"timer": cj.Labels["name"],
+	}
+
+	if r := cj.Spec.Template.Labels["release"]; r != "" {
+		data["release"] = r
+	}
+
+	return c.Provider.EventSend("timer:fail", structs.EventSendOptions{Data: data, Error: options.String(reason)})
+}
+
+func assertJob(v interface{}) (*bv1.Job, error) {
+	j, ok := v.(*bv1.Job)
+	if !ok {
+		return nil, errors.WithStack(fmt.Errorf("could not assert job for type: %T", v))
+	}
+
+	return j, nil
+}
@@ -3,7 +3,6 @@ package k8s
 import (
 	"fmt"
 	"reflect"
-	"sort"
 
 	"github.com/convox/convox/pkg/kctl"
 	"github.com/pkg/errors"
@@ -70,7 +69,7 @@ func (c *WebhookController) Add(obj interface{}) error {
 		return errors.WithStack(err)
 	}
 
-	c.Provider.webhooks = webhookConfigMapURLs(cm)
+	c.Provider.webhooks = c.Provider.webhookHooks(cm)
 
 	return nil
 }
@@ -81,7 +80,7 @@ func (c *WebhookController) Delete(obj interface{}) error {
 		return errors.WithStack(err)
 	}
 
-	c.Provider.webhooks = webhookConfigMapURLs(cm)
+	c.Provider.webhooks = c.Provider.webhookHooks(cm)
 
 	return nil
 }
@@ -101,7 +100,7 @@ func (c *WebhookController) Update(prev, cur interface{}) error {
 		return nil
 	}
 
-	c.Provider.webhooks = webhookConfigMapURLs(ccm)
+	c.Provider.webhooks = c.Provider.webhookHooks(ccm)
 
 	return nil
 }
@@ -114,19 +113,3 @@ func assertConfigMap(v interface{}) (*ac.ConfigMap, error) {
 
 	return p, nil
 }
-
-func webhookConfigMapURLs(cm *ac.ConfigMap) []string {
-	us := []string{}
-
-	if cm.Data == nil {
-		return us
-	}
-
-	for _, v := range cm.Data {
-		us = append(us, v)
-	}
-
-	sort.Strings(us)
-
-	return us
-}
@@ -20,6 +20,7 @@ type Engine interface {
 	RepositoryAuth(app string) (string, string, error)
 	RepositoryHost(app string) (string, bool, error)
 	RepositoryPrefix() string
+	RepositoryUntag(app string, tags []string) error
 	ResolverHost() (string, error)
 	ServiceHost(app string, s manifest.Service) string
 	SystemHost() string
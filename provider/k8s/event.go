@@ -1,21 +1,31 @@
 package k8s
 
 import (
-	"bytes"
 	"encoding/json"
-	"net/http"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/convox/convox/pkg/common"
 	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-type event struct {
-	Action    string            `json:"action"`
-	Data      map[string]string `json:"data"`
-	Status    string            `json:"status"`
-	Timestamp time.Time         `json:"timestamp"`
-}
+const (
+	EventHistory      = 24 * time.Hour
+	EventLookback     = 1 * time.Minute
+	EventPollInterval = 2 * time.Second
+)
+
+// events are bucketed into one configmap per hour like the audit log and
+// keyed by the time they were sent so that keys sort in the order they arrived
+const eventBucketFormat = "2006010215"
+
+type event structs.Event
 
 func (p *Provider) EventSend(action string, opts structs.EventSendOptions) error {
 	e := event{
@@ -25,6 +35,10 @@ func (p *Provider) EventSend(action string, opts structs.EventSendOptions) error
 		Timestamp: time.Now().UTC(),
 	}
 
+	if e.Data == nil {
+		e.Data = map[string]string{}
+	}
+
 	if e.Data["timestamp"] != "" {
 		t, err := time.Parse(time.RFC3339, e.Data["timestamp"])
 		if err == nil {
@@ -39,24 +53,257 @@ func (p *Provider) EventSend(action string, opts structs.EventSendOptions) error
 
 	e.Data["rack"] = p.Name
 
-	msg, err := json.Marshal(e)
-	if err != nil {
-		return err
+	if err := p.eventAppend(e); err != nil {
+		p.logger.At("EventSend").Errorf("action=%s err=%q", action, err)
 	}
 
 	for _, wh := range p.webhooks {
-		go dispatchWebhook(wh, msg)
+		if !wh.structs().Accepts(action, e.app()) {
+			continue
+		}
+
+		msg, err := wh.payload(e)
+		if err != nil {
+			p.logger.At("EventSend").Errorf("webhook=%s err=%q", wh.Name, err)
+			continue
+		}
+		if msg == nil {
+			continue
+		}
+
+		if err := p.webhookEnqueue(wh, action, msg); err != nil {
+			p.logger.At("EventSend").Errorf("webhook=%s err=%q", wh.Name, err)
+			continue
+		}
+
+		go p.webhookDeliver(wh)
 	}
 
 	return nil
 }
 
-func dispatchWebhook(url string, body []byte) error {
-	res, err := http.Post(url, "application/json", bytes.NewReader(body))
+// EventStream writes the events sent since opts.Since as one JSON object per
+// line and, when following, keeps writing new events until the caller goes
+// away. A poll that finds no new events writes an empty line so that a caller
+// that went away is noticed.
+func (p *Provider) EventStream(opts structs.EventStreamOptions) (io.ReadCloser, error) {
+	filter := structs.Webhook{
+		Apps:   eventPatterns(opts.App),
+		Events: eventPatterns(opts.Action),
+	}
+
+	now := time.Now().UTC()
+	since := now.Add(-1 * common.DefaultDuration(opts.Since, time.Hour))
+
+	keys, es, err := p.eventsAfter(since, "")
 	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	seen := map[string]bool{}
+
+	for _, k := range keys {
+		seen[k] = true
+	}
+
+	r, w := io.Pipe()
+
+	go func() {
+		w.CloseWithError(p.eventStream(w, filter, since, es, seen, common.DefaultBool(opts.Follow, false)))
+	}()
+
+	return r, nil
+}
+
+// eventStream writes es and, when following, polls for new events. Other api
+// replicas can store an event after one with a later key was read, so each
+// poll reads back EventLookback and skips the keys it has seen.
+func (p *Provider) eventStream(w io.Writer, filter structs.Webhook, since time.Time, es []event, seen map[string]bool, follow bool) error {
+	enc := json.NewEncoder(w)
+
+	// events written since the last poll
+	n := 0
+
+	write := func(es []event, since time.Time) error {
+		for _, e := range es {
+			if e.Timestamp.Before(since) || !filter.Accepts(e.Action, e.app()) {
+				continue
+			}
+
+			if err := enc.Encode(structs.Event(e)); err != nil {
+				return err
+			}
+
+			n++
+		}
+
+		return nil
+	}
+
+	if err := write(es, since); err != nil {
 		return err
 	}
-	defer res.Body.Close()
+
+	if !follow {
+		return nil
+	}
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return nil
+		case <-time.After(EventPollInterval):
+		}
+
+		cutoff := eventKeyPrefix(time.Now().UTC().Add(-1 * EventLookback))
+
+		keys, es, err := p.eventsAfter(eventKeyTime(cutoff), cutoff)
+		if err != nil {
+			p.logger.At("EventStream").Errorf("err=%q", err)
+		}
+
+		fresh := []event{}
+
+		for i, k := range keys {
+			if !seen[k] {
+				seen[k] = true
+				fresh = append(fresh, es[i])
+			}
+		}
+
+		for k := range seen {
+			if k < cutoff {
+				delete(seen, k)
+			}
+		}
+
+		if err := write(fresh, time.Time{}); err != nil {
+			return err
+		}
+
+		if n == 0 {
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return err
+			}
+		}
+
+		n = 0
+	}
+}
+
+// eventAppend keeps e in the rack event history
+func (p *Provider) eventAppend(e event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	now := time.Now().UTC()
+
+	key, err := eventKey(now)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	hour := now.Format(eventBucketFormat)
+
+	return p.bucketAppend(fmt.Sprintf("events-%s", hour), "event", hour, key, data)
+}
+
+// eventsAfter returns the events kept in the buckets from the hour of since
+// on that arrived after key, in the order they arrived
+func (p *Provider) eventsAfter(since time.Time, key string) ([]string, []event, error) {
+	cms, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).List(p.ctx, am.ListOptions{
+		LabelSelector: fmt.Sprintf("system=convox,type=event,%s", bucketSince(since, eventBucketFormat)),
+	})
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	data := map[string]string{}
+	keys := []string{}
+
+	for _, cm := range cms.Items {
+		for k, v := range cm.Data {
+			if k > key {
+				data[k] = v
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	es := make([]event, len(keys))
+
+	for i, k := range keys {
+		if err := json.Unmarshal([]byte(data[k]), &es[i]); err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+	}
+
+	return keys, es, nil
+}
+
+// eventPrune removes the event history older than EventHistory
+func (p *Provider) eventPrune() error {
+	cms, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).List(p.ctx, am.ListOptions{
+		LabelSelector: "system=convox,type=event",
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	first := fmt.Sprintf("events-%s", time.Now().UTC().Add(-1*EventHistory).Format(eventBucketFormat))
+
+	for _, cm := range cms.Items {
+		if cm.Name >= first {
+			continue
+		}
+
+		if err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Delete(p.ctx, cm.Name, am.DeleteOptions{}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
 
 	return nil
 }
+
+// eventKey is unique across api replicas sending events at the same time
+func eventKey(t time.Time) (string, error) {
+	suffix, err := common.RandomString(6)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%s", eventKeyPrefix(t), suffix), nil
+}
+
+func eventKeyPrefix(t time.Time) string {
+	return fmt.Sprintf("%019d", t.UnixNano())
+}
+
+func eventKeyTime(key string) time.Time {
+	ns, err := strconv.ParseInt(strings.SplitN(key, "-", 2)[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(0, ns).UTC()
+}
+
+func eventPatterns(s *string) []string {
+	if s == nil || *s == "" {
+		return nil
+	}
+
+	ps := []string{}
+
+	for _, p := range strings.Split(*s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			ps = append(ps, p)
+		}
+	}
+
+	return ps
+}
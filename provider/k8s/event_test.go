@@ -0,0 +1,216 @@
+package k8s_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	"github.com/stretchr/testify/require"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func eventActions(t *testing.T, r io.Reader) []string {
+	actions := []string{}
+
+	s := bufio.NewScanner(r)
+
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+
+		var e structs.Event
+		require.NoError(t, json.Unmarshal(s.Bytes(), &e))
+		actions = append(actions, e.Action+" "+e.Data["app"]+e.Data["name"])
+	}
+
+	return actions
+}
+
+// eventNext scans past the empty lines that keep a followed stream alive
+func eventNext(t *testing.T, s *bufio.Scanner) structs.Event {
+	deadline := time.Now().Add(10 * time.Second)
+
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			require.True(t, time.Now().Before(deadline), "no event before the deadline")
+			continue
+		}
+
+		var e structs.Event
+		require.NoError(t, json.Unmarshal(s.Bytes(), &e))
+		return e
+	}
+
+	require.Fail(t, "stream ended")
+
+	return structs.Event{}
+}
+
+func TestEventSendHistory(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		require.NoError(t, p.EventSend("app:create", structs.EventSendOptions{Data: map[string]string{"name": "app1"}}))
+
+		cms, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).List(context.TODO(), am.ListOptions{LabelSelector: "system=convox,type=event"})
+		require.NoError(t, err)
+		require.Len(t, cms.Items, 1)
+		require.Len(t, cms.Items[0].Data, 1)
+
+		for _, data := range cms.Items[0].Data {
+			var e structs.Event
+			require.NoError(t, json.Unmarshal([]byte(data), &e))
+			require.Equal(t, "app:create", e.Action)
+			require.Equal(t, "success", e.Status)
+			require.Equal(t, map[string]string{"name": "app1", "rack": "rack1"}, e.Data)
+		}
+	})
+}
+
+func TestEventStream(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		require.NoError(t, p.EventSend("app:create", structs.EventSendOptions{Data: map[string]string{"name": "app1"}}))
+		require.NoError(t, p.EventSend("release:promote", structs.EventSendOptions{Data: map[string]string{"app": "app1", "id": "R1"}}))
+		require.NoError(t, p.EventSend("release:promote", structs.EventSendOptions{Data: map[string]string{"app": "app2", "id": "R2"}}))
+		require.NoError(t, p.EventSend("cert:renew", structs.EventSendOptions{Data: map[string]string{"id": "cert1"}, Error: options.String("err1")}))
+
+		r, err := p.EventStream(structs.EventStreamOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"app:create app1", "release:promote app1", "release:promote app2", "cert:renew "}, eventActions(t, r))
+
+		r, err = p.EventStream(structs.EventStreamOptions{App: options.String("app1")})
+		require.NoError(t, err)
+		require.Equal(t, []string{"app:create app1", "release:promote app1"}, eventActions(t, r))
+
+		r, err = p.EventStream(structs.EventStreamOptions{Action: options.String("release:*,cert:*")})
+		require.NoError(t, err)
+		require.Equal(t, []string{"release:promote app1", "release:promote app2", "cert:renew "}, eventActions(t, r))
+
+		r, err = p.EventStream(structs.EventStreamOptions{App: options.String("app2"), Action: options.String("app:*")})
+		require.NoError(t, err)
+		require.Equal(t, []string{}, eventActions(t, r))
+	})
+}
+
+func TestEventStreamSince(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		ts := time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)
+
+		require.NoError(t, p.EventSend("app:create", structs.EventSendOptions{Data: map[string]string{"name": "app1", "timestamp": ts}}))
+		require.NoError(t, p.EventSend("app:create", structs.EventSendOptions{Data: map[string]string{"name": "app2"}}))
+
+		r, err := p.EventStream(structs.EventStreamOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"app:create app2"}, eventActions(t, r))
+
+		r, err = p.EventStream(structs.EventStreamOptions{Since: options.Duration(3 * time.Hour)})
+		require.NoError(t, err)
+		require.Equal(t, []string{"app:create app1", "app:create app2"}, eventActions(t, r))
+	})
+}
+
+func TestEventStreamFollow(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		require.NoError(t, p.EventSend("release:promote", structs.EventSendOptions{Data: map[string]string{"app": "app1", "id": "R1"}, Status: options.String("start")}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		pp := p.WithContext(ctx)
+
+		r, err := pp.EventStream(structs.EventStreamOptions{App: options.String("app1"), Follow: options.Bool(true)})
+		require.NoError(t, err)
+		defer r.Close()
+
+		s := bufio.NewScanner(r)
+
+		e := eventNext(t, s)
+		require.Equal(t, "start", e.Status)
+
+		require.NoError(t, p.EventSend("release:promote", structs.EventSendOptions{Data: map[string]string{"app": "app2", "id": "R2"}}))
+		require.NoError(t, p.EventSend("release:promote", structs.EventSendOptions{Data: map[string]string{"app": "app1", "id": "R1"}}))
+
+		e = eventNext(t, s)
+		require.Equal(t, "success", e.Status)
+		require.Equal(t, "R1", e.Data["id"])
+
+		cancel()
+
+		for s.Scan() {
+			require.Empty(t, s.Bytes())
+		}
+	})
+}
+
+func TestEventStreamFollowClosed(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kk := p.Cluster.(*fake.Clientset)
+
+		r, err := p.EventStream(structs.EventStreamOptions{Follow: options.Bool(true)})
+		require.NoError(t, err)
+
+		// a poll without events keeps the stream alive
+		s := bufio.NewScanner(r)
+		require.True(t, s.Scan())
+		require.Empty(t, s.Bytes())
+
+		require.NoError(t, r.Close())
+
+		time.Sleep(k8s.EventPollInterval + 500*time.Millisecond)
+
+		polls := len(kk.Actions())
+
+		time.Sleep(k8s.EventPollInterval + 500*time.Millisecond)
+
+		require.Equal(t, polls, len(kk.Actions()))
+	})
+}
+
+func TestEventStreamFollowLate(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r, err := p.WithContext(ctx).EventStream(structs.EventStreamOptions{Follow: options.Bool(true)})
+		require.NoError(t, err)
+		defer r.Close()
+
+		s := bufio.NewScanner(r)
+
+		require.NoError(t, p.EventSend("app:create", structs.EventSendOptions{Data: map[string]string{"name": "app1"}}))
+
+		e := eventNext(t, s)
+		require.Equal(t, "app:create", e.Action)
+
+		// another api stores an event sent before the one already read
+		cms, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).List(context.TODO(), am.ListOptions{LabelSelector: "system=convox,type=event"})
+		require.NoError(t, err)
+		require.Len(t, cms.Items, 1)
+
+		data, err := json.Marshal(structs.Event{Action: "app:delete", Data: map[string]string{"name": "app2"}, Status: "success", Timestamp: time.Now().UTC()})
+		require.NoError(t, err)
+
+		cm := cms.Items[0]
+		cm.Data[fmt.Sprintf("%019d-late01", time.Now().Add(-10*time.Second).UnixNano())] = string(data)
+
+		_, err = p.Cluster.CoreV1().ConfigMaps(p.Namespace).Update(context.TODO(), &cm, am.UpdateOptions{})
+		require.NoError(t, err)
+
+		e = eventNext(t, s)
+		require.Equal(t, "app:delete", e.Action)
+		require.Equal(t, "app2", e.Data["name"])
+
+		require.NoError(t, p.EventSend("app:update", structs.EventSendOptions{Data: map[string]string{"name": "app1"}}))
+
+		// events are written once
+		e = eventNext(t, s)
+		require.Equal(t, "app:update", e.Action)
+	})
+}
@@ -0,0 +1,14 @@
+package k8s
+
+import "crypto/x509"
+
+// SetRepositoryRootCAs trusts pool when verifying the app repository and
+// returns a func that restores the system roots
+func SetRepositoryRootCAs(pool *x509.CertPool) func() {
+	repositoryRootCAs = pool
+	return func() { repositoryRootCAs = nil }
+}
+
+func (p *Provider) RepositoryInternal(host string) bool {
+	return p.repositoryInternal(host)
+}
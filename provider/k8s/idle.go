@@ -0,0 +1,588 @@
+package k8s
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	ac "k8s.io/api/core/v1"
+	ae "k8s.io/apimachinery/pkg/api/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	IdleCheckInterval = 1 * time.Minute
+	IdleProxyPort     = 5080
+	IdleWakeTimeout   = 5 * time.Minute
+)
+
+// idleBackend is the service in each app namespace that points the routers
+// at the idle proxy of the rack
+const idleBackend = "convox-idler"
+
+// idleIngressAnnotations hand the requests for a service with no ready
+// processes to the idle proxy
+var idleIngressAnnotations = map[string]string{
+	"nginx.ingress.kubernetes.io/custom-http-errors": "503",
+	"nginx.ingress.kubernetes.io/default-backend":    idleBackend,
+}
+
+// idleTokenHeader carries the token of a namespace from its ingresses to
+// the idle proxy, see IdleToken
+const idleTokenHeader = "X-Convox-Idler"
+
+// the routers add these headers when they hand a request to the idle proxy
+var idleProxyHeaders = []string{"X-Code", "X-Format", "X-Ingress-Name", "X-Namespace", "X-Original-URI", "X-Service-Name", "X-Service-Port", idleTokenHeader}
+
+var idleWakeLock sync.Mutex
+
+// idleService is the idling state of a service, kept for every service of
+// an app in the convox.com/idle annotation of its namespace
+type idleService struct {
+	Active   time.Time  `json:"active"`
+	Idle     *time.Time `json:"idle,omitempty"`
+	Replicas int32      `json:"replicas,omitempty"`
+	Requests float64    `json:"requests"`
+	Woken    *time.Time `json:"woken,omitempty"`
+}
+
+// wakeReplicas is the count a service had before it went idle
+func (s idleService) wakeReplicas() int32 {
+	if s.Replicas < 1 {
+		return 1
+	}
+
+	return s.Replicas
+}
+
+func appIdleTimeout(a *structs.App) (time.Duration, error) {
+	v := a.Parameters[structs.AppParamIdleTimeout]
+	if v == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || (d != 0 && d < time.Minute) {
+		return 0, errors.WithStack(fmt.Errorf("invalid %s for app %s: %s", structs.AppParamIdleTimeout, a.Name, v))
+	}
+
+	return d, nil
+}
+
+func idleState(ns *ac.Namespace) (map[string]*idleService, error) {
+	state := map[string]*idleService{}
+
+	if data := ns.Annotations["convox.com/idle"]; data != "" {
+		if err := json.Unmarshal([]byte(data), &state); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return state, nil
+}
+
+func idleServices(ns *ac.Namespace) structs.AppIdles {
+	state, err := idleState(ns)
+	if err != nil {
+		return nil
+	}
+
+	ais := structs.AppIdles{}
+
+	for service, s := range state {
+		if s.Idle != nil {
+			ais = append(ais, structs.AppIdle{Service: service, Since: *s.Idle})
+		}
+	}
+
+	if len(ais) == 0 {
+		return nil
+	}
+
+	sort.Slice(ais, func(i, j int) bool { return ais[i].Service < ais[j].Service })
+
+	return ais
+}
+
+func (p *Provider) idleStateSave(ns string, state map[string]*idleService) error {
+	var value interface{}
+
+	if len(state) > 0 {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		value = string(data)
+	}
+
+	patch, err := patchBytes(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				"convox.com/idle": value,
+			},
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := p.Cluster.CoreV1().Namespaces().Patch(p.ctx, ns, types.MergePatchType, patch, am.PatchOptions{}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (p *Provider) idleScale(ns, service string, replicas int32) error {
+	patch, err := patchBytes(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := p.Cluster.AppsV1().Deployments(ns).Patch(p.ctx, service, types.MergePatchType, patch, am.PatchOptions{}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// IdleCheck scales the services of apps with an IdleTimeout to zero once the
+// routers have served them no requests for that long
+func (p *Provider) IdleCheck() error {
+	as, err := p.AppList()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for i := range as {
+		if err := p.appIdleCheck(&as[i], time.Now().UTC()); err != nil {
+			p.logger.At("IdleCheck").Errorf("app=%s err=%q", as[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) appIdleCheck(a *structs.App, now time.Time) error {
+	timeout, err := appIdleTimeout(a)
+	if err != nil {
+		return err
+	}
+
+	ns, err := p.Cluster.CoreV1().Namespaces().Get(p.ctx, p.AppNamespace(a.Name), am.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	state, err := idleState(ns)
+	if err != nil {
+		return err
+	}
+
+	// idling was turned off, wake anything left idle
+	if timeout == 0 {
+		if len(state) == 0 {
+			return nil
+		}
+
+		for service, s := range state {
+			if s.Idle != nil {
+				if err := p.idleScale(ns.Name, service, s.wakeReplicas()); err != nil {
+					return err
+				}
+			}
+		}
+
+		return p.idleStateSave(ns.Name, nil)
+	}
+
+	if a.Status != "running" {
+		return nil
+	}
+
+	counts, err := p.routerRequestCounts(ns.Name)
+	if err != nil {
+		return err
+	}
+
+	requests := map[string]float64{}
+
+	for _, cs := range counts {
+		for service, count := range cs {
+			requests[service] += count
+		}
+	}
+
+	routed, err := p.idleRoutedServices(ns.Name)
+	if err != nil {
+		return err
+	}
+
+	ds, err := p.Cluster.AppsV1().Deployments(ns.Name).List(p.ctx, am.ListOptions{LabelSelector: "system=convox,type=service"})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	next := map[string]*idleService{}
+
+	for _, d := range ds.Items {
+		if !routed[d.Name] {
+			continue
+		}
+
+		replicas := common.DefaultInt32(d.Spec.Replicas, 0)
+
+		s, ok := state[d.Name]
+
+		switch {
+		case !ok:
+			s = &idleService{Active: now, Requests: requests[d.Name]}
+		case s.Idle != nil && replicas > 0:
+			// scaled up by a deploy or convox scale
+			s = &idleService{Active: now, Requests: requests[d.Name]}
+		case s.Idle != nil:
+			s.Requests = requests[d.Name]
+		case s.Requests != requests[d.Name]:
+			s.Active, s.Requests = now, requests[d.Name]
+		case replicas > 0 && now.Sub(s.Active) >= timeout:
+			if err := p.idleScale(ns.Name, d.Name, 0); err != nil {
+				return err
+			}
+
+			s.Idle, s.Replicas = &now, replicas
+
+			p.EventSend("service:idle", structs.EventSendOptions{Data: map[string]string{"app": a.Name, "service": d.Name}})
+		}
+
+		next[d.Name] = s
+	}
+
+	data, err := json.Marshal(next)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if current := ns.Annotations["convox.com/idle"]; current == string(data) || (current == "" && len(next) == 0) {
+		return nil
+	}
+
+	return p.idleStateSave(ns.Name, next)
+}
+
+// idleRoutedServices are the services that receive requests through the
+// routers and are not autoscaled, only these can idle
+func (p *Provider) idleRoutedServices(ns string) (map[string]bool, error) {
+	is, err := p.Cluster.NetworkingV1().Ingresses(ns).List(p.ctx, am.ListOptions{LabelSelector: "system=convox,type=service"})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	routed := map[string]bool{}
+
+	for _, i := range is.Items {
+		if s := i.Labels["service"]; s != "" {
+			routed[s] = true
+		}
+	}
+
+	hs, err := p.Cluster.AutoscalingV2().HorizontalPodAutoscalers(ns).List(p.ctx, am.ListOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, h := range hs.Items {
+		delete(routed, h.Name)
+	}
+
+	return routed, nil
+}
+
+// ServiceWake scales an idle service back to the replicas it had before it
+// went idle, returning false if the service was not idle
+func (p *Provider) ServiceWake(app, service string) (bool, error) {
+	idleWakeLock.Lock()
+	defer idleWakeLock.Unlock()
+
+	ns, err := p.Cluster.CoreV1().Namespaces().Get(p.ctx, p.AppNamespace(app), am.GetOptions{})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	state, err := idleState(ns)
+	if err != nil {
+		return false, err
+	}
+
+	s, ok := state[service]
+	if !ok || s.Idle == nil {
+		return false, nil
+	}
+
+	if err := p.idleScale(ns.Name, service, s.wakeReplicas()); err != nil {
+		return false, err
+	}
+
+	now := time.Now().UTC()
+
+	s.Active, s.Idle, s.Replicas, s.Woken = now, nil, 0, &now
+
+	if err := p.idleStateSave(ns.Name, state); err != nil {
+		return false, err
+	}
+
+	p.EventSend("service:wake", structs.EventSendOptions{Data: map[string]string{"app": app, "service": service}})
+
+	return true, nil
+}
+
+func (p *Provider) startIdleProxy() {
+	if err := http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", IdleProxyPort), http.HandlerFunc(p.IdleProxy)); err != nil {
+		fmt.Printf("error: could not start idle proxy listener: %v\n", err)
+	}
+}
+
+// IdleProxy receives the requests the routers could not deliver because a
+// service has no ready processes. Only requests carrying the token of their
+// namespace are accepted. An idle service is woken and the request held until
+// the service is ready, then forwarded to it, as are the requests that arrive
+// while it wakes. Anything else gets a 503.
+func (p *Provider) IdleProxy(w http.ResponseWriter, r *http.Request) {
+	ns, service := r.Header.Get("X-Namespace"), r.Header.Get("X-Service-Name")
+
+	app := strings.TrimPrefix(ns, fmt.Sprintf("%s-", p.Name))
+
+	if service == "" || app == "" || app == ns {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	token, err := p.IdleToken(ns)
+	if err != nil {
+		p.logger.At("IdleProxy").Errorf("app=%s service=%s err=%q", app, service, err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	if !hmac.Equal([]byte(r.Header.Get(idleTokenHeader)), []byte(token)) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	woke, err := p.ServiceWake(app, service)
+	if err != nil {
+		p.logger.At("IdleProxy").Errorf("app=%s service=%s err=%q", app, service, err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	if !woke {
+		waking, err := p.idleWaking(r.Context(), ns, service)
+		if err != nil {
+			p.logger.At("IdleProxy").Errorf("app=%s service=%s err=%q", app, service, err)
+		}
+
+		// the service is up and answered 503 itself, or is down for another reason
+		if !waking {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	if err := p.idleWait(r.Context(), ns, service); err != nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	p.idleForward(w, r, ns, service)
+}
+
+// IdleToken is the value of the idleTokenHeader the ingresses of a namespace
+// send along with the requests they hand to the idle proxy
+func (p *Provider) IdleToken(ns string) (string, error) {
+	key, err := p.idleKey()
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+
+	if _, err := mac.Write([]byte(ns)); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// idleKey signs the idle tokens, it is kept in the idler secret of the rack
+// and created the first time it is needed
+func (p *Provider) idleKey() ([]byte, error) {
+	ss := p.Cluster.CoreV1().Secrets(p.Namespace)
+
+	s, err := ss.Get(p.ctx, "idler", am.GetOptions{})
+	if ae.IsNotFound(err) {
+		var key string
+
+		key, err = common.RandomString(32)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		s, err = ss.Create(p.ctx, &ac.Secret{
+			ObjectMeta: am.ObjectMeta{
+				Namespace: p.Namespace,
+				Name:      "idler",
+				Labels:    map[string]string{"system": "convox"},
+			},
+			Type: ac.SecretTypeOpaque,
+			Data: map[string][]byte{"key": []byte(key)},
+		}, am.CreateOptions{})
+		if ae.IsAlreadyExists(err) {
+			return p.idleKey()
+		}
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(s.Data["key"]) == 0 {
+		return nil, errors.WithStack(fmt.Errorf("idler secret has no key"))
+	}
+
+	return s.Data["key"], nil
+}
+
+// idleIngressAnnotate adds the annotations that hand the requests for an
+// idle service to the idle proxy. The token is set on the incoming request so
+// that it survives the hand off and is cleared from what the service receives.
+func (p *Provider) idleIngressAnnotate(ns string, ans map[string]string) error {
+	token, err := p.IdleToken(ns)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range idleIngressAnnotations {
+		ans[k] = v
+	}
+
+	snippet := fmt.Sprintf("more_set_input_headers \"%s: %s\";\nproxy_set_header %s \"\";\n", idleTokenHeader, token, idleTokenHeader)
+
+	if v := strings.TrimSpace(ans["nginx.ingress.kubernetes.io/configuration-snippet"]); v != "" {
+		snippet = fmt.Sprintf("%s\n%s", v, snippet)
+	}
+
+	ans["nginx.ingress.kubernetes.io/configuration-snippet"] = snippet
+
+	return nil
+}
+
+// idleWaking is true while a service woken by an earlier request is not yet
+// ready, so that the requests arriving meanwhile are held as well
+func (p *Provider) idleWaking(ctx context.Context, ns, service string) (bool, error) {
+	n, err := p.Cluster.CoreV1().Namespaces().Get(ctx, ns, am.GetOptions{})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	state, err := idleState(n)
+	if err != nil {
+		return false, err
+	}
+
+	s, ok := state[service]
+	if !ok || s.Idle != nil || s.Woken == nil || time.Since(*s.Woken) > IdleWakeTimeout {
+		return false, nil
+	}
+
+	d, err := p.Cluster.AppsV1().Deployments(ns).Get(ctx, service, am.GetOptions{})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return d.Status.ReadyReplicas == 0, nil
+}
+
+func (p *Provider) idleWait(ctx context.Context, ns, service string) error {
+	err := common.WaitContext(ctx, 1*time.Second, IdleWakeTimeout, 1, func() (bool, error) {
+		d, err := p.Cluster.AppsV1().Deployments(ns).Get(ctx, service, am.GetOptions{})
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+
+		return d.Status.ReadyReplicas > 0, nil
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(ctx.Err())
+}
+
+func (p *Provider) idleForward(w http.ResponseWriter, r *http.Request, ns, service string) {
+	port, err := p.idleServicePort(r.Context(), ns, service)
+	if err != nil {
+		p.logger.At("idleForward").Errorf("namespace=%s service=%s err=%q", ns, service, err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	scheme := "http"
+
+	if i, err := p.Cluster.NetworkingV1().Ingresses(ns).Get(r.Context(), r.Header.Get("X-Ingress-Name"), am.GetOptions{}); err == nil && i.Labels["service"] == service {
+		scheme = strings.ToLower(common.CoalesceString(i.Annotations["convox.com/backend-protocol"], scheme))
+	}
+
+	target := &url.URL{
+		Scheme: scheme,
+		Host:   fmt.Sprintf("%s.%s.svc.cluster.local:%d", service, ns, port),
+	}
+
+	if u, err := url.ParseRequestURI(r.Header.Get("X-Original-URI")); err == nil {
+		r.URL.Path, r.URL.RawQuery = u.Path, u.RawQuery
+	}
+
+	for _, h := range idleProxyHeaders {
+		r.Header.Del(h)
+	}
+
+	rp := httputil.NewSingleHostReverseProxy(target)
+
+	rp.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+
+	rp.ServeHTTP(w, r)
+}
+
+// idleServicePort is the port the ingresses of a service route to
+func (p *Provider) idleServicePort(ctx context.Context, ns, service string) (int32, error) {
+	s, err := p.Cluster.CoreV1().Services(ns).Get(ctx, service, am.GetOptions{})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	for _, sp := range s.Spec.Ports {
+		if sp.Name == "main" {
+			return sp.Port, nil
+		}
+	}
+
+	return 0, errors.WithStack(fmt.Errorf("service has no main port: %s", service))
+}
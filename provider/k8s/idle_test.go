@@ -0,0 +1,308 @@
+package k8s_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/mock"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	asv2 "k8s.io/api/autoscaling/v2"
+	ac "k8s.io/api/core/v1"
+	nv1 "k8s.io/api/networking/v1"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	kt "k8s.io/client-go/testing"
+)
+
+func idleSetup(t *testing.T, p *k8s.Provider, timeout string) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	p.Engine = scanEngine{TestEngine: &mock.TestEngine{}, p: p}
+
+	data, err := json.Marshal(map[string]string{structs.AppParamIdleTimeout: timeout})
+	require.NoError(t, err)
+
+	require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+		"convox.com/app-release": "release1",
+		"convox.com/app-status":  "Running",
+		"convox.com/params":      string(data),
+	}))
+
+	services := map[string]int32{"api": 1, "web": 2, "worker": 1}
+
+	for name, replicas := range services {
+		r := replicas
+
+		_, err := kk.AppsV1().Deployments("rack1-app1").Create(context.TODO(), &appsv1.Deployment{
+			ObjectMeta: am.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"service": name, "system": "convox", "type": "service"},
+			},
+			Spec:   appsv1.DeploymentSpec{Replicas: &r},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: r},
+		}, am.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	for _, name := range []string{"api", "web"} {
+		_, err := kk.NetworkingV1().Ingresses("rack1-app1").Create(context.TODO(), &nv1.Ingress{
+			ObjectMeta: am.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"service": name, "system": "convox", "type": "service"},
+			},
+		}, am.CreateOptions{})
+		require.NoError(t, err)
+
+		_, err = kk.CoreV1().Services("rack1-app1").Create(context.TODO(), &ac.Service{
+			ObjectMeta: am.ObjectMeta{Name: name},
+			Spec:       ac.ServiceSpec{Ports: []ac.ServicePort{{Name: "main", Port: 1}}},
+		}, am.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	_, err = kk.AutoscalingV2().HorizontalPodAutoscalers("rack1-app1").Create(context.TODO(), &asv2.HorizontalPodAutoscaler{
+		ObjectMeta: am.ObjectMeta{Name: "api"},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = kk.CoreV1().Pods("ns1").Create(context.TODO(), &ac.Pod{
+		ObjectMeta: am.ObjectMeta{
+			Name:   "router-1",
+			Labels: map[string]string{"service": "ingress-nginx", "system": "convox"},
+		},
+		Status: ac.PodStatus{Phase: ac.PodRunning},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+
+	kk.PrependProxyReactor("pods", func(action kt.Action) (bool, restclient.ResponseWrapper, error) {
+		return true, proxyResponse(routerMetrics), nil
+	})
+}
+
+func idleActive(t *testing.T, p *k8s.Provider, service string, active time.Time, requests float64) {
+	data, err := json.Marshal(map[string]interface{}{
+		service: map[string]interface{}{"active": active, "requests": requests},
+	})
+	require.NoError(t, err)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{"convox.com/idle": string(data)},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = p.Cluster.CoreV1().Namespaces().Patch(context.TODO(), "rack1-app1", types.MergePatchType, patch, am.PatchOptions{})
+	require.NoError(t, err)
+}
+
+func idleReplicas(t *testing.T, p *k8s.Provider, service string) int32 {
+	d, err := p.Cluster.AppsV1().Deployments("rack1-app1").Get(context.TODO(), service, am.GetOptions{})
+	require.NoError(t, err)
+
+	return *d.Spec.Replicas
+}
+
+func TestAppIdles(t *testing.T) {
+	tests := map[string]struct {
+		Idles bool
+		Error string
+	}{
+		"":    {Idles: false},
+		"0":   {Idles: false},
+		"30m": {Idles: true},
+		"10s": {Error: "invalid IdleTimeout for app app1: 10s"},
+		"foo": {Error: "invalid IdleTimeout for app app1: foo"},
+	}
+
+	for timeout, test := range tests {
+		t.Run(timeout, func(t *testing.T) {
+			testProvider(t, func(p *k8s.Provider) {
+				idleSetup(t, p, timeout)
+
+				idles, err := p.AppIdles("app1")
+				if test.Error != "" {
+					require.EqualError(t, err, test.Error)
+					return
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, test.Idles, idles)
+			})
+		})
+	}
+}
+
+func TestIdleCheck(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		idleSetup(t, p, "30m")
+
+		require.NoError(t, p.IdleCheck())
+		require.Equal(t, int32(2), idleReplicas(t, p, "web"))
+
+		ns, err := p.Cluster.CoreV1().Namespaces().Get(context.TODO(), "rack1-app1", am.GetOptions{})
+		require.NoError(t, err)
+		require.Contains(t, ns.Annotations["convox.com/idle"], `"web":{"active":`)
+		require.NotContains(t, ns.Annotations["convox.com/idle"], `"api"`)
+		require.NotContains(t, ns.Annotations["convox.com/idle"], `"worker"`)
+
+		// requests since the last check keep the service active
+		idleActive(t, p, "web", time.Now().UTC().Add(-1*time.Hour), 10)
+		require.NoError(t, p.IdleCheck())
+		require.Equal(t, int32(2), idleReplicas(t, p, "web"))
+
+		idleActive(t, p, "web", time.Now().UTC().Add(-1*time.Hour), 50)
+		require.NoError(t, p.IdleCheck())
+		require.Equal(t, int32(0), idleReplicas(t, p, "web"))
+		require.Equal(t, int32(1), idleReplicas(t, p, "api"))
+		require.Equal(t, int32(1), idleReplicas(t, p, "worker"))
+
+		a, err := p.AppGet("app1")
+		require.NoError(t, err)
+		require.Len(t, a.Idle, 1)
+		require.Equal(t, "web", a.Idle[0].Service)
+
+		woke, err := p.ServiceWake("app1", "web")
+		require.NoError(t, err)
+		require.True(t, woke)
+		require.Equal(t, int32(2), idleReplicas(t, p, "web"))
+
+		woke, err = p.ServiceWake("app1", "web")
+		require.NoError(t, err)
+		require.False(t, woke)
+
+		a, err = p.AppGet("app1")
+		require.NoError(t, err)
+		require.Len(t, a.Idle, 0)
+	})
+}
+
+func TestIdleCheckDisabled(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		idleSetup(t, p, "30m")
+
+		idleActive(t, p, "web", time.Now().UTC().Add(-1*time.Hour), 50)
+		require.NoError(t, p.IdleCheck())
+		require.Equal(t, int32(0), idleReplicas(t, p, "web"))
+
+		data, err := json.Marshal(map[string]string{structs.AppParamIdleTimeout: ""})
+		require.NoError(t, err)
+
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{"convox.com/params": string(data)},
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = p.Cluster.CoreV1().Namespaces().Patch(context.TODO(), "rack1-app1", types.MergePatchType, patch, am.PatchOptions{})
+		require.NoError(t, err)
+
+		require.NoError(t, p.IdleCheck())
+		require.Equal(t, int32(2), idleReplicas(t, p, "web"))
+
+		ns, err := p.Cluster.CoreV1().Namespaces().Get(context.TODO(), "rack1-app1", am.GetOptions{})
+		require.NoError(t, err)
+		require.NotContains(t, ns.Annotations, "convox.com/idle")
+	})
+}
+
+func idleReady(t *testing.T, p *k8s.Provider, service string, ready int32) {
+	d, err := p.Cluster.AppsV1().Deployments("rack1-app1").Get(context.TODO(), service, am.GetOptions{})
+	require.NoError(t, err)
+
+	d.Status.ReadyReplicas = ready
+
+	_, err = p.Cluster.AppsV1().Deployments("rack1-app1").UpdateStatus(context.TODO(), d, am.UpdateOptions{})
+	require.NoError(t, err)
+}
+
+func idleRequest(service, token string) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Namespace", "rack1-app1")
+	r.Header.Set("X-Original-URI", "/foo?bar=baz")
+	r.Header.Set("X-Service-Name", service)
+	r.Header.Set("X-Service-Port", "5000")
+
+	if token != "" {
+		r.Header.Set("X-Convox-Idler", token)
+	}
+
+	return r
+}
+
+func TestIdleProxy(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		idleSetup(t, p, "30m")
+
+		token, err := p.IdleToken("rack1-app1")
+		require.NoError(t, err)
+
+		other, err := p.IdleToken("rack1-app2")
+		require.NoError(t, err)
+		require.NotEqual(t, token, other)
+
+		again, err := p.IdleToken("rack1-app1")
+		require.NoError(t, err)
+		require.Equal(t, token, again)
+
+		w := httptest.NewRecorder()
+		p.IdleProxy(w, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		idleActive(t, p, "web", time.Now().UTC().Add(-1*time.Hour), 50)
+		require.NoError(t, p.IdleCheck())
+		require.Equal(t, int32(0), idleReplicas(t, p, "web"))
+
+		// only the routers know the token of the namespace
+		for _, tok := range []string{"", "invalid", other} {
+			w = httptest.NewRecorder()
+			p.IdleProxy(w, idleRequest("web", tok))
+			require.Equal(t, http.StatusForbidden, w.Code)
+			require.Equal(t, int32(0), idleReplicas(t, p, "web"))
+		}
+
+		// a service that is up answered 503 itself
+		w = httptest.NewRecorder()
+		p.IdleProxy(w, idleRequest("worker", token))
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		// an idle service is woken and the request forwarded to its main port once it is ready
+		r := idleRequest("web", token)
+
+		w = httptest.NewRecorder()
+		p.IdleProxy(w, r)
+		require.Equal(t, int32(2), idleReplicas(t, p, "web"))
+		require.Equal(t, http.StatusBadGateway, w.Code)
+		require.Equal(t, "/foo", r.URL.Path)
+		require.Equal(t, "bar=baz", r.URL.RawQuery)
+		require.Equal(t, "", r.Header.Get("X-Namespace"))
+		require.Equal(t, "", r.Header.Get("X-Convox-Idler"))
+
+		// requests are held while the service wakes
+		idleReady(t, p, "web", 0)
+
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			idleReady(t, p, "web", 2)
+		}()
+
+		w = httptest.NewRecorder()
+		p.IdleProxy(w, idleRequest("web", token))
+		require.Equal(t, http.StatusBadGateway, w.Code)
+
+		// once it is up it answers for itself
+		w = httptest.NewRecorder()
+		p.IdleProxy(w, idleRequest("web", token))
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"os"
 	"os/exec"
+	"sync/atomic"
 	"time"
 
 	"github.com/convox/convox/pkg/atom"
@@ -46,6 +47,7 @@ const (
 
 type Provider struct {
 	Atom                             atom.Interface
+	AuditLogRetentionInDays          string
 	BuildkitEnabled                  string
 	BuildNodeEnabled                 string
 	CertManager                      bool
@@ -94,11 +96,15 @@ type Provider struct {
 	RdsProvisioner         *rds.Provisioner
 	ElasticacheProvisioner *elasticache.Provisioner
 
-	ctx       context.Context
-	logger    *logger.Logger
-	metrics   *metrics.Metrics
-	templater *templater.Templater
-	webhooks  []string
+	backupAttempts map[string]time.Time
+	workersLeader  *atomic.Bool
+
+	ctx           context.Context
+	logger        *logger.Logger
+	metricHistory *metricHistory
+	metrics       *metrics.Metrics
+	templater     *templater.Templater
+	webhooks      []Webhook
 }
 
 func init() {
@@ -157,6 +163,7 @@ func FromEnv() (*Provider, error) {
 
 	p := &Provider{
 		Atom:                             ac,
+		AuditLogRetentionInDays:          os.Getenv("AUDIT_LOG_RETENTION_IN_DAYS"),
 		BuildkitEnabled:                  "true",
 		BuildNodeEnabled:                 os.Getenv("BUILD_NODE_ENABLED"),
 		BuildDisableResolver:             os.Getenv("BUILD_DISABLE_CONVOX_RESOLVER") == "true",
@@ -208,9 +215,10 @@ func (p *Provider) Context() context.Context {
 func (p *Provider) Initialize(opts structs.ProviderOptions) error {
 	p.ctx = context.Background()
 	p.logger = logger.New("ns=k8s")
+	p.metricHistory = newMetricHistory()
 	p.metrics = metrics.New("https://metrics.convox.com/metrics/rack")
 	p.templater = templater.New(template.TemplatesFS, p.templateHelpers())
-	p.webhooks = []string{}
+	p.webhooks = []Webhook{}
 
 	if os.Getenv("TEST") == "true" {
 		return nil
@@ -257,6 +265,11 @@ func (p *Provider) Start() error {
 		return errors.WithStack(log.Error(err))
 	}
 
+	jc, err := NewJobController(p)
+	if err != nil {
+		return errors.WithStack(log.Error(err))
+	}
+
 	wc, err := NewWebhookController(p)
 	if err != nil {
 		return errors.WithStack(log.Error(err))
@@ -285,6 +298,7 @@ func (p *Provider) Start() error {
 
 	go ec.Run()
 	go pc.Run()
+	go jc.Run()
 	go wc.Run()
 	go nc.Run()
 	go dc.Run()
@@ -292,8 +306,22 @@ func (p *Provider) Start() error {
 	go atomCtrl.Run()
 
 	go common.Tick(1*time.Hour, p.heartbeat)
+	go common.Tick(webhookDeliveryInterval, p.webhookDeliverAll)
+	go common.Tick(1*time.Hour, p.eventPrune)
+	go common.Tick(1*time.Hour, p.auditPrune)
+	go common.Tick(CanaryResumeInterval, p.CanaryResume)
+	go common.Tick(MetricsDefaultPeriod*time.Second, p.MetricsRecord)
+
+	metrics.NewGaugeFunc("convox_build_queue_depth", "Builds that have not finished by status", "status", p.BuildQueueDepth)
+
+	if err := p.Workers(); err != nil {
+		return errors.WithStack(log.Error(err))
+	}
+
+	go common.Tick(IdleCheckInterval, p.leaderOnly(p.IdleCheck))
 
 	go p.startApiProxy()
+	go p.startIdleProxy()
 
 	if os.Getenv("TEST") != "true" {
 		go p.RunSharedInformer(make(chan struct{}))
@@ -173,3 +173,27 @@ func (m *MetricScraperClient) GetNodesMetrics(nodeNames string, metricType struc
 
 	return data, nil
 }
+
+// podNames: single or comma seperated pod names in namespace ns
+func (m *MetricScraperClient) GetPodsMetrics(ns, podNames string, metricType structs.ScraperMetricType) (*structs.ScraperMetricList, error) {
+	if m.host == "" {
+		return nil, errors.WithStack(fmt.Errorf("unimplemented"))
+	}
+
+	resp, err := m.c.Get(fmt.Sprintf("%s/api/v1/dashboard/namespaces/%s/pod-list/%s/metrics/%s/data", m.host, ns, podNames, metricType))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.WithStack(fmt.Errorf("failed to get pod metrics"))
+	}
+
+	data := &structs.ScraperMetricList{}
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return data, nil
+}
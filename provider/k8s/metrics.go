@@ -0,0 +1,629 @@
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	ac "k8s.io/api/core/v1"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	MetricsDefaultPeriod = 60
+	MetricsDefaultRange  = time.Hour
+	MetricsHistory       = 24 * time.Hour
+	MetricsRouterPort    = "10254"
+)
+
+// metricKinds are the metrics reported for an app and each of its services:
+// cpu in cores, mem in MB, the number of running replicas, the requests the
+// routers served and the container restarts
+var metricKinds = []string{"cpu", "mem", "replicas", "requests", "restarts"}
+
+// metricCounters are the kinds read from counters that only ever grow. They
+// are reported as the change between the readings kept by MetricsRecord so a
+// period counts what happened during it.
+var metricCounters = map[string]bool{"requests": true, "restarts": true}
+
+// metricHistory keeps the readings of the counters of each app namespace for
+// MetricsHistory
+type metricHistory struct {
+	lock    sync.Mutex
+	samples map[string]map[string][]metricSample
+}
+
+func newMetricHistory() *metricHistory {
+	return &metricHistory{samples: map[string]map[string][]metricSample{}}
+}
+
+// add keeps the readings of kind in namespace ns and drops those taken
+// before since
+func (h *metricHistory) add(ns, kind string, ss []metricSample, since time.Time) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.samples[ns] == nil {
+		h.samples[ns] = map[string][]metricSample{}
+	}
+
+	kept := []metricSample{}
+
+	for _, s := range append(h.samples[ns][kind], ss...) {
+		if !s.Time.Before(since) {
+			kept = append(kept, s)
+		}
+	}
+
+	h.samples[ns][kind] = kept
+}
+
+func (h *metricHistory) get(ns, kind string) []metricSample {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return append([]metricSample{}, h.samples[ns][kind]...)
+}
+
+var routerRequestLabel = regexp.MustCompile(`(\w+)="((?:[^"\\]|\\.)*)"`)
+
+// metricSample is one reading of a metric from a single source, usually a pod
+type metricSample struct {
+	Service string
+	Source  string
+	Time    time.Time
+	Value   float64
+}
+
+// AppMetrics returns the metrics of an app and of each of its services as
+// app:<metric> and service:<name>:<metric>. History of cpu and mem comes from
+// the metrics scraper when the rack runs one, otherwise only current readings
+// are available. Requests and restarts are counted per period from the
+// readings kept by MetricsRecord. Replicas are a point in time reading only
+// reported when the range includes now.
+func (p *Provider) AppMetrics(name string, opts structs.MetricsOptions) (structs.Metrics, error) {
+	if _, err := p.AppGet(name); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	now := time.Now().UTC()
+
+	end := now
+	if opts.End != nil {
+		end = opts.End.UTC()
+	}
+
+	start := end.Add(-1 * MetricsDefaultRange)
+	if opts.Start != nil {
+		start = opts.Start.UTC()
+	}
+
+	if !start.Before(end) {
+		return nil, errors.WithStack(fmt.Errorf("start must be before end"))
+	}
+
+	period := int64(MetricsDefaultPeriod)
+	if opts.Period != nil && *opts.Period > 0 {
+		period = *opts.Period
+	}
+
+	ns := p.AppNamespace(name)
+
+	pds, err := p.ListPodsFromInformer(ns, "system=convox,type=service")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	samples, err := p.appMetricsUsage(ns, pds.Items)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, pd := range pds.Items {
+		if pd.DeletionTimestamp == nil && pd.Status.Phase == ac.PodRunning {
+			samples["replicas"] = append(samples["replicas"], metricSample{Service: pd.Labels["service"], Source: pd.Name, Time: now, Value: 1})
+		}
+	}
+
+	if counts, err := p.routerRequestCounts(ns); err == nil {
+		samples["requests"] = metricRequests(counts, now)
+	} else {
+		p.logger.Errorf("failed to count router requests: %s", err)
+	}
+
+	samples["restarts"] = metricRestarts(pds.Items, now)
+
+	services := map[string]bool{}
+
+	for _, ss := range samples {
+		for _, s := range ss {
+			services[s.Service] = true
+		}
+	}
+
+	for kind := range metricCounters {
+		if p.metricHistory != nil {
+			samples[kind] = append(p.metricHistory.get(ns, kind), samples[kind]...)
+		}
+
+		samples[kind] = metricDeltas(samples[kind])
+	}
+
+	names := []string{}
+
+	for s := range services {
+		if s != "" {
+			names = append(names, s)
+		}
+	}
+
+	sort.Strings(names)
+
+	ms := structs.Metrics{}
+
+	for _, kind := range metricKinds {
+		ms = append(ms, structs.Metric{
+			Name:   fmt.Sprintf("app:%s", kind),
+			Values: metricKindValues(kind, samples[kind], start, end, period),
+		})
+	}
+
+	for _, s := range names {
+		for _, kind := range metricKinds {
+			ss := []metricSample{}
+
+			for _, sample := range samples[kind] {
+				if sample.Service == s {
+					ss = append(ss, sample)
+				}
+			}
+
+			ms = append(ms, structs.Metric{
+				Name:   fmt.Sprintf("service:%s:%s", s, kind),
+				Values: metricKindValues(kind, ss, start, end, period),
+			})
+		}
+	}
+
+	return metricsFilter(ms, opts.Metrics), nil
+}
+
+// appMetricsUsage reads the cpu and memory history of the pods from the
+// metrics scraper, falling back to the current usage from metrics-server
+func (p *Provider) appMetricsUsage(ns string, pds []ac.Pod) (map[string][]metricSample, error) {
+	samples := map[string][]metricSample{}
+
+	if len(pds) == 0 {
+		return samples, nil
+	}
+
+	services := map[string]string{}
+	names := []string{}
+
+	for _, pd := range pds {
+		services[pd.Name] = pd.Labels["service"]
+		names = append(names, pd.Name)
+	}
+
+	if p.MetricScraper != nil && p.MetricScraper.host != "" {
+		cpus, err := p.MetricScraper.GetPodsMetrics(ns, strings.Join(names, ","), structs.ScraperMetricTypeCpu)
+		if err == nil {
+			var mems *structs.ScraperMetricList
+
+			mems, err = p.MetricScraper.GetPodsMetrics(ns, strings.Join(names, ","), structs.ScraperMetricTypeMem)
+			if err == nil {
+				samples["cpu"] = scraperSamples(cpus, services, func(v uint64) float64 { return toCpuCore(int64(v)) })
+				samples["mem"] = scraperSamples(mems, services, func(v uint64) float64 { return toMemMB(int64(v)) })
+
+				return samples, nil
+			}
+		}
+
+		p.logger.Errorf("failed to fetch pod metrics from scraper: %s", err)
+	}
+
+	pms, err := p.MetricsClient.MetricsV1beta1().PodMetricses(ns).List(p.ctx, am.ListOptions{LabelSelector: "system=convox,type=service"})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for i := range pms.Items {
+		pm := &pms.Items[i]
+
+		service, ok := services[pm.Name]
+		if !ok {
+			continue
+		}
+
+		cpu, mem := calculatePodCpuAndMem(pm)
+
+		samples["cpu"] = append(samples["cpu"], metricSample{Service: service, Source: pm.Name, Time: pm.Timestamp.UTC(), Value: cpu})
+		samples["mem"] = append(samples["mem"], metricSample{Service: service, Source: pm.Name, Time: pm.Timestamp.UTC(), Value: mem})
+	}
+
+	return samples, nil
+}
+
+func scraperSamples(ml *structs.ScraperMetricList, services map[string]string, convert func(uint64) float64) []metricSample {
+	samples := []metricSample{}
+
+	for _, m := range ml.Items {
+		if len(m.UIDs) == 0 {
+			continue
+		}
+
+		service, ok := services[m.UIDs[0]]
+		if !ok {
+			continue
+		}
+
+		for _, mp := range m.MetricPoints {
+			samples = append(samples, metricSample{Service: service, Source: m.UIDs[0], Time: mp.Timestamp.UTC(), Value: convert(mp.Value)})
+		}
+	}
+
+	return samples
+}
+
+// MetricsRecord keeps a reading of the request and restart counters of every
+// app so AppMetrics can count them per period
+func (p *Provider) MetricsRecord() error {
+	if p.metricHistory == nil {
+		return nil
+	}
+
+	as, err := p.AppList()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	now := time.Now().UTC()
+
+	rms, err := p.routerMetrics()
+	if err != nil {
+		p.logger.At("MetricsRecord").Errorf("err=%q", err)
+	}
+
+	for _, a := range as {
+		ns := p.AppNamespace(a.Name)
+
+		if len(rms) > 0 {
+			counts := map[string]map[string]float64{}
+
+			for router, data := range rms {
+				counts[router] = routerRequests(data, ns)
+			}
+
+			p.metricHistory.add(ns, "requests", metricRequests(counts, now), now.Add(-1*MetricsHistory))
+		}
+
+		pds, err := p.ListPodsFromInformer(ns, "system=convox,type=service")
+		if err != nil {
+			p.logger.At("MetricsRecord").Errorf("app=%s err=%q", a.Name, err)
+			continue
+		}
+
+		p.metricHistory.add(ns, "restarts", metricRestarts(pds.Items, now), now.Add(-1*MetricsHistory))
+	}
+
+	return nil
+}
+
+// metricRequests turns the request counts of each router into a reading per
+// router and service
+func metricRequests(counts map[string]map[string]float64, now time.Time) []metricSample {
+	samples := []metricSample{}
+
+	for router, cs := range counts {
+		for service, count := range cs {
+			samples = append(samples, metricSample{Service: service, Source: router, Time: now, Value: count})
+		}
+	}
+
+	return samples
+}
+
+// metricRestarts reads the restart count of every container of each pod
+func metricRestarts(pds []ac.Pod, now time.Time) []metricSample {
+	samples := []metricSample{}
+
+	for _, pd := range pds {
+		if pd.DeletionTimestamp != nil {
+			continue
+		}
+
+		s := metricSample{Service: pd.Labels["service"], Source: pd.Name, Time: now}
+
+		for _, cs := range pd.Status.ContainerStatuses {
+			s.Value += float64(cs.RestartCount)
+		}
+
+		samples = append(samples, s)
+	}
+
+	return samples
+}
+
+// metricDeltas turns the readings of a counter into how much it grew since
+// the previous reading of the same source. A counter that went down was
+// reset so all of its reading is new. The first reading of a source has
+// nothing to compare to and is dropped.
+func metricDeltas(samples []metricSample) []metricSample {
+	sources := map[string][]metricSample{}
+
+	for _, s := range samples {
+		sources[s.Source] = append(sources[s.Source], s)
+	}
+
+	deltas := []metricSample{}
+
+	for _, ss := range sources {
+		sort.SliceStable(ss, func(i, j int) bool { return ss[i].Time.Before(ss[j].Time) })
+
+		for i := 1; i < len(ss); i++ {
+			d := ss[i]
+
+			if d.Value >= ss[i-1].Value {
+				d.Value -= ss[i-1].Value
+			}
+
+			deltas = append(deltas, d)
+		}
+	}
+
+	return deltas
+}
+
+// routerRequestCounts reads the request counters of each running router for
+// the services in namespace ns
+func (p *Provider) routerRequestCounts(ns string) (map[string]map[string]float64, error) {
+	rms, err := p.routerMetrics()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]map[string]float64{}
+
+	for router, data := range rms {
+		counts[router] = routerRequests(data, ns)
+	}
+
+	return counts, nil
+}
+
+// routerMetrics reads the metrics of each running router. A router that can
+// not be read is skipped so the others are still counted.
+func (p *Provider) routerMetrics() (map[string][]byte, error) {
+	pds, err := p.ListPodsFromInformer(p.Namespace, "system=convox,service in (ingress-nginx,ingress-nginx-internal)")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	rms := map[string][]byte{}
+
+	for _, pd := range pds.Items {
+		if pd.Status.Phase != ac.PodRunning {
+			continue
+		}
+
+		data, err := p.Cluster.CoreV1().Pods(p.Namespace).ProxyGet("http", pd.Name, MetricsRouterPort, "/metrics", nil).DoRaw(p.ctx)
+		if err != nil {
+			p.logger.Errorf("failed to fetch router metrics: %s", err)
+			continue
+		}
+
+		rms[pd.Name] = data
+	}
+
+	if len(rms) == 0 {
+		return nil, errors.WithStack(fmt.Errorf("no router metrics available"))
+	}
+
+	return rms, nil
+}
+
+// routerCounts are the requests a router served for a service and how many
+// of them failed with a 5xx status
+type routerCounts struct {
+	Errors float64
+	Total  float64
+}
+
+// routerRequests sums the nginx_ingress_controller_requests counters of a
+// router by service for the ingresses in namespace ns, canary traffic is
+// counted for its service
+func routerRequests(data []byte, ns string) map[string]float64 {
+	counts := map[string]float64{}
+
+	for service, rc := range routerServiceRequests(data, ns) {
+		counts[strings.TrimSuffix(service, CanarySuffix)] += rc.Total
+	}
+
+	return counts
+}
+
+// routerServiceRequests sums the nginx_ingress_controller_requests counters
+// of a router by the backend service of the ingresses in namespace ns
+func routerServiceRequests(data []byte, ns string) map[string]routerCounts {
+	counts := map[string]routerCounts{}
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+
+	for s.Scan() {
+		line := s.Text()
+
+		if !strings.HasPrefix(line, "nginx_ingress_controller_requests{") {
+			continue
+		}
+
+		end := strings.LastIndex(line, "}")
+		if end == -1 {
+			continue
+		}
+
+		labels := map[string]string{}
+
+		for _, m := range routerRequestLabel.FindAllStringSubmatch(line[:end], -1) {
+			labels[m[1]] = m[2]
+		}
+
+		if labels["namespace"] != ns || labels["service"] == "" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSpace(line[end+1:]), 64)
+		if err != nil {
+			continue
+		}
+
+		rc := counts[labels["service"]]
+
+		rc.Total += v
+
+		if strings.HasPrefix(labels["status"], "5") {
+			rc.Errors += v
+		}
+
+		counts[labels["service"]] = rc
+	}
+
+	return counts
+}
+
+func metricKindValues(kind string, samples []metricSample, start, end time.Time, period int64) structs.MetricValues {
+	if kind == "replicas" {
+		return metricCurrent(samples, start, end)
+	}
+
+	return metricValues(samples, start, end, period)
+}
+
+// metricCurrent adds up the current reading of every source into a single
+// value at the time they were read, nothing is known about a range that does
+// not include it
+func metricCurrent(samples []metricSample, start, end time.Time) structs.MetricValues {
+	if len(samples) == 0 || samples[0].Time.Before(start) || samples[0].Time.After(end) {
+		return structs.MetricValues{}
+	}
+
+	v := structs.MetricValue{Time: samples[0].Time}
+
+	for _, s := range samples {
+		v.Count++
+		v.Sum += s.Value
+	}
+
+	v.Average = v.Sum
+	v.Maximum = v.Sum
+	v.Minimum = v.Sum
+
+	return structs.MetricValues{v}
+}
+
+// metricValues buckets samples into periods of period seconds from start to
+// end. The samples of each source are summarized first and the summaries
+// added up so a bucket describes all the sources together.
+func metricValues(samples []metricSample, start, end time.Time, period int64) structs.MetricValues {
+	type summary struct {
+		count, max, min, sum float64
+	}
+
+	last := int64(end.Sub(start).Seconds()-1) / period
+
+	buckets := map[int64]map[string]*summary{}
+
+	for _, s := range samples {
+		if s.Time.Before(start) || s.Time.After(end) {
+			continue
+		}
+
+		b := int64(s.Time.Sub(start).Seconds()) / period
+
+		if b > last {
+			b = last
+		}
+
+		if buckets[b] == nil {
+			buckets[b] = map[string]*summary{}
+		}
+
+		sm, ok := buckets[b][s.Source]
+		if !ok {
+			sm = &summary{max: s.Value, min: s.Value}
+			buckets[b][s.Source] = sm
+		}
+
+		sm.count++
+		sm.sum += s.Value
+
+		if s.Value > sm.max {
+			sm.max = s.Value
+		}
+
+		if s.Value < sm.min {
+			sm.min = s.Value
+		}
+	}
+
+	keys := []int64{}
+
+	for b := range buckets {
+		keys = append(keys, b)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	vs := structs.MetricValues{}
+
+	for _, b := range keys {
+		v := structs.MetricValue{Time: start.Add(time.Duration(b*period) * time.Second)}
+
+		for _, sm := range buckets[b] {
+			v.Average += sm.sum / sm.count
+			v.Count += sm.count
+			v.Maximum += sm.max
+			v.Minimum += sm.min
+			v.Sum += sm.sum
+		}
+
+		vs = append(vs, v)
+	}
+
+	return vs
+}
+
+// metricsFilter keeps the metrics named in names, either by their full name
+// or by kind such as cpu
+func metricsFilter(ms structs.Metrics, names []string) structs.Metrics {
+	if len(names) == 0 {
+		return ms
+	}
+
+	keep := map[string]bool{}
+
+	for _, n := range names {
+		for _, nn := range strings.Split(n, ",") {
+			keep[strings.TrimSpace(nn)] = true
+		}
+	}
+
+	fms := structs.Metrics{}
+
+	for _, m := range ms {
+		parts := strings.Split(m.Name, ":")
+
+		if keep[m.Name] || keep[parts[len(parts)-1]] {
+			fms = append(fms, m)
+		}
+	}
+
+	return fms
+}
@@ -0,0 +1,332 @@
+package k8s_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	"github.com/stretchr/testify/require"
+	ac "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	kt "k8s.io/client-go/testing"
+	mv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+type proxyResponse []byte
+
+func (r proxyResponse) DoRaw(context.Context) ([]byte, error) {
+	return r, nil
+}
+
+func (r proxyResponse) Stream(context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(string(r))), nil
+}
+
+var routerMetrics = `# HELP nginx_ingress_controller_requests The total number of client requests
+# TYPE nginx_ingress_controller_requests counter
+nginx_ingress_controller_requests{canary="",controller_class="k8s.io/ingress-nginx",ingress="web",method="GET",namespace="rack1-app1",path="/",service="web",status="200"} 40
+nginx_ingress_controller_requests{canary="",controller_class="k8s.io/ingress-nginx",ingress="web",method="GET",namespace="rack1-app1",path="/",service="web",status="500"} 2
+nginx_ingress_controller_requests{canary="",controller_class="k8s.io/ingress-nginx",ingress="web-canary",method="GET",namespace="rack1-app1",path="/",service="web-canary",status="200"} 8
+nginx_ingress_controller_requests{canary="",controller_class="k8s.io/ingress-nginx",ingress="web",method="GET",namespace="rack1-app2",path="/",service="web",status="200"} 99
+`
+
+func metricsSetup(t *testing.T, p *k8s.Provider) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+		"convox.com/app-release": "release1",
+		"convox.com/app-status":  "running",
+	}))
+
+	pods := []struct {
+		name     string
+		service  string
+		phase    ac.PodPhase
+		restarts int32
+	}{
+		{"web-1", "web", ac.PodRunning, 2},
+		{"web-2", "web", ac.PodRunning, 1},
+		{"worker-1", "worker", ac.PodPending, 0},
+	}
+
+	for _, pd := range pods {
+		_, err := kk.CoreV1().Pods("rack1-app1").Create(context.TODO(), &ac.Pod{
+			ObjectMeta: am.ObjectMeta{
+				Name: pd.name,
+				Labels: map[string]string{
+					"service": pd.service,
+					"system":  "convox",
+					"type":    "service",
+				},
+			},
+			Status: ac.PodStatus{
+				Phase: pd.phase,
+				ContainerStatuses: []ac.ContainerStatus{
+					{Name: "main", RestartCount: pd.restarts},
+				},
+			},
+		}, am.CreateOptions{})
+		require.NoError(t, err)
+	}
+}
+
+func metricsValue(t *testing.T, ms structs.Metrics, name string) structs.MetricValue {
+	for _, m := range ms {
+		if m.Name == name {
+			require.Len(t, m.Values, 1, name)
+			return m.Values[0]
+		}
+	}
+
+	require.Fail(t, fmt.Sprintf("metric not found: %s", name))
+
+	return structs.MetricValue{}
+}
+
+func TestAppMetrics(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kk := p.Cluster.(*fake.Clientset)
+		mc := p.MetricsClient.(*metricfake.Clientset)
+
+		metricsSetup(t, p)
+
+		for _, name := range []string{"web-1", "web-2"} {
+			err := mc.Tracker().Create(mv1beta1.SchemeGroupVersion.WithResource("pods"), &mv1beta1.PodMetrics{
+				ObjectMeta: am.ObjectMeta{
+					Name:      name,
+					Namespace: "rack1-app1",
+					Labels: map[string]string{
+						"service": "web",
+						"system":  "convox",
+						"type":    "service",
+					},
+				},
+				Timestamp: am.NewTime(time.Now().Add(-1 * time.Minute)),
+				Containers: []mv1beta1.ContainerMetrics{
+					{
+						Name: "main",
+						Usage: ac.ResourceList{
+							ac.ResourceCPU:    resource.MustParse("250m"),
+							ac.ResourceMemory: resource.MustParse("64Mi"),
+						},
+					},
+				},
+			}, "rack1-app1")
+			require.NoError(t, err)
+		}
+
+		_, err := kk.CoreV1().Pods("ns1").Create(context.TODO(), &ac.Pod{
+			ObjectMeta: am.ObjectMeta{
+				Name: "router-1",
+				Labels: map[string]string{
+					"service": "ingress-nginx",
+					"system":  "convox",
+				},
+			},
+			Status: ac.PodStatus{Phase: ac.PodRunning},
+		}, am.CreateOptions{})
+		require.NoError(t, err)
+
+		kk.PrependProxyReactor("pods", func(action kt.Action) (bool, restclient.ResponseWrapper, error) {
+			return true, proxyResponse(routerMetrics), nil
+		})
+
+		ms, err := p.AppMetrics("app1", structs.MetricsOptions{Period: options.Int64(86400)})
+		require.NoError(t, err)
+		require.Len(t, ms, 15)
+
+		cpu := metricsValue(t, ms, "app:cpu")
+		require.Equal(t, 0.5, cpu.Sum)
+		require.Equal(t, 0.5, cpu.Average)
+		require.Equal(t, float64(2), cpu.Count)
+
+		require.Equal(t, float64(128), metricsValue(t, ms, "service:web:mem").Average)
+		require.Equal(t, float64(2), metricsValue(t, ms, "app:replicas").Average)
+
+		for _, m := range ms {
+			switch {
+			case m.Name == "service:worker:cpu" || m.Name == "service:worker:replicas":
+				require.Len(t, m.Values, 0)
+			case strings.HasSuffix(m.Name, ":requests") || strings.HasSuffix(m.Name, ":restarts"):
+				require.Len(t, m.Values, 0, "counters need a kept reading to compare to")
+			}
+		}
+	})
+}
+
+func TestAppMetricsScraper(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		metricsSetup(t, p)
+
+		start := time.Date(2020, 1, 2, 3, 0, 0, 0, time.UTC)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v1/dashboard/namespaces/rack1-app1/pod-list/web-1,web-2,worker-1/metrics/cpu/data", strings.Replace(r.URL.Path, "/mem/", "/cpu/", 1))
+
+			value := uint64(100)
+			if strings.Contains(r.URL.Path, "/mem/") {
+				value = 1024 * 1024
+			}
+
+			json.NewEncoder(w).Encode(structs.ScraperMetricList{
+				Items: []structs.ScraperMetric{
+					{
+						UIDs: []string{"web-1"},
+						MetricPoints: []structs.MetricPoint{
+							{Timestamp: start.Add(30 * time.Second), Value: value},
+							{Timestamp: start.Add(90 * time.Second), Value: value * 3},
+							{Timestamp: start.Add(110 * time.Second), Value: value},
+							{Timestamp: start.Add(150 * time.Second), Value: value},
+						},
+					},
+					{
+						UIDs: []string{"web-2"},
+						MetricPoints: []structs.MetricPoint{
+							{Timestamp: start.Add(100 * time.Second), Value: value},
+						},
+					},
+					{
+						UIDs: []string{"unknown"},
+						MetricPoints: []structs.MetricPoint{
+							{Timestamp: start.Add(100 * time.Second), Value: value},
+						},
+					},
+				},
+			})
+		}))
+		defer ts.Close()
+
+		p.MetricScraper = k8s.NewMetricScraperClient(p.Cluster, ts.URL)
+
+		ms, err := p.AppMetrics("app1", structs.MetricsOptions{
+			End:     options.Time(start.Add(2 * time.Minute)),
+			Metrics: []string{"app:cpu,mem"},
+			Period:  options.Int64(60),
+			Start:   options.Time(start),
+		})
+		require.NoError(t, err)
+		require.Len(t, ms, 4)
+		require.Equal(t, "app:cpu", ms[0].Name)
+		require.Equal(t, "app:mem", ms[1].Name)
+		require.Equal(t, "service:web:mem", ms[2].Name)
+		require.Equal(t, "service:worker:mem", ms[3].Name)
+
+		require.Equal(t, structs.MetricValues{
+			{Time: start, Average: 0.1, Count: 1, Maximum: 0.1, Minimum: 0.1, Sum: 0.1},
+			{Time: start.Add(60 * time.Second), Average: 0.3, Count: 3, Maximum: 0.4, Minimum: 0.2, Sum: 0.5},
+		}, roundMetricValues(ms[0].Values))
+
+		require.Equal(t, structs.MetricValues{
+			{Time: start, Average: 1, Count: 1, Maximum: 1, Minimum: 1, Sum: 1},
+			{Time: start.Add(60 * time.Second), Average: 3, Count: 3, Maximum: 4, Minimum: 2, Sum: 5},
+		}, ms[2].Values)
+	})
+}
+
+func TestAppMetricsCounters(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kk := p.Cluster.(*fake.Clientset)
+
+		metricsSetup(t, p)
+
+		_, err := kk.CoreV1().Pods("ns1").Create(context.TODO(), &ac.Pod{
+			ObjectMeta: am.ObjectMeta{
+				Name: "router-1",
+				Labels: map[string]string{
+					"service": "ingress-nginx",
+					"system":  "convox",
+				},
+			},
+			Status: ac.PodStatus{Phase: ac.PodRunning},
+		}, am.CreateOptions{})
+		require.NoError(t, err)
+
+		data := routerMetrics
+
+		kk.PrependProxyReactor("pods", func(action kt.Action) (bool, restclient.ResponseWrapper, error) {
+			return true, proxyResponse(data), nil
+		})
+
+		require.NoError(t, p.MetricsRecord())
+
+		data = strings.Replace(routerMetrics, "} 40\n", "} 45\n", 1)
+
+		pd, err := kk.CoreV1().Pods("rack1-app1").Get(context.TODO(), "web-1", am.GetOptions{})
+		require.NoError(t, err)
+
+		pd.Status.ContainerStatuses[0].RestartCount = 4
+
+		_, err = kk.CoreV1().Pods("rack1-app1").Update(context.TODO(), pd, am.UpdateOptions{})
+		require.NoError(t, err)
+
+		ms, err := p.AppMetrics("app1", structs.MetricsOptions{
+			Metrics: []string{"replicas,requests,restarts"},
+			Period:  options.Int64(3600),
+		})
+		require.NoError(t, err)
+
+		replicas := metricsValue(t, ms, "service:web:replicas")
+		require.Equal(t, structs.MetricValue{Time: replicas.Time, Average: 2, Count: 2, Maximum: 2, Minimum: 2, Sum: 2}, replicas)
+
+		requests := metricsValue(t, ms, "service:web:requests")
+		require.Equal(t, structs.MetricValue{Time: requests.Time, Average: 5, Count: 1, Maximum: 5, Minimum: 5, Sum: 5}, requests)
+
+		restarts := metricsValue(t, ms, "service:web:restarts")
+		require.Equal(t, structs.MetricValue{Time: restarts.Time, Average: 2, Count: 2, Maximum: 2, Minimum: 2, Sum: 2}, restarts)
+
+		end := time.Now().UTC().Add(-1 * time.Hour)
+
+		ms, err = p.AppMetrics("app1", structs.MetricsOptions{
+			End:     options.Time(end),
+			Metrics: []string{"replicas,requests,restarts"},
+			Start:   options.Time(end.Add(-6 * time.Hour)),
+		})
+		require.NoError(t, err)
+
+		for _, m := range ms {
+			require.Empty(t, m.Values, m.Name)
+		}
+	})
+}
+
+func TestAppMetricsRange(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		metricsSetup(t, p)
+
+		now := time.Now().UTC()
+
+		_, err := p.AppMetrics("app1", structs.MetricsOptions{Start: options.Time(now), End: options.Time(now.Add(-1 * time.Hour))})
+		require.EqualError(t, err, "start must be before end")
+
+		_, err = p.AppMetrics("app2", structs.MetricsOptions{})
+		require.EqualError(t, err, `app not found: app2`)
+	})
+}
+
+func roundMetricValues(vs structs.MetricValues) structs.MetricValues {
+	round := func(f float64) float64 {
+		return float64(int64(f*1000+0.5)) / 1000
+	}
+
+	for i := range vs {
+		vs[i].Average = round(vs[i].Average)
+		vs[i].Maximum = round(vs[i].Maximum)
+		vs[i].Minimum = round(vs[i].Minimum)
+		vs[i].Sum = round(vs[i].Sum)
+	}
+
+	return vs
+}
@@ -0,0 +1,216 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/structs"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+var notificationColors = map[string]string{
+	"error":   "D00000",
+	"success": "2EB886",
+}
+
+func (p *Provider) systemResourceCreateNotification(kind string, opts structs.ResourceCreateOptions) (*structs.Resource, error) {
+	w := Webhook{
+		Apps:   splitWebhookList(opts.Parameters["Apps"]),
+		Events: splitWebhookList(opts.Parameters["Events"]),
+		Kind:   kind,
+		URL:    opts.Parameters["Url"],
+	}
+
+	switch kind {
+	case structs.WebhookKindPagerDuty:
+		w.RoutingKey = opts.Parameters["RoutingKey"]
+		w.URL = common.CoalesceString(w.URL, pagerDutyEventsURL)
+
+		if w.RoutingKey == "" {
+			return nil, fmt.Errorf("parameter required: RoutingKey")
+		}
+	default:
+		if w.URL == "" {
+			return nil, fmt.Errorf("parameter required: Url")
+		}
+	}
+
+	w.Name = common.DefaultString(opts.Name, systemResourceName(kind, w.URL))
+
+	if err := p.webhookCreate(w); err != nil {
+		return nil, err
+	}
+
+	return p.SystemResourceGet(w.Name)
+}
+
+// app returns the app an event is about, if any
+func (e event) app() string {
+	if app := e.Data["app"]; app != "" {
+		return app
+	}
+
+	if strings.HasPrefix(e.Action, "app:") {
+		return e.Data["name"]
+	}
+
+	return ""
+}
+
+// facts returns the event data other than the rack sorted by key
+func (e event) facts() [][2]string {
+	keys := []string{}
+
+	for k := range e.Data {
+		if k != "rack" {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	facts := [][2]string{}
+
+	for _, k := range keys {
+		facts = append(facts, [2]string{k, e.Data[k]})
+	}
+
+	return facts
+}
+
+// summary describes an event in one line, e.g.
+// "rack1/app1: build:create error BABCDEFGHIJ: exit status 1"
+func (e event) summary() string {
+	s := e.Data["rack"]
+
+	if app := e.app(); app != "" {
+		s = fmt.Sprintf("%s/%s", s, app)
+	}
+
+	s = fmt.Sprintf("%s: %s %s", s, e.Action, e.Status)
+
+	if id := e.Data["id"]; id != "" {
+		s = fmt.Sprintf("%s %s", s, id)
+	}
+
+	if msg := e.Data["message"]; msg != "" {
+		s = fmt.Sprintf("%s: %s", s, msg)
+	}
+
+	return s
+}
+
+func (e event) color() string {
+	return common.CoalesceString(notificationColors[e.Status], "439FE0")
+}
+
+// payload renders an event in the shape expected by the receiving service, a
+// nil payload means the event is not sent to this webhook
+func (w Webhook) payload(e event) ([]byte, error) {
+	var v interface{}
+
+	switch w.Kind {
+	case structs.WebhookKindPagerDuty:
+		pv := pagerDutyPayload(w.RoutingKey, e)
+		if pv == nil {
+			return nil, nil
+		}
+		v = pv
+	case structs.WebhookKindSlack:
+		v = slackPayload(e)
+	case structs.WebhookKindTeams:
+		v = teamsPayload(e)
+	default:
+		v = e
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// pagerDutyPayload opens an incident when an event fails and resolves it the
+// next time the same action succeeds for the app, other statuses such as
+// start are not sent
+func pagerDutyPayload(key string, e event) map[string]interface{} {
+	dedup := strings.Join([]string{e.Data["rack"], e.app(), e.Action}, ":")
+
+	switch e.Status {
+	case "error":
+	case "success":
+		return map[string]interface{}{
+			"routing_key":  key,
+			"event_action": "resolve",
+			"dedup_key":    dedup,
+		}
+	default:
+		return nil
+	}
+
+	details := map[string]string{}
+
+	for _, f := range e.facts() {
+		details[f[0]] = f[1]
+	}
+
+	return map[string]interface{}{
+		"routing_key":  key,
+		"event_action": "trigger",
+		"dedup_key":    dedup,
+		"payload": map[string]interface{}{
+			"summary":        e.summary(),
+			"source":         e.Data["rack"],
+			"severity":       "error",
+			"timestamp":      e.Timestamp.Format(time.RFC3339),
+			"component":      e.app(),
+			"group":          e.Action,
+			"custom_details": details,
+		},
+	}
+}
+
+func slackPayload(e event) map[string]interface{} {
+	fields := []map[string]interface{}{}
+
+	for _, f := range e.facts() {
+		fields = append(fields, map[string]interface{}{"title": f[0], "value": f[1], "short": true})
+	}
+
+	return map[string]interface{}{
+		"text": e.summary(),
+		"attachments": []map[string]interface{}{
+			{
+				"color":  fmt.Sprintf("#%s", e.color()),
+				"fields": fields,
+				"ts":     e.Timestamp.Unix(),
+			},
+		},
+	}
+}
+
+func teamsPayload(e event) map[string]interface{} {
+	facts := []map[string]string{}
+
+	for _, f := range e.facts() {
+		facts = append(facts, map[string]string{"name": f[0], "value": f[1]})
+	}
+
+	return map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    e.summary(),
+		"themeColor": e.color(),
+		"title":      e.summary(),
+		"sections": []map[string]interface{}{
+			{"facts": facts},
+		},
+	}
+}
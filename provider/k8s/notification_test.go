@@ -0,0 +1,140 @@
+package k8s_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemResourceCreateNotification(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		r, err := p.SystemResourceCreate("slack", structs.ResourceCreateOptions{
+			Parameters: map[string]string{"Url": "https://hooks.slack.com/services/T1", "Apps": "app1, staging-*"},
+		})
+		require.NoError(t, err)
+		require.Regexp(t, "^slack-[0-9a-f]+", r.Name)
+		require.Equal(t, "slack", r.Type)
+		require.Equal(t, map[string]string{"Apps": "app1,staging-*", "Url": "https://hooks.slack.com/services/T1"}, r.Parameters)
+
+		r, err = p.SystemResourceCreate("pagerduty", structs.ResourceCreateOptions{
+			Name:       options.String("pd1"),
+			Parameters: map[string]string{"RoutingKey": "key1", "Events": "*:*"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "pagerduty", r.Type)
+		require.Equal(t, map[string]string{"Events": "*:*", "Url": "https://events.pagerduty.com/v2/enqueue"}, r.Parameters)
+
+		ws, err := p.WebhookList()
+		require.NoError(t, err)
+		require.Len(t, ws, 2)
+		require.Equal(t, "pagerduty", ws[0].Kind)
+
+		_, err = p.SystemResourceCreate("pagerduty", structs.ResourceCreateOptions{})
+		require.EqualError(t, err, "parameter required: RoutingKey")
+
+		_, err = p.SystemResourceCreate("teams", structs.ResourceCreateOptions{})
+		require.EqualError(t, err, "parameter required: Url")
+
+		require.NoError(t, p.SystemResourceDelete("pd1"))
+	})
+}
+
+func TestEventSendSlack(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		s, ch := testWebhookServer(t, 200)
+		defer s.Close()
+
+		_, err := p.SystemResourceCreate("slack", structs.ResourceCreateOptions{
+			Name:       options.String("slack1"),
+			Parameters: map[string]string{"Apps": "app1", "Url": s.URL},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, p.EventSend("build:create", structs.EventSendOptions{Data: map[string]string{"app": "app2", "id": "B1"}}))
+		require.NoError(t, p.EventSend("rack:update", structs.EventSendOptions{}))
+		require.NoError(t, p.EventSend("build:create", structs.EventSendOptions{Data: map[string]string{"app": "app1", "id": "B2"}, Error: options.String("exit status 1")}))
+
+		var req webhookRequest
+
+		select {
+		case req = <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("notification not delivered")
+		}
+
+		var body struct {
+			Text        string
+			Attachments []struct {
+				Color  string
+				Fields []struct {
+					Title string
+					Value string
+				}
+			}
+		}
+
+		require.NoError(t, json.Unmarshal([]byte(req.Body), &body))
+		require.Equal(t, "rack1/app1: build:create error B2: exit status 1", body.Text)
+		require.Len(t, body.Attachments, 1)
+		require.Equal(t, "#D00000", body.Attachments[0].Color)
+		require.Equal(t, "app", body.Attachments[0].Fields[0].Title)
+		require.Equal(t, "app1", body.Attachments[0].Fields[0].Value)
+
+		select {
+		case req = <-ch:
+			t.Fatalf("unexpected notification: %s", req.Body)
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+}
+
+func TestEventSendPagerDuty(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		s, ch := testWebhookServer(t, 200)
+		defer s.Close()
+
+		_, err := p.SystemResourceCreate("pagerduty", structs.ResourceCreateOptions{
+			Name:       options.String("pd1"),
+			Parameters: map[string]string{"RoutingKey": "key1", "Url": s.URL},
+		})
+		require.NoError(t, err)
+
+		receive := func() map[string]interface{} {
+			select {
+			case req := <-ch:
+				var body map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(req.Body), &body))
+				return body
+			case <-time.After(5 * time.Second):
+				t.Fatal("notification not delivered")
+			}
+			return nil
+		}
+
+		require.NoError(t, p.EventSend("build:create", structs.EventSendOptions{Data: map[string]string{"app": "app1", "id": "B1"}, Error: options.String("exit status 1")}))
+
+		body := receive()
+		require.Equal(t, "trigger", body["event_action"])
+		require.Equal(t, "key1", body["routing_key"])
+		require.Equal(t, "rack1:app1:build:create", body["dedup_key"])
+
+		require.NoError(t, p.EventSend("release:promote", structs.EventSendOptions{Data: map[string]string{"app": "app1"}, Status: options.String("start")}))
+		require.NoError(t, p.EventSend("build:create", structs.EventSendOptions{Data: map[string]string{"app": "app1", "id": "B2"}}))
+
+		body = receive()
+		require.Equal(t, "resolve", body["event_action"])
+		require.Equal(t, "rack1:app1:build:create", body["dedup_key"])
+		require.Nil(t, body["payload"])
+
+		select {
+		case req := <-ch:
+			t.Fatalf("unexpected notification: %s", req.Body)
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+}
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/convox/convox/pkg/structs"
 	"github.com/pkg/errors"
@@ -49,8 +51,28 @@ func (p *Provider) ObjectFetch(app, key string) (io.ReadCloser, error) {
 	return fd, nil
 }
 
+// ObjectList returns the keys of the objects directly under a directory
+// prefix such as backups/
 func (p *Provider) ObjectList(app, prefix string) ([]string, error) {
-	return nil, errors.WithStack(fmt.Errorf("unimplemented"))
+	dir := strings.TrimSuffix(prefix, "/")
+
+	es, err := os.ReadDir(p.objectFilename(app, dir))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	keys := []string{}
+
+	for _, e := range es {
+		if e.Type().IsRegular() {
+			keys = append(keys, path.Join(dir, e.Name()))
+		}
+	}
+
+	return keys, nil
 }
 
 func (p *Provider) ObjectStore(app, key string, r io.Reader, opts structs.ObjectStoreOptions) (*structs.Object, error) {
@@ -32,8 +32,10 @@ type BuildSpec struct {
 	Manifest    string `json:"manifest"`
 	Process     string `json:"process"`
 	Release     string `json:"release"`
+	Scan        string `json:"scan,omitempty"`
 	Started     string `json:"started"`
 	Status      string `json:"status"`
+	Tested      string `json:"tested,omitempty"`
 }
 
 // +genclient
@@ -0,0 +1,482 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/manifest"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	ae "k8s.io/apimachinery/pkg/api/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+type planKind struct {
+	cluster bool
+	get     func(ns, name string) (interface{}, error)
+	list    func(ns string, opts am.ListOptions) (interface{}, error)
+}
+
+type planObject struct {
+	Kind     string
+	Metadata struct {
+		Name      string
+		Namespace string
+	}
+}
+
+// ReleasePlan renders the same objects as ReleasePromote and compares them
+// with the live objects in the app namespace without applying anything
+func (p *Provider) ReleasePlan(app, id string, opts structs.ReleasePromoteOptions) (*structs.ReleasePlan, error) {
+	a, err := p.AppGet(app)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	plan := &structs.ReleasePlan{
+		App:        app,
+		Release:    id,
+		Operations: structs.ReleasePlanOperations{},
+		Warnings:   []string{},
+	}
+
+	if id != "" {
+		if err := p.releaseScanCheck(a, id); err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("promote will be refused: %s", err))
+		}
+
+		vs, err := p.releasePolicyViolations(a, id, common.DefaultString(opts.User, ""), time.Now())
+		switch {
+		case err != nil:
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("promote will be refused: %s", err))
+		case len(vs) > 0 && common.DefaultBool(opts.Force, false):
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("promote will override the promotion policy: %s", strings.Join(vs, ", ")))
+		case len(vs) > 0:
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("promote will be refused: %s", releasePolicyError(a, id, vs)))
+		}
+	}
+
+	items, _, err := p.releaseItems(a, id, opts, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ApplyLabels(bytes.Join(items, []byte("---\n")), fmt.Sprintf("system=convox,provider=k8s,rack=%s,app=%s,release=%s", p.Name, app, id))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	kinds := p.planKinds()
+	ns := p.AppNamespace(app)
+	seen := map[string]bool{}
+
+	for _, part := range bytes.Split(data, []byte("---\n")) {
+		jd, err := yaml.ToJSON(part)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		var desired map[string]interface{}
+
+		if err := json.Unmarshal(jd, &desired); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if len(desired) == 0 {
+			continue
+		}
+
+		var o planObject
+
+		if err := json.Unmarshal(jd, &o); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		k, ok := kinds[o.Kind]
+		if !ok {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s %s can not be compared and will be applied as rendered", o.Kind, o.Metadata.Name))
+			continue
+		}
+
+		if !k.cluster && o.Metadata.Namespace == "" {
+			o.Metadata.Namespace = ns
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", o.Kind, o.Metadata.Namespace, o.Metadata.Name)
+
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+
+		op := structs.ReleasePlanOperation{Kind: o.Kind, Name: o.Metadata.Name, Namespace: o.Metadata.Namespace}
+
+		lo, err := k.get(o.Metadata.Namespace, o.Metadata.Name)
+		if ae.IsNotFound(err) {
+			op.Action = structs.ReleasePlanCreate
+			plan.Operations = append(plan.Operations, op)
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		live, err := planValue(lo)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		op.Changes = planChanges(o.Kind, desired, live)
+
+		if len(op.Changes) > 0 {
+			op.Action = structs.ReleasePlanUpdate
+			plan.Operations = append(plan.Operations, op)
+			plan.Warnings = append(plan.Warnings, planUpdateWarnings(op)...)
+		}
+	}
+
+	deletes, err := p.planDeletes(app, kinds, seen)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, op := range deletes {
+		plan.Operations = append(plan.Operations, op)
+
+		if op.Kind == "PersistentVolumeClaim" {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("this will delete the persistent volume claim %s and its data", op.Name))
+		}
+	}
+
+	return plan, nil
+}
+
+// planProvisioned renders the resources provisioned outside the cluster from
+// their live connection configmap so a plan neither provisions them nor
+// reports them as deleted
+func (p *Provider) planProvisioned(a *structs.App, m *manifest.Manifest, plan *structs.ReleasePlan) ([][]byte, error) {
+	items := [][]byte{}
+
+	for _, r := range m.Resources {
+		if !r.IsRds() && !r.IsElastiCache() {
+			continue
+		}
+
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("resource %s is provisioned outside the cluster and is not part of this plan", r.Name))
+
+		cm, err := p.Cluster.CoreV1().ConfigMaps(p.AppNamespace(a.Name)).Get(context.TODO(), fmt.Sprintf("resource-%s", nameFilter(r.Name)), am.GetOptions{})
+		if ae.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		data, err := json.Marshal(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"namespace": cm.Namespace,
+				"name":      cm.Name,
+				"labels":    cm.Labels,
+			},
+			"data": cm.Data,
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		items = append(items, append(data, '\n'))
+	}
+
+	return items, nil
+}
+
+// planDeletes finds the live objects the atom for the app would prune because
+// they are no longer rendered
+func (p *Provider) planDeletes(app string, kinds map[string]planKind, seen map[string]bool) (structs.ReleasePlanOperations, error) {
+	ns := p.AppNamespace(app)
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s.%s", ns, "app"))))[0:60]
+
+	names := []string{}
+
+	for name := range kinds {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	ops := structs.ReleasePlanOperations{}
+
+	for _, kind := range names {
+		k := kinds[kind]
+
+		if k.cluster {
+			continue
+		}
+
+		lo, err := k.list(ns, am.ListOptions{LabelSelector: fmt.Sprintf("atom=%s", hash)})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		data, err := json.Marshal(lo)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		var list struct {
+			Items []planObject
+		}
+
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for _, i := range list.Items {
+			if !seen[fmt.Sprintf("%s/%s/%s", kind, ns, i.Metadata.Name)] {
+				ops = append(ops, structs.ReleasePlanOperation{Action: structs.ReleasePlanDelete, Kind: kind, Name: i.Metadata.Name, Namespace: ns})
+			}
+		}
+	}
+
+	return ops, nil
+}
+
+func (p *Provider) planKinds() map[string]planKind {
+	c := p.Cluster
+	ctx := context.TODO()
+
+	return map[string]planKind{
+		"ConfigMap": {
+			get: func(ns, name string) (interface{}, error) {
+				return c.CoreV1().ConfigMaps(ns).Get(ctx, name, am.GetOptions{})
+			},
+			list: func(ns string, opts am.ListOptions) (interface{}, error) {
+				return c.CoreV1().ConfigMaps(ns).List(ctx, opts)
+			},
+		},
+		"CronJob": {
+			get: func(ns, name string) (interface{}, error) {
+				return c.BatchV1().CronJobs(ns).Get(ctx, name, am.GetOptions{})
+			},
+			list: func(ns string, opts am.ListOptions) (interface{}, error) {
+				return c.BatchV1().CronJobs(ns).List(ctx, opts)
+			},
+		},
+		"DaemonSet": {
+			get: func(ns, name string) (interface{}, error) {
+				return c.AppsV1().DaemonSets(ns).Get(ctx, name, am.GetOptions{})
+			},
+			list: func(ns string, opts am.ListOptions) (interface{}, error) {
+				return c.AppsV1().DaemonSets(ns).List(ctx, opts)
+			},
+		},
+		"Deployment": {
+			get: func(ns, name string) (interface{}, error) {
+				return c.AppsV1().Deployments(ns).Get(ctx, name, am.GetOptions{})
+			},
+			list: func(ns string, opts am.ListOptions) (interface{}, error) {
+				return c.AppsV1().Deployments(ns).List(ctx, opts)
+			},
+		},
+		"HorizontalPodAutoscaler": {
+			get: func(ns, name string) (interface{}, error) {
+				return c.AutoscalingV2().HorizontalPodAutoscalers(ns).Get(ctx, name, am.GetOptions{})
+			},
+			list: func(ns string, opts am.ListOptions) (interface{}, error) {
+				return c.AutoscalingV2().HorizontalPodAutoscalers(ns).List(ctx, opts)
+			},
+		},
+		"Ingress": {
+			get: func(ns, name string) (interface{}, error) {
+				return c.NetworkingV1().Ingresses(ns).Get(ctx, name, am.GetOptions{})
+			},
+			list: func(ns string, opts am.ListOptions) (interface{}, error) {
+				return c.NetworkingV1().Ingresses(ns).List(ctx, opts)
+			},
+		},
+		"Namespace": {
+			cluster: true,
+			get: func(_, name string) (interface{}, error) {
+				return c.CoreV1().Namespaces().Get(ctx, name, am.GetOptions{})
+			},
+		},
+		"PersistentVolume": {
+			cluster: true,
+			get: func(_, name string) (interface{}, error) {
+				return c.CoreV1().PersistentVolumes().Get(ctx, name, am.GetOptions{})
+			},
+		},
+		"PersistentVolumeClaim": {
+			get: func(ns, name string) (interface{}, error) {
+				return c.CoreV1().PersistentVolumeClaims(ns).Get(ctx, name, am.GetOptions{})
+			},
+			list: func(ns string, opts am.ListOptions) (interface{}, error) {
+				return c.CoreV1().PersistentVolumeClaims(ns).List(ctx, opts)
+			},
+		},
+		"Secret": {
+			get: func(ns, name string) (interface{}, error) {
+				return c.CoreV1().Secrets(ns).Get(ctx, name, am.GetOptions{})
+			},
+			list: func(ns string, opts am.ListOptions) (interface{}, error) {
+				return c.CoreV1().Secrets(ns).List(ctx, opts)
+			},
+		},
+		"Service": {
+			get: func(ns, name string) (interface{}, error) {
+				return c.CoreV1().Services(ns).Get(ctx, name, am.GetOptions{})
+			},
+			list: func(ns string, opts am.ListOptions) (interface{}, error) {
+				return c.CoreV1().Services(ns).List(ctx, opts)
+			},
+		},
+		"ServiceAccount": {
+			get: func(ns, name string) (interface{}, error) {
+				return c.CoreV1().ServiceAccounts(ns).Get(ctx, name, am.GetOptions{})
+			},
+			list: func(ns string, opts am.ListOptions) (interface{}, error) {
+				return c.CoreV1().ServiceAccounts(ns).List(ctx, opts)
+			},
+		},
+	}
+}
+
+func planValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var o interface{}
+
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// planChanges lists the fields set by the rendered object that differ from the
+// live object, fields only the cluster sets are not compared and secret values
+// are masked. The release label changes on every promote and is left out.
+func planChanges(kind string, desired, live interface{}) structs.ReleasePlanChanges {
+	cs := structs.ReleasePlanChanges{}
+
+	planWalk("", desired, live, func(field string, from, to interface{}) {
+		switch field {
+		case "apiVersion", "kind", "metadata.labels.release":
+			return
+		}
+
+		c := structs.ReleasePlanChange{Field: field, From: planString(from), To: planString(to)}
+
+		if kind == "Secret" && (strings.HasPrefix(field, "data.") || strings.HasPrefix(field, "stringData.")) {
+			c.From = planMask(c.From)
+			c.To = planMask(c.To)
+		}
+
+		cs = append(cs, c)
+	})
+
+	return cs
+}
+
+func planWalk(prefix string, desired, live interface{}, fn func(field string, from, to interface{})) {
+	switch d := desired.(type) {
+	case nil:
+		return
+	case map[string]interface{}:
+		l, _ := live.(map[string]interface{})
+
+		keys := []string{}
+
+		for k := range d {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			field := k
+
+			if prefix != "" {
+				field = fmt.Sprintf("%s.%s", prefix, k)
+			}
+
+			planWalk(field, d[k], l[k], fn)
+		}
+	case []interface{}:
+		l, _ := live.([]interface{})
+
+		if len(d) == len(l) {
+			for i := range d {
+				planWalk(fmt.Sprintf("%s[%d]", prefix, i), d[i], l[i], fn)
+			}
+
+			return
+		}
+
+		fn(prefix, live, desired)
+	default:
+		switch {
+		case live == nil && (d == false || d == float64(0) || d == ""):
+			// the cluster omits zero values
+		case live == nil || fmt.Sprint(d) != fmt.Sprint(live):
+			fn(prefix, live, desired)
+		}
+	}
+}
+
+func planString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case bool, float64:
+		return fmt.Sprint(t)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
+
+func planMask(v string) string {
+	if v == "" {
+		return ""
+	}
+
+	return "****"
+}
+
+// planUpdateWarnings points out updates the cluster can only make by deleting
+// and recreating an object
+func planUpdateWarnings(op structs.ReleasePlanOperation) []string {
+	ws := []string{}
+
+	for _, c := range op.Changes {
+		switch {
+		case op.Kind == "PersistentVolumeClaim" && strings.HasPrefix(c.Field, "spec.") && c.Field != "spec.resources.requests.storage":
+			return append(ws, fmt.Sprintf("this will recreate the persistent volume claim %s and lose its data", op.Name))
+		case (op.Kind == "Deployment" || op.Kind == "DaemonSet") && strings.HasPrefix(c.Field, "spec.selector."):
+			return append(ws, fmt.Sprintf("this will recreate %s %s", strings.ToLower(op.Kind), op.Name))
+		}
+	}
+
+	return ws
+}
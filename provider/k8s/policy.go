@@ -0,0 +1,139 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	ac "k8s.io/api/core/v1"
+	ae "k8s.io/apimachinery/pkg/api/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (p *Provider) PolicyCreate(name string, opts structs.PolicyCreateOptions) (*structs.Policy, error) {
+	pp, err := structs.NewPolicy(name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cm, err := p.policyConfigMap()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := cm.Data[name]; ok {
+		return nil, fmt.Errorf("policy already exists: %s", name)
+	}
+
+	data, err := json.Marshal(pp)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cm.Data[name] = string(data)
+
+	if _, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Update(p.ctx, cm, am.UpdateOptions{}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return pp, nil
+}
+
+func (p *Provider) PolicyDelete(name string) error {
+	if _, ok := structs.BuiltinPolicy(name); ok {
+		return fmt.Errorf("can not delete builtin policy: %s", name)
+	}
+
+	cm, err := p.policyConfigMap()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := cm.Data[name]; !ok {
+		return fmt.Errorf("policy not found: %s", name)
+	}
+
+	delete(cm.Data, name)
+
+	if _, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Update(p.ctx, cm, am.UpdateOptions{}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (p *Provider) PolicyGet(name string) (*structs.Policy, error) {
+	if pp, ok := structs.BuiltinPolicy(name); ok {
+		return pp, nil
+	}
+
+	cm, err := p.policyConfigMap()
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := cm.Data[name]
+	if !ok {
+		return nil, fmt.Errorf("policy not found: %s", name)
+	}
+
+	var pp structs.Policy
+
+	if err := json.Unmarshal([]byte(data), &pp); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &pp, nil
+}
+
+func (p *Provider) PolicyList() (structs.Policies, error) {
+	cm, err := p.policyConfigMap()
+	if err != nil {
+		return nil, err
+	}
+
+	ps := append(structs.Policies{}, structs.BuiltinPolicies...)
+
+	for _, data := range cm.Data {
+		var pp structs.Policy
+
+		if err := json.Unmarshal([]byte(data), &pp); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		ps = append(ps, pp)
+	}
+
+	sort.Slice(ps, ps.Less)
+
+	return ps, nil
+}
+
+func (p *Provider) policyConfigMap() (*ac.ConfigMap, error) {
+	cms := p.Cluster.CoreV1().ConfigMaps(p.Namespace)
+
+	cm, err := cms.Get(p.ctx, "policies", am.GetOptions{})
+	if ae.IsNotFound(err) {
+		cm, err = cms.Create(p.ctx, &ac.ConfigMap{
+			ObjectMeta: am.ObjectMeta{
+				Namespace: p.Namespace,
+				Name:      "policies",
+				Labels: map[string]string{
+					"system": "convox",
+					"type":   "policies",
+				},
+			},
+		}, am.CreateOptions{})
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+
+	return cm, nil
+}
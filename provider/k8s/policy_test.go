@@ -0,0 +1,73 @@
+package k8s_test
+
+import (
+	"testing"
+
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyCreate(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		pp, err := p.PolicyCreate("operator", structs.PolicyCreateOptions{
+			Apps:  options.String("staging-*, qa-*"),
+			Verbs: options.String("read,scale"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, &structs.Policy{Name: "operator", Apps: []string{"staging-*", "qa-*"}, Verbs: []string{"read", "scale"}}, pp)
+
+		pg, err := p.PolicyGet("operator")
+		require.NoError(t, err)
+		require.Equal(t, pp, pg)
+
+		_, err = p.PolicyCreate("operator", structs.PolicyCreateOptions{Verbs: options.String("read")})
+		require.EqualError(t, err, "policy already exists: operator")
+	})
+}
+
+func TestPolicyCreateInvalid(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		_, err := p.PolicyCreate("write", structs.PolicyCreateOptions{Verbs: options.String("read")})
+		require.EqualError(t, err, "can not override builtin policy: write")
+
+		_, err = p.PolicyCreate("operator", structs.PolicyCreateOptions{})
+		require.EqualError(t, err, "verbs required")
+
+		_, err = p.PolicyCreate("operator", structs.PolicyCreateOptions{Verbs: options.String("read,fly")})
+		require.EqualError(t, err, "unknown verb: fly")
+	})
+}
+
+func TestPolicyDelete(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		_, err := p.PolicyCreate("operator", structs.PolicyCreateOptions{Verbs: options.String("read")})
+		require.NoError(t, err)
+
+		require.NoError(t, p.PolicyDelete("operator"))
+
+		_, err = p.PolicyGet("operator")
+		require.EqualError(t, err, "policy not found: operator")
+
+		require.EqualError(t, p.PolicyDelete("operator"), "policy not found: operator")
+		require.EqualError(t, p.PolicyDelete("read"), "can not delete builtin policy: read")
+	})
+}
+
+func TestPolicyList(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		_, err := p.PolicyCreate("operator", structs.PolicyCreateOptions{Verbs: options.String("read,exec")})
+		require.NoError(t, err)
+
+		ps, err := p.PolicyList()
+		require.NoError(t, err)
+
+		names := []string{}
+		for _, pp := range ps {
+			names = append(names, pp.Name)
+		}
+
+		require.Equal(t, []string{"deployer", "operator", "read", "write"}, names)
+	})
+}
@@ -137,6 +137,10 @@ func (p *Provider) ProcessExec(app, pid, command string, rw io.ReadWriter, opts
 		return 1, errors.WithStack(err)
 	}
 
+	if err := p.buildTestRecord(app, pid, command); err != nil {
+		p.logger.At("ProcessExec").Errorf("pid=%s err=%q", pid, err)
+	}
+
 	return 0, nil
 }
 
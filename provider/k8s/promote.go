@@ -0,0 +1,376 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var promoteWindowDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// promotePolicy is what a release of an app needs before it can be promoted
+type promotePolicy struct {
+	Approvals   int  // distinct users that approved the release
+	RequireTest bool // the build of the release passed convox test
+	Window      *promoteWindow
+}
+
+// promoteWindow is a daily span of time promotes are allowed in, a span that
+// ends before it starts crosses midnight and belongs to the day it starts on
+type promoteWindow struct {
+	Days     [7]bool
+	Start    int // minutes since midnight
+	End      int
+	Location *time.Location
+	Spec     string
+}
+
+func appPromotePolicy(a *structs.App) (*promotePolicy, error) {
+	pp := &promotePolicy{
+		RequireTest: a.Parameters[structs.AppParamPromoteRequireTest] == "true",
+	}
+
+	if v := a.Parameters[structs.AppParamPromoteApprovals]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, errors.WithStack(fmt.Errorf("invalid %s for app %s: %s", structs.AppParamPromoteApprovals, a.Name, v))
+		}
+
+		pp.Approvals = n
+	}
+
+	if v := a.Parameters[structs.AppParamPromoteWindow]; v != "" {
+		w, err := parsePromoteWindow(v)
+		if err != nil {
+			return nil, errors.WithStack(fmt.Errorf("invalid %s for app %s: %s", structs.AppParamPromoteWindow, a.Name, err))
+		}
+
+		pp.Window = w
+	}
+
+	return pp, nil
+}
+
+// parsePromoteWindow parses a window like "Mon-Fri 09:00-17:00 America/New_York",
+// the days default to every day and the time zone to UTC
+func parsePromoteWindow(spec string) (*promoteWindow, error) {
+	w := &promoteWindow{Location: time.UTC, Spec: spec}
+
+	fields := strings.Fields(spec)
+
+	if len(fields) > 0 && !strings.Contains(fields[0], ":") {
+		if err := w.parseDays(fields[0]); err != nil {
+			return nil, err
+		}
+
+		fields = fields[1:]
+	} else {
+		w.Days = [7]bool{true, true, true, true, true, true, true}
+	}
+
+	switch len(fields) {
+	case 2:
+		loc, err := time.LoadLocation(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone: %s", fields[1])
+		}
+
+		w.Location = loc
+	case 1:
+	default:
+		return nil, fmt.Errorf("%s", spec)
+	}
+
+	parts := strings.Split(fields[0], "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid time range: %s", fields[0])
+	}
+
+	start, err := parseWindowTime(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := parseWindowTime(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if start == end {
+		return nil, fmt.Errorf("empty time range: %s", fields[0])
+	}
+
+	w.Start = start
+	w.End = end
+
+	return w, nil
+}
+
+func (w *promoteWindow) parseDays(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		parts := strings.Split(item, "-")
+
+		if len(parts) > 2 {
+			return fmt.Errorf("invalid days: %s", item)
+		}
+
+		from, err := parseWindowDay(parts[0])
+		if err != nil {
+			return err
+		}
+
+		to := from
+
+		if len(parts) == 2 {
+			if to, err = parseWindowDay(parts[1]); err != nil {
+				return err
+			}
+		}
+
+		for d := from; ; d = (d + 1) % 7 {
+			w.Days[d] = true
+
+			if d == to {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseWindowDay(s string) (int, error) {
+	for i, d := range promoteWindowDays {
+		if strings.EqualFold(s, d) {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid day: %s", s)
+}
+
+func parseWindowTime(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time: %s", s)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Open returns true if t falls inside the window
+func (w *promoteWindow) Open(t time.Time) bool {
+	t = t.In(w.Location)
+
+	day := int(t.Weekday())
+	minute := t.Hour()*60 + t.Minute()
+
+	if w.Start < w.End {
+		return w.Days[day] && minute >= w.Start && minute < w.End
+	}
+
+	switch {
+	case minute >= w.Start:
+		return w.Days[day]
+	case minute < w.End:
+		return w.Days[(day+6)%7]
+	default:
+		return false
+	}
+}
+
+// releasePolicyCheck returns an error if a release does not meet the
+// promotion policy of its app. A forced promote goes through anyway and is
+// recorded in the audit log.
+func (p *Provider) releasePolicyCheck(a *structs.App, id string, opts structs.ReleasePromoteOptions) error {
+	vs, err := p.releasePolicyViolations(a, id, common.DefaultString(opts.User, ""), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if len(vs) == 0 {
+		return nil
+	}
+
+	if !common.DefaultBool(opts.Force, false) {
+		return releasePolicyError(a, id, vs)
+	}
+
+	l := structs.NewAuditLog()
+
+	l.Action = "ReleasePromoteOverride"
+	l.App = a.Name
+	l.Method = http.MethodPost
+	l.Params = map[string]string{"id": id, "policy": strings.Join(vs, ", ")}
+	l.Result = "success"
+	l.Route = "/apps/{app}/releases/{id}/promote"
+	l.User = common.DefaultString(opts.User, "")
+
+	if err := p.AuditLogAppend(*l); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func releasePolicyError(a *structs.App, id string, vs []string) error {
+	return errors.WithStack(fmt.Errorf("release %s is blocked by the promotion policy of app %s: %s", id, a.Name, strings.Join(vs, ", ")))
+}
+
+// releasePolicyViolations lists the parts of the promotion policy of an app a
+// release promoted by user does not meet at a given time, users can not
+// approve their own promotion
+func (p *Provider) releasePolicyViolations(a *structs.App, id, user string, now time.Time) ([]string, error) {
+	// promoting the active release again applies changes to the app itself
+	if strings.EqualFold(a.Release, id) {
+		return nil, nil
+	}
+
+	pp, err := appPromotePolicy(a)
+	if err != nil {
+		return nil, err
+	}
+
+	vs := []string{}
+
+	if pp.Window != nil && !pp.Window.Open(now) {
+		vs = append(vs, fmt.Sprintf("outside the deploy window %s", pp.Window.Spec))
+	}
+
+	if !pp.RequireTest && pp.Approvals == 0 {
+		return vs, nil
+	}
+
+	r, err := p.ReleaseGet(a.Name, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if pp.RequireTest {
+		if r.Build == "" {
+			vs = append(vs, "release has no build to test")
+		} else {
+			b, err := p.BuildGet(a.Name, r.Build)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			if b.Tested == nil {
+				vs = append(vs, fmt.Sprintf("build %s has not passed convox test", b.Id))
+			}
+		}
+	}
+
+	n := 0
+
+	for _, ra := range r.Approvals {
+		if ra.User != user {
+			n++
+		}
+	}
+
+	if n < pp.Approvals {
+		vs = append(vs, fmt.Sprintf("%d of %d required approvals", n, pp.Approvals))
+	}
+
+	return vs, nil
+}
+
+// buildTestRecord runs after command exits successfully in process pid. When
+// command is the test of the service of the process and the process runs the
+// image of its release the service is recorded as passed on the build, which
+// is marked as tested once every service with a test has passed.
+func (p *Provider) buildTestRecord(app, pid, command string) error {
+	pd, err := p.GetPodFromInformer(pid, p.AppNamespace(app))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ps, err := p.processFromPod(*pd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if ps.Release == "" {
+		return nil
+	}
+
+	m, r, err := common.ReleaseManifest(p, app, ps.Release)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if r.Build == "" {
+		return nil
+	}
+
+	s, err := m.Service(ps.Name)
+	if err != nil || s.Test == "" || s.Test != command {
+		return nil
+	}
+
+	repo, _, err := p.Engine.RepositoryHost(app)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if ps.Image != fmt.Sprintf("%s:%s.%s", repo, s.Name, r.Build) {
+		return nil
+	}
+
+	bs := p.Convox.ConvoxV1().Builds(p.AppNamespace(app))
+
+	kb, err := bs.Get(strings.ToLower(r.Build), am.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	passed := map[string]string{}
+
+	if v := kb.Annotations["convox.com/tests-passed"]; v != "" {
+		if err := json.Unmarshal([]byte(v), &passed); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	now := time.Now().UTC().Format(common.SortableTime)
+
+	passed[s.Name] = now
+
+	data, err := json.Marshal(passed)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if kb.Annotations == nil {
+		kb.Annotations = map[string]string{}
+	}
+
+	kb.Annotations["convox.com/tests-passed"] = string(data)
+
+	tested := kb.Spec.Tested == ""
+
+	for _, ms := range m.Services {
+		if ms.Test != "" && passed[ms.Name] == "" {
+			tested = false
+		}
+	}
+
+	if tested {
+		kb.Spec.Tested = now
+	}
+
+	if _, err := bs.Update(kb); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
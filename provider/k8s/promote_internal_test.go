@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/convox/convox/pkg/mock"
+	"github.com/convox/convox/pkg/structs"
+	ca "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
+	cvfake "github.com/convox/convox/provider/k8s/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/require"
+	ac "k8s.io/api/core/v1"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBuildTestRecord(t *testing.T) {
+	c := fake.NewSimpleClientset()
+	cc := cvfake.NewSimpleClientset()
+
+	manifest := "services:\n  web:\n    build: .\n    test: make test\n  worker:\n    build: .\n    test: make check\n  other:\n    build: .\n"
+
+	_, err := cc.ConvoxV1().Builds("rack1-app1").Create(&ca.Build{
+		ObjectMeta: am.ObjectMeta{Name: "build1"},
+		Spec:       ca.BuildSpec{Ended: "20200101.000000.000000000", Manifest: manifest, Started: "20200101.000000.000000000", Status: "complete"},
+	})
+	require.NoError(t, err)
+
+	_, err = cc.ConvoxV1().Releases("rack1-app1").Create(&ca.Release{
+		ObjectMeta: am.ObjectMeta{Name: "release1"},
+		Spec:       ca.ReleaseSpec{Build: "BUILD1", Created: "20200101.000000.000000000", Manifest: manifest},
+	})
+	require.NoError(t, err)
+
+	for _, pod := range [][3]string{
+		{"web-test", "web", "repo1:web.BUILD1"},
+		{"web-other", "web", "other:latest"},
+		{"worker-test", "worker", "repo1:worker.BUILD1"},
+	} {
+		_, err := c.CoreV1().Pods("rack1-app1").Create(context.TODO(), &ac.Pod{
+			ObjectMeta: am.ObjectMeta{Name: pod[0], Labels: map[string]string{"app": "app1", "release": "RELEASE1", "service": pod[1]}},
+			Spec:       ac.PodSpec{Containers: []ac.Container{{Name: "app1", Image: pod[2]}}},
+		}, am.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	p := &Provider{
+		Cluster: c,
+		Convox:  cc,
+		Engine:  &mock.TestEngine{},
+		Name:    "rack1",
+	}
+	require.NoError(t, p.Initialize(structs.ProviderOptions{}))
+
+	tested := func() *structs.Build {
+		b, err := p.BuildGet("app1", "BUILD1")
+		require.NoError(t, err)
+		return b
+	}
+
+	// not the test command or not the image of the build
+	require.NoError(t, p.buildTestRecord("app1", "web-test", "make other"))
+	require.NoError(t, p.buildTestRecord("app1", "web-other", "make test"))
+	require.NoError(t, p.buildTestRecord("app1", "worker-test", "make test"))
+	require.Nil(t, tested().Tested)
+
+	require.NoError(t, p.buildTestRecord("app1", "web-test", "make test"))
+	require.Nil(t, tested().Tested)
+
+	require.NoError(t, p.buildTestRecord("app1", "worker-test", "make check"))
+	require.NotNil(t, tested().Tested)
+
+	kb, err := cc.ConvoxV1().Builds("rack1-app1").Get("build1", am.GetOptions{})
+	require.NoError(t, err)
+	require.Contains(t, kb.Annotations["convox.com/tests-passed"], `"web":`)
+	require.Contains(t, kb.Annotations["convox.com/tests-passed"], `"worker":`)
+}
@@ -0,0 +1,221 @@
+package k8s_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/atom"
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/mock"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	tm "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func promoteSetup(t *testing.T, p *k8s.Provider, params map[string]string) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	p.Engine = scanEngine{TestEngine: &mock.TestEngine{}, p: p}
+
+	data, err := json.Marshal(params)
+	require.NoError(t, err)
+
+	require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+		"convox.com/app-release": "release1",
+		"convox.com/app-status":  "running",
+		"convox.com/params":      string(data),
+	}))
+	require.NoError(t, buildCreate(p.Convox, "rack1-app1", "build1", "basic"))
+	require.NoError(t, releaseCreate(p.Convox, "rack1-app1", "release1", "basic"))
+	require.NoError(t, releaseCreate(p.Convox, "rack1-app1", "release2", "basic"))
+}
+
+// promoteWindow returns a window around or away from the current time
+func promoteWindow(open bool) string {
+	now := time.Now().UTC()
+
+	start, end := now.Add(-1*time.Hour), now.Add(1*time.Hour)
+
+	if !open {
+		start, end = now.Add(2*time.Hour), now.Add(3*time.Hour)
+	}
+
+	return fmt.Sprintf("%s-%s", start.Format("15:04"), end.Format("15:04"))
+}
+
+func TestReleaseApprove(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		promoteSetup(t, p, map[string]string{})
+
+		r, err := p.ReleaseApprove("app1", "release2", structs.ReleaseApproveOptions{User: options.String("user1")})
+		require.NoError(t, err)
+		require.Len(t, r.Approvals, 1)
+		require.Equal(t, "user1", r.Approvals[0].User)
+
+		r, err = p.ReleaseApprove("app1", "release2", structs.ReleaseApproveOptions{User: options.String("user2")})
+		require.NoError(t, err)
+		require.Len(t, r.Approvals, 2)
+
+		r, err = p.ReleaseApprove("app1", "release2", structs.ReleaseApproveOptions{User: options.String("user1")})
+		require.NoError(t, err)
+		require.Len(t, r.Approvals, 2)
+		require.Equal(t, "user2", r.Approvals[0].User)
+		require.Equal(t, "user1", r.Approvals[1].User)
+
+		r, err = p.ReleaseGet("app1", "release2")
+		require.NoError(t, err)
+		require.Len(t, r.Approvals, 2)
+
+		_, err = p.ReleaseApprove("app1", "release2", structs.ReleaseApproveOptions{})
+		require.EqualError(t, err, "approving a release requires an authenticated user")
+	})
+}
+
+func TestReleasePromotePolicyOwnApproval(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		promoteSetup(t, p, map[string]string{
+			structs.AppParamPromoteApprovals: "1",
+		})
+
+		aa := p.Atom.(*atom.MockInterface)
+		aa.On("Apply", "rack1-app1", "app", tm.Anything).Return(nil)
+
+		_, err := p.ReleaseApprove("app1", "release2", structs.ReleaseApproveOptions{User: options.String("user1")})
+		require.NoError(t, err)
+
+		err = p.ReleasePromote("app1", "release2", structs.ReleasePromoteOptions{User: options.String("user1")})
+		require.EqualError(t, err, "release release2 is blocked by the promotion policy of app app1: 0 of 1 required approvals")
+
+		err = p.ReleasePromote("app1", "release2", structs.ReleasePromoteOptions{User: options.String("user2")})
+		require.NoError(t, err)
+	})
+}
+
+// buildTested marks a build as having passed its tests
+func buildTested(t *testing.T, p *k8s.Provider, id string) {
+	kb, err := p.Convox.ConvoxV1().Builds("rack1-app1").Get(id, am.GetOptions{})
+	require.NoError(t, err)
+
+	kb.Spec.Tested = time.Now().UTC().Format(common.SortableTime)
+
+	_, err = p.Convox.ConvoxV1().Builds("rack1-app1").Update(kb)
+	require.NoError(t, err)
+}
+
+func TestReleasePromotePolicyBlocked(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		promoteSetup(t, p, map[string]string{
+			structs.AppParamPromoteApprovals:   "2",
+			structs.AppParamPromoteRequireTest: "true",
+			structs.AppParamPromoteWindow:      promoteWindow(false),
+		})
+
+		_, err := p.ReleaseApprove("app1", "release2", structs.ReleaseApproveOptions{User: options.String("user1")})
+		require.NoError(t, err)
+
+		err = p.ReleasePromote("app1", "release2", structs.ReleasePromoteOptions{})
+		require.EqualError(t, err, fmt.Sprintf("release release2 is blocked by the promotion policy of app app1: outside the deploy window %s, build BUILD1 has not passed convox test, 1 of 2 required approvals", promoteWindow(false)))
+	})
+}
+
+func TestReleasePromotePolicyActive(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		promoteSetup(t, p, map[string]string{
+			structs.AppParamPromoteApprovals: "1",
+		})
+
+		aa := p.Atom.(*atom.MockInterface)
+		aa.On("Apply", "rack1-app1", "app", tm.Anything).Return(nil)
+
+		err := p.ReleasePromote("app1", "release1", structs.ReleasePromoteOptions{})
+		require.NoError(t, err)
+	})
+}
+
+func TestReleasePromotePolicyMet(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		promoteSetup(t, p, map[string]string{
+			structs.AppParamPromoteApprovals:   "1",
+			structs.AppParamPromoteRequireTest: "true",
+			structs.AppParamPromoteWindow:      promoteWindow(true),
+		})
+
+		aa := p.Atom.(*atom.MockInterface)
+		aa.On("Apply", "rack1-app1", "app", tm.Anything).Return(nil)
+
+		_, err := p.ReleaseApprove("app1", "release2", structs.ReleaseApproveOptions{User: options.String("user1")})
+		require.NoError(t, err)
+
+		buildTested(t, p, "build1")
+
+		err = p.ReleasePromote("app1", "release2", structs.ReleasePromoteOptions{})
+		require.NoError(t, err)
+
+		as, err := p.AuditLogList(structs.AuditLogListOptions{})
+		require.NoError(t, err)
+		require.Len(t, as, 0)
+	})
+}
+
+func TestReleasePromotePolicyForce(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		promoteSetup(t, p, map[string]string{
+			structs.AppParamPromoteApprovals: "1",
+		})
+
+		aa := p.Atom.(*atom.MockInterface)
+		aa.On("Apply", "rack1-app1", "app", tm.Anything).Return(nil)
+
+		err := p.ReleasePromote("app1", "release2", structs.ReleasePromoteOptions{Force: options.Bool(true), User: options.String("user1")})
+		require.NoError(t, err)
+
+		as, err := p.AuditLogList(structs.AuditLogListOptions{})
+		require.NoError(t, err)
+		require.Len(t, as, 1)
+		require.Equal(t, "ReleasePromoteOverride", as[0].Action)
+		require.Equal(t, "app1", as[0].App)
+		require.Equal(t, "user1", as[0].User)
+		require.Equal(t, map[string]string{"id": "release2", "policy": "0 of 1 required approvals"}, as[0].Params)
+	})
+}
+
+func TestReleasePromotePolicyInvalid(t *testing.T) {
+	tests := map[string]string{
+		"PromoteApprovals": "-1",
+		"PromoteWindow":    "Mon-Fri 09:00",
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			testProvider(t, func(p *k8s.Provider) {
+				promoteSetup(t, p, map[string]string{k: v})
+
+				err := p.ReleasePromote("app1", "release2", structs.ReleasePromoteOptions{})
+				require.Error(t, err)
+				require.Contains(t, err.Error(), fmt.Sprintf("invalid %s for app app1", k))
+			})
+		})
+	}
+}
+
+func TestReleasePlanPolicy(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		promoteSetup(t, p, map[string]string{
+			structs.AppParamPromoteApprovals: "1",
+		})
+
+		plan, err := p.ReleasePlan("app1", "release2", structs.ReleasePromoteOptions{})
+		require.NoError(t, err)
+		require.Contains(t, plan.Warnings, "promote will be refused: release release2 is blocked by the promotion policy of app app1: 0 of 1 required approvals")
+
+		plan, err = p.ReleasePlan("app1", "release2", structs.ReleasePromoteOptions{Force: options.Bool(true)})
+		require.NoError(t, err)
+		require.Contains(t, plan.Warnings, "promote will override the promotion policy: 0 of 1 required approvals")
+	})
+}
@@ -0,0 +1,194 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/manifest"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	ae "k8s.io/apimachinery/pkg/api/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	retainBuildsDefault   = BuildMax
+	retainReleasesDefault = 10
+)
+
+// retention is the policy that decides which builds and releases of an app
+// are kept, anything kept by any one of the rules is not removed
+type retention struct {
+	Builds   int // keep the newest n builds
+	Days     int // keep anything newer than n days, 0 to disable
+	Releases int // keep the newest n releases and the builds they reference
+}
+
+// appRetention returns the retention policy of an app, or nil when the app
+// has not set any of the retention parameters and nothing should be pruned
+func appRetention(a *structs.App) (*retention, error) {
+	r := &retention{
+		Builds:   retainBuildsDefault,
+		Releases: retainReleasesDefault,
+	}
+
+	params := map[string]*int{
+		structs.AppParamRetainBuilds:   &r.Builds,
+		structs.AppParamRetainDays:     &r.Days,
+		structs.AppParamRetainReleases: &r.Releases,
+	}
+
+	set := false
+
+	for name, v := range params {
+		s := a.Parameters[name]
+		if s == "" {
+			continue
+		}
+
+		set = true
+
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, errors.WithStack(fmt.Errorf("invalid %s for app %s: %s", name, a.Name, s))
+		}
+
+		*v = n
+	}
+
+	if !set {
+		return nil, nil
+	}
+
+	return r, nil
+}
+
+func (p *Provider) AppPrune(name string, opts structs.AppPruneOptions) (structs.PruneObjects, error) {
+	a, err := p.AppGet(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	r, err := appRetention(a)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return structs.PruneObjects{}, nil
+	}
+
+	bs, err := p.buildList(name, 1000)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	rs, err := p.releaseList(name, 1000)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	pbs, prs := r.prune(a.Release, bs, rs, time.Now().UTC())
+
+	pos := structs.PruneObjects{}
+
+	for _, b := range pbs {
+		pos = append(pos, structs.PruneObject{Created: b.Started, Id: b.Id, Images: buildImageTags(b), Type: "build"})
+	}
+
+	for _, r := range prs {
+		pos = append(pos, structs.PruneObject{Created: r.Created, Id: r.Id, Type: "release"})
+	}
+
+	sort.Slice(pos, pos.Less)
+
+	if common.DefaultBool(opts.DryRun, false) {
+		return pos, nil
+	}
+
+	ns := p.AppNamespace(name)
+
+	for _, po := range pos {
+		switch po.Type {
+		case "build":
+			if len(po.Images) > 0 {
+				if err := p.Engine.RepositoryUntag(name, po.Images); err != nil {
+					return nil, errors.WithStack(err)
+				}
+			}
+
+			if err := p.Convox.ConvoxV1().Builds(ns).Delete(strings.ToLower(po.Id), &am.DeleteOptions{}); err != nil && !ae.IsNotFound(err) {
+				return nil, errors.WithStack(err)
+			}
+		case "release":
+			if err := p.Convox.ConvoxV1().Releases(ns).Delete(strings.ToLower(po.Id), &am.DeleteOptions{}); err != nil && !ae.IsNotFound(err) {
+				return nil, errors.WithStack(err)
+			}
+		}
+	}
+
+	return pos, nil
+}
+
+// prune returns the builds and releases that the policy does not keep. The
+// active release and its build, and builds that have not finished, are
+// always kept.
+func (r retention) prune(current string, bs structs.Builds, rs structs.Releases, now time.Time) (structs.Builds, structs.Releases) {
+	sort.Slice(bs, func(i, j int) bool { return bs[j].Started.Before(bs[i].Started) })
+	sort.Slice(rs, func(i, j int) bool { return rs[j].Created.Before(rs[i].Created) })
+
+	recent := func(t time.Time) bool {
+		return r.Days > 0 && t.After(now.Add(-time.Duration(r.Days)*24*time.Hour))
+	}
+
+	referenced := map[string]bool{}
+	prs := structs.Releases{}
+
+	for i, rr := range rs {
+		if i < r.Releases || rr.Id == current || recent(rr.Created) {
+			referenced[rr.Build] = true
+			continue
+		}
+
+		prs = append(prs, rr)
+	}
+
+	pbs := structs.Builds{}
+
+	for i, b := range bs {
+		switch {
+		case i < r.Builds, referenced[b.Id], recent(b.Started):
+		case b.Status == "created", b.Status == "running":
+		default:
+			pbs = append(pbs, b)
+		}
+	}
+
+	return pbs, prs
+}
+
+// buildImageTags returns the repository tags pushed for each service of a build
+func buildImageTags(b structs.Build) []string {
+	m, err := manifest.Load([]byte(b.Manifest), map[string]string{})
+	if err != nil {
+		return nil
+	}
+
+	tags := []string{}
+
+	for _, s := range m.Services {
+		tags = append(tags, fmt.Sprintf("%s.%s", s.Name, b.Id))
+	}
+
+	return tags
+}
+
+// override this function to provide infrastructure-specific image cleanup,
+// such as untagging images in ecr. registries without an api to remove a
+// single tag keep their images.
+func (p *Provider) RepositoryUntag(app string, tags []string) error {
+	return nil
+}
@@ -0,0 +1,133 @@
+package k8s_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/mock"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	ca "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
+	"github.com/stretchr/testify/require"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAppPrune(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kk := p.Cluster.(*fake.Clientset)
+
+		p.Engine = scanEngine{TestEngine: &mock.TestEngine{}, p: p}
+
+		require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+			"convox.com/app-release": "R12",
+			"convox.com/app-status":  "running",
+			"convox.com/params":      `{"RetainReleases":"10"}`,
+		}))
+
+		now := time.Now().UTC()
+
+		// b01 is the newest build, b35 the oldest, b34 is still running
+		for i := 1; i <= 35; i++ {
+			status := "complete"
+			if i == 34 {
+				status = "running"
+			}
+
+			started := now.Add(-time.Duration(i) * time.Hour).Format(common.SortableTime)
+
+			_, err := p.Convox.ConvoxV1().Builds("rack1-app1").Create(&ca.Build{
+				ObjectMeta: am.ObjectMeta{Name: fmt.Sprintf("b%02d", i), Labels: map[string]string{"app": "app1"}},
+				Spec: ca.BuildSpec{
+					Ended:    started,
+					Manifest: "services:\n  web:\n    build: .\n",
+					Started:  started,
+					Status:   status,
+				},
+			})
+			require.NoError(t, err)
+		}
+
+		// r01-r10 are kept and use b23-b32, r11 uses b33 and r12, the oldest
+		// release, is active and uses b35
+		for i := 1; i <= 12; i++ {
+			build := i + 22
+			if i == 12 {
+				build = 35
+			}
+
+			_, err := p.Convox.ConvoxV1().Releases("rack1-app1").Create(&ca.Release{
+				ObjectMeta: am.ObjectMeta{Name: fmt.Sprintf("r%02d", i), Labels: map[string]string{"app": "app1"}},
+				Spec: ca.ReleaseSpec{
+					Build:   fmt.Sprintf("B%02d", build),
+					Created: now.Add(-time.Duration(i) * time.Hour).Format(common.SortableTime),
+				},
+			})
+			require.NoError(t, err)
+		}
+
+		pos, err := p.AppPrune("app1", structs.AppPruneOptions{DryRun: options.Bool(true)})
+		require.NoError(t, err)
+
+		ids := []string{}
+
+		for _, po := range pos {
+			ids = append(ids, po.Id)
+		}
+
+		require.Equal(t, []string{"B33", "R11"}, ids)
+		require.Equal(t, []string{"web.B33"}, pos[0].Images)
+
+		bs, err := p.BuildList("app1", structs.BuildListOptions{Limit: options.Int(100)})
+		require.NoError(t, err)
+		require.Len(t, bs, 35)
+
+		pos, err = p.AppPrune("app1", structs.AppPruneOptions{})
+		require.NoError(t, err)
+		require.Len(t, pos, 2)
+
+		bs, err = p.BuildList("app1", structs.BuildListOptions{Limit: options.Int(100)})
+		require.NoError(t, err)
+		require.Len(t, bs, 34)
+
+		rs, err := p.ReleaseList("app1", structs.ReleaseListOptions{Limit: options.Int(100)})
+		require.NoError(t, err)
+		require.Len(t, rs, 11)
+
+		pos, err = p.AppPrune("app1", structs.AppPruneOptions{})
+		require.NoError(t, err)
+		require.Len(t, pos, 0)
+	})
+}
+
+func TestAppPruneDisabled(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kk := p.Cluster.(*fake.Clientset)
+
+		require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+			"convox.com/app-release": "R01",
+			"convox.com/app-status":  "running",
+		}))
+
+		for i := 1; i <= 12; i++ {
+			_, err := p.Convox.ConvoxV1().Releases("rack1-app1").Create(&ca.Release{
+				ObjectMeta: am.ObjectMeta{Name: fmt.Sprintf("r%02d", i), Labels: map[string]string{"app": "app1"}},
+				Spec: ca.ReleaseSpec{
+					Created: time.Now().UTC().Add(-time.Duration(i) * time.Hour).Format(common.SortableTime),
+				},
+			})
+			require.NoError(t, err)
+		}
+
+		pos, err := p.AppPrune("app1", structs.AppPruneOptions{})
+		require.NoError(t, err)
+		require.Len(t, pos, 0)
+
+		rs, err := p.ReleaseList("app1", structs.ReleaseListOptions{Limit: options.Int(100)})
+		require.NoError(t, err)
+		require.Len(t, rs, 12)
+	})
+}
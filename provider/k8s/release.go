@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -21,12 +22,63 @@ import (
 	"golang.org/x/text/language"
 	v1 "k8s.io/api/core/v1"
 	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 const (
 	APP_CONFIG_KEY = "app.json"
 )
 
+// ReleaseApprove records an approval of a release by opts.User, approving it
+// again only refreshes the time of that approval
+func (p *Provider) ReleaseApprove(app, id string, opts structs.ReleaseApproveOptions) (*structs.Release, error) {
+	user := common.DefaultString(opts.User, "")
+
+	if user == "" {
+		return nil, errors.WithStack(fmt.Errorf("approving a release requires an authenticated user"))
+	}
+
+	r, err := p.releaseGet(app, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	approvals := structs.ReleaseApprovals{}
+
+	for _, ra := range r.Approvals {
+		if ra.User != user {
+			approvals = append(approvals, ra)
+		}
+	}
+
+	approvals = append(approvals, structs.ReleaseApproval{User: user, Time: time.Now().UTC()})
+
+	data, err := json.Marshal(approvals)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	patch, err := patchBytes(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				"convox.com/approvals": string(data),
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	kr, err := p.Convox.ConvoxV1().Releases(p.AppNamespace(app)).Patch(strings.ToLower(id), types.MergePatchType, patch)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	p.EventSend("release:approve", structs.EventSendOptions{Data: map[string]string{"app": app, "id": r.Id, "user": user}})
+
+	return p.releaseUnmarshal(kr)
+}
+
 func (p *Provider) ReleaseCreate(app string, opts structs.ReleaseCreateOptions) (*structs.Release, error) {
 	r, err := p.releaseFork(app, opts.ParentRelease)
 	if err != nil {
@@ -73,6 +125,47 @@ func (p *Provider) ReleaseCreate(app string, opts structs.ReleaseCreateOptions)
 	return ro, nil
 }
 
+// ReleaseDiff compares a release to opts.From, or to the current release of
+// the app when it is not set
+func (p *Provider) ReleaseDiff(app, id string, opts structs.ReleaseDiffOptions) (*structs.ReleaseDiff, error) {
+	to, err := p.releaseGet(app, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	from := common.DefaultString(opts.From, "")
+
+	if opts.From == nil {
+		a, err := p.AppGet(app)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		from = a.Release
+	}
+
+	var fr *structs.Release
+
+	if from != "" {
+		fr, err = p.releaseGet(app, from)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	repo, _, err := p.Engine.RepositoryHost(app)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	d, err := common.ReleaseDiff(fr, to, repo)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return d, nil
+}
+
 func (p *Provider) ReleaseGet(app, id string) (*structs.Release, error) {
 	r, err := p.releaseGet(app, id)
 	if err != nil {
@@ -109,13 +202,69 @@ func (p *Provider) ReleasePromote(app, id string, opts structs.ReleasePromoteOpt
 		return errors.WithStack(err)
 	}
 
+	if id != "" {
+		if err := p.releaseScanCheck(a, id); err != nil {
+			return err
+		}
+
+		if err := p.releasePolicyCheck(a, id, opts); err != nil {
+			return err
+		}
+	}
+
+	if cid, err := p.canaryActive(app); err != nil {
+		return err
+	} else if cid != "" {
+		return errors.WithStack(fmt.Errorf("canary rollout of %s in progress for app: %s", cid, app))
+	}
+
+	if opts.Canary != nil && id != "" {
+		return p.releasePromoteCanary(a, id, opts)
+	}
+
+	return p.releasePromote(a, id, opts)
+}
+
+// releasePromote applies a release that already passed the checks of the app
+func (p *Provider) releasePromote(a *structs.App, id string, opts structs.ReleasePromoteOptions) error {
+	app := a.Name
+
+	items, dependencies, err := p.releaseItems(a, id, opts, nil)
+	if err != nil {
+		return err
+	}
+
+	tdata := bytes.Join(items, []byte("---\n"))
+
+	timeout := int32(common.DefaultInt(opts.Timeout, 3000))
+
+	if err := p.Apply(p.AppNamespace(app), "app", PromoteApplyConfig{
+		Version:      id,
+		Data:         tdata,
+		Labels:       fmt.Sprintf("system=convox,provider=k8s,rack=%s,app=%s,release=%s", p.Name, app, id),
+		Timeout:      timeout,
+		Dependencies: dependencies,
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	p.EventSend("release:promote", structs.EventSendOptions{Data: map[string]string{"app": app, "id": id}, Status: options.String("start")})
+
+	p.FlushStateLog(app)
+
+	return nil
+}
+
+// releaseItems renders the objects promoting a release applies. When
+// rendering for a plan nothing is provisioned or written.
+func (p *Provider) releaseItems(a *structs.App, id string, opts structs.ReleasePromoteOptions, plan *structs.ReleasePlan) ([][]byte, []string, error) {
 	items := [][]byte{}
 	dependencies := []string{}
 
 	// app
 	data, err := p.releaseTemplateApp(a, opts)
 	if err != nil {
-		return errors.WithStack(err)
+		return nil, nil, errors.WithStack(err)
 	}
 
 	items = append(items, data)
@@ -124,28 +273,28 @@ func (p *Provider) ReleasePromote(app, id string, opts structs.ReleasePromoteOpt
 	if ca, err := p.Cluster.CoreV1().Secrets("convox-system").Get(context.TODO(), "ca", am.GetOptions{}); err == nil {
 		data, err := p.releaseTemplateCA(a, ca)
 		if err != nil {
-			return errors.WithStack(err)
+			return nil, nil, errors.WithStack(err)
 		}
 
 		items = append(items, data)
 	}
 
 	if id != "" {
-		m, r, err := common.ReleaseManifest(p, app, id)
+		m, r, err := common.ReleaseManifest(p, a.Name, id)
 		if err != nil {
-			return errors.WithStack(err)
+			return nil, nil, errors.WithStack(err)
 		}
 
 		e, err := structs.NewEnvironment([]byte(r.Env))
 		if err != nil {
-			return errors.WithStack(err)
+			return nil, nil, errors.WithStack(err)
 		}
 
 		// balancers
 		for _, b := range m.Balancers {
 			data, err := p.releaseTemplateBalancer(a, r, b, m.Labels)
 			if err != nil {
-				return errors.WithStack(err)
+				return nil, nil, errors.WithStack(err)
 			}
 
 			items = append(items, data)
@@ -155,7 +304,7 @@ func (p *Provider) ReleasePromote(app, id string, opts structs.ReleasePromoteOpt
 		if rss := m.Services.Routable().External(); len(rss) > 0 {
 			data, err := p.releaseTemplateIngress(a, rss, opts)
 			if err != nil {
-				return errors.WithStack(err)
+				return nil, nil, errors.WithStack(err)
 			}
 
 			items = append(items, data)
@@ -164,11 +313,11 @@ func (p *Provider) ReleasePromote(app, id string, opts structs.ReleasePromoteOpt
 		// ingress internal
 		if rss := m.Services.Routable().InternalRouter(); len(rss) > 0 {
 			if p.DomainInternal == "" {
-				return errors.New("please enable the rack's internal router first: convox rack params set internal_router=true")
+				return nil, nil, errors.New("please enable the rack's internal router first: convox rack params set internal_router=true")
 			}
 			data, err := p.releaseTemplateIngressInternal(a, rss, opts)
 			if err != nil {
-				return errors.WithStack(err)
+				return nil, nil, errors.WithStack(err)
 			}
 
 			items = append(items, data)
@@ -179,7 +328,7 @@ func (p *Provider) ReleasePromote(app, id string, opts structs.ReleasePromoteOpt
 			if !r.IsCustomManagedResource() {
 				data, err := p.releaseTemplateResource(a, e, r)
 				if err != nil {
-					return errors.WithStack(err)
+					return nil, nil, errors.WithStack(err)
 				}
 
 				items = append(items, data)
@@ -189,7 +338,7 @@ func (p *Provider) ReleasePromote(app, id string, opts structs.ReleasePromoteOpt
 		// services
 		data, err := p.releaseTemplateServices(a, e, r, m.Services, opts)
 		if err != nil {
-			return errors.WithStack(err)
+			return nil, nil, errors.WithStack(err)
 		}
 
 		items = append(items, data)
@@ -198,12 +347,12 @@ func (p *Provider) ReleasePromote(app, id string, opts structs.ReleasePromoteOpt
 		for _, t := range m.Timers {
 			s, err := m.Service(t.Service)
 			if err != nil {
-				return errors.WithStack(err)
+				return nil, nil, errors.WithStack(err)
 			}
 
 			data, err := p.releaseTemplateTimer(a, e, r, s, t)
 			if err != nil {
-				return errors.WithStack(err)
+				return nil, nil, errors.WithStack(err)
 			}
 
 			items = append(items, data)
@@ -211,10 +360,21 @@ func (p *Provider) ReleasePromote(app, id string, opts structs.ReleasePromoteOpt
 
 		items = append(items, data)
 
+		// resources provisioned outside the cluster keep their current
+		// connection in a plan
+		if plan != nil {
+			pitems, err := p.planProvisioned(a, m, plan)
+			if err != nil {
+				return nil, nil, errors.WithStack(err)
+			}
+
+			return append(items, pitems...), dependencies, nil
+		}
+
 		// rds resources
 		rdsItems, rdsDeps, err := p.releaseRdsResources(a, e, m)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
 		items = append(items, rdsItems...)
@@ -225,7 +385,7 @@ func (p *Provider) ReleasePromote(app, id string, opts structs.ReleasePromoteOpt
 		// elasticache resources
 		elasticacheItems, elastiDeps, err := p.releaseElasticacheResources(a, e, m)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
 		items = append(items, elasticacheItems...)
@@ -234,29 +394,11 @@ func (p *Provider) ReleasePromote(app, id string, opts structs.ReleasePromoteOpt
 		}
 
 		if err := p.releaseAppConfigs(a, m); err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
 
-	tdata := bytes.Join(items, []byte("---\n"))
-
-	timeout := int32(common.DefaultInt(opts.Timeout, 3000))
-
-	if err := p.Apply(p.AppNamespace(app), "app", PromoteApplyConfig{
-		Version:      id,
-		Data:         tdata,
-		Labels:       fmt.Sprintf("system=convox,provider=k8s,rack=%s,app=%s,release=%s", p.Name, app, id),
-		Timeout:      timeout,
-		Dependencies: dependencies,
-	}); err != nil {
-		return errors.WithStack(err)
-	}
-
-	p.EventSend("release:promote", structs.EventSendOptions{Data: map[string]string{"app": app, "id": id}, Status: options.String("start")})
-
-	p.FlushStateLog(app)
-
-	return nil
+	return items, dependencies, nil
 }
 
 func (p *Provider) releaseCreate(r *structs.Release) (*structs.Release, error) {
@@ -352,12 +494,20 @@ func (p *Provider) releaseTemplateApp(a *structs.App, opts structs.ReleasePromot
 		return nil, errors.WithStack(err)
 	}
 
+	idles, err := p.Engine.AppIdles(a.Name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	params := map[string]interface{}{
-		"Locked":     a.Locked,
-		"Name":       a.Name,
-		"Namespace":  p.AppNamespace(a.Name),
-		"Owner":      owner,
-		"Parameters": a.Parameters,
+		"IdleBackend": idleBackend,
+		"Idler":       fmt.Sprintf("idler.%s.svc.cluster.local", p.Namespace),
+		"Idles":       common.DefaultBool(opts.Idle, idles),
+		"Locked":      a.Locked,
+		"Name":        a.Name,
+		"Namespace":   p.AppNamespace(a.Name),
+		"Owner":       owner,
+		"Parameters":  a.Parameters,
 	}
 
 	data, err := p.RenderTemplate("app/app", params)
@@ -441,6 +591,12 @@ func (p *Provider) releaseTemplateIngress(a *structs.App, ss manifest.Services,
 			}
 		}
 
+		if common.DefaultBool(opts.Idle, idles) {
+			if err := p.idleIngressAnnotate(p.AppNamespace(a.Name), ans); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+
 		params := map[string]interface{}{
 			"Annotations":                ans,
 			"App":                        a.Name,
@@ -507,6 +663,12 @@ func (p *Provider) releaseTemplateIngressInternal(a *structs.App, ss manifest.Se
 			}
 		}
 
+		if common.DefaultBool(opts.Idle, idles) {
+			if err := p.idleIngressAnnotate(p.AppNamespace(a.Name), ans); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+
 		params := map[string]interface{}{
 			"Annotations":                ans,
 			"App":                        a.Name,
@@ -657,8 +819,17 @@ func (p *Provider) releaseTemplateServices(a *structs.App, e structs.Environment
 			max = *opts.Max
 		}
 
+		// a service with scale schedules keeps an autoscaler so the schedule
+		// worker can move its bounds between windows
+		autoscale := s.Scale.Count.Min != s.Scale.Count.Max
 		replicas := common.CoalesceInt(sc[s.Name], s.Scale.Count.Min)
 
+		if len(s.Scale.Schedules) > 0 {
+			s.Scale.Count = s.Scale.ScheduledCount(time.Now())
+			autoscale = s.Scale.Count.Max > 0
+			replicas = scaleClamp(replicas, s.Scale.Count)
+		}
+
 		env, err := p.environment(a, r, s, e)
 		if err != nil {
 			return nil, errors.WithStack(err)
@@ -667,6 +838,7 @@ func (p *Provider) releaseTemplateServices(a *structs.App, e structs.Environment
 		params := map[string]interface{}{
 			"Annotations":    s.AnnotationsMap(),
 			"App":            a,
+			"Autoscale":      autoscale,
 			"Environment":    env,
 			"MaxSurge":       max - 100,
 			"MaxUnavailable": 100 - min,
@@ -767,6 +939,12 @@ func (p *Provider) releaseUnmarshal(kr *ca.Release) (*structs.Release, error) {
 		Manifest:    kr.Spec.Manifest,
 	}
 
+	if data, ok := kr.ObjectMeta.Annotations["convox.com/approvals"]; ok {
+		if err := json.Unmarshal([]byte(data), &r.Approvals); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
 	if len(r.Env) == 0 {
 		if s, err := p.Cluster.CoreV1().Secrets(p.AppNamespace(r.App)).Get(
 			context.TODO(), fmt.Sprintf("release-%s", kr.ObjectMeta.Name), am.GetOptions{},
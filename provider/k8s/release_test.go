@@ -175,3 +175,105 @@ func releaseFixture(name string) (*ca.ReleaseSpec, error) {
 
 	return s, nil
 }
+
+func TestReleaseDiff(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kc := p.Convox.(*cvfake.Clientset)
+		kk := p.Cluster.(*fake.Clientset)
+
+		require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+			"convox.com/app-release": "release1",
+			"convox.com/app-status":  "running",
+		}))
+
+		require.NoError(t, releaseCreateSpec(kc, "rack1-app1", "release1", ca.ReleaseSpec{
+			Build:    "BUILD1",
+			Created:  "20200101.000001.000000000",
+			Env:      "FOO=bar\nOLD=secret1",
+			Manifest: "resources:\n  db:\n    type: postgres\nservices:\n  web:\n    build: .\n    port: 5000\n    resources:\n      - db\n  worker:\n    build: .\ntimers:\n  cleanup:\n    command: bin/cleanup\n    schedule: \"0 * * * *\"\n    service: worker\n",
+		}))
+
+		require.NoError(t, releaseCreateSpec(kc, "rack1-app1", "release2", ca.ReleaseSpec{
+			Build:    "BUILD2",
+			Created:  "20200101.000002.000000000",
+			Env:      "FOO=baz\nNEW=secret2",
+			Manifest: "resources:\n  cache:\n    type: redis\n  db:\n    type: postgres\n    options:\n      storage: 20\nservices:\n  web:\n    build: .\n    domain: example.org\n    port: 5000\n    resources:\n      - db\n      - cache\n    scale:\n      count: 3\n  worker:\n    build: .\n",
+		}))
+
+		d, err := p.ReleaseDiff("app1", "release2", structs.ReleaseDiffOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "RELEASE1", d.From)
+		require.Equal(t, "RELEASE2", d.To)
+		require.Equal(t, structs.ReleaseDiffChanges{
+			{Action: "changed", Kind: "build", From: "BUILD1", To: "BUILD2"},
+			{Action: "changed", Kind: "image", Name: "web", From: "repo1:web.BUILD1", To: "repo1:web.BUILD2"},
+			{Action: "changed", Kind: "image", Name: "worker", From: "repo1:worker.BUILD1", To: "repo1:worker.BUILD2"},
+			{Action: "changed", Kind: "env", Name: "FOO"},
+			{Action: "added", Kind: "env", Name: "NEW"},
+			{Action: "removed", Kind: "env", Name: "OLD"},
+			{Action: "changed", Kind: "service", Name: "web", Field: "domain", To: `["example.org"]`},
+			{Action: "changed", Kind: "service", Name: "web", Field: "resources", From: `["db"]`, To: `["db","cache"]`},
+			{Action: "changed", Kind: "scale", Name: "web", Field: "count.min", From: "1", To: "3"},
+			{Action: "changed", Kind: "scale", Name: "web", Field: "count.max", From: "1", To: "3"},
+			{Action: "added", Kind: "resource", Name: "cache"},
+			{Action: "changed", Kind: "resource", Name: "db", Field: "options", To: `{"storage":"20"}`},
+			{Action: "removed", Kind: "timer", Name: "cleanup"},
+		}, d.Changes)
+
+		d, err = p.ReleaseDiff("app1", "release1", structs.ReleaseDiffOptions{From: options.String("release1")})
+		require.NoError(t, err)
+		require.Equal(t, structs.ReleaseDiffChanges{}, d.Changes)
+
+		_, err = p.ReleaseDiff("app1", "release3", structs.ReleaseDiffOptions{})
+		require.Error(t, err)
+	})
+}
+
+func TestReleaseDiffMasked(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kc := p.Convox.(*cvfake.Clientset)
+		kk := p.Cluster.(*fake.Clientset)
+
+		require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+			"convox.com/app-release": "release1",
+			"convox.com/app-status":  "running",
+		}))
+
+		require.NoError(t, releaseCreateSpec(kc, "rack1-app1", "release1", ca.ReleaseSpec{
+			Build:    "BUILD1",
+			Created:  "20200101.000001.000000000",
+			Env:      "HOST=secret1.example.org\nTOKEN=token1",
+			Manifest: "services:\n  web:\n    build: .\n    domain: ${HOST}\n    health: /check\n    port: 5000\n",
+		}))
+
+		require.NoError(t, releaseCreateSpec(kc, "rack1-app1", "release2", ca.ReleaseSpec{
+			Build:    "BUILD1",
+			Created:  "20200101.000002.000000000",
+			Env:      "HOST=secret2.example.org\nTOKEN=token1",
+			Manifest: "services:\n  web:\n    build: .\n    domain: ${HOST}\n    health:\n      path: /check?token=${TOKEN}\n    port: 5000\n",
+		}))
+
+		d, err := p.ReleaseDiff("app1", "release2", structs.ReleaseDiffOptions{})
+		require.NoError(t, err)
+		require.Equal(t, structs.ReleaseDiffChanges{
+			{Action: "changed", Kind: "env", Name: "HOST"},
+			{Action: "changed", Kind: "service", Name: "web", Field: "domain", From: "****", To: "****"},
+			{Action: "changed", Kind: "service", Name: "web", Field: "health.path", From: "/check", To: "****"},
+		}, d.Changes)
+	})
+}
+
+func releaseCreateSpec(kc cv.Interface, ns, id string, spec ca.ReleaseSpec) error {
+	r := &ca.Release{
+		ObjectMeta: am.ObjectMeta{
+			Name: id,
+		},
+		Spec: spec,
+	}
+
+	if _, err := kc.ConvoxV1().Releases(ns).Create(r); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
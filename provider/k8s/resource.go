@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"net/url"
 	"os/exec"
+	"strings"
 
 	"github.com/convox/convox/pkg/common"
 	"github.com/convox/convox/pkg/structs"
@@ -35,15 +36,26 @@ func (p *Provider) ResourceConsole(app, name string, rw io.ReadWriter, opts stru
 	switch r.Type {
 	case "mariadb":
 		return resourceConsoleCommand(rw, opts, "mysql", "-h", cn.Host, "-P", cn.Port, "-u", cn.Username, fmt.Sprintf("-p%s", cn.Password), "-D", cn.Database)
-	case "memcached":
-		return resourceConsoleCommand(rw, opts, "telnet", cn.Host, cn.Port)
+	case "elasticache-memcached", "memcached":
+		return resourceConsoleFunc(rw, opts, func(tty io.ReadWriter) error {
+			c, err := memcachedDial(u)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			defer c.Close()
+
+			return memcachedConsole(c, tty)
+		})
 	case "mysql":
 		return resourceConsoleCommand(rw, opts, "mysql", "-h", cn.Host, "-P", cn.Port, "-u", cn.Username, fmt.Sprintf("-p%s", cn.Password), "-D", cn.Database)
 	case "postgis":
 		return resourceConsoleCommand(rw, opts, "psql", u)
 	case "postgres":
 		return resourceConsoleCommand(rw, opts, "psql", u)
-	case "redis":
+	case "elasticache-redis", "redis":
+		if strings.HasPrefix(u, "rediss://") {
+			return resourceConsoleCommand(rw, opts, "redis-cli", "--tls", "-u", u)
+		}
 		return resourceConsoleCommand(rw, opts, "redis-cli", "-u", u)
 	default:
 		return errors.WithStack(fmt.Errorf("console not available for resources of type: %s", r.Type))
@@ -66,6 +78,10 @@ func (p *Provider) ResourceExport(app, name string) (io.ReadCloser, error) {
 		return resourceExportMysql(u)
 	case "postgis", "postgres", "rds-postgres":
 		return resourceExportPostgres(u)
+	case "elasticache-memcached", "memcached":
+		return resourceExportMemcached(u)
+	case "elasticache-redis", "redis":
+		return resourceExportRedis(u)
 	default:
 		return nil, errors.WithStack(fmt.Errorf("export not available for resources of type: %s", r.Type))
 	}
@@ -139,10 +155,14 @@ func (p *Provider) ResourceImport(app, name string, r io.Reader) error {
 	}
 
 	switch rr.Type {
-	case "mariadb", "rds-maridadb", "mysql", "rds-mysql":
+	case "mariadb", "rds-mariadb", "mysql", "rds-mysql":
 		return resourceImportMysql(rr, r)
 	case "postgis", "postgres", "rds-postgres":
 		return resourceImportPostgres(rr, r)
+	case "elasticache-memcached", "memcached":
+		return resourceImportMemcached(rr, r)
+	case "elasticache-redis", "redis":
+		return resourceImportRedis(rr, r)
 	default:
 		return errors.WithStack(fmt.Errorf("import not available for resources of type: %s", rr.Type))
 	}
@@ -190,6 +210,8 @@ func (p *Provider) SystemResourceCreate(kind string, opts structs.ResourceCreate
 	switch kind {
 	case "webhook":
 		return p.systemResourceCreateWebhook(opts)
+	case structs.WebhookKindPagerDuty, structs.WebhookKindSlack, structs.WebhookKindTeams:
+		return p.systemResourceCreateNotification(kind, opts)
 	default:
 		return nil, fmt.Errorf("rack resource type unknown: %s", kind)
 	}
@@ -201,16 +223,30 @@ func (p *Provider) systemResourceCreateWebhook(opts structs.ResourceCreateOption
 		return nil, fmt.Errorf("parameter required: Url")
 	}
 
-	key := fmt.Sprintf("%s-%d", url, rand.Int63())
-	name := common.DefaultString(opts.Name, fmt.Sprintf("webhook-%s", fmt.Sprintf("%x", sha256.Sum256([]byte(key)))[0:6]))
+	name := common.DefaultString(opts.Name, systemResourceName("webhook", url))
 
-	if err := p.webhookCreate(name, url); err != nil {
+	w := Webhook{
+		Name:   name,
+		URL:    url,
+		Apps:   splitWebhookList(opts.Parameters["Apps"]),
+		Events: splitWebhookList(opts.Parameters["Events"]),
+		Secret: opts.Parameters["Secret"],
+	}
+
+	if err := p.webhookCreate(w); err != nil {
 		return nil, err
 	}
 
 	return p.SystemResourceGet(name)
 }
 
+// systemResourceName generates a name for a rack resource that was created
+// without one
+func systemResourceName(kind, url string) string {
+	key := fmt.Sprintf("%s-%d", url, rand.Int63())
+	return fmt.Sprintf("%s-%s", kind, fmt.Sprintf("%x", sha256.Sum256([]byte(key)))[0:6])
+}
+
 func (p *Provider) SystemResourceDelete(name string) error {
 	r, err := p.SystemResourceGet(name)
 	if err != nil {
@@ -218,7 +254,7 @@ func (p *Provider) SystemResourceDelete(name string) error {
 	}
 
 	switch r.Type {
-	case "webhook":
+	case "webhook", structs.WebhookKindPagerDuty, structs.WebhookKindSlack, structs.WebhookKindTeams:
 		return p.webhookDelete(r.Name)
 	default:
 		return fmt.Errorf("rack resource type unknown: %s", r.Type)
@@ -253,19 +289,43 @@ func (p *Provider) SystemResourceList() (structs.Resources, error) {
 	}
 
 	for _, w := range ws {
+		params := map[string]string{
+			"Url": w.URL,
+		}
+
+		if len(w.Apps) > 0 {
+			params["Apps"] = strings.Join(w.Apps, ",")
+		}
+
+		if len(w.Events) > 0 {
+			params["Events"] = strings.Join(w.Events, ",")
+		}
+
 		rs = append(rs, structs.Resource{
-			Name: w.Name,
-			Parameters: map[string]string{
-				"Url": w.URL,
-			},
-			Status: "running",
-			Type:   "webhook",
+			Name:       w.Name,
+			Parameters: params,
+			Status:     "running",
+			Type:       w.Kind,
 		})
 	}
 
 	return rs, nil
 }
 
+var (
+	resourceParameterApps = structs.ResourceParameter{
+		Default:     "",
+		Description: "comma-separated app name patterns to deliver events for, e.g. staging-*",
+		Name:        "Apps",
+	}
+
+	resourceParameterEvents = structs.ResourceParameter{
+		Default:     "",
+		Description: "comma-separated event patterns to deliver, e.g. app:*,release:promote",
+		Name:        "Events",
+	}
+)
+
 func (p *Provider) SystemResourceTypes() (structs.ResourceTypes, error) {
 	rst := structs.ResourceTypes{
 		structs.ResourceType{
@@ -276,6 +336,54 @@ func (p *Provider) SystemResourceTypes() (structs.ResourceTypes, error) {
 					Description: "url to which to post rack events",
 					Name:        "Url",
 				},
+				resourceParameterApps,
+				resourceParameterEvents,
+				structs.ResourceParameter{
+					Default:     "",
+					Description: "secret used to sign deliveries with hmac-sha256",
+					Name:        "Secret",
+				},
+			},
+		},
+		structs.ResourceType{
+			Name: structs.WebhookKindPagerDuty,
+			Parameters: structs.ResourceParameters{
+				structs.ResourceParameter{
+					Default:     "",
+					Description: "integration key of a pagerduty events api v2 integration",
+					Name:        "RoutingKey",
+				},
+				structs.ResourceParameter{
+					Default:     pagerDutyEventsURL,
+					Description: "pagerduty events api url",
+					Name:        "Url",
+				},
+				resourceParameterApps,
+				resourceParameterEvents,
+			},
+		},
+		structs.ResourceType{
+			Name: structs.WebhookKindSlack,
+			Parameters: structs.ResourceParameters{
+				structs.ResourceParameter{
+					Default:     "",
+					Description: "slack incoming webhook url",
+					Name:        "Url",
+				},
+				resourceParameterApps,
+				resourceParameterEvents,
+			},
+		},
+		structs.ResourceType{
+			Name: structs.WebhookKindTeams,
+			Parameters: structs.ResourceParameters{
+				structs.ResourceParameter{
+					Default:     "",
+					Description: "microsoft teams incoming webhook url",
+					Name:        "Url",
+				},
+				resourceParameterApps,
+				resourceParameterEvents,
 			},
 		},
 	}
@@ -419,7 +527,48 @@ func resourceConsoleCommand(rw io.ReadWriter, opts structs.ResourceConsoleOption
 	return nil
 }
 
-func resourceExportCommand(w io.WriteCloser, command string, args ...string) {
+// resourceConsoleFunc runs a console implemented in go on a pty so it gets
+// the same line editing and echo as a console command
+func resourceConsoleFunc(rw io.ReadWriter, opts structs.ResourceConsoleOptions, fn func(io.ReadWriter) error) error {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer ptmx.Close()
+
+	size := &pty.Winsize{}
+
+	if opts.Height != nil {
+		size.Rows = uint16(*opts.Height)
+	}
+
+	if opts.Width != nil {
+		size.Cols = uint16(*opts.Width)
+	}
+
+	if err := pty.Setsize(ptmx, size); err != nil {
+		tty.Close()
+		return errors.WithStack(err)
+	}
+
+	ch := make(chan error, 1)
+
+	go func() {
+		err := fn(tty)
+		if err != nil {
+			fmt.Fprintf(tty, "ERROR: %v\n", err)
+		}
+		tty.Close()
+		ch <- err
+	}()
+
+	go io.Copy(ptmx, rw)
+	io.Copy(rw, ptmx)
+
+	return <-ch
+}
+
+func resourceExportCommand(w *io.PipeWriter, command string, args ...string) {
 	defer w.Close()
 
 	cmd := exec.Command(command, args...)
@@ -429,7 +578,26 @@ func resourceExportCommand(w io.WriteCloser, command string, args ...string) {
 
 	if err := cmd.Run(); err != nil {
 		fmt.Fprintf(w, "ERROR: could not export: %v\n", err)
+
+		// fail the reader too so a stored backup is not mistaken for a dump
+		w.CloseWithError(err)
+	}
+}
+
+func resourceExportMemcached(url string) (io.ReadCloser, error) {
+	c, err := memcachedDial(url)
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
+
+	rr, ww := io.Pipe()
+
+	go func() {
+		defer c.Close()
+		ww.CloseWithError(memcachedDump(c, ww))
+	}()
+
+	return rr, nil
 }
 
 func resourceExportMysql(url string) (io.ReadCloser, error) {
@@ -453,6 +621,32 @@ func resourceExportPostgres(url string) (io.ReadCloser, error) {
 	return rr, nil
 }
 
+func resourceExportRedis(url string) (io.ReadCloser, error) {
+	c, err := redisDial(url)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	rr, ww := io.Pipe()
+
+	go func() {
+		defer c.Close()
+		ww.CloseWithError(redisDump(c, ww))
+	}()
+
+	return rr, nil
+}
+
+func resourceImportMemcached(rr *structs.Resource, r io.Reader) error {
+	c, err := memcachedDial(rr.Url)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer c.Close()
+
+	return memcachedRestore(c, r)
+}
+
 func resourceImportMysql(rr *structs.Resource, r io.Reader) error {
 	cn, err := parseResourceURL(rr.Url)
 	if err != nil {
@@ -485,3 +679,13 @@ func resourceImportPostgres(rr *structs.Resource, r io.Reader) error {
 
 	return nil
 }
+
+func resourceImportRedis(rr *structs.Resource, r io.Reader) error {
+	c, err := redisDial(rr.Url)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer c.Close()
+
+	return redisRestore(c, r)
+}
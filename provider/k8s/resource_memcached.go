@@ -0,0 +1,321 @@
+package k8s
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type memcachedConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+type memcachedError string
+
+func (e memcachedError) Error() string {
+	return string(e)
+}
+
+func memcachedDial(u string) (*memcachedConn, error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	host := pu.Host
+
+	if pu.Port() == "" {
+		host = net.JoinHostPort(pu.Hostname(), "11211")
+	}
+
+	conn, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &memcachedConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}, nil
+}
+
+func (c *memcachedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *memcachedConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// send writes a command and returns the lines of its reply up to and
+// including the line that ends it
+func (c *memcachedConn) send(command string, data []byte) ([]string, error) {
+	fmt.Fprintf(c.w, "%s\r\n", command)
+
+	if data != nil {
+		c.w.Write(data)
+		c.w.WriteString("\r\n")
+	}
+
+	if err := c.w.Flush(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	lines := []string{}
+
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, line)
+
+		if memcachedReplyEnd(line) {
+			break
+		}
+
+		// a retrieval reply carries a data block that may look like anything
+		if fs := strings.Fields(line); len(fs) >= 4 && fs[0] == "VALUE" {
+			n, err := strconv.Atoi(fs[3])
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			block := make([]byte, n+2)
+
+			if _, err := io.ReadFull(c.r, block); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			lines = append(lines, string(block[0:n]))
+		}
+	}
+
+	if last := lines[len(lines)-1]; memcachedReplyError(last) {
+		return nil, memcachedError(last)
+	}
+
+	return lines, nil
+}
+
+// memcachedConsole reads commands from rw and prints their replies, stats
+// are lined up in columns
+func memcachedConsole(c *memcachedConn, rw io.ReadWriter) error {
+	br := bufio.NewReader(rw)
+
+	fmt.Fprintf(rw, "connected to %s, type quit to exit\n", c.conn.RemoteAddr())
+
+	for {
+		fmt.Fprint(rw, "memcached> ")
+
+		line, err := br.ReadString('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		line = strings.TrimSpace(line)
+
+		fs := strings.Fields(line)
+		if len(fs) == 0 {
+			continue
+		}
+
+		var data []byte
+
+		switch strings.ToLower(fs[0]) {
+		case "exit", "quit":
+			return nil
+		case "add", "append", "cas", "prepend", "replace", "set":
+			block, err := br.ReadString('\n')
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			data = []byte(strings.TrimRight(block, "\r\n"))
+		}
+
+		lines, err := c.send(line, data)
+		if _, ok := err.(memcachedError); ok {
+			fmt.Fprintf(rw, "%s\n", err)
+			continue
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		tw := tabwriter.NewWriter(rw, 0, 8, 2, ' ', 0)
+
+		for _, l := range lines {
+			if fs := strings.SplitN(l, " ", 3); len(fs) == 3 && fs[0] == "STAT" {
+				fmt.Fprintf(tw, "%s\t%s\n", fs[1], fs[2])
+				continue
+			}
+
+			if l != "END" || len(lines) == 1 {
+				fmt.Fprintf(tw, "%s\n", l)
+			}
+		}
+
+		tw.Flush()
+	}
+}
+
+// memcachedDump writes a set command for every live item on the server, the
+// expiry of an item is kept as an absolute time so an import does not extend
+// its lifetime
+func memcachedDump(c *memcachedConn, w io.Writer) error {
+	lines, err := c.send("lru_crawler metadump all", nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	expires := map[string]int64{}
+
+	for _, line := range lines {
+		var key string
+		exp := int64(-1)
+
+		for _, f := range strings.Fields(line) {
+			switch {
+			case strings.HasPrefix(f, "key="):
+				key, _ = url.QueryUnescape(strings.TrimPrefix(f, "key="))
+			case strings.HasPrefix(f, "exp="):
+				exp, _ = strconv.ParseInt(strings.TrimPrefix(f, "exp="), 10, 64)
+			}
+		}
+
+		if key != "" {
+			expires[key] = exp
+		}
+	}
+
+	keys := []string{}
+
+	for k := range expires {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w)
+	now := time.Now().Unix()
+
+	for _, key := range keys {
+		exp := expires[key]
+
+		if exp > 0 && exp <= now {
+			continue
+		}
+
+		if exp < 0 {
+			exp = 0
+		}
+
+		lines, err := c.send(fmt.Sprintf("get %s", key), nil)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		// evicted since the metadump
+		if len(lines) < 3 {
+			continue
+		}
+
+		fs := strings.Fields(lines[0])
+		if len(fs) < 4 {
+			return errors.WithStack(fmt.Errorf("unexpected reply: %s", lines[0]))
+		}
+
+		fmt.Fprintf(bw, "set %s %s %d %d\r\n%s\r\n", key, fs[2], exp, len(lines[1]), lines[1])
+	}
+
+	return errors.WithStack(bw.Flush())
+}
+
+// memcachedRestore replays the set commands in r against c
+func memcachedRestore(c *memcachedConn, r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	for {
+		line, err := br.ReadString('\n')
+		if err == io.EOF && strings.TrimSpace(line) == "" {
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		fs := strings.Fields(line)
+		if len(fs) != 5 || fs[0] != "set" {
+			return errors.WithStack(fmt.Errorf("invalid import: %q", line))
+		}
+
+		n, err := strconv.Atoi(fs[4])
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		block := make([]byte, n+2)
+
+		if _, err := io.ReadFull(br, block); err != nil {
+			return errors.WithStack(err)
+		}
+
+		lines, err := c.send(line, block[0:n])
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if lines[0] != "STORED" {
+			return errors.WithStack(fmt.Errorf("could not import %s: %s", fs[1], lines[0]))
+		}
+	}
+}
+
+func memcachedReplyEnd(line string) bool {
+	switch line {
+	case "END", "STORED", "NOT_STORED", "EXISTS", "NOT_FOUND", "DELETED", "TOUCHED", "OK", "RESET", "ERROR":
+		return true
+	}
+
+	if _, err := strconv.ParseUint(line, 10, 64); err == nil {
+		return true
+	}
+
+	for _, prefix := range []string{"BUSY", "CLIENT_ERROR", "SERVER_ERROR", "VERSION"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func memcachedReplyError(line string) bool {
+	for _, prefix := range []string{"BUSY", "CLIENT_ERROR", "ERROR", "SERVER_ERROR"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,142 @@
+package k8s_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/convox/convox/provider/k8s"
+	"github.com/stretchr/testify/require"
+)
+
+type testMemcachedItem struct {
+	exp   int64
+	flags string
+	value string
+}
+
+type testMemcachedServer struct {
+	items    map[string]testMemcachedItem
+	listener net.Listener
+	lock     sync.Mutex
+}
+
+func newTestMemcachedServer(t *testing.T, items map[string]testMemcachedItem) *testMemcachedServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &testMemcachedServer{items: items, listener: l}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(c)
+		}
+	}()
+
+	t.Cleanup(func() { l.Close() })
+
+	return s
+}
+
+func (s *testMemcachedServer) URL() string {
+	return fmt.Sprintf("memcached://%s", s.listener.Addr())
+}
+
+func (s *testMemcachedServer) serve(c net.Conn) {
+	defer c.Close()
+
+	r := bufio.NewReader(c)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fs := strings.Fields(line)
+
+		s.lock.Lock()
+
+		switch {
+		case strings.TrimSpace(line) == "lru_crawler metadump all":
+			keys := []string{}
+			for k := range s.items {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(c, "key=%s exp=%d la=0 cas=1 fetch=no cls=1 size=64\r\n", url.QueryEscape(k), s.items[k].exp)
+			}
+			fmt.Fprint(c, "END\r\n")
+		case fs[0] == "get":
+			if i, ok := s.items[fs[1]]; ok {
+				fmt.Fprintf(c, "VALUE %s %s %d\r\n%s\r\n", fs[1], i.flags, len(i.value), i.value)
+			}
+			fmt.Fprint(c, "END\r\n")
+		case fs[0] == "set":
+			n, _ := strconv.Atoi(fs[4])
+			data := make([]byte, n+2)
+			io.ReadFull(r, data)
+			exp, _ := strconv.ParseInt(fs[3], 10, 64)
+			s.items[fs[1]] = testMemcachedItem{exp: exp, flags: fs[2], value: string(data[0:n])}
+			fmt.Fprint(c, "STORED\r\n")
+		default:
+			fmt.Fprint(c, "ERROR\r\n")
+		}
+
+		s.lock.Unlock()
+	}
+}
+
+func TestResourceExportImportMemcached(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		src := newTestMemcachedServer(t, map[string]testMemcachedItem{
+			"page:1":  {exp: -1, flags: "0", value: "<html>"},
+			"page:2":  {exp: 4102444800, flags: "3", value: "END\r\nSTORED"},
+			"expired": {exp: 1, flags: "0", value: "gone"},
+		})
+
+		dst := newTestMemcachedServer(t, map[string]testMemcachedItem{})
+
+		cacheResourceSetup(t, p, "memcached", src.URL())
+
+		r, err := p.ResourceExport("app1", "cache")
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "set page:1 0 0 6\r\n<html>\r\nset page:2 3 4102444800 11\r\nEND\r\nSTORED\r\n", string(data))
+
+		cacheResourceURL(t, p, dst.URL())
+
+		require.NoError(t, p.ResourceImport("app1", "cache", strings.NewReader(string(data))))
+		require.Equal(t, map[string]testMemcachedItem{
+			"page:1": {exp: 0, flags: "0", value: "<html>"},
+			"page:2": {exp: 4102444800, flags: "3", value: "END\r\nSTORED"},
+		}, dst.items)
+	})
+}
+
+func TestResourceImportMemcachedInvalid(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		dst := newTestMemcachedServer(t, map[string]testMemcachedItem{})
+
+		cacheResourceSetup(t, p, "memcached", dst.URL())
+
+		err := p.ResourceImport("app1", "cache", strings.NewReader("flush_all\r\n"))
+		require.EqualError(t, err, `invalid import: "flush_all"`)
+	})
+}
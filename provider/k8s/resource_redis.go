@@ -0,0 +1,302 @@
+package k8s
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// redis can not load an rdb file over the network so exports are a stream of
+// RESTORE commands, one per key, that can be replayed by an import or piped
+// to redis-cli --pipe
+const redisImportBatch = 100
+
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+func redisDial(u string) (*redisConn, error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	host := pu.Host
+
+	if pu.Port() == "" {
+		host = net.JoinHostPort(pu.Hostname(), "6379")
+	}
+
+	d := &net.Dialer{Timeout: 10 * time.Second}
+
+	var conn net.Conn
+
+	switch pu.Scheme {
+	case "redis":
+		conn, err = d.Dial("tcp", host)
+	case "rediss":
+		conn, err = tls.DialWithDialer(d, "tcp", host, &tls.Config{ServerName: pu.Hostname()})
+	default:
+		return nil, errors.WithStack(fmt.Errorf("unknown redis scheme: %s", pu.Scheme))
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+
+	// like redis-cli a url with only one credential treats it as the password
+	if pw, ok := pu.User.Password(); ok {
+		args := []string{"AUTH", pw}
+
+		if un := pu.User.Username(); un != "" {
+			args = []string{"AUTH", un, pw}
+		}
+
+		if _, err := c.do(args...); err != nil {
+			c.Close()
+			return nil, errors.WithStack(err)
+		}
+	} else if un := pu.User.Username(); un != "" {
+		if _, err := c.do("AUTH", un); err != nil {
+			c.Close()
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if db := strings.TrimPrefix(pu.Path, "/"); db != "" && db != "0" {
+		if _, err := c.do("SELECT", db); err != nil {
+			c.Close()
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return c, nil
+}
+
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	if err := redisWrite(c.w, args...); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := c.w.Flush(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return redisRead(c.r)
+}
+
+// redisDump writes a RESTORE command for every key in the database of c
+func redisDump(c *redisConn, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	cursor := "0"
+
+	for {
+		v, err := c.do("SCAN", cursor, "COUNT", "1000")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		reply, ok := v.([]interface{})
+		if !ok || len(reply) != 2 {
+			return errors.WithStack(fmt.Errorf("unexpected scan reply: %v", v))
+		}
+
+		cursor, _ = reply[0].(string)
+		keys, _ := reply[1].([]interface{})
+
+		for _, k := range keys {
+			key, _ := k.(string)
+
+			ttl, err := c.do("PTTL", key)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			payload, err := c.do("DUMP", key)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			ms, _ := ttl.(int64)
+
+			// the key expired between the scan and the dump
+			if payload == nil || ms == -2 {
+				continue
+			}
+
+			if ms < 0 {
+				ms = 0
+			}
+
+			if err := redisWrite(bw, "RESTORE", key, strconv.FormatInt(ms, 10), payload.(string), "REPLACE"); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+
+	return errors.WithStack(bw.Flush())
+}
+
+// redisRestore replays the commands in r against c, pipelining them in
+// batches and failing on the first error reply
+func redisRestore(c *redisConn, r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	pending := 0
+
+	flush := func() error {
+		if err := c.w.Flush(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		var first error
+
+		for ; pending > 0; pending-- {
+			if _, err := redisRead(c.r); err != nil && first == nil {
+				first = err
+			}
+		}
+
+		return first
+	}
+
+	for {
+		v, err := redisRead(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		cmd, ok := v.([]interface{})
+		if !ok {
+			return errors.WithStack(fmt.Errorf("invalid import: expected a command"))
+		}
+
+		args := make([]string, len(cmd))
+
+		for i := range cmd {
+			args[i], _ = cmd[i].(string)
+		}
+
+		if err := redisWrite(c.w, args...); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if pending++; pending >= redisImportBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+func redisRead(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	line = strings.TrimSuffix(line, "\r\n")
+
+	if line == "" {
+		return nil, errors.WithStack(fmt.Errorf("invalid redis reply"))
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		return n, errors.WithStack(err)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if n < 0 {
+			return nil, nil
+		}
+
+		data := make([]byte, n+2)
+
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return string(data[0:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if n < 0 {
+			return nil, nil
+		}
+
+		vs := make([]interface{}, n)
+
+		for i := range vs {
+			v, err := redisRead(r)
+			if _, ok := err.(redisError); err != nil && !ok {
+				return nil, errors.WithStack(err)
+			}
+
+			vs[i] = v
+		}
+
+		return vs, nil
+	default:
+		return nil, errors.WithStack(fmt.Errorf("invalid redis reply: %q", line))
+	}
+}
+
+func redisWrite(w io.Writer, args ...string) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return errors.WithStack(err)
+}
@@ -0,0 +1,226 @@
+package k8s_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/provider/k8s"
+	ca "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	ac "k8s.io/api/core/v1"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type testRedisKey struct {
+	ttl   int64
+	value string
+}
+
+type testRedisServer struct {
+	keys     map[string]testRedisKey
+	listener net.Listener
+	lock     sync.Mutex
+}
+
+func newTestRedisServer(t *testing.T, keys map[string]testRedisKey) *testRedisServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &testRedisServer{keys: keys, listener: l}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(c)
+		}
+	}()
+
+	t.Cleanup(func() { l.Close() })
+
+	return s
+}
+
+func (s *testRedisServer) URL() string {
+	return fmt.Sprintf("redis://%s/0", s.listener.Addr())
+}
+
+func (s *testRedisServer) serve(c net.Conn) {
+	defer c.Close()
+
+	r := bufio.NewReader(c)
+
+	for {
+		args, err := testRedisReadCommand(r)
+		if err != nil {
+			return
+		}
+
+		s.lock.Lock()
+		fmt.Fprint(c, s.reply(args))
+		s.lock.Unlock()
+	}
+}
+
+func (s *testRedisServer) reply(args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "DUMP":
+		k, ok := s.keys[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		payload := "payload:" + k.value
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(payload), payload)
+	case "PTTL":
+		k, ok := s.keys[args[1]]
+		switch {
+		case !ok:
+			return ":-2\r\n"
+		case k.ttl == 0:
+			return ":-1\r\n"
+		default:
+			return fmt.Sprintf(":%d\r\n", k.ttl)
+		}
+	case "RESTORE":
+		ttl, _ := strconv.ParseInt(args[2], 10, 64)
+		s.keys[args[1]] = testRedisKey{ttl: ttl, value: strings.TrimPrefix(args[3], "payload:")}
+		return "+OK\r\n"
+	case "SCAN":
+		keys := []string{}
+		for k := range s.keys {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		reply := fmt.Sprintf("*2\r\n$1\r\n0\r\n*%d\r\n", len(keys))
+		for _, k := range keys {
+			reply += fmt.Sprintf("$%d\r\n%s\r\n", len(k), k)
+		}
+		return reply
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func testRedisReadCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		data := make([]byte, size+2)
+
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+
+		args[i] = string(data[0:size])
+	}
+
+	return args, nil
+}
+
+// cacheResourceSetup creates an app with a running resource named cache
+func cacheResourceSetup(t *testing.T, p *k8s.Provider, kind, url string) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+		"convox.com/app-release": "R1",
+		"convox.com/app-status":  "running",
+	}))
+
+	_, err := p.Convox.ConvoxV1().Releases("rack1-app1").Create(&ca.Release{
+		ObjectMeta: am.ObjectMeta{Name: "r1", Labels: map[string]string{"app": "app1"}},
+		Spec:       ca.ReleaseSpec{Build: "B1", Created: time.Now().UTC().Format(common.SortableTime), Manifest: fmt.Sprintf("resources:\n  cache:\n    type: %s\n", kind)},
+	})
+	require.NoError(t, err)
+
+	_, err = kk.CoreV1().ConfigMaps("rack1-app1").Create(context.TODO(), &ac.ConfigMap{
+		ObjectMeta: am.ObjectMeta{Name: "resource-cache"},
+		Data:       map[string]string{"URL": url},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = kk.AppsV1().Deployments("rack1-app1").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: am.ObjectMeta{Name: "resource-cache"},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func cacheResourceURL(t *testing.T, p *k8s.Provider, url string) {
+	_, err := p.Cluster.CoreV1().ConfigMaps("rack1-app1").Update(context.TODO(), &ac.ConfigMap{
+		ObjectMeta: am.ObjectMeta{Name: "resource-cache"},
+		Data:       map[string]string{"URL": url},
+	}, am.UpdateOptions{})
+	require.NoError(t, err)
+}
+
+func TestResourceExportImportRedis(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		src := newTestRedisServer(t, map[string]testRedisKey{
+			"session:1": {value: "alice"},
+			"session:2": {ttl: 60000, value: "bob\r\n*1\r\n"},
+		})
+
+		dst := newTestRedisServer(t, map[string]testRedisKey{})
+
+		cacheResourceSetup(t, p, "redis", src.URL())
+
+		r, err := p.ResourceExport("app1", "cache")
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Contains(t, string(data), "$7\r\nRESTORE\r\n$9\r\nsession:1\r\n$1\r\n0\r\n$13\r\npayload:alice\r\n$7\r\nREPLACE\r\n")
+
+		cacheResourceURL(t, p, dst.URL())
+
+		require.NoError(t, p.ResourceImport("app1", "cache", strings.NewReader(string(data))))
+		require.Equal(t, src.keys, dst.keys)
+	})
+}
+
+func TestResourceImportRedisError(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		dst := newTestRedisServer(t, map[string]testRedisKey{})
+
+		cacheResourceSetup(t, p, "redis", dst.URL())
+
+		err := p.ResourceImport("app1", "cache", strings.NewReader("*1\r\n$8\r\nFLUSHALL\r\n"))
+		require.EqualError(t, err, "ERR unknown command 'FLUSHALL'")
+	})
+}
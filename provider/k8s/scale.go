@@ -0,0 +1,133 @@
+package k8s
+
+import (
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/manifest"
+	"github.com/pkg/errors"
+	ae "k8s.io/apimachinery/pkg/api/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AppScaleSchedules moves the scale of each service of an app with scale
+// schedules to the count of the window that is active at now
+func (p *Provider) AppScaleSchedules(app string, now time.Time) error {
+	a, err := p.AppGet(app)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if a.Release == "" {
+		return nil
+	}
+
+	m, _, err := common.ReleaseManifest(p, app, a.Release)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ns, err := p.Cluster.CoreV1().Namespaces().Get(p.ctx, p.AppNamespace(app), am.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	idles, err := idleState(ns)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range m.Services {
+		if len(s.Scale.Schedules) == 0 || s.Agent.Enabled {
+			continue
+		}
+
+		// an idle service is woken by its next request, see ServiceWake
+		if is, ok := idles[s.Name]; ok && is.Idle != nil {
+			continue
+		}
+
+		if err := p.scaleScheduleApply(app, s.Name, s.Scale.ScheduledCount(now)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// scaleScheduleApply sets the bounds of the autoscaler of a service, or its
+// replicas when it has none. A count of zero always scales the deployment
+// directly as an autoscaler can not go below one replica.
+func (p *Provider) scaleScheduleApply(app, service string, count manifest.ServiceScaleCount) error {
+	ns := p.AppNamespace(app)
+
+	d, err := p.Cluster.AppsV1().Deployments(ns).Get(p.ctx, service, am.GetOptions{})
+	if ae.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	current := 0
+	if d.Spec.Replicas != nil {
+		current = int(*d.Spec.Replicas)
+	}
+
+	replicas := scaleClamp(current, count)
+
+	if count.Max > 0 {
+		hpa, err := p.Cluster.AutoscalingV2().HorizontalPodAutoscalers(ns).Get(p.ctx, service, am.GetOptions{})
+		switch {
+		case ae.IsNotFound(err):
+		case err != nil:
+			return errors.WithStack(err)
+		default:
+			min := int32(count.Min)
+			if min < 1 {
+				min = 1
+			}
+
+			if hpa.Spec.MinReplicas == nil || *hpa.Spec.MinReplicas != min || hpa.Spec.MaxReplicas != int32(count.Max) {
+				hpa.Spec.MinReplicas = &min
+				hpa.Spec.MaxReplicas = int32(count.Max)
+
+				if _, err := p.Cluster.AutoscalingV2().HorizontalPodAutoscalers(ns).Update(p.ctx, hpa, am.UpdateOptions{}); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+
+			// the autoscaler does not act on a deployment scaled to zero
+			if current > 0 {
+				return nil
+			}
+
+			replicas = int(min)
+		}
+	}
+
+	if replicas == current {
+		return nil
+	}
+
+	r := int32(replicas)
+	d.Spec.Replicas = &r
+
+	if _, err := p.Cluster.AppsV1().Deployments(ns).Update(p.ctx, d, am.UpdateOptions{}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func scaleClamp(n int, count manifest.ServiceScaleCount) int {
+	if n < count.Min {
+		return count.Min
+	}
+
+	if n > count.Max {
+		return count.Max
+	}
+
+	return n
+}
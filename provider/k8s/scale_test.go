@@ -0,0 +1,141 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	ca "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	av2 "k8s.io/api/autoscaling/v2"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const scaleSchedulesManifest = `services:
+  web:
+    build: .
+    scale:
+      count: 1
+      schedules:
+        - name: day
+          start: "0 8 * * *"
+          end: "0 18 * * *"
+          count: 3
+  worker:
+    build: .
+    scale:
+      count: 0
+      schedules:
+        - start: "0 8 * * *"
+          end: "0 18 * * *"
+          count: 2-4
+`
+
+func TestAppScaleSchedules(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kk := p.Cluster.(*fake.Clientset)
+		ns := "rack1-app1"
+
+		require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+			"convox.com/app-release": "R1",
+			"convox.com/app-status":  "running",
+		}))
+
+		_, err := p.Convox.ConvoxV1().Releases(ns).Create(&ca.Release{
+			ObjectMeta: am.ObjectMeta{Name: "r1", Labels: map[string]string{"app": "app1"}},
+			Spec:       ca.ReleaseSpec{Build: "B1", Created: time.Now().UTC().Format(common.SortableTime), Manifest: scaleSchedulesManifest},
+		})
+		require.NoError(t, err)
+
+		for _, name := range []string{"web", "worker"} {
+			replicas := int32(1)
+			_, err := kk.AppsV1().Deployments(ns).Create(context.TODO(), &appsv1.Deployment{
+				ObjectMeta: am.ObjectMeta{Name: name, Namespace: ns},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			}, am.CreateOptions{})
+			require.NoError(t, err)
+		}
+
+		min := int32(1)
+		_, err = kk.AutoscalingV2().HorizontalPodAutoscalers(ns).Create(context.TODO(), &av2.HorizontalPodAutoscaler{
+			ObjectMeta: am.ObjectMeta{Name: "worker", Namespace: ns},
+			Spec:       av2.HorizontalPodAutoscalerSpec{MinReplicas: &min, MaxReplicas: 1},
+		}, am.CreateOptions{})
+		require.NoError(t, err)
+
+		replicas := func(name string) int32 {
+			d, err := kk.AppsV1().Deployments(ns).Get(context.TODO(), name, am.GetOptions{})
+			require.NoError(t, err)
+			return *d.Spec.Replicas
+		}
+
+		bounds := func() (int32, int32) {
+			hpa, err := kk.AutoscalingV2().HorizontalPodAutoscalers(ns).Get(context.TODO(), "worker", am.GetOptions{})
+			require.NoError(t, err)
+			return *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas
+		}
+
+		// inside the window
+		require.NoError(t, p.AppScaleSchedules("app1", time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)))
+		require.Equal(t, int32(3), replicas("web"))
+		require.Equal(t, int32(1), replicas("worker"))
+		min, max := bounds()
+		require.Equal(t, int32(2), min)
+		require.Equal(t, int32(4), max)
+
+		// outside the window the worker scales to zero
+		require.NoError(t, p.AppScaleSchedules("app1", time.Date(2024, 3, 6, 20, 0, 0, 0, time.UTC)))
+		require.Equal(t, int32(1), replicas("web"))
+		require.Equal(t, int32(0), replicas("worker"))
+
+		// the next window brings the worker back to its minimum
+		require.NoError(t, p.AppScaleSchedules("app1", time.Date(2024, 3, 7, 8, 0, 0, 0, time.UTC)))
+		require.Equal(t, int32(3), replicas("web"))
+		require.Equal(t, int32(2), replicas("worker"))
+	})
+}
+
+func TestAppScaleSchedulesIdle(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		idleSetup(t, p, "30m")
+
+		_, err := p.Convox.ConvoxV1().Releases("rack1-app1").Create(&ca.Release{
+			ObjectMeta: am.ObjectMeta{Name: "release1", Labels: map[string]string{"app": "app1"}},
+			Spec:       ca.ReleaseSpec{Build: "B1", Created: time.Now().UTC().Format(common.SortableTime), Manifest: scaleSchedulesManifest},
+		})
+		require.NoError(t, err)
+
+		day := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+
+		require.NoError(t, p.AppScaleSchedules("app1", day))
+		require.Equal(t, int32(3), idleReplicas(t, p, "web"))
+
+		idleActive(t, p, "web", time.Now().UTC().Add(-1*time.Hour), 50)
+		require.NoError(t, p.IdleCheck())
+		require.Equal(t, int32(0), idleReplicas(t, p, "web"))
+
+		// the schedule leaves an idle service alone so it stays idle
+		for i := 0; i < 3; i++ {
+			require.NoError(t, p.AppScaleSchedules("app1", day))
+			require.Equal(t, int32(0), idleReplicas(t, p, "web"))
+
+			require.NoError(t, p.IdleCheck())
+			require.Equal(t, int32(0), idleReplicas(t, p, "web"))
+		}
+
+		r, err := p.EventStream(structs.EventStreamOptions{Action: options.String("service:idle")})
+		require.NoError(t, err)
+		require.Equal(t, []string{"service:idle app1"}, eventActions(t, r))
+
+		woke, err := p.ServiceWake("app1", "web")
+		require.NoError(t, err)
+		require.True(t, woke)
+		require.Equal(t, int32(3), idleReplicas(t, p, "web"))
+	})
+}
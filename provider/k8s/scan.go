@@ -0,0 +1,81 @@
+package k8s
+
+import (
+	"fmt"
+
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+)
+
+// scanPolicy is how the image scan of the builds of an app is enforced
+type scanPolicy struct {
+	Action    string // fail the build or block promotion of its release
+	Enabled   bool
+	Threshold string // lowest severity that is not allowed, empty to only report
+}
+
+func appScanPolicy(a *structs.App) (*scanPolicy, error) {
+	sp := &scanPolicy{
+		Action:    structs.BuildScanActionFail,
+		Enabled:   a.Parameters[structs.AppParamScanImages] == "true",
+		Threshold: a.Parameters[structs.AppParamScanThreshold],
+	}
+
+	switch v := a.Parameters[structs.AppParamScanAction]; v {
+	case "":
+	case structs.BuildScanActionBlock, structs.BuildScanActionFail:
+		sp.Action = v
+	default:
+		return nil, errors.WithStack(fmt.Errorf("invalid %s for app %s: %s", structs.AppParamScanAction, a.Name, v))
+	}
+
+	if sp.Threshold != "" {
+		if _, err := (&structs.BuildScanSummary{}).Exceeding(sp.Threshold); err != nil {
+			return nil, errors.WithStack(fmt.Errorf("invalid %s for app %s: %s", structs.AppParamScanThreshold, a.Name, sp.Threshold))
+		}
+	}
+
+	return sp, nil
+}
+
+// releaseScanCheck returns an error if the scan of the build of a release
+// exceeds the threshold of an app that blocks promotion
+func (p *Provider) releaseScanCheck(a *structs.App, id string) error {
+	sp, err := appScanPolicy(a)
+	if err != nil {
+		return err
+	}
+
+	if !sp.Enabled || sp.Action != structs.BuildScanActionBlock || sp.Threshold == "" {
+		return nil
+	}
+
+	r, err := p.ReleaseGet(a.Name, id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if r.Build == "" {
+		return nil
+	}
+
+	b, err := p.BuildGet(a.Name, r.Build)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if b.Scan == nil {
+		return nil
+	}
+
+	n, err := b.Scan.Exceeding(sp.Threshold)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if n > 0 {
+		return errors.WithStack(fmt.Errorf("release %s is blocked: the image scan of build %s found %d vulnerabilities at or above %s severity", id, b.Id, n, sp.Threshold))
+	}
+
+	return nil
+}
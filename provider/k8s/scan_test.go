@@ -0,0 +1,114 @@
+package k8s_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/convox/convox/pkg/mock"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	"github.com/stretchr/testify/require"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// scanEngine accepts the app parameters of the provider so the scan
+// parameters are not filtered out
+type scanEngine struct {
+	*mock.TestEngine
+	p *k8s.Provider
+}
+
+func (e scanEngine) AppParameters() map[string]string {
+	return e.p.AppParameters()
+}
+
+func fxBuildScan() *structs.BuildScan {
+	return &structs.BuildScan{
+		Images: map[string]string{"web": "repo1:web.BUILD1"},
+		Vulnerabilities: structs.BuildVulnerabilities{
+			{Id: "CVE-2024-0001", Fixed: "3.0.14", Installed: "3.0.13", Package: "openssl", Service: "web", Severity: "high"},
+			{Id: "CVE-2024-0002", Installed: "1.2.3", Package: "zlib", Service: "web", Severity: "low"},
+		},
+	}
+}
+
+func scanSetup(t *testing.T, p *k8s.Provider, params map[string]string) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	p.Engine = scanEngine{TestEngine: &mock.TestEngine{}, p: p}
+
+	data, err := json.Marshal(params)
+	require.NoError(t, err)
+
+	require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+		"convox.com/app-release": "release1",
+		"convox.com/app-status":  "running",
+		"convox.com/params":      string(data),
+	}))
+	require.NoError(t, buildCreate(p.Convox, "rack1-app1", "build1", "basic"))
+	require.NoError(t, releaseCreate(p.Convox, "rack1-app1", "release1", "basic"))
+
+	scan, err := json.Marshal(fxBuildScan().Summarize())
+	require.NoError(t, err)
+
+	_, err = p.BuildUpdate("app1", "build1", structs.BuildUpdateOptions{Scan: options.String(string(scan))})
+	require.NoError(t, err)
+}
+
+func TestBuildUpdateScan(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		scanSetup(t, p, map[string]string{})
+
+		b, err := p.BuildGet("app1", "build1")
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{"high": 1, "low": 1}, b.Scan.Counts)
+		require.Equal(t, map[string]string{"web": "repo1:web.BUILD1"}, b.Scan.Images)
+
+		_, err = p.BuildUpdate("app1", "build1", structs.BuildUpdateOptions{Scan: options.String("invalid")})
+		require.Error(t, err)
+	})
+}
+
+func TestBuildUpdateScanReport(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		scanSetup(t, p, map[string]string{})
+
+		report, err := json.Marshal(fxBuildScan())
+		require.NoError(t, err)
+
+		b, err := p.BuildUpdate("app1", "build1", structs.BuildUpdateOptions{Scan: options.String(string(report))})
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{"high": 1, "low": 1}, b.Scan.Counts)
+
+		kb, err := p.Convox.ConvoxV1().Builds("rack1-app1").Get("build1", am.GetOptions{})
+		require.NoError(t, err)
+		require.NotContains(t, kb.Spec.Scan, "CVE-2024-0001")
+	})
+}
+
+func TestReleasePromoteScanBlocked(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		scanSetup(t, p, map[string]string{
+			structs.AppParamScanAction:    "block",
+			structs.AppParamScanImages:    "true",
+			structs.AppParamScanThreshold: "medium",
+		})
+
+		err := p.ReleasePromote("app1", "release1", structs.ReleasePromoteOptions{})
+		require.EqualError(t, err, "release release1 is blocked: the image scan of build BUILD1 found 1 vulnerabilities at or above medium severity")
+	})
+}
+
+func TestReleasePromoteScanInvalid(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		scanSetup(t, p, map[string]string{
+			structs.AppParamScanAction: "warn",
+			structs.AppParamScanImages: "true",
+		})
+
+		err := p.ReleasePromote("app1", "release1", structs.ReleasePromoteOptions{})
+		require.EqualError(t, err, "invalid ScanAction for app app1: warn")
+	})
+}
@@ -27,7 +27,7 @@ func (p *Provider) ServiceHost(app string, s manifest.Service) string {
 
 func (p *Provider) ServiceList(app string) (structs.Services, error) {
 	lopts := am.ListOptions{
-		LabelSelector: fmt.Sprintf("app=%s,type=service", app),
+		LabelSelector: fmt.Sprintf("app=%s,type=service,!canary", app),
 	}
 
 	a, err := p.AppGet(app)
@@ -9,11 +9,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/convox/convox/pkg/attest"
 	"github.com/convox/convox/pkg/common"
 	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
+	ac "k8s.io/api/core/v1"
 	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
 	kerr "k8s.io/apimachinery/pkg/api/errors"
 	am "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -24,7 +26,10 @@ import (
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 )
 
-const ConvoxJwtSecretName = "convox-jwt-key"
+const (
+	ConvoxJwtSecretName     = "convox-jwt-key"
+	ConvoxSigningSecretName = "convox-signing-key"
+)
 
 func (p *Provider) SystemGet() (*structs.System, error) {
 	status := "running"
@@ -157,6 +162,17 @@ func (p *Provider) SystemReleases() (structs.Releases, error) {
 	return nil, errors.WithStack(fmt.Errorf("release history is unavailable"))
 }
 
+// SystemSigningKey returns the public half of the key the rack signs build
+// attestations with
+func (p *Provider) SystemSigningKey() (string, error) {
+	key, err := p.signingKey()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return attest.PublicKey(key)
+}
+
 func (p *Provider) SystemUninstall(name string, w io.Writer, opts structs.SystemUninstallOptions) error {
 	return errors.WithStack(fmt.Errorf("direct rack doesn't support uninstall, make sure you are not using RACK_URL environment variable"))
 }
@@ -189,6 +205,49 @@ func (p *Provider) updateJwtSignKey() (string, error) {
 	return base64.StdEncoding.EncodeToString([]byte(signKey)), err
 }
 
+// signingKey returns the key the rack signs build attestations with, it is
+// created on first use and unlike the jwt key it is never replaced as that
+// would invalidate every existing attestation
+func (p *Provider) signingKey() (string, error) {
+	s, err := p.Cluster.CoreV1().Secrets(p.Namespace).Get(context.TODO(), ConvoxSigningSecretName, am.GetOptions{})
+	if err == nil {
+		if s.Data["signKey"] == nil {
+			return "", errors.WithStack(fmt.Errorf("invalid signing key secret: %s", ConvoxSigningSecretName))
+		}
+
+		return string(s.Data["signKey"]), nil
+	}
+	if !kerr.IsNotFound(err) {
+		return "", errors.WithStack(err)
+	}
+
+	key, err := attest.GenerateKey()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	_, err = p.Cluster.CoreV1().Secrets(p.Namespace).Create(context.TODO(), &ac.Secret{
+		ObjectMeta: am.ObjectMeta{
+			Name: ConvoxSigningSecretName,
+			Labels: map[string]string{
+				"system": "convox",
+				"rack":   p.Name,
+			},
+		},
+		Data: map[string][]byte{
+			"signKey": []byte(key),
+		},
+	}, am.CreateOptions{})
+	if kerr.IsAlreadyExists(err) {
+		return p.signingKey()
+	}
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return key, nil
+}
+
 func (p *Provider) createOrUpdateFlowSchema(namespace string, saNames []string) error {
 	p.logger.Logf("Creating or updating flow schema for service accounts %s in namespace %s", strings.Join(saNames, ", "), namespace)
 
@@ -1,16 +1,25 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
 
+	"github.com/convox/convox/pkg/common"
 	"github.com/convox/convox/pkg/manifest"
 	"github.com/convox/convox/pkg/mock"
 	"github.com/convox/convox/pkg/options"
 	"github.com/convox/convox/pkg/structs"
 	"github.com/convox/convox/pkg/templater"
+	ca "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
+	cvfake "github.com/convox/convox/provider/k8s/pkg/client/clientset/versioned/fake"
 	"github.com/convox/convox/provider/k8s/template"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	ac "k8s.io/api/core/v1"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestRenderTemplate(t *testing.T) {
@@ -101,3 +110,57 @@ func TestRenderTemplateService(t *testing.T) {
 
 	fmt.Println(string(data))
 }
+
+func TestReleaseTemplateCanary(t *testing.T) {
+	c := fake.NewSimpleClientset()
+	cc := cvfake.NewSimpleClientset()
+
+	_, err := c.CoreV1().Namespaces().Create(context.TODO(), &ac.Namespace{
+		ObjectMeta: am.ObjectMeta{
+			Name:        "rack1-app1",
+			Annotations: map[string]string{"convox.com/app-release": "release1", "convox.com/app-status": "running", "convox.com/lock": "false"},
+			Labels:      map[string]string{"app": "app1", "name": "app1", "rack": "rack1", "system": "convox", "type": "app"},
+		},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+
+	manifest := "services:\n  web:\n    build: .\n    port: 5000\n    scale:\n      count: 1-6\n"
+
+	_, err = cc.ConvoxV1().Releases("rack1-app1").Create(&ca.Release{
+		ObjectMeta: am.ObjectMeta{Name: "release1"},
+		Spec:       ca.ReleaseSpec{Created: "20200101.000000.000000000", Manifest: manifest},
+	})
+	require.NoError(t, err)
+
+	replicas := int32(4)
+
+	_, err = c.AppsV1().Deployments("rack1-app1").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: am.ObjectMeta{Name: "web", Labels: map[string]string{"app": "app1", "type": "service"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: ac.PodTemplateSpec{Spec: ac.PodSpec{Containers: []ac.Container{{Name: "app1"}}}},
+		},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+
+	p := &Provider{
+		Cluster: c,
+		Convox:  cc,
+		Engine:  &mock.TestEngine{},
+		Name:    "rack1",
+	}
+	require.NoError(t, p.Initialize(structs.ProviderOptions{}))
+
+	a, err := p.AppGet("app1")
+	require.NoError(t, err)
+
+	m, r, err := common.ReleaseManifest(p, "app1", "release1")
+	require.NoError(t, err)
+
+	data, err := p.releaseTemplateCanary(a, structs.Environment{}, r, &canaryRollout{App: "app1", Release: "release1", Services: m.Services, Weight: 20})
+	require.NoError(t, err)
+
+	require.Contains(t, string(data), "name: web-canary")
+	require.Contains(t, string(data), "replicas: 4")
+	require.Contains(t, string(data), `nginx.ingress.kubernetes.io/canary-weight: "20"`)
+}
@@ -0,0 +1,210 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	bv1 "k8s.io/api/batch/v1"
+	ac "k8s.io/api/core/v1"
+	ae "k8s.io/apimachinery/pkg/api/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// kubectl marks jobs created from a cronjob by hand with this annotation,
+// use the same so runs look alike no matter how they were started
+const timerManualAnnotation = "cronjob.kubernetes.io/instantiate"
+
+func (p *Provider) TimerList(app string) (structs.Timers, error) {
+	a, err := p.AppGet(app)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ts := structs.Timers{}
+
+	if a.Release == "" {
+		return ts, nil
+	}
+
+	m, _, err := common.ReleaseManifest(p, app, a.Release)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ns := p.AppNamespace(app)
+
+	for _, t := range m.Timers {
+		st := structs.Timer{
+			Name:        t.Name,
+			Command:     t.Command,
+			Concurrency: common.CoalesceString(t.Concurrency, "allow"),
+			Release:     a.Release,
+			Schedule:    t.Schedule,
+			Service:     t.Service,
+		}
+
+		cj, err := p.Cluster.BatchV1().CronJobs(ns).Get(p.ctx, timerCronJob(t.Name), am.GetOptions{})
+		switch {
+		case ae.IsNotFound(err):
+		case err != nil:
+			return nil, errors.WithStack(err)
+		default:
+			if cj.Status.LastScheduleTime != nil {
+				st.LastRun = cj.Status.LastScheduleTime.UTC()
+			}
+
+			st.Suspended = cj.Spec.Suspend != nil && *cj.Spec.Suspend
+		}
+
+		ts = append(ts, st)
+	}
+
+	return ts, nil
+}
+
+func (p *Provider) TimerRun(app, name string) (*structs.TimerRun, error) {
+	ns := p.AppNamespace(app)
+
+	cj, err := p.Cluster.BatchV1().CronJobs(ns).Get(p.ctx, timerCronJob(name), am.GetOptions{})
+	if ae.IsNotFound(err) {
+		return nil, errors.WithStack(fmt.Errorf("timer not found: %s", name))
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// runs started within the same second need their own names
+	suffix, err := common.RandomString(6)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	annotations := map[string]string{timerManualAnnotation: "manual"}
+
+	for k, v := range cj.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+
+	j := &bv1.Job{
+		ObjectMeta: am.ObjectMeta{
+			Annotations: annotations,
+			Labels:      timerJobLabels(p.Name, app, name, cj),
+			Name:        fmt.Sprintf("%s-%d-%s", cj.Name, time.Now().Unix(), suffix),
+			Namespace:   ns,
+			OwnerReferences: []am.OwnerReference{
+				*am.NewControllerRef(cj, bv1.SchemeGroupVersion.WithKind("CronJob")),
+			},
+		},
+		Spec: *cj.Spec.JobTemplate.Spec.DeepCopy(),
+	}
+
+	j, err = p.Cluster.BatchV1().Jobs(ns).Create(p.ctx, j, am.CreateOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return timerRunFromJob(j), nil
+}
+
+func (p *Provider) TimerRunList(app, name string) (structs.TimerRuns, error) {
+	ns := p.AppNamespace(app)
+
+	if _, err := p.Cluster.BatchV1().CronJobs(ns).Get(p.ctx, timerCronJob(name), am.GetOptions{}); ae.IsNotFound(err) {
+		return nil, errors.WithStack(fmt.Errorf("timer not found: %s", name))
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	js, err := p.Cluster.BatchV1().Jobs(ns).List(p.ctx, am.ListOptions{
+		LabelSelector: fmt.Sprintf("type=timer,name=%s", name),
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	rs := structs.TimerRuns{}
+
+	for i := range js.Items {
+		rs = append(rs, *timerRunFromJob(&js.Items[i]))
+	}
+
+	sort.Slice(rs, rs.Less)
+
+	return rs, nil
+}
+
+func timerCronJob(name string) string {
+	return fmt.Sprintf("timer-%s", name)
+}
+
+// timerJobLabels returns the labels of a job started from a timer, cronjobs
+// from releases before jobs carried labels fall back to the ones we know
+func timerJobLabels(rack, app, name string, cj *bv1.CronJob) map[string]string {
+	labels := map[string]string{
+		"app":    app,
+		"name":   name,
+		"rack":   rack,
+		"system": "convox",
+		"type":   "timer",
+	}
+
+	for k, v := range cj.Spec.JobTemplate.Labels {
+		labels[k] = v
+	}
+
+	if r, ok := cj.Spec.JobTemplate.Spec.Template.Labels["release"]; ok {
+		labels["release"] = r
+	}
+
+	return labels
+}
+
+func timerJobFailed(j *bv1.Job) (bool, string) {
+	for _, c := range j.Status.Conditions {
+		if c.Type == bv1.JobFailed && c.Status == ac.ConditionTrue {
+			return true, common.CoalesceString(c.Message, c.Reason)
+		}
+	}
+
+	return false, ""
+}
+
+func timerRunFromJob(j *bv1.Job) *structs.TimerRun {
+	r := &structs.TimerRun{
+		Id:      j.Name,
+		Manual:  j.Annotations[timerManualAnnotation] == "manual",
+		Release: j.Spec.Template.Labels["release"],
+		Started: j.CreationTimestamp.UTC(),
+		Status:  structs.TimerRunRunning,
+		Timer:   j.Labels["name"],
+	}
+
+	if j.Status.StartTime != nil {
+		r.Started = j.Status.StartTime.UTC()
+	}
+
+	if j.Status.CompletionTime != nil {
+		r.Ended = j.Status.CompletionTime.UTC()
+	}
+
+	for _, c := range j.Status.Conditions {
+		if c.Status != ac.ConditionTrue {
+			continue
+		}
+
+		switch c.Type {
+		case bv1.JobComplete:
+			r.Status = structs.TimerRunSucceeded
+		case bv1.JobFailed:
+			r.Ended = c.LastTransitionTime.UTC()
+			r.Error = common.CoalesceString(c.Message, c.Reason)
+			r.Status = structs.TimerRunFailed
+		}
+	}
+
+	return r
+}
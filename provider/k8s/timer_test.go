@@ -0,0 +1,212 @@
+package k8s_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	ca "github.com/convox/convox/provider/k8s/pkg/apis/convox/v1"
+	"github.com/stretchr/testify/require"
+	bv1 "k8s.io/api/batch/v1"
+	ac "k8s.io/api/core/v1"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const timersManifest = `services:
+  web:
+    build: .
+timers:
+  cleanup:
+    command: bin/cleanup
+    schedule: "0 * * * *"
+    service: web
+    concurrency: forbid
+`
+
+func timerSetup(t *testing.T, p *k8s.Provider) {
+	kk := p.Cluster.(*fake.Clientset)
+
+	require.NoError(t, appCreateWithAnnotation(kk, "rack1", "app1", map[string]string{
+		"convox.com/app-release": "R1",
+		"convox.com/app-status":  "running",
+	}))
+
+	_, err := p.Convox.ConvoxV1().Releases("rack1-app1").Create(&ca.Release{
+		ObjectMeta: am.ObjectMeta{Name: "r1", Labels: map[string]string{"app": "app1"}},
+		Spec:       ca.ReleaseSpec{Build: "B1", Created: time.Now().UTC().Format(common.SortableTime), Manifest: timersManifest},
+	})
+	require.NoError(t, err)
+
+	last := am.NewTime(time.Date(2024, 3, 6, 10, 0, 0, 0, time.UTC))
+
+	_, err = kk.BatchV1().CronJobs("rack1-app1").Create(context.TODO(), &bv1.CronJob{
+		ObjectMeta: am.ObjectMeta{Name: "timer-cleanup", Namespace: "rack1-app1"},
+		Spec: bv1.CronJobSpec{
+			Schedule: "0 * * * *",
+			JobTemplate: bv1.JobTemplateSpec{
+				ObjectMeta: am.ObjectMeta{
+					Labels: map[string]string{"app": "app1", "name": "cleanup", "rack": "rack1", "release": "R1", "system": "convox", "type": "timer"},
+				},
+				Spec: bv1.JobSpec{
+					Template: ac.PodTemplateSpec{
+						ObjectMeta: am.ObjectMeta{Labels: map[string]string{"release": "R1", "type": "timer"}},
+					},
+				},
+			},
+		},
+		Status: bv1.CronJobStatus{LastScheduleTime: &last},
+	}, am.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func TestTimerList(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		timerSetup(t, p)
+
+		ts, err := p.TimerList("app1")
+		require.NoError(t, err)
+		require.Equal(t, structs.Timers{
+			{
+				Name:        "cleanup",
+				Command:     "bin/cleanup",
+				Concurrency: "forbid",
+				LastRun:     time.Date(2024, 3, 6, 10, 0, 0, 0, time.UTC),
+				Release:     "R1",
+				Schedule:    "0 * * * *",
+				Service:     "web",
+			},
+		}, ts)
+	})
+}
+
+func TestTimerRun(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		timerSetup(t, p)
+
+		r, err := p.TimerRun("app1", "cleanup")
+		require.NoError(t, err)
+		require.Regexp(t, "^timer-cleanup-[0-9]+-[0-9a-f]{6}$", r.Id)
+		require.True(t, r.Manual)
+		require.Equal(t, "R1", r.Release)
+		require.Equal(t, structs.TimerRunRunning, r.Status)
+
+		j, err := p.Cluster.BatchV1().Jobs("rack1-app1").Get(context.TODO(), r.Id, am.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "timer-cleanup", j.OwnerReferences[0].Name)
+		require.Equal(t, "cleanup", j.Labels["name"])
+
+		rs, err := p.TimerRunList("app1", "cleanup")
+		require.NoError(t, err)
+		require.Len(t, rs, 1)
+		require.Equal(t, r.Id, rs[0].Id)
+
+		r2, err := p.TimerRun("app1", "cleanup")
+		require.NoError(t, err)
+		require.NotEqual(t, r.Id, r2.Id)
+
+		_, err = p.TimerRun("app1", "missing")
+		require.EqualError(t, err, "timer not found: missing")
+
+		_, err = p.TimerRunList("app1", "missing")
+		require.EqualError(t, err, "timer not found: missing")
+	})
+}
+
+func TestTimerRunList(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		timerSetup(t, p)
+
+		started := am.NewTime(time.Date(2024, 3, 6, 10, 0, 0, 0, time.UTC))
+		ended := am.NewTime(time.Date(2024, 3, 6, 10, 2, 0, 0, time.UTC))
+		labels := map[string]string{"name": "cleanup", "type": "timer"}
+
+		jobs := []bv1.Job{
+			{
+				ObjectMeta: am.ObjectMeta{Name: "timer-cleanup-1", Labels: labels},
+				Status: bv1.JobStatus{
+					CompletionTime: &ended,
+					Conditions:     []bv1.JobCondition{{Type: bv1.JobComplete, Status: ac.ConditionTrue}},
+					StartTime:      &started,
+				},
+			},
+			{
+				ObjectMeta: am.ObjectMeta{Name: "timer-cleanup-2", Labels: labels},
+				Status: bv1.JobStatus{
+					Conditions: []bv1.JobCondition{{Type: bv1.JobFailed, Status: ac.ConditionTrue, LastTransitionTime: ended, Reason: "BackoffLimitExceeded"}},
+					StartTime:  &ended,
+				},
+			},
+			{
+				ObjectMeta: am.ObjectMeta{Name: "timer-other-1", Labels: map[string]string{"name": "other", "type": "timer"}},
+			},
+		}
+
+		for i := range jobs {
+			_, err := p.Cluster.BatchV1().Jobs("rack1-app1").Create(context.TODO(), &jobs[i], am.CreateOptions{})
+			require.NoError(t, err)
+		}
+
+		rs, err := p.TimerRunList("app1", "cleanup")
+		require.NoError(t, err)
+		require.Equal(t, structs.TimerRuns{
+			{Id: "timer-cleanup-2", Ended: ended.UTC(), Error: "BackoffLimitExceeded", Started: ended.UTC(), Status: structs.TimerRunFailed, Timer: "cleanup"},
+			{Id: "timer-cleanup-1", Ended: ended.UTC(), Started: started.UTC(), Status: structs.TimerRunSucceeded, Timer: "cleanup"},
+		}, rs)
+	})
+}
+
+func TestJobControllerTimerFail(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		s, ch := testWebhookServer(t, 200)
+		defer s.Close()
+
+		_, err := p.SystemResourceCreate("webhook", structs.ResourceCreateOptions{
+			Name:       options.String("wh1"),
+			Parameters: map[string]string{"Events": "timer:*", "Url": s.URL},
+		})
+		require.NoError(t, err)
+
+		jc, err := k8s.NewJobController(p)
+		require.NoError(t, err)
+
+		running := &bv1.Job{
+			ObjectMeta: am.ObjectMeta{Name: "timer-cleanup-1", Namespace: "rack1-app1", Labels: map[string]string{"app": "app1", "name": "cleanup", "type": "timer"}},
+		}
+
+		failed := running.DeepCopy()
+		failed.Status.Conditions = []bv1.JobCondition{{Type: bv1.JobFailed, Status: ac.ConditionTrue, Message: "Job has reached the specified backoff limit"}}
+
+		require.NoError(t, jc.Update(running, running))
+		require.NoError(t, jc.Update(running, failed))
+		require.NoError(t, jc.Update(failed, failed))
+
+		var req webhookRequest
+
+		select {
+		case req = <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timer:fail not delivered")
+		}
+
+		var body struct {
+			Action string
+			Data   map[string]string
+		}
+
+		require.NoError(t, json.Unmarshal([]byte(req.Body), &body))
+		require.Equal(t, "timer:fail", body.Action)
+		require.Equal(t, map[string]string{"app": "app1", "id": "timer-cleanup-1", "message": "Job has reached the specified backoff limit", "rack": "rack1", "timer": "cleanup"}, body.Data)
+
+		select {
+		case req = <-ch:
+			t.Fatalf("unexpected event: %s", req.Body)
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+}
@@ -2,17 +2,79 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
 
+	"github.com/convox/convox/pkg/structs"
 	ac "k8s.io/api/core/v1"
+	ae "k8s.io/apimachinery/pkg/api/errors"
 	am "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Webhook is stored in the webhooks configmap as a bare url or, when it has
+// filters or a notification kind, as json. Its signing secret and routing key
+// are kept in the webhooks secret, older racks stored them in the json which
+// is still read
 type Webhook struct {
-	Name string
-	URL  string
+	Name       string   `json:"-"`
+	URL        string   `json:"url"`
+	Apps       []string `json:"apps,omitempty"`
+	Events     []string `json:"events,omitempty"`
+	Kind       string   `json:"kind,omitempty"`
+	RoutingKey string   `json:"routing_key,omitempty"`
+	Secret     string   `json:"secret,omitempty"`
+}
+
+func parseWebhook(name, value string) Webhook {
+	w := Webhook{Name: name, URL: value}
+
+	if strings.HasPrefix(value, "{") {
+		if err := json.Unmarshal([]byte(value), &w); err == nil {
+			w.Name = name
+		}
+	}
+
+	if w.Kind == "" {
+		w.Kind = structs.WebhookKindWebhook
+	}
+
+	return w
+}
+
+func (w Webhook) encode() (string, error) {
+	plain := w.Kind == "" || w.Kind == structs.WebhookKindWebhook
+
+	// credentials go in the webhooks secret, see webhookSecretSet
+	w.RoutingKey = ""
+	w.Secret = ""
+
+	if plain && len(w.Apps) == 0 && len(w.Events) == 0 {
+		return w.URL, nil
+	}
+
+	if plain {
+		w.Kind = ""
+	}
+
+	data, err := json.Marshal(w)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (w Webhook) structs() structs.Webhook {
+	return structs.Webhook{
+		Name:   w.Name,
+		Apps:   w.Apps,
+		Events: w.Events,
+		Kind:   w.Kind,
+		Signed: w.Secret != "",
+		Url:    w.URL,
+	}
 }
 
 func (p *Provider) webhookConfigMap() (*ac.ConfigMap, error) {
@@ -43,30 +105,41 @@ func (p *Provider) webhookConfigMap() (*ac.ConfigMap, error) {
 	return cm, nil
 }
 
-func (p *Provider) webhookCreate(name, url string) error {
+func (p *Provider) webhookCreate(w Webhook) error {
 	cm, err := p.webhookConfigMap()
 	if err != nil {
 		return err
 	}
 
-	if name == "" {
+	if w.Name == "" {
 		return fmt.Errorf("name required")
 	}
 
-	if url == "" {
+	if w.URL == "" {
 		return fmt.Errorf("url required")
 	}
 
-	if _, ok := cm.Data[name]; ok {
-		return fmt.Errorf("webhook already exists: %s", name)
+	if _, ok := cm.Data[w.Name]; ok {
+		return fmt.Errorf("webhook already exists: %s", w.Name)
+	}
+
+	value, err := w.encode()
+	if err != nil {
+		return err
+	}
+
+	if err := p.webhookSecretSet(w); err != nil {
+		return err
 	}
 
-	cm.Data[name] = url
+	cm.Data[w.Name] = value
 
 	if _, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Update(context.TODO(), cm, am.UpdateOptions{}); err != nil {
 		return err
 	}
 
+	p.webhooks = p.webhookHooks(cm)
+
 	return nil
 }
 
@@ -86,25 +159,176 @@ func (p *Provider) webhookDelete(name string) error {
 		return err
 	}
 
+	p.webhooks = p.webhookHooks(cm)
+
+	if err := p.webhookSecretDelete(name); err != nil {
+		return err
+	}
+
+	if err := p.webhookQueueDelete(name); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+func (p *Provider) webhookGet(name string) (*Webhook, error) {
+	ws, err := p.webhookList()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range ws {
+		if w.Name == name {
+			return &w, nil
+		}
+	}
+
+	return nil, fmt.Errorf("webhook does not exist: %s", name)
+}
+
 func (p *Provider) webhookList() ([]Webhook, error) {
 	cm, err := p.webhookConfigMap()
 	if err != nil {
 		return nil, err
 	}
 
+	return p.webhookHooks(cm), nil
+}
+
+func (p *Provider) WebhookList() (structs.Webhooks, error) {
+	ws, err := p.webhookList()
+	if err != nil {
+		return nil, err
+	}
+
+	sws := structs.Webhooks{}
+
+	for _, w := range ws {
+		sws = append(sws, w.structs())
+	}
+
+	return sws, nil
+}
+
+// webhookHooks parses the webhooks configmap and fills in the credentials
+// from the webhooks secret
+func (p *Provider) webhookHooks(cm *ac.ConfigMap) []Webhook {
+	ws := webhookConfigMapHooks(cm)
+
+	s, err := p.Cluster.CoreV1().Secrets(p.Namespace).Get(context.TODO(), "webhooks", am.GetOptions{})
+	if err != nil {
+		if !ae.IsNotFound(err) {
+			p.logger.At("webhookHooks").Errorf("err=%q", err)
+		}
+		return ws
+	}
+
+	for i := range ws {
+		if v, ok := s.Data[webhookSecretKey(ws[i].Name, "routing-key")]; ok {
+			ws[i].RoutingKey = string(v)
+		}
+		if v, ok := s.Data[webhookSecretKey(ws[i].Name, "secret")]; ok {
+			ws[i].Secret = string(v)
+		}
+	}
+
+	return ws
+}
+
+// webhookSecretSet stores the credentials of a webhook in the webhooks secret
+func (p *Provider) webhookSecretSet(w Webhook) error {
+	data := map[string][]byte{}
+
+	if w.RoutingKey != "" {
+		data[webhookSecretKey(w.Name, "routing-key")] = []byte(w.RoutingKey)
+	}
+
+	if w.Secret != "" {
+		data[webhookSecretKey(w.Name, "secret")] = []byte(w.Secret)
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	ss := p.Cluster.CoreV1().Secrets(p.Namespace)
+
+	s, err := ss.Get(context.TODO(), "webhooks", am.GetOptions{})
+	if ae.IsNotFound(err) {
+		_, err := ss.Create(context.TODO(), &ac.Secret{
+			ObjectMeta: am.ObjectMeta{
+				Namespace: p.Namespace,
+				Name:      "webhooks",
+				Labels:    map[string]string{"system": "convox"},
+			},
+			Type: ac.SecretTypeOpaque,
+			Data: data,
+		}, am.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.Data == nil {
+		s.Data = map[string][]byte{}
+	}
+
+	for k, v := range data {
+		s.Data[k] = v
+	}
+
+	_, err = ss.Update(context.TODO(), s, am.UpdateOptions{})
+	return err
+}
+
+func (p *Provider) webhookSecretDelete(name string) error {
+	ss := p.Cluster.CoreV1().Secrets(p.Namespace)
+
+	s, err := ss.Get(context.TODO(), "webhooks", am.GetOptions{})
+	if ae.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	delete(s.Data, webhookSecretKey(name, "routing-key"))
+	delete(s.Data, webhookSecretKey(name, "secret"))
+
+	_, err = ss.Update(context.TODO(), s, am.UpdateOptions{})
+	return err
+}
+
+func webhookSecretKey(name, field string) string {
+	return fmt.Sprintf("%s.%s", name, field)
+}
+
+func webhookConfigMapHooks(cm *ac.ConfigMap) []Webhook {
 	ws := []Webhook{}
 
 	for k, v := range cm.Data {
-		ws = append(ws, Webhook{
-			Name: k,
-			URL:  v,
-		})
+		ws = append(ws, parseWebhook(k, v))
 	}
 
 	sort.Slice(ws, func(i, j int) bool { return ws[i].Name < ws[j].Name })
 
-	return ws, nil
+	return ws
+}
+
+func splitWebhookList(s string) []string {
+	items := []string{}
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	return items
 }
@@ -0,0 +1,422 @@
+package k8s
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/convox/convox/pkg/structs"
+	"github.com/pkg/errors"
+	ac "k8s.io/api/core/v1"
+	ae "k8s.io/apimachinery/pkg/api/errors"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	webhookDeliveryAttempts   = 8
+	webhookDeliveryBackoff    = 10 * time.Second
+	webhookDeliveryBackoffMax = 1 * time.Hour
+	webhookDeliveryInterval   = 5 * time.Second
+	webhookDeliveryLease      = 1 * time.Minute
+	webhookDeliveryTimeout    = 10 * time.Second
+
+	// finished deliveries kept in the queue for inspection and redelivery
+	webhookHistoryDead      = 100
+	webhookHistoryDelivered = 50
+
+	// pending deliveries beyond this are given up on oldest first so an
+	// endpoint that is down can not fill the queue
+	webhookQueuePending = 500
+)
+
+var webhookClient = &http.Client{Timeout: webhookDeliveryTimeout}
+
+type webhookQueue map[string]*structs.WebhookDelivery
+
+func (p *Provider) WebhookDeliveryList(name string, opts structs.WebhookDeliveryListOptions) (structs.WebhookDeliveries, error) {
+	if _, err := p.webhookGet(name); err != nil {
+		return nil, err
+	}
+
+	cm, err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Get(p.ctx, webhookQueueName(name), am.GetOptions{})
+	if ae.IsNotFound(err) {
+		return structs.WebhookDeliveries{}, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	q, err := webhookQueueDecode(cm)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := structs.WebhookDeliveries{}
+
+	for _, d := range q {
+		if opts.Status != nil && d.Status != *opts.Status {
+			continue
+		}
+
+		ds = append(ds, *d)
+	}
+
+	sort.Slice(ds, ds.Less)
+
+	return ds, nil
+}
+
+func (p *Provider) WebhookRedeliver(name, id string) error {
+	w, err := p.webhookGet(name)
+	if err != nil {
+		return err
+	}
+
+	err = p.webhookQueueUpdate(name, func(q webhookQueue) (bool, error) {
+		d, ok := q[id]
+		if !ok {
+			return false, fmt.Errorf("delivery not found: %s", id)
+		}
+
+		d.Attempts = 0
+		d.Error = ""
+		d.Next = time.Now().UTC()
+		d.Status = structs.WebhookDeliveryPending
+
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	go p.webhookDeliver(*w)
+
+	return nil
+}
+
+// webhookDeliverAll is run on an interval to retry deliveries that are due
+func (p *Provider) webhookDeliverAll() error {
+	for _, w := range p.webhooks {
+		if err := p.webhookDeliver(w); err != nil {
+			p.logger.At("webhookDeliverAll").Errorf("webhook=%s err=%q", w.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// webhookDeliver sends every due delivery for a webhook. Deliveries are
+// claimed with a lease before sending so that api replicas sharing a queue do
+// not send the same delivery at the same time.
+func (p *Provider) webhookDeliver(w Webhook) error {
+	claimed := []structs.WebhookDelivery{}
+
+	err := p.webhookQueueUpdate(w.Name, func(q webhookQueue) (bool, error) {
+		now := time.Now().UTC()
+
+		claimed = claimed[:0]
+
+		trimmed := webhookQueueTrim(q)
+
+		for _, d := range q {
+			if d.Status == structs.WebhookDeliveryPending && !d.Next.After(now) {
+				d.Attempts++
+				d.Next = now.Add(webhookDeliveryLease)
+				claimed = append(claimed, *d)
+			}
+		}
+
+		return trimmed || len(claimed) > 0, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(claimed, func(i, j int) bool { return claimed[i].Created.Before(claimed[j].Created) })
+
+	for _, d := range claimed {
+		serr := webhookSend(w, d)
+
+		err := p.webhookQueueUpdate(w.Name, func(q webhookQueue) (bool, error) {
+			cur, ok := q[d.Id]
+			if !ok {
+				return false, nil
+			}
+
+			webhookDeliveryResult(cur, serr, time.Now().UTC())
+			webhookQueueTrim(q)
+
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) webhookEnqueue(w Webhook, event string, payload []byte) error {
+	d := structs.NewWebhookDelivery(w.Name, event, payload)
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	name := webhookQueueName(w.Name)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"data": map[string]string{d.Id: string(data)},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cms := p.Cluster.CoreV1().ConfigMaps(p.Namespace)
+
+	if _, err := cms.Patch(p.ctx, name, types.MergePatchType, patch, am.PatchOptions{}); err == nil {
+		return nil
+	} else if !ae.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+
+	cm := &ac.ConfigMap{
+		ObjectMeta: am.ObjectMeta{
+			Namespace: p.Namespace,
+			Name:      name,
+			Annotations: map[string]string{
+				"convox.com/webhook": w.Name,
+			},
+			Labels: map[string]string{
+				"system": "convox",
+				"type":   "webhook-queue",
+			},
+		},
+		Data: map[string]string{d.Id: string(data)},
+	}
+
+	if _, err := cms.Create(p.ctx, cm, am.CreateOptions{}); err != nil {
+		if !ae.IsAlreadyExists(err) {
+			return errors.WithStack(err)
+		}
+
+		if _, err := cms.Patch(p.ctx, name, types.MergePatchType, patch, am.PatchOptions{}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) webhookQueueDelete(name string) error {
+	err := p.Cluster.CoreV1().ConfigMaps(p.Namespace).Delete(p.ctx, webhookQueueName(name), am.DeleteOptions{})
+	if err != nil && !ae.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// webhookQueueUpdate applies fn to the queue of a webhook and writes it back,
+// fn is called again with fresh data if the queue changed in the meantime
+func (p *Provider) webhookQueueUpdate(name string, fn func(q webhookQueue) (bool, error)) error {
+	cms := p.Cluster.CoreV1().ConfigMaps(p.Namespace)
+
+	for i := 0; i < 5; i++ {
+		cm, err := cms.Get(p.ctx, webhookQueueName(name), am.GetOptions{})
+		if ae.IsNotFound(err) {
+			cm = &ac.ConfigMap{}
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		q, err := webhookQueueDecode(cm)
+		if err != nil {
+			return err
+		}
+
+		changed, err := fn(q)
+		if err != nil {
+			return err
+		}
+		if !changed || cm.Name == "" {
+			return nil
+		}
+
+		data := map[string]string{}
+
+		for id, d := range q {
+			dd, err := json.Marshal(d)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			data[id] = string(dd)
+		}
+
+		cm.Data = data
+
+		if _, err := cms.Update(p.ctx, cm, am.UpdateOptions{}); ae.IsConflict(err) {
+			continue
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("could not update webhook queue: %s", name)
+}
+
+func webhookQueueDecode(cm *ac.ConfigMap) (webhookQueue, error) {
+	q := webhookQueue{}
+
+	for id, data := range cm.Data {
+		var d structs.WebhookDelivery
+
+		if err := json.Unmarshal([]byte(data), &d); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		q[id] = &d
+	}
+
+	return q, nil
+}
+
+func webhookQueueName(webhook string) string {
+	return fmt.Sprintf("webhook-queue-%x", sha256.Sum256([]byte(webhook)))[0:26]
+}
+
+// webhookQueueTrim marks the oldest pending deliveries over the limit dead
+// and drops the oldest finished deliveries so that the queue stays well under
+// the configmap size limit. It returns true if the queue changed.
+func webhookQueueTrim(q webhookQueue) bool {
+	changed := false
+
+	for _, d := range webhookQueueOldest(q, structs.WebhookDeliveryPending, webhookQueuePending) {
+		d.Error = "too many pending deliveries"
+		d.Next = time.Time{}
+		d.Status = structs.WebhookDeliveryDead
+		changed = true
+	}
+
+	limits := map[string]int{
+		structs.WebhookDeliveryDead:      webhookHistoryDead,
+		structs.WebhookDeliveryDelivered: webhookHistoryDelivered,
+	}
+
+	for status, limit := range limits {
+		for _, d := range webhookQueueOldest(q, status, limit) {
+			delete(q, d.Id)
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// webhookQueueOldest returns the deliveries with a status past the newest limit
+func webhookQueueOldest(q webhookQueue, status string, limit int) []*structs.WebhookDelivery {
+	ds := []*structs.WebhookDelivery{}
+
+	for _, d := range q {
+		if d.Status == status {
+			ds = append(ds, d)
+		}
+	}
+
+	if len(ds) <= limit {
+		return nil
+	}
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i].Created.After(ds[j].Created) })
+
+	return ds[limit:]
+}
+
+func webhookDeliveryResult(d *structs.WebhookDelivery, err error, now time.Time) {
+	if err == nil {
+		d.Delivered = now
+		d.Error = ""
+		d.Next = time.Time{}
+		d.Status = structs.WebhookDeliveryDelivered
+		return
+	}
+
+	d.Error = err.Error()
+
+	if d.Attempts >= webhookDeliveryAttempts {
+		d.Next = time.Time{}
+		d.Status = structs.WebhookDeliveryDead
+		return
+	}
+
+	d.Next = now.Add(webhookBackoff(d.Attempts))
+}
+
+// webhookBackoff doubles the delay after every attempt up to a maximum
+func webhookBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	b := webhookDeliveryBackoff << uint(attempts-1)
+
+	if b <= 0 || b > webhookDeliveryBackoffMax {
+		return webhookDeliveryBackoffMax
+	}
+
+	return b
+}
+
+func webhookSend(w Webhook, d structs.WebhookDelivery) error {
+	req, err := http.NewRequest("POST", w.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+
+	ts := strconv.FormatInt(time.Now().UTC().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "convox")
+	req.Header.Set("X-Convox-Delivery", d.Id)
+	req.Header.Set("X-Convox-Event", d.Event)
+	req.Header.Set("X-Convox-Timestamp", ts)
+
+	if w.Secret != "" {
+		req.Header.Set("X-Convox-Signature", fmt.Sprintf("sha256=%s", webhookSignature(w.Secret, ts, d.Payload)))
+	}
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response: %s", res.Status)
+	}
+
+	return nil
+}
+
+// webhookSignature is the hex encoded HMAC-SHA256 of "<timestamp>.<payload>"
+// that receivers can use to verify the X-Convox-Signature header
+func webhookSignature(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%s.%s", timestamp, payload)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
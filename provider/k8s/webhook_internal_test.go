@@ -0,0 +1,48 @@
+package k8s
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookQueueTrim(t *testing.T) {
+	now := time.Now().UTC()
+
+	q := webhookQueue{}
+
+	add := func(status string, n int) {
+		for i := 0; i < n; i++ {
+			id := fmt.Sprintf("%s-%03d", status, i)
+			q[id] = &structs.WebhookDelivery{Id: id, Created: now.Add(time.Duration(i) * time.Second), Next: now, Status: status}
+		}
+	}
+
+	add(structs.WebhookDeliveryPending, webhookQueuePending+20)
+	add(structs.WebhookDeliveryDead, 90)
+	add(structs.WebhookDeliveryDelivered, 60)
+
+	require.True(t, webhookQueueTrim(q))
+
+	count := map[string]int{}
+
+	for _, d := range q {
+		count[d.Status]++
+	}
+
+	require.Equal(t, map[string]int{
+		structs.WebhookDeliveryDead:      webhookHistoryDead,
+		structs.WebhookDeliveryDelivered: webhookHistoryDelivered,
+		structs.WebhookDeliveryPending:   webhookQueuePending,
+	}, count)
+
+	// the oldest pending deliveries were given up on, the newest dead ones kept
+	require.Nil(t, q["pending-000"])
+	require.Equal(t, structs.WebhookDeliveryPending, q["pending-020"].Status)
+	require.Equal(t, structs.WebhookDeliveryDead, q["dead-089"].Status)
+
+	require.False(t, webhookQueueTrim(q))
+}
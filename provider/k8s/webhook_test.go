@@ -0,0 +1,256 @@
+package k8s_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+	"github.com/convox/convox/provider/k8s"
+	"github.com/stretchr/testify/require"
+	ac "k8s.io/api/core/v1"
+	am "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type webhookRequest struct {
+	Body   string
+	Header http.Header
+}
+
+func testWebhookServer(t *testing.T, status int) (*httptest.Server, chan webhookRequest) {
+	ch := make(chan webhookRequest, 10)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		ch <- webhookRequest{Body: string(data), Header: r.Header}
+		w.WriteHeader(status)
+	}))
+
+	return s, ch
+}
+
+func testWebhookDeliveries(t *testing.T, p *k8s.Provider, name string, status string) func() bool {
+	return func() bool {
+		ds, err := p.WebhookDeliveryList(name, structs.WebhookDeliveryListOptions{Status: options.String(status)})
+		require.NoError(t, err)
+		return len(ds) > 0
+	}
+}
+
+func TestEventSendWebhookSigned(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		s, ch := testWebhookServer(t, 200)
+		defer s.Close()
+
+		_, err := p.SystemResourceCreate("webhook", structs.ResourceCreateOptions{
+			Name: options.String("wh1"),
+			Parameters: map[string]string{
+				"Events": "app:*",
+				"Secret": "secret1",
+				"Url":    s.URL,
+			},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, p.EventSend("release:create", structs.EventSendOptions{Data: map[string]string{"app": "app1"}}))
+		require.NoError(t, p.EventSend("app:create", structs.EventSendOptions{Data: map[string]string{"app": "app1"}}))
+
+		var req webhookRequest
+
+		select {
+		case req = <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook not delivered")
+		}
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(req.Body), &body))
+		require.Equal(t, "app:create", body["action"])
+
+		mac := hmac.New(sha256.New, []byte("secret1"))
+		mac.Write([]byte(req.Header.Get("X-Convox-Timestamp") + "." + req.Body))
+
+		require.Equal(t, "app:create", req.Header.Get("X-Convox-Event"))
+		require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Convox-Signature"))
+		require.NotEmpty(t, req.Header.Get("X-Convox-Delivery"))
+
+		require.Eventually(t, testWebhookDeliveries(t, p, "wh1", structs.WebhookDeliveryDelivered), 5*time.Second, 50*time.Millisecond)
+
+		ds, err := p.WebhookDeliveryList("wh1", structs.WebhookDeliveryListOptions{})
+		require.NoError(t, err)
+		require.Len(t, ds, 1)
+		require.Equal(t, "app:create", ds[0].Event)
+		require.Equal(t, 1, ds[0].Attempts)
+	})
+}
+
+func TestWebhookSecretStorage(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		kk := p.Cluster.(*fake.Clientset)
+
+		_, err := p.SystemResourceCreate("webhook", structs.ResourceCreateOptions{
+			Name:       options.String("wh1"),
+			Parameters: map[string]string{"Secret": "secret1", "Url": "https://example.org"},
+		})
+		require.NoError(t, err)
+
+		cm, err := kk.CoreV1().ConfigMaps("ns1").Get(context.TODO(), "webhooks", am.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "https://example.org", cm.Data["wh1"])
+
+		s, err := kk.CoreV1().Secrets("ns1").Get(context.TODO(), "webhooks", am.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "secret1", string(s.Data["wh1.secret"]))
+
+		ws, err := p.WebhookList()
+		require.NoError(t, err)
+		require.Len(t, ws, 1)
+		require.True(t, ws[0].Signed)
+
+		require.NoError(t, p.SystemResourceDelete("wh1"))
+
+		s, err = kk.CoreV1().Secrets("ns1").Get(context.TODO(), "webhooks", am.GetOptions{})
+		require.NoError(t, err)
+		require.Empty(t, s.Data)
+	})
+}
+
+func TestEventSendWebhookRetry(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		s, ch := testWebhookServer(t, 500)
+		defer s.Close()
+
+		_, err := p.SystemResourceCreate("webhook", structs.ResourceCreateOptions{
+			Name:       options.String("wh1"),
+			Parameters: map[string]string{"Url": s.URL},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, p.EventSend("app:create", structs.EventSendOptions{Data: map[string]string{"app": "app1"}}))
+
+		select {
+		case req := <-ch:
+			require.Empty(t, req.Header.Get("X-Convox-Signature"))
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook not attempted")
+		}
+
+		require.Eventually(t, func() bool {
+			ds, err := p.WebhookDeliveryList("wh1", structs.WebhookDeliveryListOptions{})
+			require.NoError(t, err)
+			return len(ds) == 1 && ds[0].Error != ""
+		}, 5*time.Second, 50*time.Millisecond)
+
+		ds, err := p.WebhookDeliveryList("wh1", structs.WebhookDeliveryListOptions{})
+		require.NoError(t, err)
+		require.Equal(t, structs.WebhookDeliveryPending, ds[0].Status)
+		require.Equal(t, 1, ds[0].Attempts)
+		require.Equal(t, "unexpected response: 500 Internal Server Error", ds[0].Error)
+		require.True(t, ds[0].Next.After(time.Now().Add(5*time.Second)))
+	})
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		s, ch := testWebhookServer(t, 204)
+		defer s.Close()
+
+		_, err := p.SystemResourceCreate("webhook", structs.ResourceCreateOptions{
+			Name:       options.String("wh1"),
+			Parameters: map[string]string{"Url": s.URL},
+		})
+		require.NoError(t, err)
+
+		d := structs.WebhookDelivery{
+			Id:       "delivery1",
+			Attempts: 8,
+			Error:    "unexpected response: 502 Bad Gateway",
+			Event:    "app:delete",
+			Payload:  `{"action":"app:delete"}`,
+			Status:   structs.WebhookDeliveryDead,
+			Webhook:  "wh1",
+		}
+
+		data, err := json.Marshal(d)
+		require.NoError(t, err)
+
+		fc := p.Cluster.(*fake.Clientset)
+
+		require.Error(t, p.WebhookRedeliver("wh1", "delivery1"))
+
+		require.NoError(t, p.EventSend("app:create", structs.EventSendOptions{Data: map[string]string{"app": "app1"}}))
+		<-ch
+
+		require.Eventually(t, testWebhookDeliveries(t, p, "wh1", structs.WebhookDeliveryDelivered), 5*time.Second, 50*time.Millisecond)
+
+		cms, err := fc.CoreV1().ConfigMaps(p.Namespace).List(context.TODO(), am.ListOptions{LabelSelector: "type=webhook-queue"})
+		require.NoError(t, err)
+		require.Len(t, cms.Items, 1)
+
+		cm := cms.Items[0]
+		cm.Data["delivery1"] = string(data)
+
+		_, err = fc.CoreV1().ConfigMaps(p.Namespace).Update(context.TODO(), &cm, am.UpdateOptions{})
+		require.NoError(t, err)
+
+		require.True(t, testWebhookDeliveries(t, p, "wh1", structs.WebhookDeliveryDead)())
+
+		require.NoError(t, p.WebhookRedeliver("wh1", "delivery1"))
+
+		select {
+		case req := <-ch:
+			require.Equal(t, `{"action":"app:delete"}`, req.Body)
+			require.Equal(t, "delivery1", req.Header.Get("X-Convox-Delivery"))
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook not redelivered")
+		}
+
+		require.Eventually(t, func() bool {
+			return !testWebhookDeliveries(t, p, "wh1", structs.WebhookDeliveryDead)()
+		}, 5*time.Second, 50*time.Millisecond)
+
+		require.EqualError(t, p.WebhookRedeliver("wh1", "delivery2"), "delivery not found: delivery2")
+		require.EqualError(t, p.WebhookRedeliver("wh2", "delivery1"), "webhook does not exist: wh2")
+	})
+}
+
+func TestWebhookList(t *testing.T) {
+	testProvider(t, func(p *k8s.Provider) {
+		fc := p.Cluster.(*fake.Clientset)
+
+		cm := &ac.ConfigMap{
+			ObjectMeta: am.ObjectMeta{
+				Namespace: p.Namespace,
+				Name:      "webhooks",
+			},
+			Data: map[string]string{
+				"wh1": "https://example1.org",
+				"wh2": `{"url":"https://example2.org","events":["app:*","release:promote"],"secret":"secret1"}`,
+			},
+		}
+
+		_, err := fc.CoreV1().ConfigMaps(p.Namespace).Create(context.TODO(), cm, am.CreateOptions{})
+		require.NoError(t, err)
+
+		ws, err := p.WebhookList()
+		require.NoError(t, err)
+		require.Equal(t, structs.Webhooks{
+			{Name: "wh1", Kind: "webhook", Url: "https://example1.org"},
+			{Name: "wh2", Kind: "webhook", Url: "https://example2.org", Events: []string{"app:*", "release:promote"}, Signed: true},
+		}, ws)
+
+		r, err := p.SystemResourceGet("wh2")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"Events": "app:*,release:promote", "Url": "https://example2.org"}, r.Parameters)
+	})
+}
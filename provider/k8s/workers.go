@@ -1,62 +1,166 @@
 package k8s
 
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/options"
+	"github.com/convox/convox/pkg/structs"
+)
+
 const (
 	BuildMax = 30
 )
 
+// Workers starts the periodic jobs that act on every app. They only run on the
+// api replica holding the workers lease so that each runs once per rack.
 func (p *Provider) Workers() error {
-	// go common.Tick(1*time.Hour, workerHandler(p.workerBuildCleanup))
+	p.workersLeader = &atomic.Bool{}
+
+	if err := p.workersElect(); err != nil {
+		return err
+	}
+
+	go common.Tick(1*time.Hour, p.leaderOnly(p.workerPrune))
+	go common.Tick(1*time.Minute, p.leaderOnly(p.workerResourceBackups))
+	go common.Tick(1*time.Minute, p.leaderOnly(p.workerScaleSchedules))
 
 	return nil
 }
 
-// func (p *Provider) workerBuildCleanup() error {
-//   as, err := p.AppList()
-//   if err != nil {
-//     return err
-//   }
-
-//   for _, a := range as {
-//     fmt.Printf("a = %+v\n", a)
-
-//     build := ""
-
-//     if a.Release != "" {
-//       r, err := p.ReleaseGet(a.Name, a.Release)
-//       if err != nil {
-//         return err
-//       }
-//       build = r.Build
-//     }
-
-//     fmt.Printf("build = %+v\n", build)
-
-//     bs, err := p.BuildList(a.Name, structs.BuildListOptions{Limit: options.Int(1000)})
-//     if err != nil {
-//       return err
-//     }
-
-//     if len(bs) > BuildMax {
-//       for _, b := range bs[BuildMax:] {
-//         fmt.Printf("b = %+v\n", b)
-//       }
-//     }
-
-//     // rs, err := p.BuildList(a.Name, structs.BuildListOptions{})
-//     // if err != nil {
-//     //   return err
-//     // }
-
-//     // fmt.Printf("len(rs) = %+v\n", len(rs))
-//   }
-
-//   return nil
-// }
-
-// func workerHandler(fn func() error) func() {
-//   return func() {
-//     if err := fn(); err != nil {
-//       fmt.Printf("err = %+v\n", err)
-//     }
-//   }
-// }
+func (p *Provider) workersElect() error {
+	return RunUsingLeaderElection(p.Namespace, "convox-k8s-workers", p.Cluster, func(context.Context) {
+		p.workersLeader.Store(true)
+	}, func() {
+		p.workersLeader.Store(false)
+
+		// keep contending so that the lease can be picked up again
+		if err := p.workersElect(); err != nil {
+			p.logger.At("workersElect").Errorf("err=%q", err)
+		}
+	})
+}
+
+// leaderOnly skips fn on replicas that do not hold the workers lease
+func (p *Provider) leaderOnly(fn func() error) func() error {
+	return func() error {
+		if p.workersLeader == nil || !p.workersLeader.Load() {
+			return nil
+		}
+
+		return fn()
+	}
+}
+
+// workerPrune removes the builds and releases that fall outside the retention
+// policy of each app
+func (p *Provider) workerPrune() error {
+	log := p.logger.At("workerPrune")
+
+	as, err := p.AppList()
+	if err != nil {
+		return err
+	}
+
+	for _, a := range as {
+		pos, err := p.AppPrune(a.Name, structs.AppPruneOptions{})
+		if err != nil {
+			log.Errorf("app=%s err=%q", a.Name, err)
+			continue
+		}
+
+		if len(pos) > 0 {
+			log.Logf("app=%s pruned=%d", a.Name, len(pos))
+		}
+	}
+
+	return nil
+}
+
+// workerResourceBackups dumps each resource with a backup schedule that has
+// fired since its latest backup
+func (p *Provider) workerResourceBackups() error {
+	log := p.logger.At("workerResourceBackups")
+
+	as, err := p.AppList()
+	if err != nil {
+		return err
+	}
+
+	if p.backupAttempts == nil {
+		p.backupAttempts = map[string]time.Time{}
+	}
+
+	for _, a := range as {
+		if a.Release == "" {
+			continue
+		}
+
+		m, _, err := common.ReleaseManifest(p, a.Name, a.Release)
+		if err != nil {
+			log.Errorf("app=%s err=%q", a.Name, err)
+			continue
+		}
+
+		for _, r := range m.Resources {
+			if r.Backup.Schedule == "" {
+				continue
+			}
+
+			due, err := p.resourceBackupDue(a.Name, r, time.Now())
+			if err != nil {
+				log.Errorf("app=%s resource=%s err=%q", a.Name, r.Name, err)
+				continue
+			}
+
+			key := fmt.Sprintf("%s/%s", a.Name, r.Name)
+
+			// a failed backup is retried at the next firing of the schedule
+			if due.IsZero() || !p.backupAttempts[key].Before(due) {
+				continue
+			}
+
+			p.backupAttempts[key] = time.Now().UTC()
+
+			data := map[string]string{"app": a.Name, "name": r.Name}
+
+			b, err := p.resourceBackup(a.Name, r, time.Now())
+			if err != nil {
+				log.Errorf("app=%s resource=%s err=%q", a.Name, r.Name, err)
+				p.EventSend("resource:backup", structs.EventSendOptions{Data: data, Error: options.String(err.Error())})
+				continue
+			}
+
+			data["id"] = b.Id
+
+			log.Logf("app=%s resource=%s backup=%s", a.Name, r.Name, b.Id)
+			p.EventSend("resource:backup", structs.EventSendOptions{Data: data})
+		}
+	}
+
+	return nil
+}
+
+// workerScaleSchedules applies the scale schedules declared in the manifest
+// of each app
+func (p *Provider) workerScaleSchedules() error {
+	log := p.logger.At("workerScaleSchedules")
+
+	as, err := p.AppList()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, a := range as {
+		if err := p.AppScaleSchedules(a.Name, now); err != nil {
+			log.Errorf("app=%s err=%q", a.Name, err)
+		}
+	}
+
+	return nil
+}
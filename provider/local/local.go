@@ -29,6 +29,12 @@ func FromEnv() (*Provider, error) {
 
 	k.Engine = p
 
+	if dir := os.Getenv("LOG_DIR"); dir != "" {
+		if err := setupLogStorage(dir, os.Environ()); err != nil {
+			return nil, err
+		}
+	}
+
 	return p, nil
 }
 
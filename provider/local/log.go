@@ -5,15 +5,113 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/convox/convox/pkg/common"
+	"github.com/convox/convox/pkg/logquery"
 	"github.com/convox/convox/pkg/logstorage"
 	"github.com/convox/convox/pkg/structs"
+	"github.com/dustin/go-humanize"
 )
 
 var logs = logstorage.New()
 
+// setupLogStorage switches log storage to disk so that history survives api
+// restarts, env holds the LOG_RETENTION and LOG_MAX_SIZE settings along with
+// their per-app LOG_RETENTION_<APP> and LOG_MAX_SIZE_<APP> overrides
+func setupLogStorage(dir string, env []string) error {
+	opts := logstorage.DiskOptions{
+		Dir:     dir,
+		Streams: map[string]logstorage.Retention{},
+	}
+
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			continue
+		}
+
+		name, value := parts[0], parts[1]
+
+		switch {
+		case name == "LOG_RETENTION":
+			d, err := parseLogRetention(value)
+			if err != nil {
+				return err
+			}
+			opts.Retention.MaxAge = d
+		case name == "LOG_MAX_SIZE":
+			n, err := parseLogMaxSize(value)
+			if err != nil {
+				return err
+			}
+			opts.Retention.MaxSize = n
+		case strings.HasPrefix(name, "LOG_RETENTION_"):
+			d, err := parseLogRetention(value)
+			if err != nil {
+				return err
+			}
+			stream := logStream(strings.TrimPrefix(name, "LOG_RETENTION_"))
+			r := opts.Streams[stream]
+			r.MaxAge = d
+			opts.Streams[stream] = r
+		case strings.HasPrefix(name, "LOG_MAX_SIZE_"):
+			n, err := parseLogMaxSize(value)
+			if err != nil {
+				return err
+			}
+			stream := logStream(strings.TrimPrefix(name, "LOG_MAX_SIZE_"))
+			r := opts.Streams[stream]
+			r.MaxSize = n
+			opts.Streams[stream] = r
+		}
+	}
+
+	// an override only changes what it sets, the rest comes from the defaults
+	for stream, r := range opts.Streams {
+		if r.MaxAge == 0 {
+			r.MaxAge = opts.Retention.MaxAge
+		}
+		if r.MaxSize == 0 {
+			r.MaxSize = opts.Retention.MaxSize
+		}
+		opts.Streams[stream] = r
+	}
+
+	b, err := logstorage.NewDiskBackend(opts)
+	if err != nil {
+		return err
+	}
+
+	logs = logstorage.NewWithBackend(b)
+
+	return nil
+}
+
+// logStream converts the suffix of an env var back into an app name
+func logStream(suffix string) string {
+	return strings.ReplaceAll(strings.ToLower(suffix), "_", "-")
+}
+
+func parseLogRetention(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid log retention: %s", s)
+	}
+
+	return d, nil
+}
+
+func parseLogMaxSize(s string) (int64, error) {
+	n, err := humanize.ParseBytes(s)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid log max size: %s", s)
+	}
+
+	return int64(n), nil
+}
+
 func (p *Provider) Log(app, stream string, ts time.Time, message string) error {
 	logs.Append(app, ts, stream, message)
 	logs.Append(fmt.Sprintf("%s/%s", app, stream), ts, stream, message)
@@ -82,6 +180,12 @@ func (p *Provider) SystemLogs(opts structs.LogsOptions) (io.ReadCloser, error) {
 func subscribeLogs(ctx context.Context, w io.WriteCloser, stream string, opts structs.LogsOptions) {
 	defer w.Close()
 
+	q, err := logquery.Parse(common.DefaultString(opts.Query, ""))
+	if err != nil {
+		fmt.Fprintf(w, "error: %v\n", err)
+		return
+	}
+
 	ch := make(chan logstorage.Log, 1000)
 
 	sctx, cancel := context.WithCancel(ctx)
@@ -90,6 +194,13 @@ func subscribeLogs(ctx context.Context, w io.WriteCloser, stream string, opts st
 	since := time.Now().UTC().Add(-1 * common.DefaultDuration(opts.Since, 0))
 	follow := common.DefaultBool(opts.Follow, true)
 
+	var until time.Time
+
+	if opts.Until != nil {
+		until = time.Now().UTC().Add(-1 * *opts.Until)
+		follow = false
+	}
+
 	logs.Subscribe(sctx, ch, stream, since, follow)
 
 	for {
@@ -100,6 +211,12 @@ func subscribeLogs(ctx context.Context, w io.WriteCloser, stream string, opts st
 			if !ok {
 				return
 			}
+			if !until.IsZero() && l.Timestamp.After(until) {
+				continue
+			}
+			if !q.Match(l.Message, l.Prefix) {
+				continue
+			}
 			prefix := ""
 			if common.DefaultBool(opts.Prefix, false) {
 				prefix = fmt.Sprintf("%s %s ", l.Timestamp.Format(time.RFC3339), l.Prefix)
@@ -160,6 +160,21 @@ func (c *Client) AppMetrics(name string, opts structs.MetricsOptions) (structs.M
 	return v, err
 }
 
+func (c *Client) AppPrune(name string, opts structs.AppPruneOptions) (structs.PruneObjects, error) {
+	var err error
+
+	ro, err := stdsdk.MarshalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var v structs.PruneObjects
+
+	err = c.Post(fmt.Sprintf("/apps/%s/prune", name), ro, &v)
+
+	return v, err
+}
+
 func (c *Client) AppUpdate(name string, opts structs.AppUpdateOptions) error {
 	var err error
 
@@ -173,6 +188,27 @@ func (c *Client) AppUpdate(name string, opts structs.AppUpdateOptions) error {
 	return err
 }
 
+// skipcq
+func (*Client) AuditLogAppend(log structs.AuditLog) error {
+	err := fmt.Errorf("not available via api")
+	return err
+}
+
+func (c *Client) AuditLogList(opts structs.AuditLogListOptions) (structs.AuditLogs, error) {
+	var err error
+
+	ro, err := stdsdk.MarshalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var v structs.AuditLogs
+
+	err = c.Get("/system/audit", ro, &v)
+
+	return v, err
+}
+
 func (c *Client) BalancerList(app string) (structs.Balancers, error) {
 	var err error
 
@@ -221,6 +257,30 @@ func (c *Client) BuildExport(app, id string, w io.Writer) error {
 	return err
 }
 
+func (c *Client) BuildAttest(app, id string) (*structs.BuildAttestation, error) {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	var v *structs.BuildAttestation
+
+	err = c.Post(fmt.Sprintf("/apps/%s/builds/%s/attestation", app, id), ro, &v)
+
+	return v, err
+}
+
+func (c *Client) BuildAttestation(app, id string) (*structs.BuildAttestation, error) {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	var v *structs.BuildAttestation
+
+	err = c.Get(fmt.Sprintf("/apps/%s/builds/%s/attestation", app, id), ro, &v)
+
+	return v, err
+}
+
 func (c *Client) BuildGet(app, id string) (*structs.Build, error) {
 	var err error
 
@@ -282,6 +342,26 @@ func (c *Client) BuildLogs(app, id string, opts structs.LogsOptions) (io.ReadClo
 	return v, err
 }
 
+func (c *Client) BuildSbom(app, id string, opts structs.BuildSbomOptions) (io.ReadCloser, error) {
+	var err error
+
+	ro, err := stdsdk.MarshalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var v io.ReadCloser
+
+	res, err := c.GetStream(fmt.Sprintf("/apps/%s/builds/%s/sbom", app, id), ro)
+	if err != nil {
+		return nil, err
+	}
+
+	v = res.Body
+
+	return v, err
+}
+
 func (c *Client) BuildUpdate(app, id string, opts structs.BuildUpdateOptions) (*structs.Build, error) {
 	var err error
 
@@ -309,6 +389,21 @@ func (c *Client) CapacityGet() (*structs.Capacity, error) {
 	return v, err
 }
 
+func (c *Client) CapacityPlan(app string, opts structs.CapacityPlanOptions) (*structs.CapacityPlan, error) {
+	var err error
+
+	ro, err := stdsdk.MarshalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var v *structs.CapacityPlan
+
+	err = c.Get(fmt.Sprintf("/apps/%s/capacity", app), ro, &v)
+
+	return v, err
+}
+
 func (c *Client) CertificateApply(app, service string, port int, id string) error {
 	var err error
 
@@ -406,6 +501,26 @@ func (c *Client) EventSend(action string, opts structs.EventSendOptions) error {
 	return err
 }
 
+func (c *Client) EventStream(opts structs.EventStreamOptions) (io.ReadCloser, error) {
+	var err error
+
+	ro, err := stdsdk.MarshalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var v io.ReadCloser
+
+	r, err := c.Websocket("/events", ro)
+	if err != nil {
+		return nil, err
+	}
+
+	v = r
+
+	return v, err
+}
+
 func (c *Client) FilesDelete(app, pid string, files []string) error {
 	var err error
 
@@ -616,6 +731,57 @@ func (c *Client) ObjectStore(app, key string, r io.Reader, opts structs.ObjectSt
 	return v, err
 }
 
+func (c *Client) PolicyCreate(name string, opts structs.PolicyCreateOptions) (*structs.Policy, error) {
+	var err error
+
+	ro, err := stdsdk.MarshalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ro.Params["name"] = name
+
+	var v *structs.Policy
+
+	err = c.Post("/system/policies", ro, &v)
+
+	return v, err
+}
+
+func (c *Client) PolicyDelete(name string) error {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	err = c.Delete(fmt.Sprintf("/system/policies/%s", name), ro, nil)
+
+	return err
+}
+
+func (c *Client) PolicyGet(name string) (*structs.Policy, error) {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	var v *structs.Policy
+
+	err = c.Get(fmt.Sprintf("/system/policies/%s", name), ro, &v)
+
+	return v, err
+}
+
+func (c *Client) PolicyList() (structs.Policies, error) {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	var v structs.Policies
+
+	err = c.Get("/system/policies", ro, &v)
+
+	return v, err
+}
+
 func (c *Client) ProcessExec(app, pid, command string, rw io.ReadWriter, opts structs.ProcessExecOptions) (int, error) {
 	var err error
 
@@ -775,6 +941,21 @@ func (c *Client) RegistryRemove(server string) error {
 	return err
 }
 
+func (c *Client) ReleaseApprove(app, id string, opts structs.ReleaseApproveOptions) (*structs.Release, error) {
+	var err error
+
+	ro, err := stdsdk.MarshalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var v *structs.Release
+
+	err = c.Post(fmt.Sprintf("/apps/%s/releases/%s/approve", app, id), ro, &v)
+
+	return v, err
+}
+
 func (c *Client) ReleaseCreate(app string, opts structs.ReleaseCreateOptions) (*structs.Release, error) {
 	var err error
 
@@ -790,6 +971,21 @@ func (c *Client) ReleaseCreate(app string, opts structs.ReleaseCreateOptions) (*
 	return v, err
 }
 
+func (c *Client) ReleaseDiff(app, id string, opts structs.ReleaseDiffOptions) (*structs.ReleaseDiff, error) {
+	var err error
+
+	ro, err := stdsdk.MarshalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var v *structs.ReleaseDiff
+
+	err = c.Get(fmt.Sprintf("/apps/%s/releases/%s/diff", app, id), ro, &v)
+
+	return v, err
+}
+
 func (c *Client) ReleaseGet(app, id string) (*structs.Release, error) {
 	var err error
 
@@ -817,6 +1013,21 @@ func (c *Client) ReleaseList(app string, opts structs.ReleaseListOptions) (struc
 	return v, err
 }
 
+func (c *Client) ReleasePlan(app, id string, opts structs.ReleasePromoteOptions) (*structs.ReleasePlan, error) {
+	var err error
+
+	ro, err := stdsdk.MarshalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var v *structs.ReleasePlan
+
+	err = c.Post(fmt.Sprintf("/apps/%s/releases/%s/plan", app, id), ro, &v)
+
+	return v, err
+}
+
 func (c *Client) ReleasePromote(app, id string, opts structs.ReleasePromoteOptions) error {
 	var err error
 
@@ -830,6 +1041,18 @@ func (c *Client) ReleasePromote(app, id string, opts structs.ReleasePromoteOptio
 	return err
 }
 
+func (c *Client) ResourceBackupList(app, name string) (structs.ResourceBackups, error) {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	var v structs.ResourceBackups
+
+	err = c.Get(fmt.Sprintf("/apps/%s/resources/%s/backups", app, name), ro, &v)
+
+	return v, err
+}
+
 func (c *Client) ResourceConsole(app, name string, rw io.ReadWriter, opts structs.ResourceConsoleOptions) error {
 	var err error
 
@@ -905,6 +1128,16 @@ func (c *Client) ResourceList(app string) (structs.Resources, error) {
 	return v, err
 }
 
+func (c *Client) ResourceRestore(app, name, backup string) error {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	err = c.Post(fmt.Sprintf("/apps/%s/resources/%s/backups/%s/restore", app, name, backup), ro, nil)
+
+	return err
+}
+
 func (c *Client) RackHost(rackOrgSlug string) (structs.RackData, error) {
 	var err error
 
@@ -1129,6 +1362,18 @@ func (c *Client) SystemReleases() (structs.Releases, error) {
 	return v, err
 }
 
+func (c *Client) SystemSigningKey() (string, error) {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	var v string
+
+	err = c.Get("/system/signing-key", ro, &v)
+
+	return v, err
+}
+
 func (c *Client) SystemResourceCreate(kind string, opts structs.ResourceCreateOptions) (*structs.Resource, error) {
 	var err error
 
@@ -1252,6 +1497,79 @@ func (c *Client) SystemUpdate(opts structs.SystemUpdateOptions) error {
 	return err
 }
 
+func (c *Client) TimerList(app string) (structs.Timers, error) {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	var v structs.Timers
+
+	err = c.Get(fmt.Sprintf("/apps/%s/timers", app), ro, &v)
+
+	return v, err
+}
+
+func (c *Client) TimerRun(app, name string) (*structs.TimerRun, error) {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	var v *structs.TimerRun
+
+	err = c.Post(fmt.Sprintf("/apps/%s/timers/%s/runs", app, name), ro, &v)
+
+	return v, err
+}
+
+func (c *Client) TimerRunList(app, name string) (structs.TimerRuns, error) {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	var v structs.TimerRuns
+
+	err = c.Get(fmt.Sprintf("/apps/%s/timers/%s/runs", app, name), ro, &v)
+
+	return v, err
+}
+
+func (c *Client) WebhookDeliveryList(name string, opts structs.WebhookDeliveryListOptions) (structs.WebhookDeliveries, error) {
+	var err error
+
+	ro, err := stdsdk.MarshalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var v structs.WebhookDeliveries
+
+	err = c.Get(fmt.Sprintf("/system/webhooks/%s/deliveries", name), ro, &v)
+
+	return v, err
+}
+
+func (c *Client) WebhookList() (structs.Webhooks, error) {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	var v structs.Webhooks
+
+	err = c.Get("/system/webhooks", ro, &v)
+
+	return v, err
+}
+
+func (c *Client) WebhookRedeliver(name, id string) error {
+	var err error
+
+	ro := stdsdk.RequestOptions{Headers: stdsdk.Headers{}, Params: stdsdk.Params{}, Query: stdsdk.Query{}}
+
+	err = c.Post(fmt.Sprintf("/system/webhooks/%s/deliveries/%s/redeliver", name, id), ro, nil)
+
+	return err
+}
+
 // skipcq
 func (*Client) Workers() error {
 	err := fmt.Errorf("not available via api")
@@ -41,6 +41,7 @@ module "k8s" {
   }
 
   env = {
+    AUDIT_LOG_RETENTION_IN_DAYS          = var.audit_log_retention_in_days
     AWS_REGION                           = data.aws_region.current.name
     BUCKET                               = var.custom_provided_bucket != "" ? data.aws_s3_bucket.custom_bucket[0].id : aws_s3_bucket.storage.id
     CERT_MANAGER                         = "true"
@@ -1,3 +1,7 @@
+variable "audit_log_retention_in_days" {
+  default = "30"
+}
+
 variable "buildkit_enabled" {
   default = false
 }
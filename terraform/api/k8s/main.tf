@@ -238,6 +238,10 @@ resource "kubernetes_deployment" "api" {
             container_port = 5443
           }
 
+          port {
+            container_port = 5080
+          }
+
           liveness_probe {
             http_get {
               path   = "/check"
@@ -341,6 +345,79 @@ resource "kubernetes_service" "api" {
   }
 }
 
+resource "kubernetes_service" "idler" {
+  metadata {
+    namespace = var.namespace
+    name      = "idler"
+
+    labels = {
+      system  = "convox"
+      service = "idler"
+    }
+  }
+
+  spec {
+    port {
+      name        = "http"
+      port        = 80
+      target_port = 5080
+      protocol    = "TCP"
+    }
+
+    selector = {
+      system  = "convox"
+      service = "api"
+    }
+  }
+}
+
+resource "kubernetes_network_policy" "idler" {
+  metadata {
+    namespace = var.namespace
+    name      = "idler"
+  }
+
+  spec {
+    pod_selector {
+      match_labels = {
+        system  = "convox"
+        service = "api"
+      }
+    }
+
+    ingress {
+      ports {
+        port     = "5443"
+        protocol = "TCP"
+      }
+
+      ports {
+        port     = "8001"
+        protocol = "TCP"
+      }
+    }
+
+    ingress {
+      from {
+        pod_selector {
+          match_expressions {
+            key      = "service"
+            operator = "In"
+            values   = ["ingress-nginx", "ingress-nginx-internal"]
+          }
+        }
+      }
+
+      ports {
+        port     = "5080"
+        protocol = "TCP"
+      }
+    }
+
+    policy_types = ["Ingress"]
+  }
+}
+
 resource "kubernetes_ingress_v1" "api" {
   wait_for_load_balancer = true
 
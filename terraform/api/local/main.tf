@@ -29,15 +29,22 @@ module "k8s" {
     "convox.com/idles"               = "true"
   }
 
-  env = {
-    CERT_MANAGER = "true"
-    PROVIDER     = "local"
-    REGISTRY     = "registry.${var.domain}"
-    RESOLVER     = var.resolver
-    ROUTER       = var.router
-    SECRET       = var.secret
-    STORAGE      = "/var/storage"
-  }
+  env = merge(
+    {
+      CERT_MANAGER  = "true"
+      LOG_DIR       = "/var/storage/logs"
+      LOG_MAX_SIZE  = var.log_max_size
+      LOG_RETENTION = var.log_retention
+      PROVIDER      = "local"
+      REGISTRY      = "registry.${var.domain}"
+      RESOLVER      = var.resolver
+      ROUTER        = var.router
+      SECRET        = var.secret
+      STORAGE       = "/var/storage"
+    },
+    { for s in compact(split(",", var.log_stream_max_size)) : "LOG_MAX_SIZE_${upper(replace(split("=", s)[0], "-", "_"))}" => split("=", s)[1] },
+    { for s in compact(split(",", var.log_stream_retention)) : "LOG_RETENTION_${upper(replace(split("=", s)[0], "-", "_"))}" => split("=", s)[1] },
+  )
 
   volumes = {
     storage-local = "/var/storage"
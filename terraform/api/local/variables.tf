@@ -10,6 +10,26 @@ variable "image" {
   type = string
 }
 
+variable "log_max_size" {
+  default = ""
+  type    = string
+}
+
+variable "log_retention" {
+  default = ""
+  type    = string
+}
+
+variable "log_stream_max_size" {
+  default = ""
+  type    = string
+}
+
+variable "log_stream_retention" {
+  default = ""
+  type    = string
+}
+
 variable "name" {
   type = string
 }
@@ -24,6 +24,7 @@ module "api" {
     kubernetes = kubernetes
   }
 
+  audit_log_retention_in_days          = var.audit_log_retention_in_days
   buildkit_enabled                     = var.buildkit_enabled
   build_disable_convox_resolver        = var.build_disable_convox_resolver
   build_node_enabled                   = var.build_node_enabled
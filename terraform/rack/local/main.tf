@@ -25,6 +25,10 @@ module "api" {
   domain                    = module.router.endpoint
   docker_hub_authentication = module.k8s.docker_hub_authentication
   image                     = var.image
+  log_max_size              = var.log_max_size
+  log_retention             = var.log_retention
+  log_stream_max_size       = var.log_stream_max_size
+  log_stream_retention      = var.log_stream_retention
   name                      = var.name
   rack_name                 = var.rack_name
   namespace                 = module.k8s.namespace
@@ -157,6 +157,7 @@ module "rack" {
     null_resource.wait_for_cluster
   ]
 
+  audit_log_retention_in_days          = var.audit_log_retention_in_days
   build_disable_convox_resolver        = var.build_disable_convox_resolver
   build_node_enabled                   = var.build_node_enabled
   cluster                              = module.cluster.id
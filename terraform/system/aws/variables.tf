@@ -12,6 +12,10 @@ variable "additional_build_groups_config" {
   default = ""
 }
 
+variable "audit_log_retention_in_days" {
+  default = "30"
+}
+
 variable "availability_zones" {
   default = ""
 }
@@ -28,6 +28,10 @@ module "rack" {
   docker_hub_username   = var.docker_hub_username
   docker_hub_password   = var.docker_hub_password
   image                 = var.image
+  log_max_size          = var.log_max_size
+  log_retention         = var.log_retention
+  log_stream_max_size   = var.log_stream_max_size
+  log_stream_retention  = var.log_stream_retention
   name                  = local.name
   rack_name             = local.rack_name
   platform              = module.platform.name
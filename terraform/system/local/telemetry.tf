@@ -3,26 +3,34 @@
 
 locals {
   telemetry_map = {
-    docker_hub_password = var.docker_hub_password
-    docker_hub_username = var.docker_hub_username
-    image               = var.image
-    name                = var.name
-    os                  = var.os
-    rack_name           = var.rack_name
-    release             = var.release
-    settings            = var.settings
-    telemetry           = var.telemetry
+    docker_hub_password  = var.docker_hub_password
+    docker_hub_username  = var.docker_hub_username
+    image                = var.image
+    log_max_size         = var.log_max_size
+    log_retention        = var.log_retention
+    log_stream_max_size  = var.log_stream_max_size
+    log_stream_retention = var.log_stream_retention
+    name                 = var.name
+    os                   = var.os
+    rack_name            = var.rack_name
+    release              = var.release
+    settings             = var.settings
+    telemetry            = var.telemetry
   }
 
   telemetry_default_map = {
-    docker_hub_password = ""
-    docker_hub_username = ""
-    image               = "convox/convox"
-    name                = ""
-    os                  = "ubuntu"
-    rack_name           = ""
-    release             = ""
-    settings            = ""
-    telemetry           = "false"
+    docker_hub_password  = ""
+    docker_hub_username  = ""
+    image                = "convox/convox"
+    log_max_size         = ""
+    log_retention        = ""
+    log_stream_max_size  = ""
+    log_stream_retention = ""
+    name                 = ""
+    os                   = "ubuntu"
+    rack_name            = ""
+    release              = ""
+    settings             = ""
+    telemetry            = "false"
   }
 }